@@ -100,6 +100,7 @@ type Movie struct {
 // DropAllTables drops all tables in the database
 func DropAllTables(db *gorm.DB) error {
 	tables := []interface{}{
+		&postgres.GameReplay{},
 		&postgres.GameInvitation{},
 		&postgres.InGamePlayer{},
 		&postgres.GameLobby{},
@@ -136,7 +137,12 @@ func MigrateDatabase(db *gorm.DB) error {
 		postgres.FriendshipRequest{},
 		postgres.GameLobby{},
 		postgres.InGamePlayer{},
-		postgres.GameInvitation{})
+		postgres.GameInvitation{},
+		postgres.GameReplay{},
+		postgres.MatchHistory{},
+		postgres.RatingHistory{},
+		postgres.PlayerReport{},
+		postgres.DirectMessage{})
 
 	if err != nil {
 		return fmt.Errorf("auto migration failed: %w", err)
@@ -1,24 +1,42 @@
 package game_constants
 
+import "time"
+
+// Default rules of a lobby, they can be changed when the lobby is created (see redis.GameRules)
 const MaxGameRounds = 10
 const MaxJokersPerPlayer = 5 // NOTE: This is what frontend uses
 const TOTAL_HAND_PLAYS = 3
 const TOTAL_DISCARDS = 3
 const BASE_BLIND = 10
+const BASE_BLIND_GROWTH = 2 // The base blind is multiplied by this every round
+const STARTING_MONEY = 10
 const ROUND_BLIND_MULTIPLIER = 3
 const MAX_BLIND = 1e6
 
+// Default phase durations
+const (
+	PLAY_ROUND_DURATION = 2 * time.Minute
+	BLIND_DURATION      = 20 * time.Second
+	SHOP_DURATION       = 1 * time.Minute
+	VOUCHER_DURATION    = 1 * time.Minute
+)
+
+// Time a disconnected player has to come back before forfeiting the game
+const RECONNECT_GRACE_PERIOD = 60 * time.Second
+
 // Shop constants
 const (
 	// Pack types (1-3) - Used to identify the type of pack
 	PACK_TYPE_CARDS    = 1 // Contains regular playing cards
 	PACK_TYPE_JOKERS   = 2 // Contains joker cards with special abilities
 	PACK_TYPE_VOUCHERS = 3 // Contains game modifiers/vouchers
+	PACK_TYPE_PLANETS  = 4 // Contains planets, which level up a hand type
 )
 
 // Modifier type constants
 const MODIFIER_TYPE = "modifier"
 const JOKER_TYPE = "joker"
 const PACK_TYPE = "pack"
+const PLANET_TYPE = "planet" // Consumable, levels up a hand type when bought
 
 // "current_pot":        lobby.CurrentRound + lobby.CurrentRound/2 + 1,
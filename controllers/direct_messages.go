@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"Nogler/middleware"
+	models "Nogler/models/postgres"
+	"Nogler/services/app_errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// @Summary Gets the conversation with another user
+// @Description Returns the direct messages between the authenticated user and another one, the newest first, paginated. New messages are sent with the send_direct_message socket event
+// @Tags direct messages
+// @Produce json
+// @Param Authorization header string true "Bearer JWT token"
+// @Param username path string true "Username of the other user"
+// @Param page query int false "Page number, starting at 1 (default 1)"
+// @Param page_size query int false "Messages per page (default 10, max 50)"
+// @Success 200 {object} object{username=string,page=integer,page_size=integer,total=integer,messages=[]object{id=integer,sender=string,receiver=string,message=string,sent_at=string,read_at=string}}
+// @Failure 400 {object} app_errors.Error
+// @Failure 401 {object} app_errors.Error
+// @Failure 404 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
+// @Router /auth/direct_messages/{username} [get]
+// @Security ApiKeyAuth
+func GetConversation(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username, ok := authenticatedUsername(c, db)
+		if !ok {
+			return
+		}
+		otherUsername := c.Param("username")
+
+		page, pageSize, ok := parsePagination(c)
+		if !ok {
+			return
+		}
+
+		var profile models.GameProfile
+		if err := db.Where("username = ?", otherUsername).First(&profile).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				app_errors.Respond(c, app_errors.UserNotFound)
+			} else {
+				app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error fetching user"))
+			}
+			return
+		}
+
+		conversation := db.Model(&models.DirectMessage{}).Where(
+			"(sender_username = ? AND receiver_username = ?) OR (sender_username = ? AND receiver_username = ?)",
+			username, otherUsername, otherUsername, username,
+		)
+
+		var total int64
+		if err := conversation.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			log.Printf("[DIRECT-MESSAGES-ERROR] Error counting messages of %s and %s: %v", username, otherUsername, err)
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to retrieve messages"))
+			return
+		}
+
+		var messages []models.DirectMessage
+		if err := conversation.Session(&gorm.Session{}).
+			Order("created_at desc, id desc").
+			Offset((page - 1) * pageSize).
+			Limit(pageSize).
+			Find(&messages).Error; err != nil {
+			log.Printf("[DIRECT-MESSAGES-ERROR] Error getting messages of %s and %s: %v", username, otherUsername, err)
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to retrieve messages"))
+			return
+		}
+
+		messagesData := make([]gin.H, len(messages))
+		for i, message := range messages {
+			messagesData[i] = gin.H{
+				"id":       message.ID,
+				"sender":   message.SenderUsername,
+				"receiver": message.ReceiverUsername,
+				"message":  message.Content,
+				"sent_at":  message.CreatedAt,
+				"read_at":  message.ReadAt,
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"username":  otherUsername,
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+			"messages":  messagesData,
+		})
+	}
+}
+
+// @Summary Gets the unread direct messages
+// @Description Returns how many direct messages the authenticated user hasn't read, in total and by sender
+// @Tags direct messages
+// @Produce json
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} object{total=integer,conversations=[]object{username=string,unread=integer}}
+// @Failure 401 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
+// @Router /auth/unread_direct_messages [get]
+// @Security ApiKeyAuth
+func GetUnreadDirectMessages(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username, ok := authenticatedUsername(c, db)
+		if !ok {
+			return
+		}
+
+		var counts []struct {
+			SenderUsername string
+			Unread         int64
+		}
+		if err := db.Model(&models.DirectMessage{}).
+			Select("sender_username, count(*) as unread").
+			Where("receiver_username = ? AND read_at IS NULL", username).
+			Group("sender_username").
+			Order("sender_username").
+			Scan(&counts).Error; err != nil {
+			log.Printf("[DIRECT-MESSAGES-ERROR] Error counting unread messages of %s: %v", username, err)
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to count unread messages"))
+			return
+		}
+
+		var total int64
+		conversations := make([]gin.H, len(counts))
+		for i, count := range counts {
+			total += count.Unread
+			conversations[i] = gin.H{
+				"username": count.SenderUsername,
+				"unread":   count.Unread,
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"total":         total,
+			"conversations": conversations,
+		})
+	}
+}
+
+// @Summary Marks a conversation as read
+// @Description Marks every direct message received from another user as read
+// @Tags direct messages
+// @Produce json
+// @Param Authorization header string true "Bearer JWT token"
+// @Param username path string true "Username of the sender"
+// @Success 200 {object} object{username=string,marked=integer}
+// @Failure 401 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
+// @Router /auth/direct_messages/{username}/read [post]
+// @Security ApiKeyAuth
+func MarkConversationRead(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username, ok := authenticatedUsername(c, db)
+		if !ok {
+			return
+		}
+		otherUsername := c.Param("username")
+
+		result := db.Model(&models.DirectMessage{}).
+			Where("sender_username = ? AND receiver_username = ? AND read_at IS NULL", otherUsername, username).
+			Update("read_at", time.Now())
+		if result.Error != nil {
+			log.Printf("[DIRECT-MESSAGES-ERROR] Error marking messages of %s to %s as read: %v", otherUsername, username, result.Error)
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to mark messages as read"))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"username": otherUsername,
+			"marked":   result.RowsAffected,
+		})
+	}
+}
+
+// Returns the username of the user of the request's JWT. If it can't be found, the error is
+// sent to the client and ok is false
+func authenticatedUsername(c *gin.Context, db *gorm.DB) (username string, ok bool) {
+	email, err := middleware.JWT_decoder(c)
+	if err != nil {
+		app_errors.Respond(c, app_errors.InvalidToken)
+		return "", false
+	}
+
+	var user models.User
+	if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+		app_errors.Respond(c, app_errors.AuthUserNotFound)
+		return "", false
+	}
+	return user.ProfileUsername, true
+}
@@ -0,0 +1,18 @@
+package controllers
+
+import (
+	"Nogler/services/app_errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Gets the error catalogue
+// @Description Returns every error the API and the socket.io events can answer with: its code, HTTP status, default message and i18n key
+// @Tags errors
+// @Produce json
+// @Success 200 {array} app_errors.Definition
+// @Router /errors [get]
+func GetErrorCatalogue(c *gin.Context) {
+	c.JSON(http.StatusOK, app_errors.Catalogue())
+}
@@ -4,6 +4,7 @@ import (
 	"Nogler/middleware"
 	"Nogler/models/postgres"
 	models "Nogler/models/postgres"
+	"Nogler/services/app_errors"
 	"log"
 
 	// "errors"
@@ -20,7 +21,7 @@ import (
 // @Produce json
 // @Param Authorization header string true "Bearer JWT token"
 // @Success 200 {array} object{username=string,icon=integer}
-// @Failure 500 {object} object{error=string}
+// @Failure 500 {object} app_errors.Error
 // @Router /auth/friends [get]
 // @Security ApiKeyAuth
 func ListFriends(db *gorm.DB) gin.HandlerFunc {
@@ -34,21 +35,21 @@ func ListFriends(db *gorm.DB) gin.HandlerFunc {
 
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found: invalid email"})
+			app_errors.Respond(c, app_errors.AuthUserNotFound)
 			return
 		}
 
 		username := user.ProfileUsername
 
 		if username == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Username is required"})
+			app_errors.Respond(c, app_errors.MissingField, app_errors.Details{"field": "username"})
 			return
 		}
 
 		var friendships []postgres.Friendship
 		result := db.Where("username1 = ? OR username2 = ?", username, username).Find(&friendships)
 		if result.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching friendships"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error fetching friendships"))
 			return
 		}
 
@@ -66,7 +67,7 @@ func ListFriends(db *gorm.DB) gin.HandlerFunc {
 		if len(friendsUsernames) > 0 {
 			result = db.Where("username IN (?)", friendsUsernames).Find(&friends)
 			if result.Error != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching friends data"})
+				app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error fetching friends data"))
 				return
 			}
 		}
@@ -93,8 +94,8 @@ func ListFriends(db *gorm.DB) gin.HandlerFunc {
 // @in header
 // @Param friendUsername formData string true "Username of the friend to be added"
 // @Success 200 {object} object{message=string}
-// @Failure 400 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 400 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
 // @Router /auth/addFriend [post]
 // @Security ApiKeyAuth
 func AddFriend(db *gorm.DB) gin.HandlerFunc {
@@ -108,7 +109,7 @@ func AddFriend(db *gorm.DB) gin.HandlerFunc {
 
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found: invalid email"})
+			app_errors.Respond(c, app_errors.AuthUserNotFound)
 			return
 		}
 
@@ -116,12 +117,12 @@ func AddFriend(db *gorm.DB) gin.HandlerFunc {
 		friendUsername := c.PostForm("friendUsername")
 
 		if username == "" || friendUsername == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Both usernames are required"})
+			app_errors.Respond(c, app_errors.MissingField, app_errors.Details{"fields": []string{"username", "friendUsername"}})
 			return
 		}
 
 		if username == friendUsername {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot add yourself as a friend"})
+			app_errors.Respond(c, app_errors.CannotBefriendYourself)
 			return
 		}
 
@@ -133,7 +134,7 @@ func AddFriend(db *gorm.DB) gin.HandlerFunc {
 		).First(&existingFriendship)
 
 		if result.RowsAffected > 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Friendship already exists"})
+			app_errors.Respond(c, app_errors.AlreadyFriends)
 			return
 		}
 
@@ -145,7 +146,7 @@ func AddFriend(db *gorm.DB) gin.HandlerFunc {
 
 		result = db.Create(&newFriendship)
 		if result.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error adding friend"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error adding friend"))
 			return
 		}
 
@@ -162,8 +163,8 @@ func AddFriend(db *gorm.DB) gin.HandlerFunc {
 // @in header
 // @Param friendUsername path string true "Username of the friend to be removed"
 // @Success 200 {object} object{message=string}
-// @Failure 400 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 400 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
 // @Security ApiKeyAuth
 // @Router /auth/deleteFriend/{friendUsername} [delete]
 func DeleteFriend(db *gorm.DB) gin.HandlerFunc {
@@ -177,7 +178,7 @@ func DeleteFriend(db *gorm.DB) gin.HandlerFunc {
 
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found: invalid email"})
+			app_errors.Respond(c, app_errors.AuthUserNotFound)
 			return
 		}
 
@@ -186,7 +187,7 @@ func DeleteFriend(db *gorm.DB) gin.HandlerFunc {
 		friendUsername := c.Param("friendUsername")
 
 		if username == "" || friendUsername == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Both usernames are required"})
+			app_errors.Respond(c, app_errors.MissingField, app_errors.Details{"fields": []string{"username", "friendUsername"}})
 			return
 		}
 
@@ -198,14 +199,14 @@ func DeleteFriend(db *gorm.DB) gin.HandlerFunc {
 		).First(&friendship)
 
 		if result.RowsAffected == 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Friendship does not exist"})
+			app_errors.Respond(c, app_errors.FriendshipNotFound)
 			return
 		}
 
 		// Delete the friendship
 		result = db.Delete(&friendship)
 		if result.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting friend"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error deleting friend"))
 			return
 		}
 
@@ -222,8 +223,9 @@ func DeleteFriend(db *gorm.DB) gin.HandlerFunc {
 // @in header
 // @Param friendUsername formData string true "Username of the recipient"
 // @Success 200 {object} object{message=string}
-// @Failure 400 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 400 {object} app_errors.Error
+// @Failure 429 {object} app_errors.Error "Too many requests"
+// @Failure 500 {object} app_errors.Error
 // @Security ApiKeyAuth
 // @Router /auth/sendFriendshipRequest [post]
 func SendFriendshipRequest(db *gorm.DB) gin.HandlerFunc {
@@ -236,7 +238,7 @@ func SendFriendshipRequest(db *gorm.DB) gin.HandlerFunc {
 
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found: invalid email"})
+			app_errors.Respond(c, app_errors.AuthUserNotFound)
 			return
 		}
 
@@ -245,12 +247,12 @@ func SendFriendshipRequest(db *gorm.DB) gin.HandlerFunc {
 		receiverUsername := c.PostForm("friendUsername")
 
 		if senderUsername == "" || receiverUsername == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Both usernames are required", "senderUsername": senderUsername, "recieverUsername": receiverUsername})
+			app_errors.Respond(c, app_errors.MissingField, app_errors.Details{"fields": []string{"username", "friendUsername"}})
 			return
 		}
 
 		if senderUsername == receiverUsername {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot send a friend request to yourself"})
+			app_errors.Respond(c, app_errors.CannotBefriendYourself)
 			return
 		}
 
@@ -258,7 +260,7 @@ func SendFriendshipRequest(db *gorm.DB) gin.HandlerFunc {
 		var receiver postgres.GameProfile
 		result := db.Where("username = ?", receiverUsername).First(&receiver)
 		if result.Error != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Receiver user not found"})
+			app_errors.Respond(c, app_errors.UserNotFound)
 			return
 		}
 
@@ -270,7 +272,7 @@ func SendFriendshipRequest(db *gorm.DB) gin.HandlerFunc {
 		).First(&existingFriendship)
 
 		if result.RowsAffected > 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "You are already friends"})
+			app_errors.Respond(c, app_errors.AlreadyFriends)
 			return
 		}
 
@@ -282,7 +284,7 @@ func SendFriendshipRequest(db *gorm.DB) gin.HandlerFunc {
 		).First(&existingRequest)
 
 		if result.RowsAffected > 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Friend request already sent"})
+			app_errors.Respond(c, app_errors.FriendRequestAlreadySent)
 			return
 		}
 
@@ -294,7 +296,7 @@ func SendFriendshipRequest(db *gorm.DB) gin.HandlerFunc {
 
 		result = db.Create(&friendRequest)
 		if result.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error sending friend request"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error sending friend request"))
 			return
 		}
 
@@ -3,6 +3,7 @@ package controllers
 import (
 	"Nogler/middleware"
 	models "Nogler/models/postgres"
+	"Nogler/services/app_errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -17,9 +18,9 @@ import (
 // @Produce json
 // @Param Authorization header string true "Bearer JWT token"
 // @Success 200 {object} object{received_friendship_requests=[]object{username=string,icon=integer}}
-// @Failure 401 {object} object{error=string}
-// @Failure 404 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 401 {object} app_errors.Error
+// @Failure 404 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
 // @Router /auth/received_friendship_requests [get]
 // @Security ApiKeyAuth
 func GetAllReceivedFriendshipRequests(db *gorm.DB) gin.HandlerFunc {
@@ -34,14 +35,14 @@ func GetAllReceivedFriendshipRequests(db *gorm.DB) gin.HandlerFunc {
 		// Buscar el perfil de juego del usuario
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			app_errors.Respond(c, app_errors.UserNotFound)
 			return
 		}
 
 		// Obtener todas las solicitudes de amistad donde el usuario es el receptor
 		var friendRequests []models.FriendshipRequest
 		if err := db.Where("recipient = ?", user.ProfileUsername).Find(&friendRequests).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving received friendship requests"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error retrieving received friendship requests"))
 			return
 		}
 
@@ -71,9 +72,9 @@ func GetAllReceivedFriendshipRequests(db *gorm.DB) gin.HandlerFunc {
 // @Produce json
 // @Param Authorization header string true "Bearer JWT token"
 // @Success 200 {object} object{sent_friendship_requests=[]object{username=string,icon=integer}}
-// @Failure 401 {object} object{error=string}
-// @Failure 404 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 401 {object} app_errors.Error
+// @Failure 404 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
 // @Router /auth/sent_friendship_requests [get]
 // @Security ApiKeyAuth
 func GetAllSentFriendshipRequests(db *gorm.DB) gin.HandlerFunc {
@@ -88,14 +89,14 @@ func GetAllSentFriendshipRequests(db *gorm.DB) gin.HandlerFunc {
 		// Buscar el perfil de juego del usuario
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			app_errors.Respond(c, app_errors.UserNotFound)
 			return
 		}
 
 		// Obtener todas las solicitudes de amistad donde el usuario es el receptor
 		var friendRequests []models.FriendshipRequest
 		if err := db.Where("sender = ?", user.ProfileUsername).Find(&friendRequests).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving sent friendship requests"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error retrieving sent friendship requests"))
 			return
 		}
 
@@ -125,9 +126,9 @@ func GetAllSentFriendshipRequests(db *gorm.DB) gin.HandlerFunc {
 // @Produce json
 // @Param Authorization header string true "Bearer JWT token"
 // @Success 200 {object} object{received_game_lobby_invitations=[]object{username=string,icon=integer,lobby_id=string,player_count=integer}}
-// @Failure 401 {object} object{error=string}
-// @Failure 404 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 401 {object} app_errors.Error
+// @Failure 404 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
 // @Router /auth/received_lobby_invitations [get]
 // @Security ApiKeyAuth
 func GetAllReceivedGameLobbyInvitations(db *gorm.DB) gin.HandlerFunc {
@@ -142,7 +143,7 @@ func GetAllReceivedGameLobbyInvitations(db *gorm.DB) gin.HandlerFunc {
 		// Find the user's game profile
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			app_errors.Respond(c, app_errors.UserNotFound)
 			return
 		}
 
@@ -150,7 +151,7 @@ func GetAllReceivedGameLobbyInvitations(db *gorm.DB) gin.HandlerFunc {
 		// Preload the SenderGameProfile relationship to get sender information
 		var gameInvitations []models.GameInvitation
 		if err := db.Preload("SenderGameProfile").Where("invited_username = ?", user.ProfileUsername).Find(&gameInvitations).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving game lobby invitations"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error retrieving game lobby invitations"))
 			return
 		}
 
@@ -174,7 +175,7 @@ func GetAllReceivedGameLobbyInvitations(db *gorm.DB) gin.HandlerFunc {
 			Where("lobby_id IN ?", lobbies).
 			Group("lobby_id").
 			Find(&playerCountResult).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count players"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to count players"))
 			return
 		}
 
@@ -207,9 +208,9 @@ func GetAllReceivedGameLobbyInvitations(db *gorm.DB) gin.HandlerFunc {
 // @Produce json
 // @Param Authorization header string true "Bearer JWT token"
 // @Success 200 {object} object{sent_game_lobby_invitations=[]object{username=string,icon=integer,lobby_id=string}}
-// @Failure 401 {object} object{error=string}
-// @Failure 404 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 401 {object} app_errors.Error
+// @Failure 404 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
 // @Router /auth/sent_lobby_invitations [get]
 // @Security ApiKeyAuth
 func GetAllSentGameLobbyInvitations(db *gorm.DB) gin.HandlerFunc {
@@ -224,7 +225,7 @@ func GetAllSentGameLobbyInvitations(db *gorm.DB) gin.HandlerFunc {
 		// Find the user's game profile
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			app_errors.Respond(c, app_errors.UserNotFound)
 			return
 		}
 
@@ -232,7 +233,7 @@ func GetAllSentGameLobbyInvitations(db *gorm.DB) gin.HandlerFunc {
 		// Preload the InvitedGameProfile relationship to get recipient information
 		var gameInvitations []models.GameInvitation
 		if err := db.Preload("InvitedGameProfile").Where("sender_username = ?", user.ProfileUsername).Find(&gameInvitations).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving game lobby invitations"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error retrieving game lobby invitations"))
 			return
 		}
 
@@ -260,9 +261,9 @@ func GetAllSentGameLobbyInvitations(db *gorm.DB) gin.HandlerFunc {
 // @Param Authorization header string true "Bearer JWT token"
 // @Param username path string true "Recipient's username"
 // @Success 200 {object} object{message=string}
-// @Failure 401 {object} object{error=string}
-// @Failure 404 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 401 {object} app_errors.Error
+// @Failure 404 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
 // @Router /auth/sent_friendship_request/{username} [delete]
 // @Security ApiKeyAuth
 func DeleteSentFriendshipRequest(db *gorm.DB) gin.HandlerFunc {
@@ -277,7 +278,7 @@ func DeleteSentFriendshipRequest(db *gorm.DB) gin.HandlerFunc {
 		// Buscar el perfil de juego del usuario
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			app_errors.Respond(c, app_errors.UserNotFound)
 			return
 		}
 
@@ -287,13 +288,13 @@ func DeleteSentFriendshipRequest(db *gorm.DB) gin.HandlerFunc {
 		// Eliminar la solicitud de amistad
 		result := db.Where("sender = ? AND recipient = ?", user.ProfileUsername, username).Delete(&models.FriendshipRequest{})
 		if result.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting friendship request"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error deleting friendship request"))
 			return
 		}
 
 		// Verificar si la solicitud se eliminó realmente
 		if result.RowsAffected == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Friendship request not found"})
+			app_errors.Respond(c, app_errors.FriendRequestNotFound)
 			return
 		}
 
@@ -311,9 +312,9 @@ func DeleteSentFriendshipRequest(db *gorm.DB) gin.HandlerFunc {
 // @Param Authorization header string true "Bearer JWT token"
 // @Param username path string true "Sender's username"
 // @Success 200 {object} object{message=string}
-// @Failure 401 {object} object{error=string}
-// @Failure 404 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 401 {object} app_errors.Error
+// @Failure 404 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
 // @Router /auth/received_friendship_request/{username} [delete]
 // @Security ApiKeyAuth
 func DeleteReceivedFriendshipRequest(db *gorm.DB) gin.HandlerFunc {
@@ -328,7 +329,7 @@ func DeleteReceivedFriendshipRequest(db *gorm.DB) gin.HandlerFunc {
 		// Buscar el perfil de juego del usuario
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			app_errors.Respond(c, app_errors.UserNotFound)
 			return
 		}
 
@@ -338,13 +339,13 @@ func DeleteReceivedFriendshipRequest(db *gorm.DB) gin.HandlerFunc {
 		// Eliminar la solicitud de amistad donde el usuario es el receptor y el nombre de usuario especificado es el emisor
 		result := db.Where("sender = ? AND recipient = ?", senderUsername, user.ProfileUsername).Delete(&models.FriendshipRequest{})
 		if result.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting friendship request"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error deleting friendship request"))
 			return
 		}
 
 		// Verificar si la solicitud se eliminó realmente
 		if result.RowsAffected == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Friendship request not found"})
+			app_errors.Respond(c, app_errors.FriendRequestNotFound)
 			return
 		}
 
@@ -363,9 +364,9 @@ func DeleteReceivedFriendshipRequest(db *gorm.DB) gin.HandlerFunc {
 // @Param lobby_id path string true "Lobby ID"
 // @Param username path string true "Sender's username"
 // @Success 200 {object} object{message=string}
-// @Failure 401 {object} object{error=string}
-// @Failure 404 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 401 {object} app_errors.Error
+// @Failure 404 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
 // @Router /auth/received_lobby_invitation/{lobby_id}/{username} [delete]
 // @Security ApiKeyAuth
 func DeleteReceivedGameLobbyInvitation(db *gorm.DB) gin.HandlerFunc {
@@ -380,7 +381,7 @@ func DeleteReceivedGameLobbyInvitation(db *gorm.DB) gin.HandlerFunc {
 		// Find the user's game profile
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			app_errors.Respond(c, app_errors.UserNotFound)
 			return
 		}
 
@@ -392,13 +393,13 @@ func DeleteReceivedGameLobbyInvitation(db *gorm.DB) gin.HandlerFunc {
 		result := db.Where("lobby_id = ? AND sender_username = ? AND invited_username = ?",
 			lobbyID, senderUsername, user.ProfileUsername).Delete(&models.GameInvitation{})
 		if result.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting game lobby invitation"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error deleting game lobby invitation"))
 			return
 		}
 
 		// Check if the invitation was actually deleted
 		if result.RowsAffected == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Game lobby invitation not found"})
+			app_errors.Respond(c, app_errors.InvitationNotFound)
 			return
 		}
 
@@ -417,9 +418,9 @@ func DeleteReceivedGameLobbyInvitation(db *gorm.DB) gin.HandlerFunc {
 // @Param lobby_id path string true "Lobby ID"
 // @Param username path string true "Recipient's username"
 // @Success 200 {object} object{message=string}
-// @Failure 401 {object} object{error=string}
-// @Failure 404 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 401 {object} app_errors.Error
+// @Failure 404 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
 // @Router /auth/sent_lobby_invitation/{lobby_id}/{username} [delete]
 // @Security ApiKeyAuth
 func DeleteSentGameLobbyInvitation(db *gorm.DB) gin.HandlerFunc {
@@ -434,7 +435,7 @@ func DeleteSentGameLobbyInvitation(db *gorm.DB) gin.HandlerFunc {
 		// Find the user's game profile
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			app_errors.Respond(c, app_errors.UserNotFound)
 			return
 		}
 
@@ -446,13 +447,13 @@ func DeleteSentGameLobbyInvitation(db *gorm.DB) gin.HandlerFunc {
 		result := db.Where("lobby_id = ? AND sender_username = ? AND invited_username = ?",
 			lobbyID, user.ProfileUsername, recipientUsername).Delete(&models.GameInvitation{})
 		if result.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting game lobby invitation"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error deleting game lobby invitation"))
 			return
 		}
 
 		// Check if the invitation was actually deleted
 		if result.RowsAffected == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Game lobby invitation not found"})
+			app_errors.Respond(c, app_errors.InvitationNotFound)
 			return
 		}
 
@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"Nogler/middleware"
+	models "Nogler/models/postgres"
+	"Nogler/services/app_errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Sends a page of the profiles matched by query, ordered by rating
+func sendLeaderboardPage(c *gin.Context, query *gorm.DB, page int, pageSize int) {
+	var total int64
+	if err := query.Session(&gorm.Session{}).Model(&models.GameProfile{}).Count(&total).Error; err != nil {
+		log.Printf("[LEADERBOARD-ERROR] Error counting profiles: %v", err)
+		app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to retrieve leaderboard"))
+		return
+	}
+
+	offset := (page - 1) * pageSize
+	var profiles []models.GameProfile
+	if err := query.Session(&gorm.Session{}).
+		Order("user_score desc, username asc").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&profiles).Error; err != nil {
+		log.Printf("[LEADERBOARD-ERROR] Error getting profiles: %v", err)
+		app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to retrieve leaderboard"))
+		return
+	}
+
+	entries := make([]gin.H, len(profiles))
+	for i, profile := range profiles {
+		stats := models.ParseUserStats(profile.UserStats)
+		entries[i] = gin.H{
+			"rank":         offset + i + 1,
+			"username":     profile.Username,
+			"icon":         profile.UserIcon,
+			"rating":       profile.UserScore,
+			"games_played": stats.GamesPlayed,
+			"games_won":    stats.GamesWon,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+		"players":   entries,
+	})
+}
+
+// @Summary Gets the global leaderboard
+// @Description Returns the players ordered by rating, paginated
+// @Tags leaderboard
+// @Produce json
+// @Param page query int false "Page number, starting at 1 (default 1)"
+// @Param page_size query int false "Players per page (default 10, max 50)"
+// @Success 200 {object} object{page=integer,page_size=integer,total=integer,players=[]object{rank=integer,username=string,icon=integer,rating=integer,games_played=integer,games_won=integer}}
+// @Failure 400 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
+// @Router /leaderboard [get]
+func GetLeaderboard(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, pageSize, ok := parsePagination(c)
+		if !ok {
+			return
+		}
+
+		sendLeaderboardPage(c, db.Model(&models.GameProfile{}), page, pageSize)
+	}
+}
+
+// @Summary Gets the friends leaderboard
+// @Description Returns the user and their friends ordered by rating, paginated
+// @Tags leaderboard
+// @Produce json
+// @Param Authorization header string true "Bearer JWT token"
+// @Param page query int false "Page number, starting at 1 (default 1)"
+// @Param page_size query int false "Players per page (default 10, max 50)"
+// @Success 200 {object} object{page=integer,page_size=integer,total=integer,players=[]object{rank=integer,username=string,icon=integer,rating=integer,games_played=integer,games_won=integer}}
+// @Failure 400 {object} app_errors.Error
+// @Failure 401 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
+// @Router /auth/leaderboard/friends [get]
+// @Security ApiKeyAuth
+func GetFriendsLeaderboard(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		email, err := middleware.JWT_decoder(c)
+		if err != nil {
+			app_errors.Respond(c, app_errors.InvalidToken)
+			return
+		}
+
+		var user models.User
+		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+			app_errors.Respond(c, app_errors.AuthUserNotFound)
+			return
+		}
+		username := user.ProfileUsername
+
+		page, pageSize, ok := parsePagination(c)
+		if !ok {
+			return
+		}
+
+		var friendships []models.Friendship
+		if err := db.Where("username1 = ? OR username2 = ?", username, username).Find(&friendships).Error; err != nil {
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error fetching friendships"))
+			return
+		}
+
+		// The user is ranked among their friends
+		usernames := []string{username}
+		for _, friendship := range friendships {
+			if friendship.Username1 == username {
+				usernames = append(usernames, friendship.Username2)
+			} else {
+				usernames = append(usernames, friendship.Username1)
+			}
+		}
+
+		sendLeaderboardPage(c, db.Model(&models.GameProfile{}).Where("username IN ?", usernames), page, pageSize)
+	}
+}
+
+// @Summary Gets the rating history of a user
+// @Description Returns the rating changes of a user, most recent first, paginated
+// @Tags users
+// @Produce json
+// @Param username path string true "Username"
+// @Param page query int false "Page number, starting at 1 (default 1)"
+// @Param page_size query int false "Entries per page (default 10, max 50)"
+// @Success 200 {object} object{username=string,rating=integer,page=integer,page_size=integer,total=integer,history=[]object{lobby_id=string,old_rating=integer,new_rating=integer,date=string}}
+// @Failure 400 {object} app_errors.Error
+// @Failure 404 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
+// @Router /users/{username}/rating_history [get]
+func GetUserRatingHistory(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("username")
+
+		page, pageSize, ok := parsePagination(c)
+		if !ok {
+			return
+		}
+
+		var profile models.GameProfile
+		if err := db.Where("username = ?", username).First(&profile).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				app_errors.Respond(c, app_errors.UserNotFound)
+			} else {
+				app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error fetching user"))
+			}
+			return
+		}
+
+		var total int64
+		if err := db.Model(&models.RatingHistory{}).Where("username = ?", username).Count(&total).Error; err != nil {
+			log.Printf("[RATING-HISTORY-ERROR] Error counting rating history of %s: %v", username, err)
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to retrieve rating history"))
+			return
+		}
+
+		var history []models.RatingHistory
+		if err := db.Where("username = ?", username).
+			Order("created_at desc, id desc").
+			Offset((page - 1) * pageSize).
+			Limit(pageSize).
+			Find(&history).Error; err != nil {
+			log.Printf("[RATING-HISTORY-ERROR] Error getting rating history of %s: %v", username, err)
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to retrieve rating history"))
+			return
+		}
+
+		entries := make([]gin.H, len(history))
+		for i, entry := range history {
+			entries[i] = gin.H{
+				"lobby_id":   entry.LobbyID,
+				"old_rating": entry.OldRating,
+				"new_rating": entry.NewRating,
+				"date":       entry.CreatedAt,
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"username":  username,
+			"rating":    profile.UserScore,
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+			"history":   entries,
+		})
+	}
+}
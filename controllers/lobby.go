@@ -1,17 +1,17 @@
 package controllers
 
 import (
-	game_constants "Nogler/constants/game"
 	"Nogler/middleware"
 	models "Nogler/models/postgres"
 	redis_models "Nogler/models/redis"
-	"Nogler/services/poker"
+	"Nogler/services/app_errors"
 	"Nogler/services/redis"
-	"Nogler/services/socket_io/utils/game_flow"
+	"Nogler/services/socket_io/utils/stages/lobby_setup"
 	"Nogler/utils"
-	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -26,10 +26,22 @@ import (
 // @Produce json
 // @Param Authorization header string true "Bearer JWT token"
 // @Param public formData int true "Set to 1 for public lobby, 2 for AI lobby and 0 for private lobby"
-// @Success 200 {object} object{message=string,lobby_id=string}
-// @Failure 400 {object} object{error=string}
-// @Failure 401 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Param seed formData int false "Seed for every random event of the game, from 0 to 9223372036854775807 (random if not set), useful to reproduce a game"
+// @Param max_rounds formData int false "Number of rounds of the game (default 10)"
+// @Param hand_plays formData int false "Hands that can be played each round (default 3)"
+// @Param discards formData int false "Discards allowed each round (default 3)"
+// @Param base_blind formData int false "Base blind of the first round (default 10)"
+// @Param blind_growth formData int false "The base blind is multiplied by this every round (default 2)"
+// @Param blind_seconds formData int false "Duration of the blind phase in seconds (default 20)"
+// @Param play_round_seconds formData int false "Duration of the play round phase in seconds (default 120)"
+// @Param shop_seconds formData int false "Duration of the shop phase in seconds (default 60)"
+// @Param vouchers_seconds formData int false "Duration of the vouchers phase in seconds (default 60)"
+// @Param starting_money formData int false "Money of each player when the game starts (default 10)"
+// @Param max_jokers formData int false "Joker slots of each player (default 5)"
+// @Success 200 {object} object{message=string,lobby_id=string,public=integer,rules=object}
+// @Failure 400 {object} app_errors.Error
+// @Failure 401 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
 // @Router /auth/CreateLobby [post]
 // @Security ApiKeyAuth
 func CreateLobby(db *gorm.DB, redisClient *redis.RedisClient) gin.HandlerFunc {
@@ -51,116 +63,48 @@ func CreateLobby(db *gorm.DB, redisClient *redis.RedisClient) gin.HandlerFunc {
 			isPublic = 0
 		}
 
+		// Seed of the game, a fixed one can be given to reproduce a previous game. It's stored
+		// in a PostgreSQL bigint with the replay, so it can't be over math.MaxInt64
+		seed := uint64(time.Now().UnixNano())
+		if seedParam := c.PostForm("seed"); seedParam != "" {
+			parsed, err := strconv.ParseInt(seedParam, 10, 64)
+			if err != nil || parsed < 0 {
+				app_errors.Respond(c, app_errors.InvalidField, app_errors.Details{"field": "seed"})
+				return
+			}
+			seed = uint64(parsed)
+		}
+
+		// Rules of the game, the ones not given keep their default value
+		rules, err := parseGameRules(c)
+		if err != nil {
+			app_errors.Respond(c, app_errors.InvalidField, app_errors.Reason(err.Error()))
+			return
+		}
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found: invalid email"})
+			app_errors.Respond(c, app_errors.AuthUserNotFound)
 			return
 		}
 
 		username := user.ProfileUsername
 		if username == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Username is required"})
+			app_errors.Respond(c, app_errors.MissingField, app_errors.Details{"field": "username"})
 			return
 		}
 
-		// Create lobby with public/private setting
-		NewLobby := models.GameLobby{
-			CreatorUsername: username,
-			NumberOfRounds:  0,
-			TotalPoints:     0,
-			IsPublic:        isPublic, // Set from parameter
-		}
-
-		if err := db.Create(&NewLobby).Error; err != nil {
+		newLobby, err := lobby_setup.CreateLobby(db, redisClient, username, isPublic, seed, rules)
+		if err != nil {
 			log.Printf("Failed to create lobby: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating lobby"})
-			return
-		}
-
-		// Create corresponding Redis lobby with matching public/private setting
-		redisLobby := &redis_models.GameLobby{
-			Id:                      NewLobby.ID,
-			CreatorUsername:         username,
-			MaxRounds:               game_constants.MaxGameRounds,
-			TotalPoints:             0,
-			CreatedAt:               NewLobby.CreatedAt,
-			GameHasBegun:            false,
-			IsPublic:                isPublic,
-			CurrentHighBlind:        0,
-			NumberOfVotes:           0,
-			CurrentRound:            0,
-			ProposedBlinds:          make(map[string]bool),
-			PlayersFinishedRound:    make(map[string]bool),
-			PlayersFinishedShop:     make(map[string]bool),
-			PlayersFinishedVouchers: make(map[string]bool),
-			PlayerCount:             0,
-			BlindTimeout:            time.Time{},
-			GameRoundTimeout:        time.Time{},
-			ShopTimeout:             time.Time{},
-			VouchersTimeout:         time.Time{},
-			BlindsCompleted:         make(map[int]bool),
-			GameRoundsCompleted:     make(map[int]bool),
-			ShopsCompleted:          make(map[int]bool),
-			VouchersCompleted:       make(map[int]bool),
-			CurrentPhase:            redis_models.PhaseNone, // Initialize with "none" phase
-			CurrentBaseBlind:        game_constants.BASE_BLIND,
-		}
-
-		if isPublic == 2 {
-			// Add bot to Redis lobby
-			purchasedPackCards := make([]poker.Card, 0)
-			purchasedPackCardsJSON, _ := json.Marshal(purchasedPackCards)
-			redisAIPlayer := &redis_models.InGamePlayer{
-				Username:                game_flow.FormatAIPlayerName(NewLobby.ID),
-				LobbyId:                 NewLobby.ID,
-				PlayersMoney:            10, // Initial money --> TODO: ver cuánto es la cifra inicial
-				Rerolls:                 0,
-				CurrentDeck:             poker.InitializePlayerDeck(), // Will be initialized when game starts
-				Modifiers:               nil,                          // Will be initialized when game starts
-				CurrentJokers:           nil,                          // Will be initialized when game starts
-				MostPlayedHand:          nil,                          // Will be initialized during game
-				HandPlaysLeft:           game_constants.TOTAL_HAND_PLAYS,
-				DiscardsLeft:            game_constants.TOTAL_DISCARDS,
-				Winner:                  false,
-				CurrentRoundPoints:      0,
-				TotalGamePoints:         0,
-				IsBot:                   true, // Mark as AI player
-				LastPurchasedPackItemId: -1,
-				PurchasedPackCards:      purchasedPackCardsJSON,
-			}
-
-			// Save the AI player in Redis
-			if err := redisClient.SaveInGamePlayer(redisAIPlayer); err != nil {
-				log.Printf("[AI-ERROR] Error saving AI player in Redis: %v", err)
-				// Rollback lobby creation in Redis and PostgreSQL
-				if err := redisClient.DeleteGameLobby(NewLobby.ID); err != nil {
-					log.Printf("Failed to rollback Redis lobby creation: %v", err)
-				}
-				if err := db.Delete(&NewLobby).Error; err != nil {
-					log.Printf("Failed to rollback PostgreSQL lobby creation: %v", err)
-				}
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error saving AI player in Redis"})
-				return
-			}
-
-			// Update player count in Redis lobby
-			redisLobby.PlayerCount = 1
-		}
-
-		// Save the lobby in Redis
-		if err := redisClient.SaveGameLobby(redisLobby); err != nil {
-			log.Printf("Failed to create lobby in Redis: %v", err)
-			if err := db.Delete(&NewLobby).Error; err != nil {
-				log.Printf("Failed to rollback PostgreSQL lobby creation: %v", err)
-			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating lobby in Redis"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error creating lobby"))
 			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{
-			"lobby_id": NewLobby.ID,
+			"lobby_id": newLobby.ID,
 			"message":  "Lobby created successfully",
 			"public":   isPublic,
+			"rules":    rules,
 		})
 
 		// TODO!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!! INITIAL USER DOESNT HAVE DECK SINCE HE DOESNT EXPLICITLY JOIN??
@@ -174,6 +118,40 @@ func CreateLobby(db *gorm.DB, redisClient *redis.RedisClient) gin.HandlerFunc {
 	*/
 }
 
+// Reads the optional rule parameters of CreateLobby over the default rules and validates them
+func parseGameRules(c *gin.Context) (redis_models.GameRules, error) {
+	rules := redis_models.DefaultGameRules()
+	params := map[string]*int{
+		"max_rounds":         &rules.MaxRounds,
+		"hand_plays":         &rules.HandPlays,
+		"discards":           &rules.Discards,
+		"base_blind":         &rules.BaseBlind,
+		"blind_growth":       &rules.BlindGrowth,
+		"blind_seconds":      &rules.BlindSeconds,
+		"play_round_seconds": &rules.PlayRoundSeconds,
+		"shop_seconds":       &rules.ShopSeconds,
+		"vouchers_seconds":   &rules.VouchersSeconds,
+		"starting_money":     &rules.StartingMoney,
+		"max_jokers":         &rules.MaxJokers,
+	}
+	for name, rule := range params {
+		param := c.PostForm(name)
+		if param == "" {
+			continue
+		}
+		value, err := strconv.Atoi(param)
+		if err != nil {
+			return rules, fmt.Errorf("invalid %s", name)
+		}
+		*rule = value
+	}
+
+	if err := rules.Validate(); err != nil {
+		return rules, err
+	}
+	return rules, nil
+}
+
 // @Summary Gives info of a lobby
 // @Description Given a lobby id, it will return its information
 // @Tags lobby
@@ -183,9 +161,9 @@ func CreateLobby(db *gorm.DB, redisClient *redis.RedisClient) gin.HandlerFunc {
 // @in header
 // @Param lobby_id path string true "Id of the lobby wanted"
 // @Success 200 {object} object{lobby_id=string,creator_username=string,number_rounds=integer,total_points=integer,created_at=string,is_public=boolean,number_players=integer,players=[]string}
-// @Failure 400 {object} object{error=string}
-// @Failure 404 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 400 {object} app_errors.Error
+// @Failure 404 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
 // @Router /auth/lobbyInfo/{lobby_id} [get]
 // @Security ApiKeyAuth
 func GetLobbyInfo(db *gorm.DB) gin.HandlerFunc {
@@ -197,16 +175,16 @@ func GetLobbyInfo(db *gorm.DB) gin.HandlerFunc {
 
 		if err != nil {
 			if err.Error() == "lobby not found" {
-				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				app_errors.Respond(c, app_errors.LobbyNotFound)
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				app_errors.Respond(c, app_errors.Internal, app_errors.Reason(err.Error()))
 			}
 			return
 		}
 
 		var usersInLobby []string
 		if err := db.Model(&models.InGamePlayer{}).Where("lobby_id = ?", lobbyID).Pluck("username", &usersInLobby).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users in lobby"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to retrieve users in lobby"))
 			return
 		}
 
@@ -233,8 +211,8 @@ func GetLobbyInfo(db *gorm.DB) gin.HandlerFunc {
 // @Param Authorization header string true "Bearer JWT token"
 // @in header
 // @Success 200 {array} object{lobby_id=string,creator_username=string,number_rounds=integer,total_points=integer,created_at=string,host_icon=integer,player_count=integer,is_public=boolean}
-// @Failure 401 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 401 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
 // @Router /auth/getAllLobbies [get]
 // @Security ApiKeyAuth
 func GetAllLobbies(db *gorm.DB) gin.HandlerFunc {
@@ -243,14 +221,14 @@ func GetAllLobbies(db *gorm.DB) gin.HandlerFunc {
 		email, err := middleware.JWT_decoder(c)
 		if err != nil {
 			log.Print("Error en jwt...")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			app_errors.Respond(c, app_errors.InvalidToken)
 			return
 		}
 
 		// Verify user exists
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found: invalid email"})
+			app_errors.Respond(c, app_errors.AuthUserNotFound)
 			return
 		}
 
@@ -258,7 +236,7 @@ func GetAllLobbies(db *gorm.DB) gin.HandlerFunc {
 
 		// Get only public lobbies from database
 		if err := db.Where("is_public = ?", 1).Find(&gameLobbies).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve lobbies"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to retrieve lobbies"))
 			return
 		}
 
@@ -276,7 +254,7 @@ func GetAllLobbies(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		if err := db.Model(&models.GameProfile{}).Where("username IN ?", usernames).Select("username, user_icon").Find(&profiles).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve host icons"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to retrieve host icons"))
 			return
 		}
 
@@ -297,7 +275,7 @@ func GetAllLobbies(db *gorm.DB) gin.HandlerFunc {
 			Select("lobby_id, COUNT(*) AS player_count").
 			Group("lobby_id").
 			Find(&playerCountResult).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count players"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to count players"))
 			return
 		}
 
@@ -335,10 +313,10 @@ func GetAllLobbies(db *gorm.DB) gin.HandlerFunc {
 // @Param lobby_id path string true "lobby_id"
 // @in header
 // @Success 200 {object} object{message=string,lobby_info=object{id=string,creator=string,number_rounds=integer,total_points=integer,game_has_begun=boolean,public=boolean}}
-// @Failure 400 {object} object{error=string}
-// @Failure 401 {object} object{error=string}
-// @Failure 404 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 400 {object} app_errors.Error
+// @Failure 401 {object} app_errors.Error
+// @Failure 404 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
 // @Security ApiKeyAuth
 // @Router /auth/joinLobby/{lobby_id} [post]
 func JoinLobby(db *gorm.DB, redisClient *redis.RedisClient) gin.HandlerFunc {
@@ -351,9 +329,9 @@ func JoinLobby(db *gorm.DB, redisClient *redis.RedisClient) gin.HandlerFunc {
 
 		if result.Error != nil {
 			if result.Error == gorm.ErrRecordNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Lobby not found"})
+				app_errors.Respond(c, app_errors.LobbyNotFound)
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+				app_errors.Respond(c, app_errors.Internal, app_errors.Reason(result.Error.Error()))
 			}
 			return
 		}
@@ -366,14 +344,14 @@ func JoinLobby(db *gorm.DB, redisClient *redis.RedisClient) gin.HandlerFunc {
 
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found: invalid email"})
+			app_errors.Respond(c, app_errors.AuthUserNotFound)
 			return
 		}
 
 		username := user.ProfileUsername
 
 		if username == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Username is required"})
+			app_errors.Respond(c, app_errors.MissingField, app_errors.Details{"field": "username"})
 			return
 		}
 
@@ -385,83 +363,48 @@ func JoinLobby(db *gorm.DB, redisClient *redis.RedisClient) gin.HandlerFunc {
 		).First(&userInLobby)
 
 		if result.RowsAffected > 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "User already exists in a lobby"})
+			app_errors.Respond(c, app_errors.AlreadyInLobby)
 			return
 		}
 
-		gamePlayer := models.InGamePlayer{
-			LobbyID:  lobbyID,
-			Username: username,
-		}
-
 		// Check if the lobby is full
 		var playersInLobby []models.InGamePlayer
 		if err := db.Where("lobby_id = ?", lobbyID).Find(&playersInLobby).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users in lobby"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to retrieve users in lobby"))
 			return
 		}
 
 		if len(playersInLobby) >= 8 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Lobby is full"})
+			app_errors.Respond(c, app_errors.LobbyFull)
 			return
 		}
 
 		// Check if the game has already started
 		if lobby.GameHasBegun {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Game has already started"})
+			app_errors.Respond(c, app_errors.GameAlreadyStarted)
 			return
 		}
 
-		// Start transaction
-		tx := db.Begin()
-		if err := tx.Create(&gamePlayer).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error adding user to the lobby"})
-			return
-		}
-
-		// Create Redis InGamePlayer entry
-		purchasedPackCards := make([]poker.Card, 0)
-		purchasedPackCardsJSON, _ := json.Marshal(purchasedPackCards)
-		redisPlayer := &redis_models.InGamePlayer{
-			Username:     username,
-			LobbyId:      lobbyID,
-			Rerolls:      0,
-			PlayersMoney: 10,                           // Initial money --> TODO: ver cuánto es la cifra inicial
-			CurrentDeck:  poker.InitializePlayerDeck(), // Will be initialized when game starts
-			// TODO: see in_game_player.go
-			// PlayersRemainingCards: 52,
-			Modifiers:          nil, // Will be initialized when game starts
-			CurrentJokers:      nil, // Will be initialized when game starts
-			MostPlayedHand:     nil, // Will be initialized during game
-			HandPlaysLeft:      game_constants.TOTAL_HAND_PLAYS,
-			DiscardsLeft:       game_constants.TOTAL_DISCARDS,
-			Winner:             false,
-			CurrentRoundPoints: 0,
-			TotalGamePoints:    0,
-			// NEW: initially, the player hasn't bought any packs yet
-			LastPurchasedPackItemId:     -1,
-			PurchasedPackCards:          purchasedPackCardsJSON,
-			CurrentShopPurchasedItemIDs: make(map[int]bool),
-		}
-
-		if err := redisClient.SaveInGamePlayer(redisPlayer); err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error adding user to Redis lobby"})
+		// Get Redis lobby (to update it and for the lobby seed)
+		redisLobby, err := redisClient.GetGameLobby(lobbyID)
+		if err != nil {
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error retrieving Redis lobby"))
 			return
 		}
 
-		// Get Redis lobby to update
-		redisLobby, err := redisClient.GetGameLobby(lobbyID)
-		if err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving Redis lobby"})
+		// Joining a lobby by hand takes the player out of the matchmaking queue, so the
+		// matchmaker doesn't put them in a second lobby
+		if removed, err := redisClient.DequeuePlayer(username); err != nil {
+			log.Printf("Failed to remove %s from the matchmaking queue: %v", username, err)
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error leaving the matchmaking queue"))
 			return
+		} else if removed {
+			log.Printf("[MATCHMAKING] %s left the queue to join lobby %s", username, lobbyID)
 		}
 
-		// Commit PostgreSQL transaction
-		if err := tx.Commit().Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error committing transaction"})
+		if err := lobby_setup.AddPlayer(db, redisClient, redisLobby, username); err != nil {
+			log.Printf("Failed to join lobby %s: %v", lobbyID, err)
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error adding user to the lobby"))
 			return
 		}
 
@@ -474,6 +417,7 @@ func JoinLobby(db *gorm.DB, redisClient *redis.RedisClient) gin.HandlerFunc {
 				"total_points":   redisLobby.TotalPoints,
 				"game_has_begun": redisLobby.GameHasBegun,
 				"public":         redisLobby.IsPublic,
+				"rules":          redisLobby.Rules,
 			},
 		})
 	}
@@ -487,9 +431,10 @@ func JoinLobby(db *gorm.DB, redisClient *redis.RedisClient) gin.HandlerFunc {
 // @Param lobby_id formData string true "Lobby ID"
 // @Param friendUsername formData string true "Username of the recipient"
 // @Success 200 {object} object{message=string} "Lobby invitation sent successfully"
-// @Failure 400 {object} object{error=string} "Friendship does not exist"
-// @Failure 401 {object} object{error=string} "User not authenticated"
-// @Failure 500 {object} object{error=string} "Error sending invitation"
+// @Failure 400 {object} app_errors.Error "Friendship does not exist"
+// @Failure 401 {object} app_errors.Error "User not authenticated"
+// @Failure 429 {object} app_errors.Error "Too many requests"
+// @Failure 500 {object} app_errors.Error "Error sending invitation"
 // @Router /auth/sendLobbyInvitation [post]
 // @Security ApiKeyAuth
 func SendLobbyInvitation(db *gorm.DB) gin.HandlerFunc {
@@ -503,7 +448,7 @@ func SendLobbyInvitation(db *gorm.DB) gin.HandlerFunc {
 
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			app_errors.Respond(c, app_errors.AuthUserNotFound)
 			return
 		}
 
@@ -511,14 +456,14 @@ func SendLobbyInvitation(db *gorm.DB) gin.HandlerFunc {
 		friendUsername := c.PostForm("friendUsername")
 
 		if username == "" || friendUsername == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Both usernames are required"})
+			app_errors.Respond(c, app_errors.MissingField, app_errors.Details{"fields": []string{"username", "friendUsername"}})
 			return
 		}
 
 		// Check if the friendship exists
 		var friendship models.Friendship
 		if err := db.Where("(username1 = ? AND username2 = ?) OR (username1 = ? AND username2 = ?)", username, friendUsername, friendUsername, username).First(&friendship).Error; err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Friendship does not exist"})
+			app_errors.Respond(c, app_errors.FriendshipNotFound)
 			return
 		}
 
@@ -528,14 +473,14 @@ func SendLobbyInvitation(db *gorm.DB) gin.HandlerFunc {
 		// Check if lobby exists
 		var lobby models.GameLobby
 		if err := db.Where("id = ?", lobbyID).First(&lobby).Error; err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Lobby does not exist"})
+			app_errors.Respond(c, app_errors.LobbyNotFound)
 			return
 		}
 
 		// Check if the invitation already exists
 		var existingInvitation models.GameInvitation
 		if err := db.Where("lobby_id = ? AND sender_username = ? AND invited_username = ?", lobbyID, username, friendUsername).First(&existingInvitation).Error; err == nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invitation already sent to this user"})
+			app_errors.Respond(c, app_errors.InvitationAlreadySent)
 			return
 		}
 
@@ -548,7 +493,7 @@ func SendLobbyInvitation(db *gorm.DB) gin.HandlerFunc {
 
 		if err := db.Create(&newLobbyInvitation).Error; err != nil {
 			log.Fatal("Failed to send lobby invitation:", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error sending invitation"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error sending invitation"))
 			return
 		}
 
@@ -557,14 +502,15 @@ func SendLobbyInvitation(db *gorm.DB) gin.HandlerFunc {
 }
 
 // @Summary Returns a lobby code
-// @Description Returns the code of a lobby with a similiar score to the user
+// @Description Returns the code of the public lobby whose creator has the closest rating to the user.
+// @Description To be matched with players of a similar rating in a new game, use the join_queue socket.io event instead
 // @Tags lobby
 // @Produce json
 // @Param Authorization header string true "Bearer JWT token"
 // @Success 200 {object} object{lobby_id=string,message=string}
-// @Failure 400 {object} object{error=string} "User not found"
-// @Failure 401 {object} object{error=string} "User not authenticated"
-// @Failure 500 {object} object{error=string} "Error retrieving lobby"
+// @Failure 400 {object} app_errors.Error "User not found"
+// @Failure 401 {object} app_errors.Error "User not authenticated"
+// @Failure 500 {object} app_errors.Error "Error retrieving lobby"
 // @Router /auth/matchMaking [get]
 // @Security ApiKeyAuth
 func MatchMaking(db *gorm.DB) gin.HandlerFunc {
@@ -574,28 +520,32 @@ func MatchMaking(db *gorm.DB) gin.HandlerFunc {
 		email, err := middleware.JWT_decoder(c)
 		if err != nil {
 			log.Print("Error en jwt...")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			app_errors.Respond(c, app_errors.InvalidToken)
 			return
 		}
 
 		// Verify user exists
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found: invalid email"})
+			app_errors.Respond(c, app_errors.AuthUserNotFound)
 			return
 		}
 
 		// Get user's game profile
 		var userProfile models.GameProfile
 		if err := db.Where("username = ?", user.ProfileUsername).First(&userProfile).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user's game profile"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to retrieve user's game profile"))
 			return
 		}
 
 		var lobby models.GameLobby
-		// Get public and not started lobbies players from database
-		if err := db.Preload("InGamePlayers").Where("game_has_begun = ? AND is_public = ?", false, 1).Take(&lobby).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve lobby"})
+		// Get the public and not started lobby whose creator has the most similar rating
+		if err := db.Preload("InGamePlayers").
+			Joins("JOIN game_profiles ON game_profiles.username = game_lobbies.creator_username").
+			Where("game_lobbies.game_has_begun = ? AND game_lobbies.is_public = ?", false, 1).
+			Order(gorm.Expr("ABS(game_profiles.user_score - ?)", userProfile.UserScore)).
+			First(&lobby).Error; err != nil {
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to retrieve lobby"))
 			return
 		}
 
@@ -612,11 +562,11 @@ func MatchMaking(db *gorm.DB) gin.HandlerFunc {
 // @Param lobby_id path string true "Lobby ID"
 // @Param public formData int true "Set to 1 for public lobby, 2 for AI lobby and 0 for private lobby"
 // @Success 200 {object} object{message=string,is_public=boolean}
-// @Failure 400 {object} object{error=string}
-// @Failure 401 {object} object{error=string}
-// @Failure 403 {object} object{error=string} "User is not the lobby creator"
-// @Failure 404 {object} object{error=string} "Lobby not found"
-// @Failure 500 {object} object{error=string}
+// @Failure 400 {object} app_errors.Error
+// @Failure 401 {object} app_errors.Error
+// @Failure 403 {object} app_errors.Error "User is not the lobby creator"
+// @Failure 404 {object} app_errors.Error "Lobby not found"
+// @Failure 500 {object} app_errors.Error
 // @Router /auth/setLobbyVisibility/{lobby_id} [post]
 // @Security ApiKeyAuth
 func SetLobbyVisibility(db *gorm.DB, redisClient *redis.RedisClient) gin.HandlerFunc {
@@ -642,13 +592,13 @@ func SetLobbyVisibility(db *gorm.DB, redisClient *redis.RedisClient) gin.Handler
 		email, err := middleware.JWT_decoder(c)
 		if err != nil {
 			log.Print("Error in JWT...")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			app_errors.Respond(c, app_errors.InvalidToken)
 			return
 		}
 
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found: invalid email"})
+			app_errors.Respond(c, app_errors.AuthUserNotFound)
 			return
 		}
 
@@ -658,16 +608,16 @@ func SetLobbyVisibility(db *gorm.DB, redisClient *redis.RedisClient) gin.Handler
 		var lobby models.GameLobby
 		if err := db.Where("id = ?", lobbyID).First(&lobby).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Lobby not found"})
+				app_errors.Respond(c, app_errors.LobbyNotFound)
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				app_errors.Respond(c, app_errors.Internal, app_errors.Reason(err.Error()))
 			}
 			return
 		}
 
 		// Verify the user is the lobby creator
 		if lobby.CreatorUsername != username {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Only the lobby creator can change visibility settings"})
+			app_errors.Respond(c, app_errors.NotLobbyHost)
 			return
 		}
 
@@ -677,28 +627,24 @@ func SetLobbyVisibility(db *gorm.DB, redisClient *redis.RedisClient) gin.Handler
 		// Update visibility in PostgreSQL
 		if err := tx.Model(&lobby).Update("is_public", isPublic).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update lobby visibility"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to update lobby visibility"))
 			return
 		}
 
 		// Update visibility in Redis
-		redisLobby, err := redisClient.GetGameLobby(lobbyID)
+		_, err = redisClient.UpdateGameLobby(lobbyID, func(redisLobby *redis_models.GameLobby) error {
+			redisLobby.IsPublic = isPublic
+			return nil
+		})
 		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve Redis lobby"})
-			return
-		}
-
-		redisLobby.IsPublic = isPublic
-		if err := redisClient.SaveGameLobby(redisLobby); err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update Redis lobby"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to update Redis lobby"))
 			return
 		}
 
 		// Commit transaction
 		if err := tx.Commit().Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error committing transaction"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error committing transaction"))
 			return
 		}
 
@@ -717,28 +663,28 @@ func SetLobbyVisibility(db *gorm.DB, redisClient *redis.RedisClient) gin.Handler
 // @Param Authorization header string true "Bearer JWT token"
 // @Produce json
 // @Success 200 {object} object{in_lobby=boolean,lobby_id=string}
-// @Failure 400 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 400 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
 // @Router /auth/isUserInLobby [get]
 func IsUserInLobby(db *gorm.DB, redisClient *redis.RedisClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		email, err := middleware.JWT_decoder(c)
 		if err != nil {
 			log.Print("JWT error...")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			app_errors.Respond(c, app_errors.InvalidToken)
 			return
 		}
 
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found: invalid email"})
+			app_errors.Respond(c, app_errors.AuthUserNotFound)
 			return
 		}
 
 		username := user.ProfileUsername
 
 		if username == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Username is required"})
+			app_errors.Respond(c, app_errors.MissingField, app_errors.Details{"field": "username"})
 			return
 		}
 
@@ -751,7 +697,7 @@ func IsUserInLobby(db *gorm.DB, redisClient *redis.RedisClient) gin.HandlerFunc
 
 		lobby, err := redisClient.GetGameLobby(inGamePlayer.LobbyId)
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Lobby not found"})
+			app_errors.Respond(c, app_errors.LobbyNotFound)
 			return
 		}
 
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	models "Nogler/models/postgres"
+	"Nogler/services/app_errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// @Summary Gets the match history of a user
+// @Description Returns the finished games of a user, most recent first, paginated
+// @Tags users
+// @Produce json
+// @Param username path string true "Username"
+// @Param page query int false "Page number, starting at 1 (default 1)"
+// @Param page_size query int false "Matches per page (default 10, max 50)"
+// @Success 200 {object} object{username=string,page=integer,page_size=integer,total=integer,matches=[]object{lobby_id=string,participants=[]string,placement=integer,winner=boolean,rounds_survived=integer,final_points=integer,final_money=integer,most_played_hand=integer,best_hand_score=integer,jokers=[]integer,finished_at=string}}
+// @Failure 400 {object} app_errors.Error
+// @Failure 404 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
+// @Router /users/{username}/matches [get]
+func GetUserMatches(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.Param("username")
+
+		page, pageSize, ok := parsePagination(c)
+		if !ok {
+			return
+		}
+
+		// Verify the user exists
+		var profile models.GameProfile
+		if err := db.Where("username = ?", username).First(&profile).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				app_errors.Respond(c, app_errors.UserNotFound)
+			} else {
+				app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error fetching user"))
+			}
+			return
+		}
+
+		var total int64
+		if err := db.Model(&models.MatchHistory{}).Where("username = ?", username).Count(&total).Error; err != nil {
+			log.Printf("[MATCH-HISTORY-ERROR] Error counting matches of %s: %v", username, err)
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to retrieve matches"))
+			return
+		}
+
+		var matches []models.MatchHistory
+		if err := db.Where("username = ?", username).
+			Order("finished_at desc, id desc").
+			Offset((page - 1) * pageSize).
+			Limit(pageSize).
+			Find(&matches).Error; err != nil {
+			log.Printf("[MATCH-HISTORY-ERROR] Error getting matches of %s: %v", username, err)
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to retrieve matches"))
+			return
+		}
+
+		matchesData := make([]gin.H, len(matches))
+		for i, match := range matches {
+			matchesData[i] = gin.H{
+				"lobby_id":         match.LobbyID,
+				"participants":     match.Participants,
+				"placement":        match.Placement,
+				"winner":           match.Winner,
+				"rounds_survived":  match.RoundsSurvived,
+				"final_points":     match.FinalPoints,
+				"final_money":      match.FinalMoney,
+				"most_played_hand": match.MostPlayedHand,
+				"best_hand_score":  match.BestHandScore,
+				"jokers":           match.Jokers,
+				"finished_at":      match.FinishedAt,
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"username":  username,
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+			"matches":   matchesData,
+		})
+	}
+}
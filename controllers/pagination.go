@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"Nogler/services/app_errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Page size of the paginated endpoints when the client doesn't send one, and the maximum allowed
+const (
+	defaultPageSize = 10
+	maxPageSize     = 50
+)
+
+// Reads the page (starting at 1) and page_size query parameters.
+// If they're invalid, the error is sent to the client and ok is false
+func parsePagination(c *gin.Context) (page int, pageSize int, ok bool) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		app_errors.Respond(c, app_errors.InvalidField, app_errors.Details{"field": "page"})
+		return 0, 0, false
+	}
+	pageSize, err = strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultPageSize)))
+	if err != nil || pageSize < 1 || pageSize > maxPageSize {
+		app_errors.Respond(c, app_errors.InvalidField, app_errors.Details{"field": "page_size"})
+		return 0, 0, false
+	}
+	return page, pageSize, true
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"Nogler/services/poker"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Gets the hand types
+// @Description Returns every poker hand type, from the best to the worst: the id sent in the hand_type fields of the socket.io events, its name, its base chips and mult at level 1 and what every level adds to them
+// @Tags poker
+// @Produce json
+// @Success 200 {array} object{id=integer,name=string,chips=integer,mult=integer,level_chips=integer,level_mult=integer}
+// @Router /poker/hand-types [get]
+func GetHandTypes(c *gin.Context) {
+	handTypes := make([]gin.H, len(poker.HandTypes))
+	for i, handType := range poker.HandTypes {
+		base, upgrade := poker.TypeMap[handType], poker.LevelUpgrades[handType]
+		handTypes[i] = gin.H{
+			"id":          handType,
+			"name":        handType.String(),
+			"chips":       base.First,
+			"mult":        base.Second,
+			"level_chips": upgrade.First,
+			"level_mult":  upgrade.Second,
+		}
+	}
+	c.JSON(http.StatusOK, handTypes)
+}
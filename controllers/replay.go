@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"Nogler/middleware"
+	models "Nogler/models/postgres"
+	"Nogler/services/app_errors"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// @Summary Gets the replay of a finished game
+// @Description Given a lobby id, returns the seed, players and ordered event log of the last finished game played in it. Only the players of that game can get it
+// @Tags replay
+// @Produce json
+// @Param Authorization header string true "Bearer JWT token"
+// @Param lobby_id path string true "Lobby ID"
+// @Success 200 {object} object{lobby_id=string,seed=integer,players=[]string,events=[]object,finished_at=string}
+// @Failure 401 {object} app_errors.Error
+// @Failure 403 {object} app_errors.Error
+// @Failure 404 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
+// @Router /auth/replays/{lobby_id} [get]
+// @Security ApiKeyAuth
+func GetGameReplay(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Validate JWT token
+		email, err := middleware.JWT_decoder(c)
+		if err != nil {
+			app_errors.Respond(c, app_errors.InvalidToken)
+			return
+		}
+
+		// Verify user exists
+		var user models.User
+		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+			app_errors.Respond(c, app_errors.AuthUserNotFound)
+			return
+		}
+
+		lobbyID := c.Param("lobby_id")
+
+		// Lobby ids can be reused, so return the last game played in the lobby
+		var replay models.GameReplay
+		if err := db.Where("lobby_id = ?", lobbyID).Order("finished_at desc").First(&replay).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				app_errors.Respond(c, app_errors.ReplayNotFound)
+			} else {
+				log.Printf("[REPLAY-ERROR] Error getting replay of lobby %s: %v", lobbyID, err)
+				app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to retrieve replay"))
+			}
+			return
+		}
+
+		// The event log has the hands and shop choices of every player, so only they can see it
+		var players []string
+		if err := json.Unmarshal(replay.Players, &players); err != nil {
+			log.Printf("[REPLAY-ERROR] Error reading players of replay of lobby %s: %v", lobbyID, err)
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to retrieve replay"))
+			return
+		}
+		isPlayer := false
+		for _, player := range players {
+			if player == user.ProfileUsername {
+				isPlayer = true
+				break
+			}
+		}
+		if !isPlayer {
+			app_errors.Respond(c, app_errors.NotReplayPlayer)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"lobby_id":    replay.LobbyID,
+			"seed":        replay.Seed,
+			"players":     replay.Players,
+			"events":      replay.Events,
+			"finished_at": replay.FinishedAt,
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"Nogler/middleware"
+	models "Nogler/models/postgres"
+	redis_models "Nogler/models/redis"
+	"Nogler/services/app_errors"
+	"Nogler/services/chat"
+	"Nogler/services/redis"
+	"Nogler/utils"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// @Summary Reports a player
+// @Description Reports another user to the moderators. If a lobby both users are in is given, the last chat messages of the reported user in it are stored with the report
+// @Tags reports
+// @Produce json
+// @Param Authorization header string true "Bearer JWT token"
+// @Param username formData string true "Username of the reported user"
+// @Param reason formData string true "Reason of the report" Enums(spam, offensive_language, cheating, other)
+// @Param lobby_id formData string false "Lobby where it happened"
+// @Param comment formData string false "Explanation of the report (max 500 characters)"
+// @Success 201 {object} object{message=string,report_id=integer}
+// @Failure 400 {object} app_errors.Error
+// @Failure 401 {object} app_errors.Error
+// @Failure 404 {object} app_errors.Error
+// @Failure 429 {object} app_errors.Error "Too many requests"
+// @Failure 500 {object} app_errors.Error
+// @Router /auth/reportPlayer [post]
+// @Security ApiKeyAuth
+func ReportPlayer(db *gorm.DB, redisClient *redis.RedisClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		email, err := middleware.JWT_decoder(c)
+		if err != nil {
+			app_errors.Respond(c, app_errors.InvalidToken)
+			return
+		}
+
+		var user models.User
+		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+			app_errors.Respond(c, app_errors.AuthUserNotFound)
+			return
+		}
+
+		reportedUsername := c.PostForm("username")
+		reason := c.PostForm("reason")
+		lobbyID := c.PostForm("lobby_id")
+		comment := c.PostForm("comment")
+
+		if reportedUsername == "" {
+			app_errors.Respond(c, app_errors.MissingField, app_errors.Details{"field": "username"})
+			return
+		}
+		switch reason {
+		case models.ReportReasonSpam, models.ReportReasonOffensive, models.ReportReasonCheating, models.ReportReasonOther:
+		case "":
+			app_errors.Respond(c, app_errors.MissingField, app_errors.Details{"field": "reason"})
+			return
+		default:
+			app_errors.Respond(c, app_errors.InvalidField, app_errors.Details{"field": "reason"})
+			return
+		}
+		if len([]rune(comment)) > chat.MaxMessageLength {
+			app_errors.Respond(c, app_errors.InvalidField, app_errors.Details{"field": "comment", "rule": "max", "param": chat.MaxMessageLength})
+			return
+		}
+		if reportedUsername == user.ProfileUsername {
+			app_errors.Respond(c, app_errors.CannotReportYourself)
+			return
+		}
+
+		var reported models.GameProfile
+		if err := db.Where("username = ?", reportedUsername).First(&reported).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				app_errors.Respond(c, app_errors.UserNotFound)
+			} else {
+				app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error fetching user"))
+			}
+			return
+		}
+
+		// The lobby is only kept if both users are in it, so a report can't take the chat of
+		// someone else's lobby
+		if lobbyID != "" && !usersInLobby(db, lobbyID, user.ProfileUsername, reportedUsername) {
+			log.Printf("[REPORT] Ignoring lobby %s of the report of %s about %s, they aren't both in it",
+				lobbyID, user.ProfileUsername, reportedUsername)
+			lobbyID = ""
+		}
+
+		// Keep what the reported user said in the lobby, the chat history expires with it
+		evidence := []redis_models.ChatMessage{}
+		if lobbyID != "" {
+			history, err := redisClient.GetChatHistory(lobbyID)
+			if err != nil {
+				log.Printf("[REPORT-ERROR] Error getting chat history of lobby %s: %v", lobbyID, err)
+			}
+			for _, message := range history {
+				if message.Username == reportedUsername {
+					evidence = append(evidence, message)
+				}
+			}
+		}
+		messages, err := json.Marshal(evidence)
+		if err != nil {
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error serializing chat messages"))
+			return
+		}
+
+		report := models.PlayerReport{
+			ReporterUsername: user.ProfileUsername,
+			ReportedUsername: reportedUsername,
+			LobbyID:          lobbyID,
+			Reason:           reason,
+			Comment:          comment,
+			Messages:         messages,
+		}
+		if err := db.Create(&report).Error; err != nil {
+			log.Printf("[REPORT-ERROR] Error saving report of %s about %s: %v", user.ProfileUsername, reportedUsername, err)
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error saving report"))
+			return
+		}
+
+		log.Printf("[REPORT] %s reported %s (%s)", user.ProfileUsername, reportedUsername, reason)
+		c.JSON(http.StatusCreated, gin.H{
+			"message":   "Report sent successfully",
+			"report_id": report.ID,
+		})
+	}
+}
+
+// Whether all the users are players of the lobby
+func usersInLobby(db *gorm.DB, lobbyID string, usernames ...string) bool {
+	for _, username := range usernames {
+		inLobby, err := utils.IsPlayerInLobby(db, lobbyID, username)
+		if err != nil {
+			log.Printf("[REPORT-ERROR] Error checking if %s is in lobby %s: %v", username, lobbyID, err)
+			return false
+		}
+		if !inLobby {
+			return false
+		}
+	}
+	return true
+}
@@ -4,6 +4,9 @@ import (
 	"Nogler/constants/auth"
 	"Nogler/middleware"
 	models "Nogler/models/postgres"
+	"Nogler/services/app_errors"
+	"Nogler/services/rating"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"os"
@@ -26,9 +29,10 @@ import (
 // @Param email formData string true "User email"
 // @Param password formData string true "User password"
 // @Success 200 {object} object{message=string,token=string}
-// @Failure 400 {object} object{error=string}
-// @Failure 401 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 400 {object} app_errors.Error
+// @Failure 401 {object} app_errors.Error
+// @Failure 429 {object} app_errors.Error "Too many requests"
+// @Failure 500 {object} app_errors.Error
 // @Router /login [post]
 func Login(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -37,18 +41,18 @@ func Login(db *gorm.DB) gin.HandlerFunc {
 
 		//Minimum input sanitizing
 		if strings.Trim(email, " ") == "" || strings.Trim(password, " ") == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Parameters can't be empty"})
+			app_errors.Respond(c, app_errors.MissingField, app_errors.Details{"fields": []string{"email", "password"}})
 			return
 		}
 
 		var user models.User
 		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found: invalid email"})
+			app_errors.Respond(c, app_errors.AuthUserNotFound)
 			return
 		}
 
 		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+			app_errors.Respond(c, app_errors.InvalidCredentials)
 			return
 		}
 
@@ -60,7 +64,7 @@ func Login(db *gorm.DB) gin.HandlerFunc {
 		secret := os.Getenv("KEY")
 		tokenString, err := token.SignedString([]byte(secret))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating JWT"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error generating JWT"))
 		}
 
 		c.JSON(http.StatusOK, gin.H{"message": "Successfully logged in.", "token": tokenString})
@@ -73,8 +77,8 @@ func Login(db *gorm.DB) gin.HandlerFunc {
 // @Produce json
 // @Param Authorization header string true "Bearer JWT token"
 // @Success 200 {object} object{message=string}
-// @Failure 400 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 400 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
 // @Router /auth/logout [delete]
 func Logout(c *gin.Context) {
 	//This serves no purpose with JWT so TODO rething
@@ -96,9 +100,10 @@ func Logout(c *gin.Context) {
 // @Param password formData string true "Password"
 // @Param icono formData string true "Icon number"
 // @Success 201 {object} object{message=string,user=object{username=string,email=string}}
-// @Failure 400 {object} object{error=string}
-// @Failure 409 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 400 {object} app_errors.Error
+// @Failure 409 {object} app_errors.Error
+// @Failure 429 {object} app_errors.Error "Too many requests"
+// @Failure 500 {object} app_errors.Error
 // @Router /signup [post]
 func SignUp(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -109,21 +114,21 @@ func SignUp(db *gorm.DB) gin.HandlerFunc {
 
 		// Minimum input sanitizing
 		if strings.TrimSpace(username) == "" || strings.TrimSpace(email) == "" || strings.TrimSpace(password) == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Username, email and password are required"})
+			app_errors.Respond(c, app_errors.MissingField, app_errors.Details{"fields": []string{"username", "email", "password"}})
 			return
 		}
 
 		// Check if user already exists
 		var existingUser models.User
 		if err := db.Where("email = ? OR profile_username = ?", email, username).First(&existingUser).Error; err == nil {
-			c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
+			app_errors.Respond(c, app_errors.UserAlreadyExists)
 			return
 		}
 
 		// Hash password
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error hashing password"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error hashing password"))
 			return
 		}
 
@@ -133,16 +138,24 @@ func SignUp(db *gorm.DB) gin.HandlerFunc {
 			iconInt = 0 // default icon if conversion fails
 		}
 
+		// Every stat starts at zero, they're updated at the end of each game
+		initialStats, err := json.Marshal(models.ParseUserStats(nil))
+		if err != nil {
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error creating game profile"))
+			return
+		}
+
 		// Create GameProfile first
 		gameProfile := models.GameProfile{
 			Username:  username,
-			UserStats: datatypes.JSON([]byte(`{"stats": "TBD"}`)),
+			UserStats: datatypes.JSON(initialStats),
 			UserIcon:  iconInt,
 			IsInAGame: false,
+			UserScore: rating.InitialRating,
 		}
 
 		if err := db.Create(&gameProfile).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating game profile"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error creating game profile"))
 			return
 		}
 
@@ -158,7 +171,7 @@ func SignUp(db *gorm.DB) gin.HandlerFunc {
 			// Rollback game profile creation if user creation fails
 			// TODO: do it with a transaction?
 			db.Delete(&gameProfile)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating user"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error creating user"))
 			return
 		}
 
@@ -178,7 +191,7 @@ func SignUp(db *gorm.DB) gin.HandlerFunc {
 // @Produce json
 // @Param Authorization header string true "Bearer JWT token"
 // @Success 200 {array} object{username=string,icon=integer}
-// @Failure 500 {object} object{error=string}
+// @Failure 500 {object} app_errors.Error
 // @Router /allusers [get]
 func GetAllUsers(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -187,7 +200,7 @@ func GetAllUsers(db *gorm.DB) gin.HandlerFunc {
 		// Preload GameProfile to get the icon
 		result := db.Preload("GameProfile").Find(&users)
 		if result.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching users"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error fetching users"))
 			return
 		}
 
@@ -205,22 +218,22 @@ func GetAllUsers(db *gorm.DB) gin.HandlerFunc {
 }
 
 // @Summary Get user public info
-// @Description Returns public information about a specific user (username and icon)
+// @Description Returns public information about a specific user (username, icon and game stats)
 // @Tags users
 // @Produce json
 // @Param Authorization header string true "Bearer JWT token"
 // @Param username path string true "Username"
-// @Success 200 {object} object{username=string,icon=integer}
-// @Failure 400 {object} object{error=string}
-// @Failure 404 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Success 200 {object} object{username=string,icon=integer,rating=integer,stats=object{games_played=integer,games_won=integer,best_hand_score=integer,favourite_joker=integer}}
+// @Failure 400 {object} app_errors.Error
+// @Failure 404 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
 // @Router /users/{username} [get]
 func GetUserPublicInfo(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		username := c.Param("username")
 
 		if username == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Username is required"})
+			app_errors.Respond(c, app_errors.MissingField, app_errors.Details{"field": "username"})
 			return
 		}
 
@@ -229,17 +242,26 @@ func GetUserPublicInfo(db *gorm.DB) gin.HandlerFunc {
 
 		if result.Error != nil {
 			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				app_errors.Respond(c, app_errors.UserNotFound)
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching user"})
+				app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error fetching user"))
 			}
 			return
 		}
 
+		stats := models.ParseUserStats(user.GameProfile.UserStats)
+
 		// Return only public information
 		publicInfo := gin.H{
 			"username": user.ProfileUsername,
 			"icon":     user.GameProfile.UserIcon,
+			"rating":   user.GameProfile.UserScore,
+			"stats": gin.H{
+				"games_played":    stats.GamesPlayed,
+				"games_won":       stats.GamesWon,
+				"best_hand_score": stats.BestHandScore,
+				"favourite_joker": stats.FavouriteJoker,
+			},
 		}
 
 		c.JSON(http.StatusOK, publicInfo)
@@ -252,9 +274,9 @@ func GetUserPublicInfo(db *gorm.DB) gin.HandlerFunc {
 // @Produce json
 // @Param Authorization header string true "Bearer JWT token"
 // @Success 200 {object} object{username=string,email=string,icon=integer}
-// @Failure 401 {object} object{error=string}
-// @Failure 404 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 401 {object} app_errors.Error
+// @Failure 404 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
 // @Router /auth/me [get]
 func GetUserPrivateInfo(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -271,9 +293,9 @@ func GetUserPrivateInfo(db *gorm.DB) gin.HandlerFunc {
 
 		if result.Error != nil {
 			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				app_errors.Respond(c, app_errors.UserNotFound)
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching user data"})
+				app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error fetching user data"))
 			}
 			return
 		}
@@ -300,11 +322,11 @@ func GetUserPrivateInfo(db *gorm.DB) gin.HandlerFunc {
 // @Param password formData string false "New password"
 // @Param icon formData string false "New icon number"
 // @Success 200 {object} object{message=string,token=string,user=object{username=string,email=string,icon=integer}}
-// @Failure 400 {object} object{error=string}
-// @Failure 401 {object} object{error=string}
-// @Failure 404 {object} object{error=string}
-// @Failure 409 {object} object{error=string}
-// @Failure 500 {object} object{error=string}
+// @Failure 400 {object} app_errors.Error
+// @Failure 401 {object} app_errors.Error
+// @Failure 404 {object} app_errors.Error
+// @Failure 409 {object} app_errors.Error
+// @Failure 500 {object} app_errors.Error
 // @Router /auth/update [patch]
 func UpdateUserInfo(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -324,7 +346,7 @@ func UpdateUserInfo(db *gorm.DB) gin.HandlerFunc {
 		// Start a transaction
 		tx := db.Begin()
 		if tx.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to start transaction"))
 			return
 		}
 
@@ -332,7 +354,7 @@ func UpdateUserInfo(db *gorm.DB) gin.HandlerFunc {
 		var user models.User
 		if err := tx.Where("email = ?", currentEmail).First(&user).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			app_errors.Respond(c, app_errors.UserNotFound)
 			return
 		}
 
@@ -340,7 +362,7 @@ func UpdateUserInfo(db *gorm.DB) gin.HandlerFunc {
 		var gameProfile models.GameProfile
 		if err := tx.Where("username = ?", user.ProfileUsername).First(&gameProfile).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusNotFound, gin.H{"error": "Game profile not found"})
+			app_errors.Respond(c, app_errors.GameProfileNotFound)
 			return
 		}
 
@@ -350,7 +372,7 @@ func UpdateUserInfo(db *gorm.DB) gin.HandlerFunc {
 			var existingUser models.User
 			if err := tx.Where("profile_username = ? AND email != ?", username, currentEmail).First(&existingUser).Error; err == nil {
 				tx.Rollback()
-				c.JSON(http.StatusConflict, gin.H{"error": "Username already taken"})
+				app_errors.Respond(c, app_errors.UsernameTaken)
 				return
 			}
 			
@@ -362,7 +384,7 @@ func UpdateUserInfo(db *gorm.DB) gin.HandlerFunc {
 			if err := tx.Exec("UPDATE game_profiles SET username = ? WHERE username = ?", 
 							 username, user.ProfileUsername).Error; err != nil {
 				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update game profile username"})
+				app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to update game profile username"))
 				return
 			}
 			
@@ -372,7 +394,7 @@ func UpdateUserInfo(db *gorm.DB) gin.HandlerFunc {
 			// Save user changes
 			if err := tx.Save(&user).Error; err != nil {
 				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+				app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to update user"))
 				return
 			}
 			
@@ -385,7 +407,7 @@ func UpdateUserInfo(db *gorm.DB) gin.HandlerFunc {
 			var existingUser models.User
 			if err := tx.Where("email = ?", email).First(&existingUser).Error; err == nil {
 				tx.Rollback()
-				c.JSON(http.StatusConflict, gin.H{"error": "Email already taken"})
+				app_errors.Respond(c, app_errors.EmailTaken)
 				return
 			}
 		}
@@ -395,7 +417,7 @@ func UpdateUserInfo(db *gorm.DB) gin.HandlerFunc {
 			hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 			if err != nil {
 				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error hashing password"})
+				app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error hashing password"))
 				return
 			}
 			user.PasswordHash = string(hashedPassword)
@@ -413,7 +435,7 @@ func UpdateUserInfo(db *gorm.DB) gin.HandlerFunc {
 			secret := os.Getenv("KEY")
 			tokenString, err = token.SignedString([]byte(secret))
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating JWT"})
+				app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Error generating JWT"))
 			}
 		} else {
 			// NEW: better not return an empty string, even if the user didn't change his email
@@ -426,7 +448,7 @@ func UpdateUserInfo(db *gorm.DB) gin.HandlerFunc {
 			iconInt, err := strconv.Atoi(icon)
 			if err != nil {
 				tx.Rollback()
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid icon value"})
+				app_errors.Respond(c, app_errors.InvalidField, app_errors.Details{"field": "icon"})
 				return
 			}
 			gameProfile.UserIcon = iconInt
@@ -434,7 +456,7 @@ func UpdateUserInfo(db *gorm.DB) gin.HandlerFunc {
 			// Save game profile changes for icon
 			if err := tx.Save(&gameProfile).Error; err != nil {
 				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update game profile"})
+				app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to update game profile"))
 				return
 			}
 		}
@@ -442,13 +464,13 @@ func UpdateUserInfo(db *gorm.DB) gin.HandlerFunc {
 		// Save user changes if we didn't already save them above
 		if err := tx.Save(&user).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to update user"))
 			return
 		}
 
 		// Commit transaction
 		if err := tx.Commit().Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit changes"})
+			app_errors.Respond(c, app_errors.Internal, app_errors.Reason("Failed to commit changes"))
 			return
 		}
 
@@ -15,8 +15,10 @@ require (
 	github.com/swaggo/gin-swagger v1.6.0
 	github.com/swaggo/swag v1.16.4
 	github.com/zishang520/engine.io/v2 v2.3.3
+	github.com/zishang520/socket.io-go-parser/v2 v2.3.1
 	github.com/zishang520/socket.io/v2 v2.3.8
 	golang.org/x/crypto v0.33.0
+	golang.org/x/exp v0.0.0-20240506185415-9bf2ced13842
 	gorm.io/datatypes v1.0.7
 	gorm.io/driver/postgres v1.4.0
 	gorm.io/gorm v1.25.12
@@ -81,10 +83,8 @@ require (
 	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	github.com/xo/terminfo v0.0.0-20210125001918-ca9a967f8778 // indirect
 	github.com/zishang520/engine.io-go-parser v1.2.7 // indirect
-	github.com/zishang520/socket.io-go-parser/v2 v2.3.1 // indirect
 	go.uber.org/mock v0.5.0 // indirect
 	golang.org/x/arch v0.14.0 // indirect
-	golang.org/x/exp v0.0.0-20240506185415-9bf2ced13842 // indirect
 	golang.org/x/mod v0.23.0 // indirect
 	golang.org/x/net v0.35.0 // indirect
 	golang.org/x/sync v0.11.0 // indirect
@@ -6,6 +6,9 @@ import (
 	_ "Nogler/config/swagger"
 	"Nogler/middleware"
 	"Nogler/routes"
+	"Nogler/services/chat"
+	"Nogler/services/poker"
+	"Nogler/services/rate_limit"
 	"Nogler/services/redis"
 	"Nogler/services/socket_io"
 	"log"
@@ -31,6 +34,30 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// The embedded joker definitions are used unless JOKERS_FILE is set
+	if jokersFile := os.Getenv("JOKERS_FILE"); jokersFile != "" {
+		if err := poker.LoadJokerRegistry(jokersFile); err != nil {
+			log.Fatalf("Error loading joker definitions: %v", err)
+		}
+		log.Println("Joker definitions loaded from", jokersFile)
+	}
+
+	// The default chat blocklist is used unless CHAT_BLOCKLIST_FILE is set
+	if blocklistFile := os.Getenv("CHAT_BLOCKLIST_FILE"); blocklistFile != "" {
+		if err := chat.LoadBlocklist(blocklistFile); err != nil {
+			log.Fatalf("Error loading chat blocklist: %v", err)
+		}
+		log.Println("Chat blocklist loaded from", blocklistFile)
+	}
+
+	// The default rate limits are used unless RATE_LIMITS_FILE is set
+	if limitsFile := os.Getenv("RATE_LIMITS_FILE"); limitsFile != "" {
+		if err := rate_limit.LoadLimits(limitsFile); err != nil {
+			log.Fatalf("Error loading rate limits: %v", err)
+		}
+		log.Println("Rate limits loaded from", limitsFile)
+	}
+
 	gormDB, err := pgconfig.ConnectGORM()
 	if err != nil {
 		log.Fatalf("Error connecting to PostgreSQL: %v", err)
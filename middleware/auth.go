@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"Nogler/services/app_errors"
 	"net/http"
 	"os"
 	"strings"
@@ -16,15 +17,13 @@ import (
 func AuthRequired(c *gin.Context) {
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing token"})
-		c.Abort()
+		app_errors.Abort(c, app_errors.MissingToken)
 		return
 	}
 
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 	if tokenString == authHeader {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token format"})
-		c.Abort()
+		app_errors.Abort(c, app_errors.InvalidToken)
 		return
 	}
 
@@ -34,8 +33,7 @@ func AuthRequired(c *gin.Context) {
 		return []byte(secret), nil
 	})
 	if err != nil || !token.Valid {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-		c.Abort()
+		app_errors.Abort(c, app_errors.InvalidToken)
 		return
 	}
 	c.Next()
@@ -55,7 +53,7 @@ func JWT_decoder(c *gin.Context) (string, error) {
 	// Obtener los datos del token
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		app_errors.Respond(c, app_errors.InvalidToken)
 		return "", err
 	}
 
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"Nogler/services/app_errors"
+	"Nogler/services/rate_limit"
+	"Nogler/services/redis"
+	"math"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit limits how often a client (by IP) can call a route, with the limit of the given
+// name in rate_limit.Limits. Rejected requests get the rate_limited error and a Retry-After header
+func RateLimit(redisClient *redis.RedisClient, name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, retryAfter := rate_limit.Allow(redisClient, name, c.ClientIP())
+		if allowed {
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		app_errors.Abort(c, app_errors.RateLimited, app_errors.Details{"retry_after_ms": retryAfter.Milliseconds()})
+	}
+}
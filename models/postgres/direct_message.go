@@ -0,0 +1,20 @@
+package postgres
+
+import "time"
+
+/*
+ * 'DirectMessage' is a private message between two friends. ReadAt is set once the
+ * receiver has read it
+ */
+type DirectMessage struct {
+	ID               uint       `gorm:"primaryKey"`
+	SenderUsername   string     `gorm:"size:50;not null;index:idx_direct_messages_conversation,priority:1"`
+	ReceiverUsername string     `gorm:"size:50;not null;index:idx_direct_messages_conversation,priority:2;index:idx_direct_messages_unread,priority:1"`
+	Content          string     `gorm:"size:500;not null"`
+	CreatedAt        time.Time  `gorm:"default:CURRENT_TIMESTAMP;index:idx_direct_messages_conversation,priority:3"`
+	ReadAt           *time.Time `gorm:"index:idx_direct_messages_unread,priority:2"`
+
+	// Relationships
+	Sender   GameProfile `gorm:"foreignKey:SenderUsername"`
+	Receiver GameProfile `gorm:"foreignKey:ReceiverUsername"`
+}
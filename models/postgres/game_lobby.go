@@ -4,6 +4,7 @@ import (
 	"math/rand"
 	"time"
 
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -19,6 +20,7 @@ type GameLobby struct {
 	CreatedAt       time.Time `gorm:"default:CURRENT_TIMESTAMP"`
 	GameHasBegun    bool      `gorm:"default:false;index_idx_game_lobbies_active"` // Indicates if the game has started
 	IsPublic        int       `gorm:"default:0;index:idx_game_lobbies_public"` // Indicates if the lobby is public (1), private (0) or AI (2)
+	Rules           datatypes.JSON `gorm:"type:jsonb;default:'{}'"` // Rules of the game (see redis.GameRules)
 
 	// Relationships
 	Creator GameProfile `gorm:"foreignKey:CreatorUsername"`
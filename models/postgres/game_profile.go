@@ -12,7 +12,7 @@ type GameProfile struct {
 	UserStats datatypes.JSON `gorm:"type:jsonb;default:'{}';index:idx_profile_stats,type:gin"`
 	UserIcon  int            `gorm:"type:integer;default:0"`
 	IsInAGame bool           `gorm:"type:boolean;default:false"`
-	UserScore int            `gorm:"type:integer;default:0"`
+	UserScore int            `gorm:"type:integer;default:1000"` // Skill rating, see services/rating
 
 	// NOTE: was creating a circular dependency between GameProfile and User
 	// User            *User               `gorm:"foreignKey:Username"`
@@ -30,4 +30,6 @@ type GameProfile struct {
 	InGamePlayers    []*InGamePlayer      `gorm:"foreignKey:Username;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 	GameInvitations1 []*GameInvitation    `gorm:"foreignKey:SenderUsername;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 	GameInvitations2 []*GameInvitation    `gorm:"foreignKey:InvitedUsername;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	MatchHistories   []*MatchHistory      `gorm:"foreignKey:Username;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	RatingHistories  []*RatingHistory     `gorm:"foreignKey:Username;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 }
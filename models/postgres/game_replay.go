@@ -0,0 +1,21 @@
+package postgres
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+/*
+ * 'GameReplay' stores the ordered event log of a finished game, so it can
+ * be replayed once the lobby has been deleted. Lobby ids can be reused,
+ * so it doesn't reference game_lobbies
+ */
+type GameReplay struct {
+	ID         uint           `gorm:"primaryKey"`
+	LobbyID    string         `gorm:"size:50;not null;index:idx_game_replays_lobby"`
+	Seed       uint64         `gorm:"not null"`                // Lobby seed, to reproduce the random events
+	Players    datatypes.JSON `gorm:"type:jsonb;default:'[]'"` // Usernames of the players in the game
+	Events     datatypes.JSON `gorm:"type:jsonb;default:'[]'"` // Ordered list of game events
+	FinishedAt time.Time      `gorm:"default:CURRENT_TIMESTAMP"`
+}
@@ -0,0 +1,27 @@
+package postgres
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+/*
+ * 'MatchHistory' stores the result of a finished game for one of its players.
+ * Lobby ids can be reused, so it doesn't reference game_lobbies
+ */
+type MatchHistory struct {
+	ID             uint           `gorm:"primaryKey"`
+	LobbyID        string         `gorm:"size:50;not null;index:idx_match_histories_lobby"`
+	Username       string         `gorm:"size:50;not null;index:idx_match_histories_user"`
+	Participants   datatypes.JSON `gorm:"type:jsonb;default:'[]'"` // Usernames of every player in the game
+	Placement      int            `gorm:"not null"`                // 1 for the winners, players with the same result share placement
+	Winner         bool           `gorm:"default:false"`
+	RoundsSurvived int            `gorm:"default:0"`
+	FinalPoints    int            `gorm:"default:0"`
+	FinalMoney     int            `gorm:"default:0"`
+	MostPlayedHand int            `gorm:"default:0"` // Hand type (see poker.BestHand), 0 if no hand was played
+	BestHandScore  int            `gorm:"default:0"`
+	Jokers         datatypes.JSON `gorm:"type:jsonb;default:'[]'"` // Jokers held at the end of the game
+	FinishedAt     time.Time      `gorm:"default:CURRENT_TIMESTAMP;index:idx_match_histories_user"`
+}
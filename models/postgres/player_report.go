@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Reasons a player can be reported for
+const (
+	ReportReasonSpam      = "spam"
+	ReportReasonOffensive = "offensive_language"
+	ReportReasonCheating  = "cheating"
+	ReportReasonOther     = "other"
+)
+
+/*
+ * 'PlayerReport' is a report of a user about another one, to be reviewed by the moderators.
+ * If it's about a lobby, the last chat messages of the reported user are kept as evidence
+ */
+type PlayerReport struct {
+	ID               uint           `gorm:"primaryKey"`
+	ReporterUsername string         `gorm:"size:50;not null;index:idx_player_reports_reporter"`
+	ReportedUsername string         `gorm:"size:50;not null;index:idx_player_reports_reported"`
+	LobbyID          string         `gorm:"size:50"` // Empty if it isn't about a game
+	Reason           string         `gorm:"size:30;not null"`
+	Comment          string         `gorm:"size:500"`
+	Messages         datatypes.JSON `gorm:"type:jsonb;default:'[]'"` // Chat messages of the reported user in the lobby
+	CreatedAt        time.Time      `gorm:"default:CURRENT_TIMESTAMP"`
+}
@@ -0,0 +1,16 @@
+package postgres
+
+import "time"
+
+/*
+ * 'RatingHistory' stores every change of a user's rating (GameProfile.UserScore),
+ * one entry per rated game
+ */
+type RatingHistory struct {
+	ID        uint      `gorm:"primaryKey"`
+	Username  string    `gorm:"size:50;not null;index:idx_rating_histories_user"`
+	LobbyID   string    `gorm:"size:50;not null"` // Lobby of the game that changed the rating
+	OldRating int       `gorm:"not null"`
+	NewRating int       `gorm:"not null"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP;index:idx_rating_histories_user"`
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"encoding/json"
+
+	"gorm.io/datatypes"
+)
+
+// UserStats are the aggregated statistics of a user, stored in GameProfile.UserStats
+type UserStats struct {
+	GamesPlayed    int         `json:"games_played"`
+	GamesWon       int         `json:"games_won"`
+	BestHandScore  int         `json:"best_hand_score"`
+	FavouriteJoker int         `json:"favourite_joker"` // Joker held at the end of the most games, 0 if none
+	JokerCounts    map[int]int `json:"joker_counts"`    // Number of games each joker was held at the end
+}
+
+// ParseUserStats reads the stats of a profile. Profiles created before the stats were
+// tracked don't have any of the fields, so they start from zero
+func ParseUserStats(data datatypes.JSON) UserStats {
+	var stats UserStats
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &stats)
+	}
+	if stats.JokerCounts == nil {
+		stats.JokerCounts = make(map[int]int)
+	}
+	return stats
+}
+
+// AddMatch updates the stats with the result of a finished game
+func (s *UserStats) AddMatch(match MatchHistory, jokers []int) {
+	s.GamesPlayed++
+	if match.Winner {
+		s.GamesWon++
+	}
+	if match.BestHandScore > s.BestHandScore {
+		s.BestHandScore = match.BestHandScore
+	}
+
+	// A joker held twice in the same game only counts once
+	held := make(map[int]bool)
+	for _, joker := range jokers {
+		if joker == 0 || held[joker] {
+			continue
+		}
+		held[joker] = true
+		s.JokerCounts[joker]++
+	}
+
+	// Ties go to the joker with the lowest id, so the result doesn't depend on map order
+	s.FavouriteJoker = 0
+	for joker, count := range s.JokerCounts {
+		best := s.JokerCounts[s.FavouriteJoker]
+		if count > best || (count == best && joker < s.FavouriteJoker) {
+			s.FavouriteJoker = joker
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package redis
+
+import "time"
+
+// ChatMessage is a message of a lobby's chat. The last ones are kept in Redis so the users
+// that join (or come back) later can read them
+type ChatMessage struct {
+	Username string    `json:"username"`
+	UserIcon int       `json:"user_icon"`
+	Message  string    `json:"message"` // Already filtered
+	SentAt   time.Time `json:"sent_at"`
+}
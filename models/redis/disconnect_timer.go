@@ -0,0 +1,11 @@
+package redis
+
+import "time"
+
+// DisconnectTimer is the deadline for a disconnected player to come back to a running game.
+// When it expires the player forfeits the game
+type DisconnectTimer struct {
+	Username string    `json:"username"`
+	LobbyID  string    `json:"lobby_id"`
+	Deadline time.Time `json:"deadline"`
+}
@@ -0,0 +1,36 @@
+package redis
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Types of the state-changing events recorded in a lobby's replay log
+const (
+	EventProposeBlind      = "propose_blind"
+	EventPlayHand          = "play_hand"
+	EventDiscardCards      = "discard_cards"
+	EventBuyJoker          = "buy_joker"
+	EventBuyVoucher        = "buy_voucher"
+	EventBuyPack           = "buy_pack"
+	EventBuyPlanet         = "buy_planet"
+	EventChoosePackItems   = "choose_pack_items"
+	EventSellJoker         = "sell_joker"
+	EventReorderJokers     = "reorder_jokers"
+	EventRerollShop        = "reroll_shop"
+	EventActivateModifiers = "activate_modifiers"
+	EventSendModifiers     = "send_modifiers"
+	EventPhaseChange       = "phase_change"
+	EventElimination       = "elimination"
+)
+
+// GameEvent is an entry of a lobby's replay log. Entries are kept in the
+// order they happened (Redis list), and persisted to PostgreSQL at game end
+type GameEvent struct {
+	Type      string          `json:"type"`
+	Username  string          `json:"username,omitempty"` // Empty for game events (e.g. phase changes)
+	Round     int             `json:"round"`
+	Phase     string          `json:"phase"`
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
@@ -62,6 +62,15 @@ type GameLobby struct {
 
 	// Current base blind proposed by the game
 	CurrentBaseBlind int `json:"current_base_blind"`
+
+	// Seed every random event of the match is derived from (see poker.NewGameRNG)
+	Seed uint64 `json:"seed"`
+
+	// Rules chosen by the creator of the lobby
+	Rules GameRules `json:"rules"`
+
+	// Set by the host to keep other users from watching the game
+	SpectatingDisabled bool `json:"spectating_disabled"`
 }
 
 // CRITICAL: if maps were not initialized, they would be nil and cause panic
@@ -90,6 +99,10 @@ func (l *GameLobby) EnsureMapsInitialized() {
 	if l.VouchersCompleted == nil {
 		l.VouchersCompleted = make(map[int]bool)
 	}
+	// Lobbies created before the rules were stored are played with the default ones
+	if l.Rules == (GameRules{}) {
+		l.Rules = DefaultGameRules()
+	}
 }
 
 type RerolledJokers struct {
@@ -101,27 +114,30 @@ type LobbyShop struct {
 	Rerolled       []RerolledJokers `json:"rerolled_items"` //Rerolls through the shop
 	FixedPacks     []ShopItem       `json:"fixed_packs"`
 	FixedModifiers []ShopItem       `json:"fixed_modifiers"`
+	FixedPlanets   []ShopItem       `json:"fixed_planets"`
 	// RerollableItems []ShopItem       `json:"rerollable_items"` // IDK if its deprecated or not
 	RerollSeed   uint64 `json:"reroll_seed"`
 	NextUniqueId int    `json:"next_unique_id"` // Unique ID for the next item to be added to the shop
 }
 
 type ShopItem struct {
-	ID            int          `json:"id"`
-	Type          string       `json:"type"` // "card", "joker", "pack", "modifier"
-	Price         int          `json:"price"`
-	PackSeed      int64        `json:"pack_seed,omitempty"`
-	Content       PackContents `gorm:"type:jsonb" json:"content"` // Directly store PackContents
-	JokerId       int          `json:"joker_id,omitempty"`        // Only for joker type
-	ModifierId    int          `json:"modifier_id,omitempty"`     // Only for modifier type
-	PackType      int          `json:"pack_type,omitempty"`       // Type of pack: 1=cards, 2=jokers, 3=vouchers
-	MaxSelectable int          `json:"max_selectable,omitempty"`  // Maximum items a player can select from this pack
+	ID            int            `json:"id"`
+	Type          string         `json:"type"` // "card", "joker", "pack", "modifier"
+	Price         int            `json:"price"`
+	PackSeed      int64          `json:"pack_seed,omitempty"`
+	Content       PackContents   `gorm:"type:jsonb" json:"content"` // Directly store PackContents
+	JokerId       int            `json:"joker_id,omitempty"`        // Only for joker type
+	ModifierId    int            `json:"modifier_id,omitempty"`     // Only for modifier type
+	HandType      poker.HandType `json:"hand_type,omitempty"`       // Only for planet type, the hand type it levels up
+	PackType      int            `json:"pack_type,omitempty"`       // Type of pack: 1=cards, 2=jokers, 3=vouchers, 4=planets
+	MaxSelectable int            `json:"max_selectable,omitempty"`  // Maximum items a player can select from this pack
 }
 
 type PackContents struct {
 	Cards    []poker.Card     `json:"cards"`
 	Jokers   []poker.Jokers   `json:"jokers"`
 	Vouchers []poker.Modifier `json:"vouchers"` // New field for voucher modifiers
+	Planets  []poker.HandType `json:"planets"`  // Hand types leveled up by the planets
 }
 
 // Value - Serialize to JSON
@@ -0,0 +1,95 @@
+package redis
+
+import (
+	game_constants "Nogler/constants/game"
+	"fmt"
+	"time"
+)
+
+// GameRules are the rules a lobby is played with. They are chosen when the lobby is
+// created and can't be changed afterwards. Durations are in seconds
+type GameRules struct {
+	MaxRounds        int `json:"max_rounds"`
+	HandPlays        int `json:"hand_plays"` // Hands that can be played each round
+	Discards         int `json:"discards"`   // Discards allowed each round
+	BaseBlind        int `json:"base_blind"` // Base blind of the first round
+	BlindGrowth      int `json:"blind_growth"`
+	BlindSeconds     int `json:"blind_seconds"`
+	PlayRoundSeconds int `json:"play_round_seconds"`
+	ShopSeconds      int `json:"shop_seconds"`
+	VouchersSeconds  int `json:"vouchers_seconds"`
+	StartingMoney    int `json:"starting_money"`
+	MaxJokers        int `json:"max_jokers"` // Joker slots of each player
+}
+
+// DefaultGameRules returns the rules used when the lobby creator doesn't choose any
+func DefaultGameRules() GameRules {
+	return GameRules{
+		MaxRounds:        game_constants.MaxGameRounds,
+		HandPlays:        game_constants.TOTAL_HAND_PLAYS,
+		Discards:         game_constants.TOTAL_DISCARDS,
+		BaseBlind:        game_constants.BASE_BLIND,
+		BlindGrowth:      game_constants.BASE_BLIND_GROWTH,
+		BlindSeconds:     int(game_constants.BLIND_DURATION.Seconds()),
+		PlayRoundSeconds: int(game_constants.PLAY_ROUND_DURATION.Seconds()),
+		ShopSeconds:      int(game_constants.SHOP_DURATION.Seconds()),
+		VouchersSeconds:  int(game_constants.VOUCHER_DURATION.Seconds()),
+		StartingMoney:    game_constants.STARTING_MONEY,
+		MaxJokers:        game_constants.MaxJokersPerPlayer,
+	}
+}
+
+// Validate checks that every rule is within its allowed range
+func (r GameRules) Validate() error {
+	checks := []struct {
+		name     string
+		value    int
+		min, max int
+	}{
+		{"max_rounds", r.MaxRounds, 1, 50},
+		{"hand_plays", r.HandPlays, 1, 10},
+		{"discards", r.Discards, 0, 10},
+		{"base_blind", r.BaseBlind, 1, game_constants.MAX_BLIND},
+		{"blind_growth", r.BlindGrowth, 1, 10},
+		{"blind_seconds", r.BlindSeconds, 5, 600},
+		{"play_round_seconds", r.PlayRoundSeconds, 10, 1800},
+		{"shop_seconds", r.ShopSeconds, 5, 600},
+		{"vouchers_seconds", r.VouchersSeconds, 5, 600},
+		{"starting_money", r.StartingMoney, 0, 1000},
+		{"max_jokers", r.MaxJokers, 1, 10},
+	}
+	for _, check := range checks {
+		if check.value < check.min || check.value > check.max {
+			return fmt.Errorf("%s must be between %d and %d", check.name, check.min, check.max)
+		}
+	}
+	return nil
+}
+
+// PhaseDuration returns how long a phase lasts before it's ended by the server
+func (r GameRules) PhaseDuration(phase string) time.Duration {
+	switch phase {
+	case PhaseBlind:
+		return time.Duration(r.BlindSeconds) * time.Second
+	case PhasePlayRound:
+		return time.Duration(r.PlayRoundSeconds) * time.Second
+	case PhaseShop:
+		return time.Duration(r.ShopSeconds) * time.Second
+	case PhaseVouchers:
+		return time.Duration(r.VouchersSeconds) * time.Second
+	}
+	return 0
+}
+
+// BaseBlindForRound calculates the base blind of a round: BaseBlind * BlindGrowth^(round - 1),
+// capped to MAX_BLIND
+func (r GameRules) BaseBlindForRound(round int) int {
+	baseBlind := r.BaseBlind
+	for i := 1; i < round; i++ {
+		baseBlind *= r.BlindGrowth
+		if baseBlind >= game_constants.MAX_BLIND {
+			return game_constants.MAX_BLIND
+		}
+	}
+	return baseBlind
+}
@@ -0,0 +1,71 @@
+package redis_test
+
+import (
+	game_constants "Nogler/constants/game"
+	redis_models "Nogler/models/redis"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGameRulesValidate(t *testing.T) {
+	assert.NoError(t, redis_models.DefaultGameRules().Validate())
+
+	tests := []struct {
+		name   string
+		change func(*redis_models.GameRules)
+		err    string
+	}{
+		{"no rounds", func(r *redis_models.GameRules) { r.MaxRounds = 0 }, "max_rounds"},
+		{"too many hand plays", func(r *redis_models.GameRules) { r.HandPlays = 11 }, "hand_plays"},
+		{"negative discards", func(r *redis_models.GameRules) { r.Discards = -1 }, "discards"},
+		{"blind over the max", func(r *redis_models.GameRules) { r.BaseBlind = game_constants.MAX_BLIND + 1 }, "base_blind"},
+		{"short play round", func(r *redis_models.GameRules) { r.PlayRoundSeconds = 9 }, "play_round_seconds"},
+		{"no joker slots", func(r *redis_models.GameRules) { r.MaxJokers = 0 }, "max_jokers"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rules := redis_models.DefaultGameRules()
+			test.change(&rules)
+			err := rules.Validate()
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), test.err)
+			}
+		})
+	}
+
+	// The limits themselves are allowed
+	rules := redis_models.DefaultGameRules()
+	rules.Discards, rules.MaxRounds = 0, 50
+	assert.NoError(t, rules.Validate())
+}
+
+func TestBaseBlindForRound(t *testing.T) {
+	rules := redis_models.DefaultGameRules()
+	rules.BaseBlind, rules.BlindGrowth = 10, 3
+
+	tests := []struct {
+		round int
+		blind int
+	}{
+		{1, 10},
+		{2, 30},
+		{4, 270},
+		{100, game_constants.MAX_BLIND},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.blind, rules.BaseBlindForRound(test.round), "round %d", test.round)
+	}
+}
+
+func TestPhaseDuration(t *testing.T) {
+	rules := redis_models.DefaultGameRules()
+	rules.BlindSeconds, rules.PlayRoundSeconds, rules.ShopSeconds, rules.VouchersSeconds = 15, 90, 45, 30
+
+	assert.Equal(t, 15*time.Second, rules.PhaseDuration(redis_models.PhaseBlind))
+	assert.Equal(t, 90*time.Second, rules.PhaseDuration(redis_models.PhasePlayRound))
+	assert.Equal(t, 45*time.Second, rules.PhaseDuration(redis_models.PhaseShop))
+	assert.Equal(t, 30*time.Second, rules.PhaseDuration(redis_models.PhaseVouchers))
+	assert.Zero(t, rules.PhaseDuration(redis_models.PhaseNone))
+}
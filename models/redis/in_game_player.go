@@ -1,6 +1,9 @@
 package redis
 
-import "encoding/json"
+import (
+	"Nogler/services/poker"
+	"encoding/json"
+)
 
 // InGamePlayer represents a player's state during a game
 type InGamePlayer struct {
@@ -16,13 +19,17 @@ type InGamePlayer struct {
 	ActivatedModifiers json.RawMessage `json:"activated_modifiers"` // Temporary Redis field
 	ReceivedModifiers  json.RawMessage `json:"received_modifiers"`  // Temporary Redis field
 	CurrentJokers      json.RawMessage `json:"current_jokers"`      // Temporary Redis field
-	MostPlayedHand     json.RawMessage `json:"most_played_hand"`    // Matches in_game_players.most_played_hand
+	MostPlayedHand     json.RawMessage `json:"most_played_hand"`    // Matches in_game_players.most_played_hand (hand type -> times played)
+	BestHandScore      int             `json:"best_hand_score"`     // Highest score of a single hand in the game
 	Winner             bool            `json:"winner"`              // Matches in_game_players.winner
 	CurrentRoundPoints int             `json:"current_points"`      // Matches in_game_players.current_points
 	TotalGamePoints    int             `json:"total_points"`        // Matches in_game_players.total_points
 	HandPlaysLeft      int             `json:"hand_plays_left"`     // Matches in_game_players.hand_plays_left
 	DiscardsLeft       int             `json:"discards_left"`       // Matches in_game_players.discards_left
 
+	// Level and times played of each hand type, leveled up with planets
+	HandLevels poker.HandLevels `json:"hand_levels"`
+
 	// Field to store last purchased pack item ID
 	LastPurchasedPackItemId int `json:"last_pack_item_id"`
 
@@ -37,3 +44,45 @@ type InGamePlayer struct {
 	// Otherwise, the entry might not even exist (or be set to false)
 	CurrentShopPurchasedItemIDs map[int]bool
 }
+
+// RecordPlayedHand counts a played hand in MostPlayedHand and its hand level, and keeps the best
+// hand score of the game
+func (p *InGamePlayer) RecordPlayedHand(handType poker.HandType, score int) {
+	p.HandLevels.RecordPlayed(handType)
+	counts := p.HandCounts()
+	counts[handType]++
+	if data, err := json.Marshal(counts); err == nil {
+		p.MostPlayedHand = data
+	}
+	if score > p.BestHandScore {
+		p.BestHandScore = score
+	}
+}
+
+// HandCounts returns how many times the player has played each hand type
+func (p *InGamePlayer) HandCounts() map[poker.HandType]int {
+	counts := make(map[poker.HandType]int)
+	if len(p.MostPlayedHand) > 0 {
+		_ = json.Unmarshal(p.MostPlayedHand, &counts)
+	}
+	return counts
+}
+
+// MostPlayedHandType returns the hand type the player has played the most, poker.HandNone if none.
+// Ties go to the best hand (lowest type)
+func (p *InGamePlayer) MostPlayedHandType() poker.HandType {
+	mostPlayed, mostCount := poker.HandNone, 0
+	for handType, count := range p.HandCounts() {
+		if count > mostCount || (count == mostCount && handType < mostPlayed) {
+			mostPlayed, mostCount = handType, count
+		}
+	}
+	return mostPlayed
+}
+
+// EliminatedPlayer is the last state of a player eliminated before the end of the game,
+// kept until the game ends to write the match history
+type EliminatedPlayer struct {
+	Player InGamePlayer `json:"player"`
+	Round  int          `json:"round"` // Round the player was eliminated in
+}
@@ -0,0 +1,13 @@
+package redis
+
+import "time"
+
+// PhaseTimer is the deadline of the current phase of a lobby. When it expires the
+// phase scheduler advances the lobby to the next phase. A lobby has at most one
+// pending timer, since scheduling the timer of a new phase replaces the previous one
+type PhaseTimer struct {
+	LobbyID  string    `json:"lobby_id"`
+	Phase    string    `json:"phase"` // Phase that ends when the timer expires
+	Round    int       `json:"round"` // Round the phase belongs to
+	Deadline time.Time `json:"deadline"`
+}
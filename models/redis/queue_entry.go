@@ -0,0 +1,11 @@
+package redis
+
+import "time"
+
+// QueueEntry is a player waiting in the matchmaking queue. The rating is the one the
+// player had when they joined the queue, it isn't updated while they wait
+type QueueEntry struct {
+	Username   string    `json:"username"`
+	Rating     int       `json:"rating"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+}
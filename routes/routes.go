@@ -34,13 +34,23 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, redisClient *redis.RedisClient
 
 	api.GET("/ping", controllers.Ping)
 
+	api.GET("/errors", controllers.GetErrorCatalogue)
+
+	api.GET("/poker/hand-types", controllers.GetHandTypes)
+
 	api.GET("/allusers", controllers.GetAllUsers(db))
 
 	api.GET("/users/:username", controllers.GetUserPublicInfo(db))
 
-	api.POST("/login", controllers.Login(db))
+	api.GET("/users/:username/matches", controllers.GetUserMatches(db))
+
+	api.GET("/users/:username/rating_history", controllers.GetUserRatingHistory(db))
 
-	api.POST("/signup", controllers.SignUp(db))
+	api.GET("/leaderboard", controllers.GetLeaderboard(db))
+
+	api.POST("/login", middleware.RateLimit(redisClient, "login"), controllers.Login(db))
+
+	api.POST("/signup", middleware.RateLimit(redisClient, "signup"), controllers.SignUp(db))
 
 	authentication := api.Group("/auth")
 	authentication.Use(middleware.AuthRequired)
@@ -69,7 +79,7 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, redisClient *redis.RedisClient
 
 		authentication.GET("/friends", controllers.ListFriends(db))
 
-		authentication.POST("/sendFriendshipRequest", controllers.SendFriendshipRequest(db))
+		authentication.POST("/sendFriendshipRequest", middleware.RateLimit(redisClient, "sendFriendshipRequest"), controllers.SendFriendshipRequest(db))
 
 		authentication.POST("/addFriend", controllers.AddFriend(db))
 
@@ -83,13 +93,25 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, redisClient *redis.RedisClient
 
 		authentication.POST("/joinLobby/:lobby_id", controllers.JoinLobby(db, redisClient))
 
-		authentication.POST("/sendLobbyInvitation", controllers.SendLobbyInvitation(db))
+		authentication.POST("/sendLobbyInvitation", middleware.RateLimit(redisClient, "sendLobbyInvitation"), controllers.SendLobbyInvitation(db))
 
 		authentication.GET("/matchMaking", controllers.MatchMaking(db))
 
 		authentication.POST("/setLobbyVisibility/:lobby_id", controllers.SetLobbyVisibility(db, redisClient))
 
 		authentication.GET("/isUserInLobby", controllers.IsUserInLobby(db, redisClient))
+
+		authentication.GET("/replays/:lobby_id", controllers.GetGameReplay(db))
+
+		authentication.GET("/leaderboard/friends", controllers.GetFriendsLeaderboard(db))
+
+		authentication.GET("/direct_messages/:username", controllers.GetConversation(db))
+
+		authentication.POST("/direct_messages/:username/read", controllers.MarkConversationRead(db))
+
+		authentication.GET("/unread_direct_messages", controllers.GetUnreadDirectMessages(db))
+
+		authentication.POST("/reportPlayer", middleware.RateLimit(redisClient, "reportPlayer"), controllers.ReportPlayer(db, redisClient))
 	}
 
 	// Routes that require authentication
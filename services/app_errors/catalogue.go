@@ -0,0 +1,120 @@
+package app_errors
+
+import (
+	"fmt"
+	"net/http"
+)
+
+var catalogue []Definition
+
+// Registers an error in the catalogue. Codes must be unique
+func define(code string, status int, message string) Definition {
+	for _, def := range catalogue {
+		if def.Code == code {
+			panic(fmt.Sprintf("duplicated error code %q", code))
+		}
+	}
+	def := Definition{
+		Code:    code,
+		Status:  status,
+		Message: message,
+		I18nKey: "errors." + code,
+	}
+	catalogue = append(catalogue, def)
+	return def
+}
+
+// Catalogue returns every error the server can answer with, in the order they are defined.
+// It's served as JSON by GET /errors so clients can keep their translations in sync
+func Catalogue() []Definition {
+	return append([]Definition(nil), catalogue...)
+}
+
+// Generic errors. The invalid and missing fields come with the "field" detail, and
+// RateLimited with "retry_after_ms"
+var (
+	InvalidPayload = define("invalid_payload", http.StatusBadRequest, "The request payload is invalid")
+	MissingField   = define("missing_field", http.StatusBadRequest, "A required field is missing")
+	InvalidField   = define("invalid_field", http.StatusBadRequest, "A field has an invalid value")
+	RateLimited    = define("rate_limited", http.StatusTooManyRequests, "Too many requests, try again later")
+	Internal       = define("internal_error", http.StatusInternalServerError, "Something went wrong on the server")
+)
+
+// Authentication
+var (
+	MissingToken       = define("missing_token", http.StatusUnauthorized, "Missing authentication token")
+	InvalidToken       = define("invalid_token", http.StatusUnauthorized, "Invalid or expired token")
+	AuthUserNotFound   = define("auth_user_not_found", http.StatusUnauthorized, "The authenticated user doesn't exist")
+	InvalidCredentials = define("invalid_credentials", http.StatusUnauthorized, "Invalid email or password")
+)
+
+// Users and friends
+var (
+	UserNotFound             = define("user_not_found", http.StatusNotFound, "User not found")
+	UserAlreadyExists        = define("user_already_exists", http.StatusConflict, "User already exists")
+	UsernameTaken            = define("username_taken", http.StatusConflict, "Username already taken")
+	EmailTaken               = define("email_taken", http.StatusConflict, "Email already taken")
+	GameProfileNotFound      = define("game_profile_not_found", http.StatusNotFound, "Game profile not found")
+	CannotBefriendYourself   = define("cannot_befriend_yourself", http.StatusBadRequest, "You cannot be friends with yourself")
+	AlreadyFriends           = define("already_friends", http.StatusBadRequest, "You are already friends")
+	FriendshipNotFound       = define("friendship_not_found", http.StatusNotFound, "Friendship does not exist")
+	FriendRequestAlreadySent = define("friend_request_already_sent", http.StatusBadRequest, "Friend request already sent")
+	FriendRequestNotFound    = define("friend_request_not_found", http.StatusNotFound, "Friendship request not found")
+	NotFriends               = define("not_friends", http.StatusForbidden, "You can only send messages to your friends")
+)
+
+// Lobbies, invitations and replays
+var (
+	LobbyNotFound         = define("lobby_not_found", http.StatusNotFound, "Lobby not found")
+	LobbyFull             = define("lobby_full", http.StatusBadRequest, "Lobby is full")
+	GameAlreadyStarted    = define("game_already_started", http.StatusBadRequest, "Game has already started")
+	AlreadyInLobby        = define("already_in_lobby", http.StatusBadRequest, "You are already in a lobby")
+	NotLobbyHost          = define("not_lobby_host", http.StatusForbidden, "Only the host of the lobby can do this")
+	NotInLobby            = define("not_in_lobby", http.StatusForbidden, "You must join the lobby first")
+	PlayerNotInLobby      = define("player_not_in_lobby", http.StatusNotFound, "The user is not in the lobby")
+	CannotKickYourself    = define("cannot_kick_yourself", http.StatusBadRequest, "The host cannot kick themselves")
+	InvitationAlreadySent = define("invitation_already_sent", http.StatusBadRequest, "Invitation already sent to this user")
+	InvitationNotFound    = define("invitation_not_found", http.StatusNotFound, "Game lobby invitation not found")
+	ReplayNotFound        = define("replay_not_found", http.StatusNotFound, "Replay not found")
+	NotReplayPlayer       = define("not_replay_player", http.StatusForbidden, "Only the players of the game can see its replay")
+)
+
+// Spectators and matchmaking queue
+var (
+	PlayerCannotSpectate = define("player_cannot_spectate", http.StatusBadRequest, "You are a player of this lobby")
+	SpectatorsNotAllowed = define("spectators_not_allowed", http.StatusForbidden, "The host doesn't allow spectators in this lobby")
+	NotSpectating        = define("not_spectating", http.StatusBadRequest, "You are not spectating this lobby")
+	AlreadyQueued        = define("already_queued", http.StatusBadRequest, "You are already in the queue")
+	NotQueued            = define("not_queued", http.StatusBadRequest, "You are not in the queue")
+)
+
+// Chat and reports
+var (
+	PlayerMuted          = define("player_muted", http.StatusForbidden, "You have been muted by the host")
+	CannotMuteYourself   = define("cannot_mute_yourself", http.StatusBadRequest, "The host cannot mute themselves")
+	CannotReportYourself = define("cannot_report_yourself", http.StatusBadRequest, "You cannot report yourself")
+)
+
+// Game rounds. WrongPhase comes with the "expected_phase" and "current_phase" details
+var (
+	WrongPhase           = define("wrong_phase", http.StatusConflict, "This action is not allowed in the current phase")
+	NoHandPlaysLeft      = define("no_hand_plays_left", http.StatusBadRequest, "No hand plays left")
+	NoDiscardsLeft       = define("no_discards_left", http.StatusBadRequest, "No discards left")
+	NotEnoughCards       = define("not_enough_cards", http.StatusConflict, "There are not enough cards available in the deck")
+	HandAlreadyFull      = define("hand_already_full", http.StatusBadRequest, "Player already has enough cards")
+	InvalidCards         = define("invalid_cards", http.StatusBadRequest, "The cards are not in the player's hand")
+	ModifierNotAvailable = define("modifier_not_available", http.StatusBadRequest, "Modifier not available")
+	InvalidJokerOrder    = define("invalid_joker_order", http.StatusBadRequest, "The new order must contain every joker of the player")
+)
+
+// Shop. MaxJokersReached comes with the "max" detail
+var (
+	ShopNotFound        = define("shop_not_found", http.StatusNotFound, "Lobby shop state not found")
+	ShopItemNotFound    = define("shop_item_not_found", http.StatusNotFound, "Shop item not found")
+	MaxJokersReached    = define("max_jokers_reached", http.StatusBadRequest, "You cannot have more jokers")
+	PurchaseFailed      = define("purchase_failed", http.StatusBadRequest, "The purchase could not be completed")
+	NotEnoughMoney      = define("not_enough_money", http.StatusBadRequest, "Not enough money")
+	PackNotPurchased    = define("pack_not_purchased", http.StatusBadRequest, "You have not purchased this pack or already selected items from it")
+	PackSelectionFailed = define("pack_selection_failed", http.StatusBadRequest, "The pack selection is not valid")
+	JokerSaleFailed     = define("joker_sale_failed", http.StatusBadRequest, "The joker could not be sold")
+)
@@ -0,0 +1,27 @@
+package app_errors_test
+
+import (
+	"Nogler/services/app_errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalogue(t *testing.T) {
+	keys := map[string]bool{}
+	for _, def := range app_errors.Catalogue() {
+		assert.NotEmpty(t, def.Message, def.Code)
+		assert.GreaterOrEqual(t, def.Status, 400, def.Code)
+		assert.False(t, keys[def.I18nKey], "duplicated i18n key %s", def.I18nKey)
+		keys[def.I18nKey] = true
+	}
+	assert.True(t, keys["errors.lobby_not_found"])
+}
+
+func TestNewMergesDetails(t *testing.T) {
+	err := app_errors.InvalidField.New(app_errors.Details{"field": "seed"}, app_errors.Reason("not a number"))
+	assert.Equal(t, "invalid_field", err.Code)
+	assert.Equal(t, app_errors.Details{"field": "seed", "reason": "not a number"}, err.Details)
+
+	assert.Equal(t, app_errors.Details{}, app_errors.LobbyFull.New().Details)
+}
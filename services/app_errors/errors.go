@@ -0,0 +1,56 @@
+package app_errors
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Definition of an error of the catalogue. Clients branch on the code and translate the
+// message with the i18n key, the message being only the default (English) text
+type Definition struct {
+	Code    string `json:"code"`
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	I18nKey string `json:"i18n_key"`
+}
+
+// Details of a specific occurrence of an error, like the field that caused it or the values
+// needed to build the translated message
+type Details map[string]any
+
+// Error sent to the clients, both by the REST endpoints and the socket.io events
+type Error struct {
+	Code    string  `json:"code"`
+	Message string  `json:"message"`
+	Details Details `json:"details"`
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// New returns an occurrence of the error, merging the given details
+func (d Definition) New(details ...Details) *Error {
+	merged := Details{}
+	for _, detail := range details {
+		for key, value := range detail {
+			merged[key] = value
+		}
+	}
+	return &Error{Code: d.Code, Message: d.Message, Details: merged}
+}
+
+// Reason returns the details of an error with a free-text explanation. It's meant for
+// debugging, clients should rely on the code instead
+func Reason(reason string) Details {
+	return Details{"reason": reason}
+}
+
+// Respond answers the request with the error and its HTTP status
+func Respond(c *gin.Context, def Definition, details ...Details) {
+	c.JSON(def.Status, def.New(details...))
+}
+
+// Abort answers the request with the error and stops the handlers chain, for middlewares
+func Abort(c *gin.Context, def Definition, details ...Details) {
+	c.AbortWithStatusJSON(def.Status, def.New(details...))
+}
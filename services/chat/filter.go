@@ -0,0 +1,86 @@
+package chat
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Chat limits. Longer messages are rejected, and only the last HistorySize messages of a
+// lobby are kept
+const (
+	MaxMessageLength = 500
+	HistorySize      = 50
+)
+
+// WordFilter censors the messages sent to the lobby chats. It can be replaced with SetFilter
+type WordFilter interface {
+	Filter(message string) string
+}
+
+// BlocklistFilter replaces the words of a blocklist with asterisks. Words are compared
+// without case, and only whole words are censored ("class" is fine)
+type BlocklistFilter struct {
+	words map[string]bool
+}
+
+func NewBlocklistFilter(words []string) *BlocklistFilter {
+	filter := &BlocklistFilter{words: make(map[string]bool, len(words))}
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			filter.words[word] = true
+		}
+	}
+	return filter
+}
+
+func (f *BlocklistFilter) Filter(message string) string {
+	runes := []rune(message)
+	for start := 0; start < len(runes); {
+		if !unicode.IsLetter(runes[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(runes) && unicode.IsLetter(runes[end]) {
+			end++
+		}
+		if f.words[strings.ToLower(string(runes[start:end]))] {
+			for i := start; i < end; i++ {
+				runes[i] = '*'
+			}
+		}
+		start = end
+	}
+	return string(runes)
+}
+
+// Words censored unless a blocklist is loaded with LoadBlocklist
+var defaultBlocklist = []string{
+	"fuck", "fucking", "shit", "bitch", "bastard", "asshole", "cunt", "dick",
+	"puta", "puto", "mierda", "cabron", "cabrón", "gilipollas", "joder", "coño", "pendejo",
+}
+
+var filter WordFilter = NewBlocklistFilter(defaultBlocklist)
+
+// SetFilter replaces the filter of the chat messages
+func SetFilter(f WordFilter) {
+	filter = f
+}
+
+// LoadBlocklist replaces the filter with a blocklist read from path, one word per line
+func LoadBlocklist(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading chat blocklist %s: %v", path, err)
+	}
+	SetFilter(NewBlocklistFilter(strings.Split(string(data), "\n")))
+	return nil
+}
+
+// Clean prepares a message to be sent: surrounding whitespace is removed and the filter
+// applied. An empty result means there's nothing to send
+func Clean(message string) string {
+	return filter.Filter(strings.TrimSpace(message))
+}
@@ -0,0 +1,21 @@
+package chat_test
+
+import (
+	"Nogler/services/chat"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlocklistFilter(t *testing.T) {
+	filter := chat.NewBlocklistFilter([]string{"darn", "Coño"})
+
+	assert.Equal(t, "**** it, **** cards!", filter.Filter("Darn it, coño cards!"))
+	assert.Equal(t, "darned", filter.Filter("darned"))
+	assert.Equal(t, "nice hand", filter.Filter("nice hand"))
+}
+
+func TestClean(t *testing.T) {
+	assert.Equal(t, "what the ****", chat.Clean("  what the fuck \n"))
+	assert.Equal(t, "", chat.Clean("   "))
+}
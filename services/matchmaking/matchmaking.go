@@ -0,0 +1,111 @@
+package matchmaking
+
+import (
+	redis_models "Nogler/models/redis"
+	"sort"
+	"time"
+)
+
+const (
+	// Rating difference accepted for a player that has just joined the queue
+	BaseRatingBand = 100
+	// The band widens by this much for every second the player has been waiting
+	RatingBandGrowth = 10
+	// Widest band, reached after waiting 90 seconds
+	MaxRatingBand = 1000
+)
+
+// RatingBand returns the rating difference accepted for a player that joined the queue at
+// enqueuedAt. It widens the longer the player waits, so everyone eventually finds a game
+func RatingBand(enqueuedAt time.Time, now time.Time) int {
+	waited := int(now.Sub(enqueuedAt) / time.Second)
+	if waited < 0 {
+		waited = 0
+	}
+	band := BaseRatingBand + waited*RatingBandGrowth
+	if band > MaxRatingBand {
+		return MaxRatingBand
+	}
+	return band
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// Two players can be matched if their rating difference fits in the band of any of them
+func compatible(a redis_models.QueueEntry, b redis_models.QueueEntry, now time.Time) bool {
+	band := RatingBand(a.EnqueuedAt, now)
+	if other := RatingBand(b.EnqueuedAt, now); other > band {
+		band = other
+	}
+	return abs(a.Rating-b.Rating) <= band
+}
+
+// FindMatches groups the players of the queue in games of size players. The players that have
+// been waiting longer are matched first, each one with the compatible players of the closest
+// rating. Every pair of players of a match is compatible, so the ratings of a match never spread
+// over the widest band of its players. Players that can't be matched yet are left out
+func FindMatches(entries []redis_models.QueueEntry, now time.Time, size int) [][]redis_models.QueueEntry {
+	queue := append([]redis_models.QueueEntry(nil), entries...)
+	sort.SliceStable(queue, func(i, j int) bool {
+		return queue[i].EnqueuedAt.Before(queue[j].EnqueuedAt)
+	})
+
+	var matches [][]redis_models.QueueEntry
+	matched := make([]bool, len(queue))
+	for i, anchor := range queue {
+		if matched[i] {
+			continue
+		}
+
+		var candidates []int
+		for j := i + 1; j < len(queue); j++ {
+			if !matched[j] && compatible(anchor, queue[j], now) {
+				candidates = append(candidates, j)
+			}
+		}
+		if len(candidates) < size-1 {
+			continue
+		}
+
+		// Closest ratings first, the ones waiting longer on a tie
+		sort.SliceStable(candidates, func(x, y int) bool {
+			return abs(queue[candidates[x]].Rating-anchor.Rating) < abs(queue[candidates[y]].Rating-anchor.Rating)
+		})
+
+		// Take the candidates that are compatible with everyone already in the match
+		group := []int{i}
+		for _, j := range candidates {
+			if len(group) == size {
+				break
+			}
+			if compatibleWithAll(queue, group, j, now) {
+				group = append(group, j)
+			}
+		}
+		if len(group) < size {
+			continue
+		}
+
+		match := make([]redis_models.QueueEntry, 0, size)
+		for _, j := range group {
+			match = append(match, queue[j])
+			matched[j] = true
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+func compatibleWithAll(queue []redis_models.QueueEntry, group []int, candidate int, now time.Time) bool {
+	for _, j := range group {
+		if !compatible(queue[j], queue[candidate], now) {
+			return false
+		}
+	}
+	return true
+}
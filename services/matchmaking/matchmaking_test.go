@@ -0,0 +1,66 @@
+package matchmaking_test
+
+import (
+	redis_models "Nogler/models/redis"
+	"Nogler/services/matchmaking"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRatingBandWidensWithWait(t *testing.T) {
+	now := time.Now()
+
+	assert.Equal(t, matchmaking.BaseRatingBand, matchmaking.RatingBand(now, now))
+	assert.Equal(t, matchmaking.BaseRatingBand+30*matchmaking.RatingBandGrowth, matchmaking.RatingBand(now.Add(-30*time.Second), now))
+	assert.Equal(t, matchmaking.MaxRatingBand, matchmaking.RatingBand(now.Add(-time.Hour), now))
+}
+
+func TestFindMatches(t *testing.T) {
+	now := time.Now()
+	entries := []redis_models.QueueEntry{
+		{Username: "newbie", Rating: 1000, EnqueuedAt: now},
+		{Username: "pro", Rating: 1600, EnqueuedAt: now.Add(-10 * time.Second)},
+		{Username: "close", Rating: 1050, EnqueuedAt: now.Add(-5 * time.Second)},
+		{Username: "closer", Rating: 1020, EnqueuedAt: now.Add(-2 * time.Second)},
+	}
+
+	// The oldest player is too far from everyone, the next one takes the closest rating
+	matches := matchmaking.FindMatches(entries, now, 2)
+	if assert.Len(t, matches, 1) {
+		assert.Equal(t, "close", matches[0][0].Username)
+		assert.Equal(t, "closer", matches[0][1].Username)
+	}
+
+	// After waiting long enough the band covers the pro
+	matches = matchmaking.FindMatches(entries, now.Add(time.Minute), 4)
+	if assert.Len(t, matches, 1) {
+		assert.Len(t, matches[0], 4)
+		assert.Equal(t, "pro", matches[0][0].Username)
+	}
+
+	assert.Empty(t, matchmaking.FindMatches(entries[:1], now, 2))
+}
+
+func TestFindMatchesChecksEveryPair(t *testing.T) {
+	now := time.Now()
+	entries := []redis_models.QueueEntry{
+		{Username: "anchor", Rating: 1000, EnqueuedAt: now},
+		{Username: "low", Rating: 920, EnqueuedAt: now},
+		{Username: "high", Rating: 1090, EnqueuedAt: now},
+	}
+
+	// Both are in the band of the anchor, but not in each other's
+	assert.Empty(t, matchmaking.FindMatches(entries, now, 3))
+
+	entries = append(entries, redis_models.QueueEntry{Username: "close", Rating: 1010, EnqueuedAt: now})
+	matches := matchmaking.FindMatches(entries, now, 3)
+	if assert.Len(t, matches, 1) {
+		usernames := []string{}
+		for _, entry := range matches[0] {
+			usernames = append(usernames, entry.Username)
+		}
+		assert.ElementsMatch(t, []string{"anchor", "close", "low"}, usernames)
+	}
+}
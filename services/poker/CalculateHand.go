@@ -1,15 +1,18 @@
 package poker
 
 import (
-	"math/rand"
+	"fmt"
 	"sort"
 	"strconv"
+
+	"golang.org/x/exp/rand"
 )
 
 type Hand struct {
-	Cards  []Card `json:"cards"`
-	Jokers Jokers `json:"jokers"`
-	Gold   int    `json:"gold"`
+	Cards  []Card     `json:"cards"`
+	Jokers Jokers     `json:"jokers"`
+	Gold   int        `json:"gold"`
+	Levels HandLevels `json:"levels,omitempty"` // Levels of the hand types of the player
 }
 
 type Deck struct {
@@ -17,11 +20,16 @@ type Deck struct {
 	PlayedCards []Card `json:"played_cards"`
 }
 
-// Define a Card struct with a Rank and suit
+// Define a Card struct with a Rank and suit. The ID tells apart the cards of a deck with the
+// same rank and suit: the standard cards go from 1 to StandardDeckSize and the bought ones
+// come after them (see AssignCardIDs)
 type Card struct {
+	ID          int
 	Rank        string
 	Suit        string
-	Enhancement int // 0 nada 1 = +5 mult 2 = +20 chips
+	Enhancement int // See the Enhancement* constants
+	Edition     int // See the Edition* constants
+	Seal        int // See the Seal* constants
 }
 
 // Cards A, 2, 3, 4, 5, 6, 7, 8, 9, 10, J, Q, K
@@ -32,21 +40,21 @@ type Multiplier struct {
 	Second int
 }
 
-// Temporal table of fichas - mult for testing. will be fetched eventually
-var TypeMap = map[string]Multiplier{
-	"RoyalFlush":    {65, 50},
-	"StraightFlush": {50, 40},
-	"FiveOfAKind":   {30, 20},
-	"FlushHouse":    {32, 22},
-	"FlushFive":     {35, 25},
-	"FourOfAKind":   {25, 15},
-	"FullHouse":     {20, 12},
-	"Flush":         {15, 8},
-	"Straight":      {12, 5},
-	"ThreeOfAKind":  {10, 4},
-	"TwoPair":       {8, 3},
-	"Pair":          {4, 2},
-	"HighCard":      {1, 1},
+// Base fichas and mult of the hand types at level 1, see HandLevels for the next levels
+var TypeMap = map[HandType]Multiplier{
+	HandRoyalFlush:    {65, 50},
+	HandStraightFlush: {50, 40},
+	HandFiveOfAKind:   {30, 20},
+	HandFlushHouse:    {32, 22},
+	HandFlushFive:     {35, 25},
+	HandFourOfAKind:   {25, 15},
+	HandFullHouse:     {20, 12},
+	HandFlush:         {15, 8},
+	HandStraight:      {12, 5},
+	HandThreeOfAKind:  {10, 4},
+	HandTwoPair:       {8, 3},
+	HandPair:          {4, 2},
+	HandHighCard:      {1, 1},
 }
 
 var RankMap = map[string]bool{
@@ -59,13 +67,19 @@ var SuitMap = map[string]bool{
 	"h": true, "d": true, "c": true, "s": true,
 }
 
+var RankOrder = []string{"A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"}
+
+var SuitOrder = []string{"h", "d", "c", "s"}
+
+const StandardDeckSize = 52
+
 func NewStandardDeck() *Deck {
 	total := make([]Card, 0, 52)
 
-	// Iterate over SuitMap and RankMap keys
-	for suit := range SuitMap {
-		for rank := range RankMap {
-			total = append(total, Card{Rank: rank, Suit: suit, Enhancement: 0})
+	// Iterate in a fixed order (not over the maps), so a seeded shuffle is reproducible
+	for _, suit := range SuitOrder {
+		for _, rank := range RankOrder {
+			total = append(total, Card{ID: len(total) + 1, Rank: rank, Suit: suit, Enhancement: 0})
 		}
 	}
 
@@ -80,31 +94,72 @@ func (d *Deck) AddCards(newCards []Card) {
 }
 
 func (d *Deck) RemoveCards(toRemove []Card) {
-	newTotal := make([]Card, 0, len(d.TotalCards))
-
-	for _, card := range d.TotalCards {
-		keep := true
-		for _, removed := range toRemove {
-			if card.Rank == removed.Rank && card.Suit == removed.Suit {
-				keep = false
-				break
-			}
+	d.TotalCards = WithoutCards(d.TotalCards, toRemove)
+}
+
+// Returns the cards without the ones with the IDs of toRemove
+func WithoutCards(cards []Card, toRemove []Card) []Card {
+	removed := make(map[int]bool, len(toRemove))
+	for _, card := range toRemove {
+		removed[card.ID] = true
+	}
+
+	kept := make([]Card, 0, len(cards))
+	for _, card := range cards {
+		if !removed[card.ID] {
+			kept = append(kept, card)
+		}
+	}
+	return kept
+}
+
+// Returns the cards with the given IDs, in the order of the IDs. Fails if an ID is
+// not in the cards or is repeated
+func FindCards(cards []Card, ids []int) ([]Card, error) {
+	byID := make(map[int]Card, len(cards))
+	for _, card := range cards {
+		byID[card.ID] = card
+	}
+
+	found := make([]Card, 0, len(ids))
+	selected := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		card, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("card %d not found", id)
 		}
-		if keep {
-			newTotal = append(newTotal, card)
+		if selected[id] {
+			return nil, fmt.Errorf("card %d selected more than once", id)
 		}
+		selected[id] = true
+		found = append(found, card)
+	}
+	return found, nil
+}
+
+// Gives the new cards IDs that aren't used by the standard deck nor the owned cards
+func AssignCardIDs(owned []Card, newCards []Card) []Card {
+	next := StandardDeckSize
+	for _, card := range owned {
+		next = max(next, card.ID)
 	}
 
-	d.TotalCards = newTotal
+	assigned := make([]Card, len(newCards))
+	for i, card := range newCards {
+		next++
+		card.ID = next
+		assigned[i] = card
+	}
+	return assigned
 }
 
 func (d *Deck) MarkAsPlayed(cards []Card) {
 	d.PlayedCards = append(d.PlayedCards, cards...)
 }
 
-func (d *Deck) Draw(n int) []Card {
+func (d *Deck) Draw(rng *rand.Rand, n int) []Card {
 	if len(d.TotalCards) < n {
-		d.reshufflePlayed()
+		d.reshufflePlayed(rng)
 	}
 
 	if n > len(d.TotalCards) {
@@ -118,7 +173,7 @@ func (d *Deck) Draw(n int) []Card {
 }
 
 // Shuffle randomizes the deck using Fisher-Yates algorithm
-func (d *Deck) Shuffle() {
+func (d *Deck) Shuffle(rng *rand.Rand) {
 
 	// If we have played cards, combine them back first
 	if len(d.PlayedCards) > 0 {
@@ -128,16 +183,16 @@ func (d *Deck) Shuffle() {
 
 	// Fisher-Yates shuffle on TotalCards (deepseek lo dice yo escucho)
 	for i := len(d.TotalCards) - 1; i > 0; i-- {
-		j := rand.Intn(i + 1)
+		j := rng.Intn(i + 1)
 		d.TotalCards[i], d.TotalCards[j] = d.TotalCards[j], d.TotalCards[i]
 	}
 }
 
 // Necesario para si p ejemplo me quedan 3 cartas por drawear y juego 5 pues reshufleo
-func (d *Deck) reshufflePlayed() {
+func (d *Deck) reshufflePlayed(rng *rand.Rand) {
 
 	// Mezclar played cards
-	rand.Shuffle(len(d.PlayedCards), func(i, j int) {
+	rng.Shuffle(len(d.PlayedCards), func(i, j int) {
 		d.PlayedCards[i], d.PlayedCards[j] = d.PlayedCards[j], d.PlayedCards[i]
 	})
 
@@ -147,7 +202,7 @@ func (d *Deck) reshufflePlayed() {
 }
 
 func grade(c1 Card) int {
-	switch c1.Rank {
+	switch c1.ScoringRank() {
 	case "K":
 		return 13
 	case "Q":
@@ -157,7 +212,7 @@ func grade(c1 Card) int {
 	case "A":
 		return 14
 	default:
-		rank, _ := strconv.Atoi(c1.Rank)
+		rank, _ := strconv.Atoi(c1.ScoringRank()) // Stone cards have no rank, 0
 		return rank
 	}
 }
@@ -165,6 +220,9 @@ func grade(c1 Card) int {
 // Get the final CHIPS a fixed card will score when played as part of a determined hand
 // Face cards are worth 10 chips, numerated cards are worth the rank chips, and aces are worth
 func PointsPerCard(c Card) int {
+	if c.isStone() {
+		return StoneChips
+	}
 	var value int
 	switch c.Rank {
 	case "K", "Q", "J":
@@ -312,11 +370,16 @@ func Flush(h Hand) ([]Card, bool) {
 		return nil, false
 	}
 
-	suit := h.Cards[0].Suit
+	// Wild cards match any suit
+	suit := ""
 	var scoringCards []Card
 	for _, c := range h.Cards {
-		if c.Suit != suit {
-			return nil, false
+		if !c.isWild() {
+			if suit == "" {
+				suit = c.Suit
+			} else if c.Suit != suit {
+				return nil, false
+			}
 		}
 		scoringCards = append(scoringCards, c)
 	}
@@ -474,100 +537,121 @@ func HighCard(h Hand) ([]Card, bool) {
 	return h.Cards[:1], true
 }
 
-// EL ÚLTIMO INT QUE DEVUELVE SIGNIFICA LO SIGUIENTE:
-//
-// Es un valor que se asocia a un tipo de mano. Esta fijado de la siguiente forma:
-// RoyalFlush = 1
-// StraightFlush = 2
-// FiveOfAKind = 3
-// FlushFive = 4
-// FlushHouse = 5
-// FourOfAKind = 6
-// FullHouse = 7
-// Flush = 8
-// Straight = 9
-// ThreeOfAKind = 10
-// TwoPair = 11
-// Pair = 12
-// HighCard = 13
-
-func BestHand(h Hand) (int, int, int, []Card) {
+// Returns the base fichas and mult of the best hand type in h at the level of h.Levels, the hand
+// type and the scored cards. The base values are recorded in the trace, if any
+func BestHand(h Hand, trace *ScoringTrace) (int, int, HandType, []Card) {
+	fichas, mult, handType, scoredCards := bestHand(h)
+	if handType != HandNone {
+		fichas, mult = h.Levels.Base(handType)
+		trace.record(ScoringStep{
+			Source:     StepHand,
+			ID:         int(handType),
+			Name:       handType.String(),
+			ChipsAfter: fichas,
+			MultAfter:  mult,
+		})
+	}
+	return fichas, mult, handType, scoredCards
+}
+
+func bestHand(h Hand) (int, int, HandType, []Card) {
 
 	// NEW: handle the case with empty cards to avoid panics
 	if len(h.Cards) <= 0 {
-		return 0, 0, 0, nil
+		return 0, 0, HandNone, nil
 	}
 
-	// Make a copy to avoid modifying original
-	tmp := Hand{Cards: make([]Card, len(h.Cards))}
-	copy(tmp.Cards, h.Cards)
+	// Stone cards don't count for the hand type but are always scored
+	tmp := Hand{Cards: make([]Card, 0, len(h.Cards))}
+	var stones []Card
+	for _, c := range h.Cards {
+		if c.isStone() {
+			stones = append(stones, c)
+		} else {
+			tmp.Cards = append(tmp.Cards, c)
+		}
+	}
+	if len(tmp.Cards) == 0 {
+		return TypeMap[HandHighCard].First, TypeMap[HandHighCard].Second, HandHighCard, stones
+	}
 	sortCards(&tmp)
 
+	fichas, mult, handType, scoringCards := rankHand(tmp)
+	if len(stones) > 0 {
+		scoringCards = append(append([]Card{}, scoringCards...), stones...)
+	}
+	return fichas, mult, handType, scoringCards
+}
+
+func rankHand(tmp Hand) (int, int, HandType, []Card) {
+
 	// Check for the strongest hand first and return as soon as we find one
 
 	switch {
 	case func(cards []Card, ok bool) bool { return ok }(RoyalFlush(tmp)):
 		scoringCards, _ := RoyalFlush(tmp)
-		return TypeMap["RoyalFlush"].First, TypeMap["RoyalFlush"].Second, 1, scoringCards
+		return TypeMap[HandRoyalFlush].First, TypeMap[HandRoyalFlush].Second, HandRoyalFlush, scoringCards
 	case func(cards []Card, ok bool) bool { return ok }(StraightFlush(tmp)):
 		scoringCards, _ := StraightFlush(tmp)
-		return TypeMap["StraightFlush"].First, TypeMap["StraightFlush"].Second, 2, scoringCards
+		return TypeMap[HandStraightFlush].First, TypeMap[HandStraightFlush].Second, HandStraightFlush, scoringCards
 	case func(cards []Card, ok bool) bool { return ok }(FiveOfAKind(tmp)):
 		scoringCards, _ := FiveOfAKind(tmp)
-		return TypeMap["FiveOfAKind"].First, TypeMap["FiveOfAKind"].Second, 5, scoringCards
+		return TypeMap[HandFiveOfAKind].First, TypeMap[HandFiveOfAKind].Second, HandFiveOfAKind, scoringCards
 	case func(cards []Card, ok bool) bool { return ok }(FlushHouse(tmp)):
 		scoringCards, _ := FlushHouse(tmp)
-		return TypeMap["FlushHouse"].First, TypeMap["FlushHouse"].Second, 4, scoringCards
+		return TypeMap[HandFlushHouse].First, TypeMap[HandFlushHouse].Second, HandFlushHouse, scoringCards
 	case func(cards []Card, ok bool) bool { return ok }(FlushFive(tmp)):
 		scoringCards, _ := FlushFive(tmp)
-		return TypeMap["FlushFive"].First, TypeMap["FlushFive"].Second, 3, scoringCards
+		return TypeMap[HandFlushFive].First, TypeMap[HandFlushFive].Second, HandFlushFive, scoringCards
 	case func(cards []Card, ok bool) bool { return ok }(FourOfAKind(tmp)):
 		scoringCards, _ := FourOfAKind(tmp)
-		return TypeMap["FourOfAKind"].First, TypeMap["FourOfAKind"].Second, 6, scoringCards
+		return TypeMap[HandFourOfAKind].First, TypeMap[HandFourOfAKind].Second, HandFourOfAKind, scoringCards
 	case func(cards []Card, ok bool) bool { return ok }(FullHouse(tmp)):
 		scoringCards, _ := FullHouse(tmp)
-		return TypeMap["FullHouse"].First, TypeMap["FullHouse"].Second, 7, scoringCards
+		return TypeMap[HandFullHouse].First, TypeMap[HandFullHouse].Second, HandFullHouse, scoringCards
 	case func(cards []Card, ok bool) bool { return ok }(Flush(tmp)):
 		scoringCards, _ := Flush(tmp)
-		return TypeMap["Flush"].First, TypeMap["Flush"].Second, 8, scoringCards
+		return TypeMap[HandFlush].First, TypeMap[HandFlush].Second, HandFlush, scoringCards
 	case func(cards []Card, ok bool) bool { return ok }(Straight(tmp)):
 		scoringCards, _ := Straight(tmp)
-		return TypeMap["Straight"].First, TypeMap["Straight"].Second, 9, scoringCards
+		return TypeMap[HandStraight].First, TypeMap[HandStraight].Second, HandStraight, scoringCards
 	case func(cards []Card, ok bool) bool { return ok }(ThreeOfAKind(tmp)):
 		scoringCards, _ := ThreeOfAKind(tmp)
-		return TypeMap["ThreeOfAKind"].First, TypeMap["ThreeOfAKind"].Second, 10, scoringCards
+		return TypeMap[HandThreeOfAKind].First, TypeMap[HandThreeOfAKind].Second, HandThreeOfAKind, scoringCards
 	case func(cards []Card, ok bool) bool { return ok }(TwoPair(tmp)):
 		scoringCards, _ := TwoPair(tmp)
-		return TypeMap["TwoPair"].First, TypeMap["TwoPair"].Second, 11, scoringCards
+		return TypeMap[HandTwoPair].First, TypeMap[HandTwoPair].Second, HandTwoPair, scoringCards
 	case func(cards []Card, ok bool) bool { return ok }(Pair(tmp)):
 		scoringCards, _ := Pair(tmp)
-		return TypeMap["Pair"].First, TypeMap["Pair"].Second, 12, scoringCards
+		return TypeMap[HandPair].First, TypeMap[HandPair].Second, HandPair, scoringCards
 	case func(cards []Card, ok bool) bool { return ok }(HighCard(tmp)):
 		scoringCards, _ := HighCard(tmp)
-		return TypeMap["HighCard"].First, TypeMap["HighCard"].Second, 13, scoringCards
+		return TypeMap[HandHighCard].First, TypeMap[HandHighCard].Second, HandHighCard, scoringCards
 	default:
 		// If no hand is found, return 0
-		return 0, 0, 0, nil
-	}
-}
-
-func ApplyEnhancements(fichas int, mult int, cards []Card) (int, int) {
-	for _, card := range cards {
-		switch card.Enhancement {
-		case 1:
-			mult += 5
-		case 2:
-			fichas += 20
-		default:
-		}
+		return 0, 0, HandNone, nil
 	}
-	return fichas, mult
 }
 
-func AddChipsPerCard(cards []Card) int {
+// Returns the chips of the scored cards, recording one step per card on top of the last step of the trace.
+// Red seal cards are scored twice
+func AddChipsPerCard(cards []Card, trace *ScoringTrace) int {
+	fichas, mult := trace.current()
 	addition := 0
 	for _, card := range cards {
-		addition += PointsPerCard(card)
+		points := PointsPerCard(card)
+		for i := 0; i < card.triggers(); i++ {
+			addition += points
+			trace.record(ScoringStep{
+				Source:      StepCard,
+				Name:        cardName(card),
+				ChipsBefore: fichas,
+				ChipsAfter:  fichas + points,
+				MultBefore:  mult,
+				MultAfter:   mult,
+			})
+			fichas += points
+		}
 	}
 	return addition
 }
@@ -0,0 +1,249 @@
+package poker
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/rand"
+)
+
+// Enhancements of a card. Mult and Bonus keep the values the decks were stored with
+const (
+	EnhancementNone  = 0
+	EnhancementMult  = 1 // +5 mult
+	EnhancementBonus = 2 // +20 chips
+	EnhancementGlass = 3 // x2 mult
+	EnhancementSteel = 4 // x1.5 mult
+	EnhancementGold  = 5 // +3 gold
+	EnhancementLucky = 6 // 1 in 5 chance of +20 mult, 1 in 15 chance of +20 gold
+	EnhancementWild  = 7 // Counts as every suit
+	EnhancementStone = 8 // 50 chips, no rank nor suit, always scores
+)
+
+// Editions of a card, applied after its enhancement
+const (
+	EditionNone        = 0
+	EditionFoil        = 1 // +50 chips
+	EditionHolographic = 2 // +10 mult
+	EditionPolychrome  = 3 // x1.5 mult
+)
+
+// Seals of a card
+const (
+	SealNone = 0
+	SealRed  = 1 // The card is scored twice
+	SealGold = 2 // +3 gold when scored
+)
+
+const (
+	StoneChips    = 50
+	luckyMultOdds = 5
+	luckyGoldOdds = 15
+)
+
+var EnhancementNames = map[int]string{
+	EnhancementMult:  "Mult",
+	EnhancementBonus: "Bonus",
+	EnhancementGlass: "Glass",
+	EnhancementSteel: "Steel",
+	EnhancementGold:  "Gold",
+	EnhancementLucky: "Lucky",
+	EnhancementWild:  "Wild",
+	EnhancementStone: "Stone",
+}
+
+var EditionNames = map[int]string{
+	EditionFoil:        "Foil",
+	EditionHolographic: "Holographic",
+	EditionPolychrome:  "Polychrome",
+}
+
+var SealNames = map[int]string{
+	SealRed:  "Red",
+	SealGold: "Gold",
+}
+
+// Checks the rank, suit, enhancement, edition and seal of a card are known ones
+func (c Card) Validate() error {
+	if !RankMap[c.Rank] {
+		return fmt.Errorf("invalid rank %q", c.Rank)
+	}
+	if !SuitMap[c.Suit] {
+		return fmt.Errorf("invalid suit %q", c.Suit)
+	}
+	if _, ok := EnhancementNames[c.Enhancement]; !ok && c.Enhancement != EnhancementNone {
+		return fmt.Errorf("invalid enhancement %d", c.Enhancement)
+	}
+	if _, ok := EditionNames[c.Edition]; !ok && c.Edition != EditionNone {
+		return fmt.Errorf("invalid edition %d", c.Edition)
+	}
+	if _, ok := SealNames[c.Seal]; !ok && c.Seal != SealNone {
+		return fmt.Errorf("invalid seal %d", c.Seal)
+	}
+	return nil
+}
+
+func (c Card) isStone() bool {
+	return c.Enhancement == EnhancementStone
+}
+
+func (c Card) isWild() bool {
+	return c.Enhancement == EnhancementWild
+}
+
+// Whether the card counts as the given suit for jokers and modifiers: wild cards count as
+// every suit and stone cards as none
+func (c Card) HasSuit(suit string) bool {
+	switch {
+	case c.isStone():
+		return false
+	case c.isWild():
+		return true
+	default:
+		return c.Suit == suit
+	}
+}
+
+// Rank the card counts as for jokers and modifiers, "" for stone cards as they have no rank
+func (c Card) ScoringRank() string {
+	if c.isStone() {
+		return ""
+	}
+	return c.Rank
+}
+
+// The hand without its stone cards, which don't count for the hand types
+func (h Hand) withoutStones() Hand {
+	cards := make([]Card, 0, len(h.Cards))
+	for _, c := range h.Cards {
+		if !c.isStone() {
+			cards = append(cards, c)
+		}
+	}
+	h.Cards = cards
+	return h
+}
+
+// How many times the card is scored
+func (c Card) triggers() int {
+	if c.Seal == SealRed {
+		return 2
+	}
+	return 1
+}
+
+// Random enhancement, edition and seal for the cards of the packs. Half of the cards are
+// enhanced, editions and seals are rarer
+func RandomModifications(rng *rand.Rand, card Card) Card {
+	if rng.Intn(2) == 0 {
+		card.Enhancement = EnhancementMult + rng.Intn(EnhancementStone)
+	}
+
+	switch roll := rng.Intn(100); {
+	case roll < 2:
+		card.Edition = EditionPolychrome
+	case roll < 7:
+		card.Edition = EditionHolographic
+	case roll < 15:
+		card.Edition = EditionFoil
+	}
+
+	switch roll := rng.Intn(100); {
+	case roll < 5:
+		card.Seal = SealRed
+	case roll < 10:
+		card.Seal = SealGold
+	}
+	return card
+}
+
+// Applies the enhancement, edition and seal of every scored card, in order. Red seal cards
+// apply them twice. Returns the new fichas, mult and gold
+func ApplyEnhancements(rng *rand.Rand, fichas int, mult int, gold int, cards []Card, trace *ScoringTrace) (int, int, int) {
+	for _, card := range cards {
+		for i := 0; i < card.triggers(); i++ {
+			fichas, mult, gold = applyEnhancement(rng, fichas, mult, gold, card, trace)
+			fichas, mult = applyEdition(fichas, mult, card, trace)
+			gold = applySeal(fichas, mult, gold, card, trace)
+		}
+	}
+	return fichas, mult, gold
+}
+
+func applyEnhancement(rng *rand.Rand, fichas int, mult int, gold int, card Card, trace *ScoringTrace) (int, int, int) {
+	fichasBefore, multBefore, goldBefore := fichas, mult, gold
+	switch card.Enhancement {
+	case EnhancementMult:
+		mult += 5
+	case EnhancementBonus:
+		fichas += 20
+	case EnhancementGlass:
+		mult *= 2
+	case EnhancementSteel:
+		mult = mult * 3 / 2
+	case EnhancementGold:
+		gold += 3
+	case EnhancementLucky:
+		if rng.Intn(luckyMultOdds) == 0 {
+			mult += 20
+		}
+		if rng.Intn(luckyGoldOdds) == 0 {
+			gold += 20
+		}
+	default:
+		// Stone cards score their chips as a card, wild ones only change the hand type
+		return fichas, mult, gold
+	}
+	trace.record(ScoringStep{
+		Source:      StepEnhancement,
+		ID:          card.Enhancement,
+		Name:        cardName(card),
+		ChipsBefore: fichasBefore,
+		ChipsAfter:  fichas,
+		MultBefore:  multBefore,
+		MultAfter:   mult,
+		GoldDelta:   gold - goldBefore,
+	})
+	return fichas, mult, gold
+}
+
+func applyEdition(fichas int, mult int, card Card, trace *ScoringTrace) (int, int) {
+	fichasBefore, multBefore := fichas, mult
+	switch card.Edition {
+	case EditionFoil:
+		fichas += 50
+	case EditionHolographic:
+		mult += 10
+	case EditionPolychrome:
+		mult = mult * 3 / 2
+	default:
+		return fichas, mult
+	}
+	trace.record(ScoringStep{
+		Source:      StepEdition,
+		ID:          card.Edition,
+		Name:        cardName(card),
+		ChipsBefore: fichasBefore,
+		ChipsAfter:  fichas,
+		MultBefore:  multBefore,
+		MultAfter:   mult,
+	})
+	return fichas, mult
+}
+
+// Red seals are applied by scoring the card twice, so only the gold seal has an effect here
+func applySeal(fichas int, mult int, gold int, card Card, trace *ScoringTrace) int {
+	if card.Seal != SealGold {
+		return gold
+	}
+	trace.record(ScoringStep{
+		Source:      StepSeal,
+		ID:          card.Seal,
+		Name:        cardName(card),
+		ChipsBefore: fichas,
+		ChipsAfter:  fichas,
+		MultBefore:  mult,
+		MultAfter:   mult,
+		GoldDelta:   3,
+	})
+	return gold + 3
+}
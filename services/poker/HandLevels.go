@@ -0,0 +1,71 @@
+package poker
+
+import "golang.org/x/exp/rand"
+
+// Level of a hand type of a player. Every level over the first adds the LevelUpgrades of the
+// hand type to its base fichas and mult
+type HandLevel struct {
+	Level       int `json:"level"`
+	TimesPlayed int `json:"times_played"` // Tracking for stats
+}
+
+// Levels of a player by hand type. Hand types that aren't in the map are at level 1
+type HandLevels map[HandType]HandLevel
+
+// Fichas and mult added to the base ones of TypeMap by every level of the hand type
+var LevelUpgrades = map[HandType]Multiplier{
+	HandRoyalFlush:    {40, 4},
+	HandStraightFlush: {40, 4},
+	HandFiveOfAKind:   {35, 3},
+	HandFlushHouse:    {40, 4},
+	HandFlushFive:     {50, 3},
+	HandFourOfAKind:   {30, 3},
+	HandFullHouse:     {25, 2},
+	HandFlush:         {15, 2},
+	HandStraight:      {30, 3},
+	HandThreeOfAKind:  {20, 2},
+	HandTwoPair:       {20, 1},
+	HandPair:          {15, 1},
+	HandHighCard:      {10, 1},
+}
+
+// Random hand type, for the planets of the shop and the packs
+func RandomHandType(rng *rand.Rand) HandType {
+	return HandTypes[rng.Intn(len(HandTypes))]
+}
+
+func (l HandLevels) Level(handType HandType) int {
+	if level := l[handType].Level; level > 1 {
+		return level
+	}
+	return 1
+}
+
+// Base fichas and mult of the hand type at its level
+func (l HandLevels) Base(handType HandType) (int, int) {
+	base, upgrade := TypeMap[handType], LevelUpgrades[handType]
+	extra := l.Level(handType) - 1
+	return base.First + extra*upgrade.First, base.Second + extra*upgrade.Second
+}
+
+// Upgrades the hand type one level and returns the new level
+func (l *HandLevels) LevelUp(handType HandType) int {
+	if *l == nil {
+		*l = make(HandLevels)
+	}
+	level := (*l)[handType]
+	level.Level = l.Level(handType) + 1
+	(*l)[handType] = level
+	return level.Level
+}
+
+// Counts a played hand of the hand type
+func (l *HandLevels) RecordPlayed(handType HandType) {
+	if *l == nil {
+		*l = make(HandLevels)
+	}
+	level := (*l)[handType]
+	level.Level = l.Level(handType)
+	level.TimesPlayed++
+	(*l)[handType] = level
+}
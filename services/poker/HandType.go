@@ -0,0 +1,66 @@
+package poker
+
+// Type of a poker hand, as returned by BestHand. The numeric values are the ones stored in Redis
+// and PostgreSQL and sent to the clients, so they must not change. The lower, the better
+type HandType int
+
+const (
+	HandNone          HandType = 0 // No cards were played
+	HandRoyalFlush    HandType = 1
+	HandStraightFlush HandType = 2
+	HandFlushFive     HandType = 3
+	HandFlushHouse    HandType = 4
+	HandFiveOfAKind   HandType = 5
+	HandFourOfAKind   HandType = 6
+	HandFullHouse     HandType = 7
+	HandFlush         HandType = 8
+	HandStraight      HandType = 9
+	HandThreeOfAKind  HandType = 10
+	HandTwoPair       HandType = 11
+	HandPair          HandType = 12
+	HandHighCard      HandType = 13
+)
+
+// Every hand type, from the best to the worst
+var HandTypes = []HandType{
+	HandRoyalFlush, HandStraightFlush, HandFlushFive, HandFlushHouse, HandFiveOfAKind,
+	HandFourOfAKind, HandFullHouse, HandFlush, HandStraight, HandThreeOfAKind,
+	HandTwoPair, HandPair, HandHighCard,
+}
+
+var handTypeNames = map[HandType]string{
+	HandRoyalFlush:    "RoyalFlush",
+	HandStraightFlush: "StraightFlush",
+	HandFlushFive:     "FlushFive",
+	HandFlushHouse:    "FlushHouse",
+	HandFiveOfAKind:   "FiveOfAKind",
+	HandFourOfAKind:   "FourOfAKind",
+	HandFullHouse:     "FullHouse",
+	HandFlush:         "Flush",
+	HandStraight:      "Straight",
+	HandThreeOfAKind:  "ThreeOfAKind",
+	HandTwoPair:       "TwoPair",
+	HandPair:          "Pair",
+	HandHighCard:      "HighCard",
+}
+
+// Name of the hand type, e.g. "FullHouse". Empty for HandNone and unknown values
+func (t HandType) String() string {
+	return handTypeNames[t]
+}
+
+// Whether the hand type is one of the ones returned by BestHand for some cards
+func (t HandType) Valid() bool {
+	_, ok := handTypeNames[t]
+	return ok
+}
+
+// Returns the hand type with the given name (see String)
+func ParseHandType(name string) (HandType, bool) {
+	for handType, handName := range handTypeNames {
+		if handName == name {
+			return handType, true
+		}
+	}
+	return HandNone, false
+}
@@ -0,0 +1,191 @@
+package poker
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/rand"
+)
+
+// When the stacks of a joker grow
+const (
+	StackOnHandPlayed = "hand_played" // Every hand played while holding the joker
+	StackOnRound      = "round"       // Every round started while holding the joker
+)
+
+// Lifecycle of a joker, as set in its definition. Every field is optional
+type JokerLifecycle struct {
+	DestroyChance int    `json:"destroy_chance,omitempty"` // 1 in N chance of being destroyed after every hand it triggers in
+	Rounds        int    `json:"rounds,omitempty"`         // Expires after being held this many rounds
+	StackOn       string `json:"stack_on,omitempty"`
+	StackFichas   int    `json:"stack_fichas,omitempty"` // Fichas added when scoring, per stack
+	StackMult     int    `json:"stack_mult,omitempty"`   // Mult added when scoring, per stack
+}
+
+// State of an owned joker, kept between hands and rounds in InGamePlayer.CurrentJokers
+type JokerState struct {
+	Stacks int `json:"stacks,omitempty"`
+	Rounds int `json:"rounds,omitempty"` // Rounds held
+}
+
+func (l *JokerLifecycle) validate() error {
+	if l.DestroyChance < 0 {
+		return fmt.Errorf("destroy_chance can't be negative, got %d", l.DestroyChance)
+	}
+	if l.Rounds < 0 {
+		return fmt.Errorf("rounds can't be negative, got %d", l.Rounds)
+	}
+
+	stacks := l.StackFichas != 0 || l.StackMult != 0
+	switch l.StackOn {
+	case "":
+		if stacks {
+			return fmt.Errorf("stack_fichas and stack_mult need stack_on")
+		}
+	case StackOnHandPlayed, StackOnRound:
+		if !stacks {
+			return fmt.Errorf("stack_on %q needs stack_fichas and/or stack_mult", l.StackOn)
+		}
+	default:
+		return fmt.Errorf("unknown stack_on %q", l.StackOn)
+	}
+	return nil
+}
+
+// Lifecycle of the given joker. Jokers without one never change nor expire
+func jokerLifecycle(jokerID int) JokerLifecycle {
+	if def, ok := jokerRegistry.definitions[jokerID]; ok && def.Lifecycle != nil {
+		return *def.Lifecycle
+	}
+	return JokerLifecycle{}
+}
+
+// State of the joker at position i of Juglares
+func (js Jokers) StateAt(i int) JokerState {
+	if i < len(js.States) {
+		return js.States[i]
+	}
+	return JokerState{}
+}
+
+// Keeps one state per joker, as jokers stored before they had state have none
+func (js *Jokers) normalize() {
+	for len(js.States) < len(js.Juglares) {
+		js.States = append(js.States, JokerState{})
+	}
+	js.States = js.States[:len(js.Juglares)]
+}
+
+// Adds new jokers, with no stacks, after the owned ones
+func (js *Jokers) Add(jokerIDs ...int) {
+	js.normalize()
+	for _, jokerID := range jokerIDs {
+		js.Juglares = append(js.Juglares, jokerID)
+		js.States = append(js.States, JokerState{})
+	}
+}
+
+// Position of the first joker with the given ID, -1 if there is none
+func (js Jokers) Index(jokerID int) int {
+	for i, id := range js.Juglares {
+		if id == jokerID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Removes the joker at position i, keeping the order of the rest (it's the order they are applied in)
+func (js *Jokers) RemoveAt(i int) {
+	js.normalize()
+	js.Juglares = append(js.Juglares[:i], js.Juglares[i+1:]...)
+	js.States = append(js.States[:i], js.States[i+1:]...)
+}
+
+// Sets the order in which the jokers are applied, moving their state with them. The new
+// order must contain exactly the owned jokers. A joker owned more than once keeps the
+// relative order of its copies
+func (js *Jokers) Reorder(order []int) error {
+	if len(order) != len(js.Juglares) {
+		return fmt.Errorf("expected %d jokers, got %d", len(js.Juglares), len(order))
+	}
+	js.normalize()
+
+	// Positions of the copies of every joker, in order
+	positions := make(map[int][]int)
+	for i, jokerID := range js.Juglares {
+		positions[jokerID] = append(positions[jokerID], i)
+	}
+
+	states := make([]JokerState, len(order))
+	for i, jokerID := range order {
+		if len(positions[jokerID]) == 0 {
+			return fmt.Errorf("joker %d not available to player", jokerID)
+		}
+		states[i] = js.States[positions[jokerID][0]]
+		positions[jokerID] = positions[jokerID][1:]
+	}
+
+	js.Juglares = append([]int{}, order...)
+	js.States = states
+	return nil
+}
+
+// Updates the jokers after a hand is played, with the jokers triggered in it as returned by
+// ApplyJokers: adds the hand stacks and destroys the triggered jokers that roll their destroy
+// chance. Returns the IDs of the destroyed jokers
+func (js *Jokers) HandPlayed(rng *rand.Rand, triggered []bool) []int {
+	js.normalize()
+	var destroyed []int
+	kept := Jokers{Juglares: []int{}, States: []JokerState{}}
+
+	for i, jokerID := range js.Juglares {
+		lifecycle, state := jokerLifecycle(jokerID), js.States[i]
+		if lifecycle.DestroyChance > 0 && i < len(triggered) && triggered[i] && rng.Intn(lifecycle.DestroyChance) == 0 {
+			destroyed = append(destroyed, jokerID)
+			continue
+		}
+		if lifecycle.StackOn == StackOnHandPlayed {
+			state.Stacks++
+		}
+		kept.Juglares = append(kept.Juglares, jokerID)
+		kept.States = append(kept.States, state)
+	}
+
+	*js = kept
+	return destroyed
+}
+
+// Updates the jokers when a new round starts: the jokers held for all of their rounds
+// expire, the rest count the round and add the round stacks. Returns the IDs of the expired jokers
+func (js *Jokers) RoundStarted() []int {
+	js.normalize()
+	var expired []int
+	kept := Jokers{Juglares: []int{}, States: []JokerState{}}
+
+	for i, jokerID := range js.Juglares {
+		lifecycle, state := jokerLifecycle(jokerID), js.States[i]
+		if lifecycle.Rounds > 0 && state.Rounds >= lifecycle.Rounds {
+			expired = append(expired, jokerID)
+			continue
+		}
+		state.Rounds++
+		if lifecycle.StackOn == StackOnRound {
+			state.Stacks++
+		}
+		kept.Juglares = append(kept.Juglares, jokerID)
+		kept.States = append(kept.States, state)
+	}
+
+	*js = kept
+	return expired
+}
+
+// Adds the fichas and mult stacked by the joker at position i
+func (js Jokers) applyStacks(i int, fichas int, mult int, used []bool) (int, int, []bool) {
+	lifecycle, state := jokerLifecycle(js.Juglares[i]), js.StateAt(i)
+	if state.Stacks == 0 || (lifecycle.StackFichas == 0 && lifecycle.StackMult == 0) {
+		return fichas, mult, used
+	}
+	used[i] = true
+	return fichas + state.Stacks*lifecycle.StackFichas, mult + state.Stacks*lifecycle.StackMult, used
+}
@@ -0,0 +1,304 @@
+package poker
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"golang.org/x/exp/rand"
+)
+
+// Default joker definitions, shipped with the binary. They can be overridden
+// at startup with LoadJokerRegistry (see JOKERS_FILE in main.go)
+//
+//go:embed jokers.json
+var defaultJokerDefinitions []byte
+
+// Supported trigger conditions for declarative jokers
+const (
+	ConditionAlways        = "always"          // Always triggers
+	ConditionHandSize      = "hand_size"       // Exactly `value` cards played
+	ConditionNOfAKind      = "n_of_a_kind"     // At least `value` cards share the same rank
+	ConditionCardMatches   = "card_matches"    // At least one card matches `ranks`/`suits`
+	ConditionNoCardMatches = "no_card_matches" // No card matches `ranks`/`suits`
+	ConditionPerCard       = "per_card"        // Effect applied once per card matching `ranks`/`suits`
+)
+
+// A joker as described in the definitions file. Either `builtin` (the name of
+// a hand-written JokerFunc) or `trigger` + `effect` must be provided, unless the
+// joker only scores the stacks of its `lifecycle`
+type JokerDefinition struct {
+	ID        int             `json:"id"`
+	Name      string          `json:"name"`
+	Rarity    string          `json:"rarity"`
+	Price     int             `json:"price"`
+	SellPrice int             `json:"sell_price"`
+	Builtin   string          `json:"builtin,omitempty"`
+	Trigger   *JokerTrigger   `json:"trigger,omitempty"`
+	Effect    *JokerEffect    `json:"effect,omitempty"`
+	Lifecycle *JokerLifecycle `json:"lifecycle,omitempty"`
+}
+
+type JokerTrigger struct {
+	Condition string   `json:"condition"`
+	Value     int      `json:"value,omitempty"`
+	Ranks     []string `json:"ranks,omitempty"`
+	Suits     []string `json:"suits,omitempty"`
+}
+
+// Additions are applied first, then the mult is multiplied by MultTimes (if set)
+type JokerEffect struct {
+	Fichas    int `json:"fichas,omitempty"`
+	Mult      int `json:"mult,omitempty"`
+	Gold      int `json:"gold,omitempty"`
+	MultTimes int `json:"mult_times,omitempty"`
+}
+
+type JokerRegistry struct {
+	definitions map[int]JokerDefinition
+	effects     map[int]JokerFunc
+	byRarity    map[string][]int // Sorted IDs, so seeded generation is deterministic
+}
+
+// Registry used by ApplyJokers, GenerateJokers and the shop prices
+var jokerRegistry *JokerRegistry
+
+func init() {
+	registry, err := ParseJokerDefinitions(defaultJokerDefinitions)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded joker definitions: %v", err))
+	}
+	jokerRegistry = registry
+}
+
+// Replaces the current joker registry with the definitions found in path.
+// The current registry is kept if the file is malformed
+func LoadJokerRegistry(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading joker definitions %s: %v", path, err)
+	}
+	registry, err := ParseJokerDefinitions(data)
+	if err != nil {
+		return fmt.Errorf("invalid joker definitions %s: %v", path, err)
+	}
+	jokerRegistry = registry
+	return nil
+}
+
+// Parses and validates a JSON array of joker definitions
+func ParseJokerDefinitions(data []byte) (*JokerRegistry, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var defs []JokerDefinition
+	if err := decoder.Decode(&defs); err != nil {
+		return nil, fmt.Errorf("error decoding joker definitions: %v", err)
+	}
+	if len(defs) == 0 {
+		return nil, errors.New("no joker definitions found")
+	}
+
+	registry := &JokerRegistry{
+		definitions: make(map[int]JokerDefinition, len(defs)),
+		effects:     make(map[int]JokerFunc, len(defs)),
+		byRarity:    make(map[string][]int),
+	}
+
+	for i, def := range defs {
+		if err := def.validate(); err != nil {
+			return nil, fmt.Errorf("joker definition #%d (id %d): %v", i, def.ID, err)
+		}
+		if _, exists := registry.definitions[def.ID]; exists {
+			return nil, fmt.Errorf("joker definition #%d: duplicated id %d", i, def.ID)
+		}
+
+		registry.definitions[def.ID] = def
+		if def.Builtin != "" {
+			registry.effects[def.ID] = builtinJokers[def.Builtin]
+		} else {
+			registry.effects[def.ID] = def.compile()
+		}
+		registry.byRarity[def.Rarity] = append(registry.byRarity[def.Rarity], def.ID)
+	}
+
+	for rarity := range registry.byRarity {
+		sort.Ints(registry.byRarity[rarity])
+	}
+
+	return registry, nil
+}
+
+func (def JokerDefinition) validate() error {
+	if def.ID <= 0 {
+		return errors.New("id must be positive")
+	}
+	if def.Name == "" {
+		return errors.New("name is required")
+	}
+	if _, ok := RarityProbabilities[def.Rarity]; !ok {
+		return fmt.Errorf("unknown rarity %q", def.Rarity)
+	}
+	if def.Price <= 0 {
+		return fmt.Errorf("price must be positive, got %d", def.Price)
+	}
+	if def.SellPrice < 0 {
+		return fmt.Errorf("sell_price can't be negative, got %d", def.SellPrice)
+	}
+	if def.Lifecycle != nil {
+		if err := def.Lifecycle.validate(); err != nil {
+			return fmt.Errorf("invalid lifecycle: %v", err)
+		}
+	}
+
+	if def.Builtin != "" {
+		if def.Trigger != nil || def.Effect != nil {
+			return errors.New("builtin jokers can't also define trigger/effect")
+		}
+		if _, ok := builtinJokers[def.Builtin]; !ok {
+			return fmt.Errorf("unknown builtin %q", def.Builtin)
+		}
+		return nil
+	}
+
+	if def.Trigger == nil && def.Effect == nil && def.Lifecycle != nil && def.Lifecycle.StackOn != "" {
+		return nil
+	}
+	if def.Trigger == nil || def.Effect == nil {
+		return errors.New("either builtin, both trigger and effect or a stacking lifecycle are required")
+	}
+	if *def.Effect == (JokerEffect{}) {
+		return errors.New("effect has no changes on fichas, mult or gold")
+	}
+	if def.Effect.MultTimes < 0 {
+		return fmt.Errorf("mult_times can't be negative, got %d", def.Effect.MultTimes)
+	}
+	return def.Trigger.validate()
+}
+
+func (t *JokerTrigger) validate() error {
+	for _, rank := range t.Ranks {
+		if !RankMap[rank] {
+			return fmt.Errorf("unknown rank %q", rank)
+		}
+	}
+	for _, suit := range t.Suits {
+		if !SuitMap[suit] {
+			return fmt.Errorf("unknown suit %q", suit)
+		}
+	}
+
+	switch t.Condition {
+	case ConditionAlways:
+	case ConditionHandSize:
+		if t.Value <= 0 {
+			return fmt.Errorf("%s needs a positive value", t.Condition)
+		}
+	case ConditionNOfAKind:
+		if t.Value < 2 {
+			return fmt.Errorf("%s needs a value of at least 2", t.Condition)
+		}
+	case ConditionCardMatches, ConditionNoCardMatches, ConditionPerCard:
+		if len(t.Ranks) == 0 && len(t.Suits) == 0 {
+			return fmt.Errorf("%s needs ranks and/or suits", t.Condition)
+		}
+	default:
+		return fmt.Errorf("unknown trigger condition %q", t.Condition)
+	}
+	return nil
+}
+
+// A card matches if its rank is in Ranks (when given) and it has one of the Suits (when given).
+// Wild cards have every suit, stone cards have no rank nor suit
+func (t *JokerTrigger) matches(card Card) bool {
+	if len(t.Ranks) > 0 && !containsString(t.Ranks, card.ScoringRank()) {
+		return false
+	}
+	if len(t.Suits) == 0 {
+		return true
+	}
+	for _, suit := range t.Suits {
+		if card.HasSuit(suit) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns how many times the effect has to be applied for the given hand
+func (t *JokerTrigger) timesTriggered(hand Hand) int {
+	switch t.Condition {
+	case ConditionAlways:
+		return 1
+	case ConditionHandSize:
+		if len(hand.Cards) == t.Value {
+			return 1
+		}
+	case ConditionNOfAKind:
+		rankCount := make(map[string]int)
+		for _, card := range hand.withoutStones().Cards {
+			rankCount[card.Rank]++
+			if rankCount[card.Rank] >= t.Value {
+				return 1
+			}
+		}
+	case ConditionCardMatches, ConditionNoCardMatches, ConditionPerCard:
+		matching := 0
+		for _, card := range hand.Cards {
+			if t.matches(card) {
+				matching++
+			}
+		}
+		switch {
+		case t.Condition == ConditionPerCard:
+			return matching
+		case t.Condition == ConditionCardMatches && matching > 0:
+			return 1
+		case t.Condition == ConditionNoCardMatches && matching == 0:
+			return 1
+		}
+	}
+	return 0
+}
+
+// Builds the JokerFunc of a declarative joker
+func (def JokerDefinition) compile() JokerFunc {
+	if def.Trigger == nil {
+		// Only its stacks are scored, see ApplyJokers
+		return func(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+			return fichas, mult, gold, used
+		}
+	}
+	trigger, effect := *def.Trigger, *def.Effect
+	return func(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+		for n := trigger.timesTriggered(hand); n > 0; n-- {
+			used[index] = true
+			fichas += effect.Fichas
+			mult += effect.Mult
+			gold += effect.Gold
+			if effect.MultTimes > 0 {
+				mult *= effect.MultTimes
+			}
+		}
+		return fichas, mult, gold, used
+	}
+}
+
+// Returns the definition of the given joker, if it exists
+func GetJokerDefinition(jokerID int) (JokerDefinition, bool) {
+	def, ok := jokerRegistry.definitions[jokerID]
+	return def, ok
+}
+
+func containsString(slice []string, value string) bool {
+	for _, v := range slice {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -3,7 +3,6 @@ package poker
 import (
 	"fmt"
 	"log"
-	"math"
 	"sort"
 
 	"golang.org/x/exp/rand"
@@ -11,64 +10,57 @@ import (
 
 type Jokers struct {
 	Juglares []int
+	States   []JokerState `json:"states,omitempty"` // Lifecycle state of every joker, by position in Juglares
 }
 
-type JokerFunc func(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool)
-
-const ExpSellPriceDenominator = 9
-
-var jokerTable = map[int]JokerFunc{
-	// Common
-	1: SolidSevenJoker,
-	2: PoorJoker,
-	3: Petpet,
-	4: AverageSizeMichel,
-	5: HellCowboy,
-	6: CarbSponge,
-	7: TwoFriendsJoker,
-	8: BIRDIFICATION,
-
-	// Uncommon
-	9:  Photograph,
-	10: EmptyJoker,
-	11: LiriliLarila,
-	12: Rustyahh,
-	13: damnapril,
-	14: crowave,
-	15: bicicleta,
-	16: salebalatrito,
-	17: diego_joker,
-	18: itssoover,
-
-	// Rare
-	19: paris,
-	20: nasus,
-	21: sombrilla,
+type JokerFunc func(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool)
+
+// Hand-written joker effects, referenced by name ("builtin") from the joker definitions file
+var builtinJokers = map[string]JokerFunc{
+	"SolidSevenJoker":   SolidSevenJoker,
+	"PoorJoker":         PoorJoker,
+	"Petpet":            Petpet,
+	"AverageSizeMichel": AverageSizeMichel,
+	"HellCowboy":        HellCowboy,
+	"CarbSponge":        CarbSponge,
+	"TwoFriendsJoker":   TwoFriendsJoker,
+	"BIRDIFICATION":     BIRDIFICATION,
+	"Photograph":        Photograph,
+	"EmptyJoker":        EmptyJoker,
+	"LiriliLarila":      LiriliLarila,
+	"Rustyahh":          Rustyahh,
+	"damnapril":         damnapril,
+	"crowave":           crowave,
+	"bicicleta":         bicicleta,
+	"salebalatrito":     salebalatrito,
+	"diego_joker":       diego_joker,
+	"itssoover":         itssoover,
+	"paris":             paris,
+	"nasus":             nasus,
+	"sombrilla":         sombrilla,
+	"kaefece":           kaefece,
 }
 
 // 5
 
-func SolidSevenJoker(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+func SolidSevenJoker(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 	used[index] = true
 	return fichas + 7, mult + 7, gold, used
 }
 
-func AverageSizeMichel(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+// Destroyed with a 1 in 15 chance after every hand, see its lifecycle in the definitions file
+func AverageSizeMichel(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 	used[index] = true
-	randomNumber := rand.Intn(15) + 1
-	if randomNumber == 1 {
-		// Manage destroy the joker
-	}
 	return fichas, mult + 15, gold, used
 }
 
-func PoorJoker(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+func PoorJoker(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 	used[index] = true
 	return fichas, mult, gold + 4, used
 }
 
-func CarbSponge(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
-	_, isThreeOfAKing := ThreeOfAKind(hand)
+func CarbSponge(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+	_, isThreeOfAKing := ThreeOfAKind(hand.withoutStones())
 	if isThreeOfAKing {
 		used[index] = true
 		return fichas, mult * 3, gold, used
@@ -76,7 +68,7 @@ func CarbSponge(hand Hand, fichas int, mult int, gold int, used []bool, index in
 	return fichas, mult, gold, used
 }
 
-func Photograph(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+func Photograph(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 	for _, card := range hand.Cards {
 		if grade(card) >= 11 { // J=11, Q=12, K=13
 			used[index] = true
@@ -86,13 +78,13 @@ func Photograph(hand Hand, fichas int, mult int, gold int, used []bool, index in
 	return fichas, mult, gold, used
 }
 
-func Petpet(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+func Petpet(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 	used[index] = true
 	return fichas, mult + gold, gold, used
 }
 
-func EmptyJoker(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
-	randomNumber := rand.Intn(50) + 1
+func EmptyJoker(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+	randomNumber := rng.Intn(50) + 1
 	if randomNumber == 1 {
 		used[index] = true
 		return fichas + 25, mult + 200, gold, used
@@ -100,7 +92,7 @@ func EmptyJoker(hand Hand, fichas int, mult int, gold int, used []bool, index in
 	return fichas, mult, gold, used
 }
 
-func TwoFriendsJoker(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+func TwoFriendsJoker(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 	used[index] = true
 	if fichas < 10 {
 		diff := 10 - fichas
@@ -110,7 +102,7 @@ func TwoFriendsJoker(hand Hand, fichas int, mult int, gold int, used []bool, ind
 	return fichas - 10, mult + 10, gold, used
 }
 
-func HellCowboy(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+func HellCowboy(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 	used[index] = true
 	max := 0
 	for _, card := range hand.Cards {
@@ -121,7 +113,7 @@ func HellCowboy(hand Hand, fichas int, mult int, gold int, used []bool, index in
 	return fichas, mult + max, gold, used
 }
 
-func LiriliLarila(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+func LiriliLarila(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 	used[index] = true
 	for _, card := range hand.Cards {
 		if grade(card) == 2 {
@@ -131,7 +123,7 @@ func LiriliLarila(hand Hand, fichas int, mult int, gold int, used []bool, index
 	return fichas, mult * 2, gold, used
 }
 
-func BIRDIFICATION(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+func BIRDIFICATION(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 	var cardGrade int
 	for _, card := range hand.Cards {
 		cardGrade = grade(card)
@@ -145,22 +137,22 @@ func BIRDIFICATION(hand Hand, fichas int, mult int, gold int, used []bool, index
 	return fichas, mult, gold, used
 }
 
-func Rustyahh(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+func Rustyahh(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 	used[index] = true
 	return fichas, mult * 2, 0, used
 }
 
-func damnapril(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+func damnapril(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 	used[index] = true
 	// None should be negative
-	total := 14 + rand.Intn(6)     // 14-19
+	total := 14 + rng.Intn(6)      // 14-19
 	maxDelta := min(total, fichas) // Previene fichas negativas
-	delta := rand.Intn(maxDelta + 1)
+	delta := rng.Intn(maxDelta + 1)
 
 	return fichas + delta, mult + (total - delta), gold, used
 }
 
-func itssoover(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+func itssoover(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 
 	// +10 de oro si solo se juega 1 carta (mano de tamaño 1)
 	if len(hand.Cards) == 1 {
@@ -171,22 +163,35 @@ func itssoover(hand Hand, fichas int, mult int, gold int, used []bool, index int
 	return fichas, mult, gold, used
 }
 
-func paris(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+func paris(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 	// +3 mult por cada pareja de cartas del mismo palo
 	suitCount := make(map[string]int)
+	wilds := 0
 	for _, card := range hand.Cards {
-		suitCount[card.Suit]++
+		switch {
+		case card.isWild():
+			wilds++
+		case !card.isStone():
+			suitCount[card.Suit]++
+		}
 	}
-	pairs := 0
+	pairs, unpaired := 0, 0
 	for _, count := range suitCount {
 		pairs += count / 2
+		unpaired += count % 2
 		used[index] = true
 
 	}
+	// Wild cards pair with the unpaired cards first, then with each other
+	matched := min(wilds, unpaired)
+	pairs += matched + (wilds-matched)/2
+	if wilds > 0 {
+		used[index] = true
+	}
 	return fichas, mult + (3 * pairs), gold, used
 }
 
-func diego_joker(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+func diego_joker(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 
 	// Solo activa si se juegan EXACTAMENTE 3 cartas
 	if len(hand.Cards) == 3 {
@@ -198,7 +203,7 @@ func diego_joker(hand Hand, fichas int, mult int, gold int, used []bool, index i
 	return fichas, mult, gold, used
 }
 
-func bicicleta(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+func bicicleta(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 
 	// Contar cuántos 2 hay en la mano
 	countTwos := 0
@@ -217,13 +222,13 @@ func bicicleta(hand Hand, fichas int, mult int, gold int, used []bool, index int
 	return fichas, mult, gold, used
 }
 
-func nasus(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+func nasus(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 	used[index] = true
 
 	return fichas, max(mult*gold, 1), gold, used
 }
 
-func sombrilla(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+func sombrilla(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 
 	// Check for face cards (J=11, Q=12, K=13, A=1/14)
 	hasFaceCard := false
@@ -246,11 +251,11 @@ func sombrilla(hand Hand, fichas int, mult int, gold int, used []bool, index int
 	return fichas, mult, gold, used
 }
 
-func salebalatrito(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+func salebalatrito(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 
 	// Contar cuántas veces aparece cada valor de carta
 	valueCounts := make(map[int]int)
-	for _, card := range hand.Cards {
+	for _, card := range hand.withoutStones().Cards {
 		valueCounts[grade(card)]++
 	}
 
@@ -273,11 +278,11 @@ func salebalatrito(hand Hand, fichas int, mult int, gold int, used []bool, index
 	return fichas, mult, gold, used
 }
 
-func kaefece(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+func kaefece(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 	// Contar cartas negras
 	darkCards := 0
 	for _, card := range hand.Cards {
-		if card.Suit == "Spades" || card.Suit == "Clubs" {
+		if card.HasSuit("s") || card.HasSuit("c") {
 			used[index] = true
 
 			darkCards++
@@ -288,20 +293,21 @@ func kaefece(hand Hand, fichas int, mult int, gold int, used []bool, index int)
 	bonus := 5
 	mult += darkCards * bonus
 
+	// Efecto 2 (+50 fichas y x2 Mult con 4 o más cartas negras)
 	if darkCards >= 4 {
 		fichas += 50
 		mult *= 2
 	}
 
-	return fichas, mult * 2, gold, used
+	return fichas, mult, gold, used
 }
 
-func crowave(hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
+func crowave(rng *rand.Rand, hand Hand, fichas int, mult int, gold int, used []bool, index int) (int, int, int, []bool) {
 
 	// Count red cards (hearts/diamonds)
 	redCards := 0
 	for _, card := range hand.Cards {
-		if card.Suit == "Hearts" || card.Suit == "Diamonds" {
+		if card.HasSuit("h") || card.HasSuit("d") {
 
 			used[index] = true
 			redCards++
@@ -310,7 +316,7 @@ func crowave(hand Hand, fichas int, mult int, gold int, used []bool, index int)
 
 	// 90%: Add to mult (original effect)
 	// 10%: Add to fichas instead
-	if rand.Intn(100) < 90 {
+	if rng.Intn(100) < 90 {
 		mult += redCards * 3 // +3 mult per red card
 	} else {
 		fichas += redCards * 5 // Alternative: +5 fichas per red card
@@ -319,7 +325,7 @@ func crowave(hand Hand, fichas int, mult int, gold int, used []bool, index int)
 	return fichas, mult, gold, used
 }
 
-func ApplyJokers(hand Hand, js Jokers, initialFichas int, initialMult int, currentGold int, username string) (int, int, int, []bool) {
+func ApplyJokers(rng *rand.Rand, hand Hand, js Jokers, initialFichas int, initialMult int, currentGold int, username string, trace *ScoringTrace) (int, int, int, []bool) {
 	currentFichas, currentMult, currentGold := initialFichas, initialMult, currentGold
 	used := make([]bool, len(js.Juglares)) // Jokers triggereados
 
@@ -328,9 +334,21 @@ func ApplyJokers(hand Hand, js Jokers, initialFichas int, initialMult int, curre
 			continue
 		}
 
-		if jokerFunc, exists := jokerTable[jokerID]; exists {
+		if jokerFunc, exists := jokerRegistry.effects[jokerID]; exists {
 			// Apply joker and update state
-			currentFichas, currentMult, currentGold, used = jokerFunc(hand, currentFichas, currentMult, currentGold, used, i)
+			fichasBefore, multBefore, goldBefore := currentFichas, currentMult, currentGold
+			currentFichas, currentMult, currentGold, used = jokerFunc(rng, hand, currentFichas, currentMult, currentGold, used, i)
+			currentFichas, currentMult, used = js.applyStacks(i, currentFichas, currentMult, used)
+			trace.record(ScoringStep{
+				Source:      StepJoker,
+				ID:          jokerID,
+				Name:        jokerRegistry.definitions[jokerID].Name,
+				ChipsBefore: fichasBefore,
+				ChipsAfter:  currentFichas,
+				MultBefore:  multBefore,
+				MultAfter:   currentMult,
+				GoldDelta:   currentGold - goldBefore,
+			})
 			log.Println("[JOKER-APPLIED] User: ", username, "Joker", jokerID, "Fichas:", currentFichas, "Mult:", currentMult, "Gold:", currentGold)
 		} else {
 			fmt.Printf("Warning: Unknown joker ID — what is %d?\n", jokerID)
@@ -340,10 +358,13 @@ func ApplyJokers(hand Hand, js Jokers, initialFichas int, initialMult int, curre
 	return currentFichas, currentMult, currentGold, used
 }
 
-// Returns a joker's sell price based on its ID.
-// The max price for a joker with ID 23 will be e^(23/9) = e^2.55 ~= 12
+// Returns a joker's sell price, as set in its definition
 func CalculateJokerSellPrice(jokerID int) int {
-	return int(math.Exp(float64(jokerID / ExpSellPriceDenominator)))
+	def, ok := jokerRegistry.definitions[jokerID]
+	if !ok {
+		return 0
+	}
+	return def.SellPrice
 }
 
 var (
@@ -353,35 +374,11 @@ var (
 		"Uncommon": 25, // 25% total chance
 		"Rare":     5,  // 5% total chance
 	}
-
-	// ID ranges for each rarity tier
-	RarityRanges = map[string][]int{
-		"Common":   {1, 8},
-		"Uncommon": {9, 18},
-		"Rare":     {19, 21},
-	}
 )
 
 func GenerateJokers(rng *rand.Rand, numJokers int) []Jokers {
-	// Group jokers by their rarity based on ID ranges
-	rarityGroups := make(map[string][]int)
-	for id := range jokerTable {
-		var found bool
-		for rarity, bounds := range RarityRanges {
-			if len(bounds) != 2 {
-				continue
-			}
-			if id >= bounds[0] && id <= bounds[1] {
-				rarityGroups[rarity] = append(rarityGroups[rarity], id)
-				found = true
-				break
-			}
-		}
-		if !found {
-			// Skip IDs outside defined ranges
-			continue
-		}
-	}
+	// Jokers grouped by their rarity, as set in the registry
+	rarityGroups := jokerRegistry.byRarity
 
 	// Filter out rarities with no available jokers
 	var availableRarities []string
@@ -427,17 +424,9 @@ func GenerateJokers(rng *rand.Rand, numJokers int) []Jokers {
 }
 
 func GetJokerPrice(jokerID int) int {
-	// Common jokers (IDs 1-8)
-	if jokerID >= 1 && jokerID <= 8 {
-		return 2
-	}
-	// Uncommon jokers (IDs 9-18)
-	if jokerID >= 9 && jokerID <= 18 {
-		return 4
-	}
-	// Rare jokers (IDs 19-21)
-	if jokerID >= 19 && jokerID <= 21 {
-		return 6
+	def, ok := jokerRegistry.definitions[jokerID]
+	if !ok {
+		return -104 // IDK I LIKE THE NUMBER, SHOULD NOT HAPPEN
 	}
-	return -104 // IDK I LIKE THE NUMBER, SHOULD NOT HAPPEN
+	return def.Price
 }
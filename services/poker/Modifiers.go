@@ -2,8 +2,8 @@ package poker
 
 import (
 	"fmt"
+	"golang.org/x/exp/rand"
 	"log" // DELETE
-	"math/rand"
 )
 
 type Modifier struct {
@@ -24,7 +24,7 @@ type ReceivedModifiers struct {
 	Received []ReceivedModifier `json:"modifiers"`
 }
 
-type ModifierFunc func(hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int)
+type ModifierFunc func(rng *rand.Rand, hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int)
 
 var modifierTable = map[int]ModifierFunc{
 	1: Damn,
@@ -49,14 +49,14 @@ var ModifierWeights = []struct {
 }
 
 // Divide starting chips and mult by 2. 1 round duration
-func Damn(hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int) {
+func Damn(rng *rand.Rand, hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int) {
 	fichas = fichas / 2
 	mult = mult / 2
 	return fichas, mult, gold, leftUses
 }
 
 // Eern 1 dollar for each card played. 1 round duration
-func PabloHoney(hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int) {
+func PabloHoney(rng *rand.Rand, hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int) {
 	log.Println("[PABLO-HONEY] gold before:", gold, "cards:", len(hand.Cards))
 	gold += len(hand.Cards)
 	log.Println("[PABLO-HONEY] gold after:", gold)
@@ -64,14 +64,14 @@ func PabloHoney(hand Hand, leftUses int, fichas int, mult int, gold int) (int, i
 }
 
 // multiply the chips by random number between 1 and 3
-func RAM(hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int) {
-	return fichas*rand.Intn(3) + 1, mult, gold, leftUses
+func RAM(rng *rand.Rand, hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int) {
+	return fichas*rng.Intn(3) + 1, mult, gold, leftUses
 }
 
 // Bans up to 4 players to play four of a kind for 1 round
-func Weezer(hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int) {
-	_, _, mano, _ := BestHand(hand)
-	if mano == 6 {
+func Weezer(rng *rand.Rand, hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int) {
+	_, _, mano, _ := BestHand(hand, nil)
+	if mano == HandFourOfAKind {
 		mult = 0
 		fichas = 0
 	}
@@ -79,9 +79,9 @@ func Weezer(hand Hand, leftUses int, fichas int, mult int, gold int) (int, int,
 }
 
 // Bans up to 2 players from playing straight for 1 round
-func Blonde(hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int) {
-	_, _, mano, _ := BestHand(hand)
-	if mano == 9 {
+func Blonde(rng *rand.Rand, hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int) {
+	_, _, mano, _ := BestHand(hand, nil)
+	if mano == HandStraight {
 		mult = 0
 		fichas = 0
 	}
@@ -89,7 +89,7 @@ func Blonde(hand Hand, leftUses int, fichas int, mult int, gold int) (int, int,
 }
 
 // Every King or Queen played scores negatie points. Choose 4 players for 1 round
-func AbbeyRoad(hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int) {
+func AbbeyRoad(rng *rand.Rand, hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int) {
 	if leftUses > 0 {
 		for _, card := range hand.Cards {
 			rank := grade(card)
@@ -102,7 +102,7 @@ func AbbeyRoad(hand Hand, leftUses int, fichas int, mult int, gold int) (int, in
 }
 
 // Aces and K's score double
-func RockTransgresivo(hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int) {
+func RockTransgresivo(rng *rand.Rand, hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int) {
 	if leftUses > 0 {
 		for _, card := range hand.Cards {
 			rank := grade(card)
@@ -115,14 +115,14 @@ func RockTransgresivo(hand Hand, leftUses int, fichas int, mult int, gold int) (
 }
 
 // Applicable to up to 3 players. Substracts from their mult the money the have
-func DiamondEyes(hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int) {
+func DiamondEyes(rng *rand.Rand, hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int) {
 	return fichas, mult - gold, gold, leftUses
 }
 
 // Each black card played (spades and clubs) grants 1 dollar, +10 chips, +2 mult. 1 round duration
-func TheMoneyStore(hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int) {
+func TheMoneyStore(rng *rand.Rand, hand Hand, leftUses int, fichas int, mult int, gold int) (int, int, int, int) {
 	for _, card := range hand.Cards {
-		if card.Suit == "s" || card.Suit == "c" {
+		if card.HasSuit("s") || card.HasSuit("c") {
 			gold++
 			fichas += 10
 			mult += 2
@@ -131,16 +131,16 @@ func TheMoneyStore(hand Hand, leftUses int, fichas int, mult int, gold int) (int
 	return fichas, mult, gold, leftUses
 }
 
-func Apply(modifier Modifier, hand Hand, fichas int, mult int, gold int) (int, int, int, int) {
+func Apply(rng *rand.Rand, modifier Modifier, hand Hand, fichas int, mult int, gold int) (int, int, int, int) {
 	if modifierFunc, exists := modifierTable[modifier.Value]; exists {
-		return modifierFunc(hand, modifier.LeftUses, fichas, mult, gold)
+		return modifierFunc(rng, hand, modifier.LeftUses, fichas, mult, gold)
 	}
 	fmt.Printf("Warning: Unknown joker ID — what is %d?\n", modifier.Value)
 	return fichas, mult, gold, modifier.LeftUses
 }
 
 // Modifiers at each play
-func ApplyModifiers(hand Hand, ms *Modifiers, initialFichas int, initialMult int, currentGold int) (int, int, int) {
+func ApplyModifiers(rng *rand.Rand, hand Hand, ms *Modifiers, initialFichas int, initialMult int, currentGold int, trace *ScoringTrace) (int, int, int) {
 	currentFichas, currentMult, currentGold := initialFichas, initialMult, currentGold
 	finalFichas := initialFichas
 	finalMult := initialMult
@@ -153,12 +153,22 @@ func ApplyModifiers(hand Hand, ms *Modifiers, initialFichas int, initialMult int
 		}
 		if modifierID.Value == 2 || modifierID.Value == 4 || modifierID.Value == 3 || modifierID.Value == 5 || modifierID.Value == 6 ||
 			modifierID.Value == 7 || modifierID.Value == 8 || modifierID.Value == 9 {
-			currentFichas, currentMult, currentGold, modifierID.LeftUses = Apply(modifierID, hand, currentFichas, currentMult, currentGold)
+			currentFichas, currentMult, currentGold, modifierID.LeftUses = Apply(rng, modifierID, hand, currentFichas, currentMult, currentGold)
 		}
 		log.Println("[APPLY-MODIFIERS] Gold obtained from:", modifierID.Value, ":", currentGold)
+		fichasBefore, multBefore, goldBefore := finalFichas, finalMult, finalGold
 		finalFichas += currentFichas
 		finalMult += currentMult
 		finalGold += currentGold
+		trace.record(ScoringStep{
+			Source:      StepModifier,
+			ID:          modifierID.Value,
+			ChipsBefore: fichasBefore,
+			ChipsAfter:  finalFichas,
+			MultBefore:  multBefore,
+			MultAfter:   finalMult,
+			GoldDelta:   finalGold - goldBefore,
+		})
 		log.Println("[APPLY-MODIFIERS] Gold after:", modifierID.Value, ":", currentGold)
 	}
 
@@ -166,7 +176,7 @@ func ApplyModifiers(hand Hand, ms *Modifiers, initialFichas int, initialMult int
 }
 
 // Modifiers at the begining of the round
-func ApplyRoundModifiers(ms *Modifiers, currentGold int) int {
+func ApplyRoundModifiers(rng *rand.Rand, ms *Modifiers, currentGold int) int {
 	finalGold := currentGold
 	for _, modifierID := range ms.Modificadores {
 		if modifierID.Value == 0 {
@@ -174,7 +184,7 @@ func ApplyRoundModifiers(ms *Modifiers, currentGold int) int {
 		}
 		if modifierID.Value == 1 {
 			emptyHand := Hand{}
-			_, _, currentGold, modifierID.LeftUses = Apply(modifierID, emptyHand, 0, 0, currentGold)
+			_, _, currentGold, modifierID.LeftUses = Apply(rng, modifierID, emptyHand, 0, 0, currentGold)
 		}
 		finalGold += currentGold
 
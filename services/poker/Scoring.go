@@ -0,0 +1,68 @@
+package poker
+
+// Sources of the scoring steps
+const (
+	StepHand        = "hand"        // Base chips and mult of the hand type
+	StepCard        = "card"        // Chips of a scored card
+	StepEnhancement = "enhancement" // Enhancement of a scored card
+	StepEdition     = "edition"     // Edition of a scored card
+	StepSeal        = "seal"        // Seal of a scored card
+	StepJoker       = "joker"
+	StepModifier    = "modifier"
+)
+
+// One step of the scoring of a hand, with the chips and mult before and after it
+type ScoringStep struct {
+	Source      string `json:"source"`
+	ID          int    `json:"id,omitempty"`   // Hand type, enhancement, edition, seal, joker or modifier id
+	Name        string `json:"name,omitempty"` // Hand type, card or joker name
+	ChipsBefore int    `json:"chips_before"`
+	ChipsAfter  int    `json:"chips_after"`
+	MultBefore  int    `json:"mult_before"`
+	MultAfter   int    `json:"mult_after"`
+	GoldDelta   int    `json:"gold_delta"`
+}
+
+// Ordered list of the steps that led to the score of a hand. A nil trace records nothing,
+// so the scoring functions can be called without one (e.g. the AI evaluating hands)
+type ScoringTrace struct {
+	Steps []ScoringStep `json:"steps"`
+}
+
+func (t *ScoringTrace) record(step ScoringStep) {
+	if t == nil {
+		return
+	}
+	t.Steps = append(t.Steps, step)
+}
+
+// Chips and mult after the last recorded step
+func (t *ScoringTrace) current() (int, int) {
+	if t == nil || len(t.Steps) == 0 {
+		return 0, 0
+	}
+	last := t.Steps[len(t.Steps)-1]
+	return last.ChipsAfter, last.MultAfter
+}
+
+// Total gold won or lost along the trace
+func (t *ScoringTrace) GoldDelta() int {
+	if t == nil {
+		return 0
+	}
+	total := 0
+	for _, step := range t.Steps {
+		total += step.GoldDelta
+	}
+	return total
+}
+
+// Final score of the trace (chips * mult after the last step)
+func (t *ScoringTrace) Score() int {
+	chips, mult := t.current()
+	return chips * mult
+}
+
+func cardName(c Card) string {
+	return c.Rank + c.Suit
+}
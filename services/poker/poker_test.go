@@ -0,0 +1,351 @@
+package poker_test
+
+import (
+	"Nogler/services/poker"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Plays a whole hand (draw + BestHand + jokers + modifiers) with RNGs derived from the given seed
+func playSeededHand(seed uint64) (int, int, int, []poker.Card) {
+	deck := poker.NewStandardDeck()
+	deck.Shuffle(poker.NewGameRNG(seed, "round_deck", "player", 1))
+
+	rng := poker.NewGameRNG(seed, 1, "play_hand", "player", 3)
+	hand := poker.Hand{
+		Cards: deck.Draw(rng, 5),
+		// Random jokers (AverageSizeMichel, EmptyJoker, damnapril, crowave) and some fixed ones
+		Jokers: poker.Jokers{Juglares: []int{4, 10, 13, 14, 1}},
+		Gold:   5,
+	}
+
+	fichas, mult, _, scored := poker.BestHand(hand, nil)
+	fichas += poker.AddChipsPerCard(scored, nil)
+	fichas, mult, gold := poker.ApplyEnhancements(rng, fichas, mult, hand.Gold, scored, nil)
+	fichas, mult, gold, _ = poker.ApplyJokers(rng, hand, hand.Jokers, fichas, mult, gold, "player", nil)
+
+	// RAM modifier multiplies the chips by a random number
+	modifiers := poker.Modifiers{Modificadores: []poker.Modifier{{Value: 3, LeftUses: 1}}}
+	fichas, mult, gold = poker.ApplyModifiers(rng, hand, &modifiers, fichas, mult, gold, nil)
+
+	return fichas, mult, gold, hand.Cards
+}
+
+func TestSeededHandIsReproducible(t *testing.T) {
+	for seed := uint64(0); seed < 50; seed++ {
+		fichas1, mult1, gold1, cards1 := playSeededHand(seed)
+		fichas2, mult2, gold2, cards2 := playSeededHand(seed)
+
+		assert.Equal(t, cards1, cards2, "seed %d drew different cards", seed)
+		assert.Equal(t, fichas1, fichas2, "seed %d scored different fichas", seed)
+		assert.Equal(t, mult1, mult2, "seed %d scored different mult", seed)
+		assert.Equal(t, gold1, gold2, "seed %d ended with different gold", seed)
+	}
+}
+
+func TestSeededShuffleDependsOnSeed(t *testing.T) {
+	deck1 := poker.NewStandardDeck()
+	deck1.Shuffle(poker.NewGameRNG(1, "round_deck", "player", 1))
+	deck2 := poker.NewStandardDeck()
+	deck2.Shuffle(poker.NewGameRNG(2, "round_deck", "player", 1))
+
+	assert.ElementsMatch(t, deck1.TotalCards, deck2.TotalCards)
+	assert.NotEqual(t, deck1.TotalCards, deck2.TotalCards)
+}
+
+func TestScoringTraceMatchesScore(t *testing.T) {
+	hand := poker.Hand{
+		Cards: []poker.Card{
+			{Rank: "K", Suit: "h"},
+			{Rank: "K", Suit: "s", Enhancement: 1},
+			{Rank: "2", Suit: "c"},
+			{Rank: "5", Suit: "d"},
+			{Rank: "9", Suit: "h"},
+		},
+		// Solid Seven Joker (+7 chips, +7 mult) and Poor Joker (+4 gold)
+		Jokers: poker.Jokers{Juglares: []int{1, 2}},
+		Gold:   5,
+	}
+	rng := poker.NewGameRNG(1)
+
+	trace := &poker.ScoringTrace{}
+	fichas, mult, handType, scored := poker.BestHand(hand, trace)
+	fichas += poker.AddChipsPerCard(scored, trace)
+	fichas, mult, gold := poker.ApplyEnhancements(rng, fichas, mult, hand.Gold, scored, trace)
+	fichas, mult, gold, _ = poker.ApplyJokers(rng, hand, hand.Jokers, fichas, mult, gold, "player", trace)
+
+	assert.Equal(t, poker.HandPair, handType)
+	assert.Equal(t, []string{poker.StepHand, poker.StepCard, poker.StepCard, poker.StepEnhancement, poker.StepJoker, poker.StepJoker},
+		sources(trace.Steps))
+	assert.Equal(t, fichas*mult, trace.Score())
+	assert.Equal(t, gold-hand.Gold, trace.GoldDelta())
+
+	// Every step starts where the previous one ended
+	for i := 1; i < len(trace.Steps); i++ {
+		assert.Equal(t, trace.Steps[i-1].ChipsAfter, trace.Steps[i].ChipsBefore, "step %d", i)
+		assert.Equal(t, trace.Steps[i-1].MultAfter, trace.Steps[i].MultBefore, "step %d", i)
+	}
+}
+
+func TestCardModifications(t *testing.T) {
+	hand := poker.Hand{
+		Cards: []poker.Card{
+			{Rank: "2", Suit: "h"},
+			{Rank: "7", Suit: "h", Enhancement: poker.EnhancementGlass},
+			{Rank: "9", Suit: "s", Enhancement: poker.EnhancementWild, Seal: poker.SealRed},
+			{Rank: "J", Suit: "h", Edition: poker.EditionFoil, Seal: poker.SealGold},
+			{Rank: "K", Suit: "h"},
+		},
+	}
+
+	// The wild card counts as a heart
+	trace := &poker.ScoringTrace{}
+	fichas, mult, handType, scored := poker.BestHand(hand, trace)
+	assert.Equal(t, poker.HandFlush, handType)
+
+	fichas += poker.AddChipsPerCard(scored, trace)
+	fichas, mult, gold := poker.ApplyEnhancements(poker.NewGameRNG(1), fichas, mult, 0, scored, trace)
+
+	// 15 + 2 + 7 + 9*2 (red seal) + 10 + 10 + 50 (foil), 8 * 2 (glass)
+	assert.Equal(t, 112, fichas)
+	assert.Equal(t, 16, mult)
+	assert.Equal(t, 3, gold)
+	assert.Equal(t, fichas*mult, trace.Score())
+	assert.Equal(t, gold, trace.GoldDelta())
+	assert.Equal(t, []string{poker.StepHand, poker.StepCard, poker.StepCard, poker.StepCard, poker.StepCard, poker.StepCard, poker.StepCard,
+		poker.StepEnhancement, poker.StepEdition, poker.StepSeal}, sources(trace.Steps))
+
+	assert.NoError(t, hand.Cards[2].Validate())
+	assert.Error(t, poker.Card{Rank: "K", Suit: "h", Seal: 9}.Validate())
+}
+
+func TestStoneCardsAlwaysScore(t *testing.T) {
+	hand := poker.Hand{
+		Cards: []poker.Card{
+			{Rank: "Q", Suit: "h"},
+			{Rank: "Q", Suit: "s"},
+			{Rank: "Q", Suit: "d", Enhancement: poker.EnhancementStone},
+			{Rank: "4", Suit: "c"},
+		},
+	}
+
+	fichas, _, handType, scored := poker.BestHand(hand, nil)
+	assert.Equal(t, poker.HandPair, handType)
+	assert.Len(t, scored, 3)
+	assert.Equal(t, 4+10+10+poker.StoneChips, fichas+poker.AddChipsPerCard(scored, nil))
+}
+
+func TestWildAndStoneCardsInJokers(t *testing.T) {
+	wild := poker.Card{Rank: "3", Suit: "h", Enhancement: poker.EnhancementWild}
+	stone := poker.Card{Rank: "K", Suit: "s", Enhancement: poker.EnhancementStone}
+	assert.True(t, wild.HasSuit("s"))
+	assert.False(t, stone.HasSuit("s"))
+	assert.Empty(t, stone.ScoringRank())
+
+	// Kaefece triggers with black cards: the wild heart is one, the stone spade isn't
+	kaefece := poker.Jokers{Juglares: []int{22}}
+	_, _, _, used := poker.ApplyJokers(nil, poker.Hand{Cards: []poker.Card{wild}}, kaefece, 10, 1, 0, "player", nil)
+	assert.Equal(t, []bool{true}, used)
+	_, _, _, used = poker.ApplyJokers(nil, poker.Hand{Cards: []poker.Card{stone}}, kaefece, 10, 1, 0, "player", nil)
+	assert.Equal(t, []bool{false}, used)
+
+	// Photograph triggers with face cards, the stone king has no rank
+	photograph := poker.Jokers{Juglares: []int{9}}
+	_, mult, _, used := poker.ApplyJokers(nil, poker.Hand{Cards: []poker.Card{stone}}, photograph, 10, 3, 0, "player", nil)
+	assert.Equal(t, 3, mult)
+	assert.Equal(t, []bool{false}, used)
+	_, mult, _, _ = poker.ApplyJokers(nil, poker.Hand{Cards: []poker.Card{{Rank: "K", Suit: "s"}}}, photograph, 10, 3, 0, "player", nil)
+	assert.Equal(t, 6, mult)
+
+	// The Money Store gives 1 gold per black card
+	moneyStore := poker.Modifier{Value: 9, LeftUses: 1}
+	_, _, gold, _ := poker.Apply(nil, moneyStore, poker.Hand{Cards: []poker.Card{wild, stone}}, 0, 0, 0)
+	assert.Equal(t, 1, gold)
+}
+
+func TestCardIDs(t *testing.T) {
+	deck := poker.NewStandardDeck()
+	ids := map[int]bool{}
+	for _, card := range deck.TotalCards {
+		assert.False(t, ids[card.ID], "duplicated card %d", card.ID)
+		ids[card.ID] = true
+	}
+	assert.Len(t, ids, poker.StandardDeckSize)
+
+	// A bought copy of a standard card only removes itself
+	bought := poker.AssignCardIDs(nil, []poker.Card{deck.TotalCards[0], deck.TotalCards[0]})
+	assert.Equal(t, []int{poker.StandardDeckSize + 1, poker.StandardDeckSize + 2}, []int{bought[0].ID, bought[1].ID})
+	deck.AddCards(bought)
+	deck.RemoveCards(bought[:1])
+	assert.Len(t, deck.TotalCards, poker.StandardDeckSize+1)
+	assert.Equal(t, poker.StandardDeckSize+3, poker.AssignCardIDs(bought, bought[:1])[0].ID)
+
+	found, err := poker.FindCards(deck.TotalCards, []int{bought[1].ID, 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []poker.Card{bought[1], deck.TotalCards[0]}, found)
+
+	_, err = poker.FindCards(deck.TotalCards, []int{1, 1})
+	assert.Error(t, err)
+	_, err = poker.FindCards(deck.TotalCards, []int{bought[0].ID})
+	assert.Error(t, err)
+}
+
+func TestHandLevels(t *testing.T) {
+	hand := poker.Hand{
+		Cards: []poker.Card{{Rank: "K", Suit: "h"}, {Rank: "K", Suit: "s"}},
+	}
+	fichas, mult, handType, _ := poker.BestHand(hand, nil)
+	assert.Equal(t, poker.HandPair, handType)
+	assert.Equal(t, []int{poker.TypeMap[poker.HandPair].First, poker.TypeMap[poker.HandPair].Second}, []int{fichas, mult})
+
+	assert.Equal(t, 2, hand.Levels.LevelUp(handType))
+	assert.Equal(t, 3, hand.Levels.LevelUp(handType))
+	hand.Levels.RecordPlayed(handType)
+
+	trace := &poker.ScoringTrace{}
+	fichas, mult, _, _ = poker.BestHand(hand, trace)
+	upgrade := poker.LevelUpgrades[poker.HandPair]
+	assert.Equal(t, poker.TypeMap[poker.HandPair].First+2*upgrade.First, fichas)
+	assert.Equal(t, poker.TypeMap[poker.HandPair].Second+2*upgrade.Second, mult)
+	assert.Equal(t, fichas*mult, trace.Score())
+	assert.Equal(t, poker.HandLevel{Level: 3, TimesPlayed: 1}, hand.Levels[handType])
+
+	// Other hand types stay at level 1
+	assert.Equal(t, 1, hand.Levels.Level(poker.HandHighCard))
+}
+
+func TestHandTypes(t *testing.T) {
+	for _, handType := range poker.HandTypes {
+		assert.True(t, handType.Valid())
+		assert.Contains(t, poker.TypeMap, handType)
+		assert.Contains(t, poker.LevelUpgrades, handType)
+
+		parsed, ok := poker.ParseHandType(handType.String())
+		assert.True(t, ok)
+		assert.Equal(t, handType, parsed)
+	}
+	assert.False(t, poker.HandNone.Valid())
+	assert.Equal(t, "FullHouse", poker.HandFullHouse.String())
+}
+
+func TestParseJokerDefinitionsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		err  string
+	}{
+		{"empty", `[]`, "no joker definitions found"},
+		{"unknown field", `[{"id": 1, "name": "A", "rarity": "Common", "price": 2, "builtin": "Petpet", "colour": "red"}]`, "unknown field"},
+		{"unknown rarity", `[{"id": 1, "name": "A", "rarity": "Mythic", "price": 2, "builtin": "Petpet"}]`, "unknown rarity"},
+		{"unknown builtin", `[{"id": 1, "name": "A", "rarity": "Common", "price": 2, "builtin": "Nope"}]`, "unknown builtin"},
+		{"builtin with trigger", `[{"id": 1, "name": "A", "rarity": "Common", "price": 2, "builtin": "Petpet",
+			"trigger": {"condition": "always"}, "effect": {"mult": 1}}]`, "builtin jokers can't also define trigger/effect"},
+		{"duplicated id", `[{"id": 1, "name": "A", "rarity": "Common", "price": 2, "builtin": "Petpet"},
+			{"id": 1, "name": "B", "rarity": "Common", "price": 2, "builtin": "Petpet"}]`, "duplicated id 1"},
+		{"unknown condition", `[{"id": 1, "name": "A", "rarity": "Common", "price": 2,
+			"trigger": {"condition": "sometimes"}, "effect": {"mult": 1}}]`, "unknown trigger condition"},
+		{"empty effect", `[{"id": 1, "name": "A", "rarity": "Common", "price": 2,
+			"trigger": {"condition": "always"}, "effect": {}}]`, "effect has no changes"},
+		{"no effect", `[{"id": 1, "name": "A", "rarity": "Common", "price": 2}]`, "are required"},
+		{"unknown stack_on", `[{"id": 1, "name": "A", "rarity": "Common", "price": 2,
+			"lifecycle": {"stack_on": "shop", "stack_mult": 1}}]`, "unknown stack_on"},
+		{"stacks without stack_on", `[{"id": 1, "name": "A", "rarity": "Common", "price": 2, "builtin": "Petpet",
+			"lifecycle": {"stack_mult": 1}}]`, "need stack_on"},
+		{"stack_on without stacks", `[{"id": 1, "name": "A", "rarity": "Common", "price": 2,
+			"lifecycle": {"stack_on": "round"}}]`, "needs stack_fichas and/or stack_mult"},
+		{"negative rounds", `[{"id": 1, "name": "A", "rarity": "Common", "price": 2, "builtin": "Petpet",
+			"lifecycle": {"rounds": -1}}]`, "rounds can't be negative"},
+		{"negative destroy_chance", `[{"id": 1, "name": "A", "rarity": "Common", "price": 2, "builtin": "Petpet",
+			"lifecycle": {"destroy_chance": -3}}]`, "destroy_chance can't be negative"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := poker.ParseJokerDefinitions([]byte(test.json))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), test.err)
+			}
+		})
+	}
+
+	_, err := poker.ParseJokerDefinitions([]byte(`[{"id": 1, "name": "A", "rarity": "Common", "price": 2, "builtin": "Petpet"}]`))
+	assert.NoError(t, err)
+}
+
+func TestJokerLifecycle(t *testing.T) {
+	rng := poker.NewGameRNG(1, "play_hand", "player", 1)
+	hand := poker.Hand{Cards: []poker.Card{{Rank: "3", Suit: "s"}}}
+
+	// Gym Bro stacks +1 mult every hand played
+	jokers := poker.Jokers{}
+	jokers.Add(23)
+	jokers.HandPlayed(rng, nil)
+	jokers.HandPlayed(rng, nil)
+	assert.Equal(t, poker.JokerState{Stacks: 2}, jokers.StateAt(0))
+	_, mult, _, used := poker.ApplyJokers(rng, hand, jokers, 10, 1, 0, "player", nil)
+	assert.Equal(t, 3, mult)
+	assert.Equal(t, []bool{true}, used)
+
+	// The state moves with the joker
+	jokers.Add(1)
+	assert.NoError(t, jokers.Reorder([]int{1, 23}))
+	assert.Equal(t, 2, jokers.StateAt(1).Stacks)
+	assert.Error(t, jokers.Reorder([]int{1, 1}))
+
+	// Helado expires after three rounds
+	jokers = poker.Jokers{Juglares: []int{24}}
+	for round := 1; round <= 3; round++ {
+		assert.Empty(t, jokers.RoundStarted())
+	}
+	assert.Equal(t, []int{24}, jokers.RoundStarted())
+	assert.Empty(t, jokers.Juglares)
+
+	// Average Size Michel is only destroyed after the hands it triggers in
+	jokers = poker.Jokers{Juglares: []int{4}}
+	for i := 0; i < 100; i++ {
+		assert.Empty(t, jokers.HandPlayed(rng, []bool{false}))
+	}
+	var destroyed []int
+	for i := 0; i < 500 && len(destroyed) == 0; i++ {
+		destroyed = jokers.HandPlayed(rng, []bool{true})
+	}
+	assert.Equal(t, []int{4}, destroyed)
+	assert.Empty(t, jokers.Juglares)
+}
+
+func TestKaefece(t *testing.T) {
+	_, ok := poker.GetJokerDefinition(22)
+	assert.True(t, ok)
+
+	jokers := poker.Jokers{Juglares: []int{22}}
+	tests := []struct {
+		name   string
+		cards  []poker.Card
+		fichas int
+		mult   int
+		used   bool
+	}{
+		{"no black cards", []poker.Card{{Rank: "7", Suit: "h"}, {Rank: "8", Suit: "d"}}, 10, 1, false},
+		// +5 mult per black card
+		{"two black cards", []poker.Card{{Rank: "3", Suit: "s"}, {Rank: "5", Suit: "c"}, {Rank: "7", Suit: "h"}}, 10, 11, true},
+		// +50 fichas and x2 mult with four or more
+		{"four black cards", []poker.Card{{Rank: "3", Suit: "s"}, {Rank: "5", Suit: "c"}, {Rank: "7", Suit: "s"}, {Rank: "9", Suit: "c"}}, 60, 42, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fichas, mult, _, used := poker.ApplyJokers(nil, poker.Hand{Cards: test.cards}, jokers, 10, 1, 0, "player", nil)
+			assert.Equal(t, test.fichas, fichas)
+			assert.Equal(t, test.mult, mult)
+			assert.Equal(t, []bool{test.used}, used)
+		})
+	}
+}
+
+func sources(steps []poker.ScoringStep) []string {
+	result := []string{}
+	for _, step := range steps {
+		result = append(result, step.Source)
+	}
+	return result
+}
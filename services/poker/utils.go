@@ -2,6 +2,10 @@ package poker
 
 import (
 	"encoding/json"
+	"fmt"
+	"hash/fnv"
+
+	"golang.org/x/exp/rand"
 )
 
 // Marshal the deck for Redis storage
@@ -30,12 +34,24 @@ func DeckFromJSON(data json.RawMessage) (*Deck, error) {
 	}, nil
 }
 
-func InitializePlayerDeck() json.RawMessage {
+func InitializePlayerDeck(rng *rand.Rand) json.RawMessage {
 	deck := NewStandardDeck() // Creates deck with TotalCards
-	deck.Shuffle()
+	deck.Shuffle(rng)
 	return deck.ToJSON()
 }
 
+// Returns a RNG derived from the game seed and the given parts (username, round, plays left...).
+// Every random event of a match goes through one of these, so the whole match can be
+// reproduced from the seed stored in the lobby
+func NewGameRNG(gameSeed uint64, parts ...interface{}) *rand.Rand {
+	h := fnv.New64a()
+	fmt.Fprint(h, gameSeed)
+	for _, part := range parts {
+		fmt.Fprintf(h, ":%v", part)
+	}
+	return rand.New(rand.NewSource(h.Sum64()))
+}
+
 // Generate all combinations of hands of a given size from a deck of cards
 func GenerateHands(hand []Card, handSize int) [][]Card {
 	combinations := [][]Card{}
@@ -0,0 +1,105 @@
+package rate_limit
+
+import (
+	"Nogler/services/redis"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// Limit of a token bucket: a client can do Burst requests in a row, and gets a new one every
+// Interval
+type Limit struct {
+	Burst    int
+	Interval time.Duration
+}
+
+// Limits of the REST routes and socket.io events, by name. The ones not listed aren't limited.
+// These are the defaults, they can be overridden at startup with LoadLimits (see RATE_LIMITS_FILE in main.go)
+var Limits = map[string]Limit{
+	// REST routes
+	"login":                 {Burst: 10, Interval: 6 * time.Second},
+	"signup":                {Burst: 3, Interval: 20 * time.Second},
+	"sendFriendshipRequest": {Burst: 10, Interval: 6 * time.Second},
+	"sendLobbyInvitation":   {Burst: 10, Interval: 3 * time.Second},
+	"reportPlayer":          {Burst: 3, Interval: time.Minute},
+
+	// Socket.io events
+	"broadcast_to_lobby":  {Burst: 5, Interval: time.Second},
+	"send_direct_message": {Burst: 5, Interval: time.Second},
+	"reroll_shop":         {Burst: 5, Interval: 500 * time.Millisecond},
+	"play_hand":           {Burst: 3, Interval: time.Second},
+}
+
+// A limit as written in the limits file, e.g. {"login": {"burst": 10, "interval": "6s"}}
+type limitOverride struct {
+	Burst    int    `json:"burst"`
+	Interval string `json:"interval"`
+}
+
+// Overrides the limits with the ones found in path. The routes and events not in the file keep
+// their default limit. The current limits are kept if the file is malformed
+func LoadLimits(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading rate limits %s: %v", path, err)
+	}
+	overrides, err := ParseLimits(data)
+	if err != nil {
+		return fmt.Errorf("invalid rate limits %s: %v", path, err)
+	}
+	for name, limit := range overrides {
+		Limits[name] = limit
+	}
+	return nil
+}
+
+// Parses and validates a JSON object of limits by route or event name
+func ParseLimits(data []byte) (map[string]Limit, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var overrides map[string]limitOverride
+	if err := decoder.Decode(&overrides); err != nil {
+		return nil, fmt.Errorf("error decoding rate limits: %v", err)
+	}
+
+	limits := make(map[string]Limit, len(overrides))
+	for name, override := range overrides {
+		if override.Burst <= 0 {
+			return nil, fmt.Errorf("limit %s: burst must be positive, got %d", name, override.Burst)
+		}
+		interval, err := time.ParseDuration(override.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("limit %s: invalid interval %q: %v", name, override.Interval, err)
+		}
+		if interval <= 0 {
+			return nil, fmt.Errorf("limit %s: interval must be positive, got %s", name, interval)
+		}
+		limits[name] = Limit{Burst: override.Burst, Interval: interval}
+	}
+	return limits, nil
+}
+
+// Allow takes a token of the bucket of a client (identity) for a route or event (name).
+// Returns whether the request can go on, and if not, how long until it can.
+// Requests are allowed if Redis fails, so an outage of the limiter doesn't take the API down
+func Allow(redisClient *redis.RedisClient, name string, identity string) (bool, time.Duration) {
+	limit, ok := Limits[name]
+	if !ok {
+		return true, 0
+	}
+
+	allowed, retryAfter, err := redisClient.TakeRateLimitToken(name, identity, limit.Burst, limit.Interval)
+	if err != nil {
+		log.Printf("[RATE-LIMIT-ERROR] Error checking the limit of %s for %s: %v", name, identity, err)
+		return true, 0
+	}
+	if !allowed {
+		log.Printf("[RATE-LIMIT] %s rate limited for %s", name, identity)
+	}
+	return allowed, retryAfter
+}
@@ -0,0 +1,26 @@
+package rate_limit_test
+
+import (
+	"Nogler/services/rate_limit"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLimits(t *testing.T) {
+	limits, err := rate_limit.ParseLimits([]byte(`{"login": {"burst": 20, "interval": "3s"}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]rate_limit.Limit{"login": {Burst: 20, Interval: 3 * time.Second}}, limits)
+
+	for _, data := range []string{
+		`{"login": {"burst": 0, "interval": "3s"}}`,
+		`{"login": {"burst": 5, "interval": "soon"}}`,
+		`{"login": {"burst": 5, "interval": "-1s"}}`,
+		`{"login": {"burst": 5, "interval": "1s", "per": "ip"}}`,
+		`[]`,
+	} {
+		_, err := rate_limit.ParseLimits([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
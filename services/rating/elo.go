@@ -0,0 +1,58 @@
+package rating
+
+import "math"
+
+const (
+	InitialRating = 1000 // Rating of a player that hasn't played any rated game
+	MinRating     = 100  // Ratings never go below this
+	KFactor       = 32   // Maximum rating change of a game
+)
+
+// Player is a participant of a finished game, ranked by its placement (1 is the best).
+// Players with the same placement tied
+type Player struct {
+	Username  string
+	Rating    int
+	Placement int
+}
+
+// Expected score of a player with rating a against a player with rating b (between 0 and 1)
+func expectedScore(a, b int) float64 {
+	return 1 / (1 + math.Pow(10, float64(b-a)/400))
+}
+
+// Update returns the new rating of every player after a multiplayer game. The game is
+// treated as a 1v1 game between every pair of players, won by the best placed one, and
+// the changes are scaled so a game changes a rating by at most KFactor
+func Update(players []Player) map[string]int {
+	ratings := make(map[string]int, len(players))
+	if len(players) < 2 {
+		for _, player := range players {
+			ratings[player.Username] = player.Rating
+		}
+		return ratings
+	}
+
+	for i, player := range players {
+		delta := 0.0
+		for j, opponent := range players {
+			if i == j {
+				continue
+			}
+			actual := 0.5
+			if player.Placement < opponent.Placement {
+				actual = 1
+			} else if player.Placement > opponent.Placement {
+				actual = 0
+			}
+			delta += actual - expectedScore(player.Rating, opponent.Rating)
+		}
+
+		newRating := player.Rating + int(math.Round(KFactor*delta/float64(len(players)-1)))
+		if newRating < MinRating {
+			newRating = MinRating
+		}
+		ratings[player.Username] = newRating
+	}
+	return ratings
+}
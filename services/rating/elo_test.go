@@ -0,0 +1,43 @@
+package rating_test
+
+import (
+	"Nogler/services/rating"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateRewardsPlacement(t *testing.T) {
+	ratings := rating.Update([]rating.Player{
+		{Username: "first", Rating: 1000, Placement: 1},
+		{Username: "second", Rating: 1000, Placement: 2},
+		{Username: "third", Rating: 1000, Placement: 3},
+	})
+
+	assert.Equal(t, 1016, ratings["first"])
+	assert.Equal(t, 1000, ratings["second"])
+	assert.Equal(t, 984, ratings["third"])
+}
+
+func TestUpdateTiesAndUpsets(t *testing.T) {
+	// Equal players that tie keep their rating
+	ratings := rating.Update([]rating.Player{
+		{Username: "a", Rating: 1200, Placement: 1},
+		{Username: "b", Rating: 1200, Placement: 1},
+	})
+	assert.Equal(t, 1200, ratings["a"])
+	assert.Equal(t, 1200, ratings["b"])
+
+	// Beating a much better player is worth more than beating an equal one
+	ratings = rating.Update([]rating.Player{
+		{Username: "underdog", Rating: 800, Placement: 1},
+		{Username: "favourite", Rating: 1400, Placement: 2},
+	})
+	assert.Greater(t, ratings["underdog"]-800, 16)
+	assert.Less(t, ratings["favourite"], 1400)
+}
+
+func TestUpdateSinglePlayerKeepsRating(t *testing.T) {
+	ratings := rating.Update([]rating.Player{{Username: "alone", Rating: 1000, Placement: 1}})
+	assert.Equal(t, 1000, ratings["alone"])
+}
@@ -0,0 +1,113 @@
+package redis
+
+import (
+	redis_models "Nogler/models/redis"
+	redis_utils "Nogler/services/redis/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Number of times an update is retried when the key is modified by someone else
+// between the read and the write
+const maxUpdateRetries = 20
+
+// ErrUpdateConflict is returned when an update couldn't be applied after maxUpdateRetries attempts
+var ErrUpdateConflict = errors.New("too many concurrent updates")
+
+// ErrUpdateSkipped can be returned by an update function to leave the value untouched when
+// there's nothing to do (e.g. a phase that has already been advanced by another goroutine)
+var ErrUpdateSkipped = errors.New("update skipped")
+
+// updateKey applies an optimistic read-modify-write to a JSON value: the key is WATCHed, read,
+// modified by apply and written back in a MULTI/EXEC. If the key changed in between the
+// transaction fails and the whole update (including apply) is retried with the new value.
+// If set, queue adds other commands to the same transaction
+func (rc *RedisClient) updateKey(key string, apply func(data []byte) ([]byte, error), queue func(pipe redis.Pipeliner)) error {
+	for attempt := 0; attempt < maxUpdateRetries; attempt++ {
+		err := rc.client.Watch(rc.ctx, func(tx *redis.Tx) error {
+			data, err := tx.Get(rc.ctx, key).Bytes()
+			if err != nil {
+				return err
+			}
+
+			updated, err := apply(data)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.TxPipelined(rc.ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(rc.ctx, key, updated, 24*time.Hour)
+				if queue != nil {
+					queue(pipe)
+				}
+				return nil
+			})
+			return err
+		}, key)
+
+		if err != redis.TxFailedErr {
+			return err
+		}
+		log.Printf("[REDIS-UPDATE] Conflict updating %s, retrying (%d/%d)", key, attempt+1, maxUpdateRetries)
+	}
+	return ErrUpdateConflict
+}
+
+// UpdateGameLobby atomically applies update to the lobby stored in Redis.
+// The function may be called several times if there are concurrent updates, so it must only
+// modify the lobby it receives. If it returns an error nothing is saved and the error is returned
+// Returns: the updated lobby
+func (rc *RedisClient) UpdateGameLobby(lobbyId string, update func(*redis_models.GameLobby) error) (*redis_models.GameLobby, error) {
+	var lobby redis_models.GameLobby
+	err := rc.updateKey(redis_utils.FormatLobbyKey(lobbyId), func(data []byte) ([]byte, error) {
+		lobby = redis_models.GameLobby{}
+		if err := json.Unmarshal(data, &lobby); err != nil {
+			return nil, fmt.Errorf("error unmarshaling lobby data: %v", err)
+		}
+		lobby.EnsureMapsInitialized()
+
+		if err := update(&lobby); err != nil {
+			return nil, err
+		}
+		return json.Marshal(lobby)
+	}, nil)
+	if err == redis.Nil {
+		return nil, fmt.Errorf("error getting lobby data: %v", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lobby, nil
+}
+
+// UpdateInGamePlayer atomically applies update to the player's game state stored in Redis.
+// Same as UpdateGameLobby, update may be called several times
+// Returns: the updated player
+func (rc *RedisClient) UpdateInGamePlayer(username string, update func(*redis_models.InGamePlayer) error) (*redis_models.InGamePlayer, error) {
+	var player redis_models.InGamePlayer
+	err := rc.updateKey(redis_utils.FormatInGamePlayerKey(username), func(data []byte) ([]byte, error) {
+		player = redis_models.InGamePlayer{}
+		if err := json.Unmarshal(data, &player); err != nil {
+			return nil, fmt.Errorf("error unmarshaling player data: %v", err)
+		}
+
+		if err := update(&player); err != nil {
+			return nil, err
+		}
+		return json.Marshal(player)
+	}, func(pipe redis.Pipeliner) {
+		rc.queueAddPlayerToLobby(pipe, &player)
+	})
+	if err == redis.Nil {
+		return nil, fmt.Errorf("error getting player data: %v", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &player, nil
+}
@@ -0,0 +1,80 @@
+package redis
+
+import (
+	redis_models "Nogler/models/redis"
+	redis_utils "Nogler/services/redis/utils"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// AppendChatMessage adds a message to the chat history of a lobby, keeping only the last
+// historySize messages
+// Key format: "lobby:{id}:chat"
+func (rc *RedisClient) AppendChatMessage(lobbyId string, message redis_models.ChatMessage, historySize int) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("error marshaling chat message: %v", err)
+	}
+
+	key := redis_utils.FormatLobbyChatKey(lobbyId)
+	pipe := rc.client.TxPipeline()
+	pipe.RPush(rc.ctx, key, data)
+	pipe.LTrim(rc.ctx, key, int64(-historySize), -1)
+	pipe.Expire(rc.ctx, key, 24*time.Hour)
+	if _, err := pipe.Exec(rc.ctx); err != nil {
+		return fmt.Errorf("error appending chat message: %v", err)
+	}
+	return nil
+}
+
+// GetChatHistory returns the chat history of a lobby, the oldest messages first
+func (rc *RedisClient) GetChatHistory(lobbyId string) ([]redis_models.ChatMessage, error) {
+	entries, err := rc.client.LRange(rc.ctx, redis_utils.FormatLobbyChatKey(lobbyId), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting chat history: %v", err)
+	}
+
+	messages := make([]redis_models.ChatMessage, 0, len(entries))
+	for _, entry := range entries {
+		var message redis_models.ChatMessage
+		if err := json.Unmarshal([]byte(entry), &message); err != nil {
+			return nil, fmt.Errorf("error unmarshaling chat message: %v", err)
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// MutePlayer prevents a user from sending messages to the chat of a lobby
+// Key format: "lobby:{id}:muted" (set of usernames)
+// Returns: whether the user wasn't muted already
+func (rc *RedisClient) MutePlayer(lobbyId string, username string) (bool, error) {
+	key := redis_utils.FormatLobbyMutedKey(lobbyId)
+	pipe := rc.client.TxPipeline()
+	added := pipe.SAdd(rc.ctx, key, username)
+	pipe.Expire(rc.ctx, key, 24*time.Hour)
+	if _, err := pipe.Exec(rc.ctx); err != nil {
+		return false, fmt.Errorf("error muting player: %v", err)
+	}
+	return added.Val() > 0, nil
+}
+
+// UnmutePlayer lets a muted user send messages again
+// Returns: whether the user was muted
+func (rc *RedisClient) UnmutePlayer(lobbyId string, username string) (bool, error) {
+	removed, err := rc.client.SRem(rc.ctx, redis_utils.FormatLobbyMutedKey(lobbyId), username).Result()
+	if err != nil {
+		return false, fmt.Errorf("error unmuting player: %v", err)
+	}
+	return removed > 0, nil
+}
+
+// IsPlayerMuted checks if a user has been muted in a lobby
+func (rc *RedisClient) IsPlayerMuted(lobbyId string, username string) (bool, error) {
+	muted, err := rc.client.SIsMember(rc.ctx, redis_utils.FormatLobbyMutedKey(lobbyId), username).Result()
+	if err != nil {
+		return false, fmt.Errorf("error checking if player is muted: %v", err)
+	}
+	return muted, nil
+}
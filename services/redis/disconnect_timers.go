@@ -0,0 +1,117 @@
+package redis
+
+import (
+	redis_models "Nogler/models/redis"
+	redis_utils "Nogler/services/redis/utils"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Sorted set with the grace periods of the disconnected players, scored by their deadline
+// (unix ms). The members are the JSON encoded timers
+const disconnectTimersKey = "disconnect_timers"
+
+// ScheduleDisconnectTimer starts the grace period of a disconnected player, replacing the previous one
+// Key format: "player:{username}:disconnect_timer" (member of the player's pending timer)
+func (rc *RedisClient) ScheduleDisconnectTimer(timer redis_models.DisconnectTimer) error {
+	member, err := json.Marshal(timer)
+	if err != nil {
+		return fmt.Errorf("error marshaling disconnect timer: %v", err)
+	}
+
+	_, err = rc.swapDisconnectTimer(timer.Username, func(pipe redis.Pipeliner, pointerKey string) {
+		pipe.ZAdd(rc.ctx, disconnectTimersKey, redis.Z{Score: float64(timer.Deadline.UnixMilli()), Member: string(member)})
+		pipe.Set(rc.ctx, pointerKey, string(member), 24*time.Hour)
+	})
+	if err != nil {
+		return fmt.Errorf("error scheduling disconnect timer: %v", err)
+	}
+	return nil
+}
+
+// CancelDisconnectTimer ends the grace period of a player that has come back
+// Returns: whether the player had a pending timer
+func (rc *RedisClient) CancelDisconnectTimer(username string) (bool, error) {
+	removed, err := rc.swapDisconnectTimer(username, func(pipe redis.Pipeliner, pointerKey string) {
+		pipe.Del(rc.ctx, pointerKey)
+	})
+	if err != nil {
+		return false, fmt.Errorf("error cancelling disconnect timer: %v", err)
+	}
+	return removed, nil
+}
+
+// swapDisconnectTimer removes the pending timer of a player and runs replace in the same
+// transaction, WATCHing the pointer to the pending timer the same way as swapPhaseTimer
+// Returns: whether a pending timer was removed
+func (rc *RedisClient) swapDisconnectTimer(username string, replace func(pipe redis.Pipeliner, pointerKey string)) (bool, error) {
+	pointerKey := redis_utils.FormatPlayerDisconnectTimerKey(username)
+	for attempt := 0; attempt < maxUpdateRetries; attempt++ {
+		removed := false
+		err := rc.client.Watch(rc.ctx, func(tx *redis.Tx) error {
+			previous, err := tx.Get(rc.ctx, pointerKey).Result()
+			if err != nil && err != redis.Nil {
+				return err
+			}
+
+			var removedCmd *redis.IntCmd
+			_, err = tx.TxPipelined(rc.ctx, func(pipe redis.Pipeliner) error {
+				if previous != "" {
+					removedCmd = pipe.ZRem(rc.ctx, disconnectTimersKey, previous)
+				}
+				replace(pipe, pointerKey)
+				return nil
+			})
+			removed = err == nil && removedCmd != nil && removedCmd.Val() > 0
+			return err
+		}, pointerKey)
+
+		if err != redis.TxFailedErr {
+			return removed, err
+		}
+		log.Printf("[REDIS-UPDATE] Conflict updating the disconnect timer of %s, retrying (%d/%d)", username, attempt+1, maxUpdateRetries)
+	}
+	return false, ErrUpdateConflict
+}
+
+// ClaimExpiredDisconnectTimers removes and returns the timers whose deadline is before now.
+// A timer is only returned to the caller that removes it, so a player never forfeits twice
+func (rc *RedisClient) ClaimExpiredDisconnectTimers(now time.Time) ([]redis_models.DisconnectTimer, error) {
+	members, err := rc.client.ZRangeByScore(rc.ctx, disconnectTimersKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting expired disconnect timers: %v", err)
+	}
+
+	var timers []redis_models.DisconnectTimer
+	for _, member := range members {
+		removed, err := rc.client.ZRem(rc.ctx, disconnectTimersKey, member).Result()
+		if err != nil {
+			return timers, fmt.Errorf("error claiming disconnect timer: %v", err)
+		}
+		if removed == 0 {
+			// Cancelled or claimed by someone else in the meantime
+			continue
+		}
+
+		var timer redis_models.DisconnectTimer
+		if err := json.Unmarshal([]byte(member), &timer); err != nil {
+			log.Printf("[DISCONNECT-TIMER-ERROR] Discarding invalid disconnect timer %s: %v", member, err)
+			continue
+		}
+		// The pointer is kept if the player already has a newer timer
+		pointerKey := redis_utils.FormatPlayerDisconnectTimerKey(timer.Username)
+		if current, err := rc.client.Get(rc.ctx, pointerKey).Result(); err == nil && current == member {
+			rc.client.Del(rc.ctx, pointerKey)
+		}
+		timers = append(timers, timer)
+	}
+	return timers, nil
+}
@@ -0,0 +1,50 @@
+package redis
+
+import (
+	redis_models "Nogler/models/redis"
+	redis_utils "Nogler/services/redis/utils"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SaveEliminatedPlayer keeps the last state of an eliminated player until the game ends
+// Key format: "lobby:{id}:eliminated" (hash of username -> eliminated player)
+func (rc *RedisClient) SaveEliminatedPlayer(lobbyId string, eliminated redis_models.EliminatedPlayer) error {
+	data, err := json.Marshal(eliminated)
+	if err != nil {
+		return fmt.Errorf("error marshaling eliminated player: %v", err)
+	}
+
+	key := redis_utils.FormatLobbyEliminatedKey(lobbyId)
+	pipe := rc.client.TxPipeline()
+	pipe.HSet(rc.ctx, key, eliminated.Player.Username, data)
+	pipe.Expire(rc.ctx, key, 24*time.Hour)
+	if _, err := pipe.Exec(rc.ctx); err != nil {
+		return fmt.Errorf("error saving eliminated player: %v", err)
+	}
+	return nil
+}
+
+// GetEliminatedPlayers returns the players eliminated in the lobby, sorted by username
+func (rc *RedisClient) GetEliminatedPlayers(lobbyId string) ([]redis_models.EliminatedPlayer, error) {
+	key := redis_utils.FormatLobbyEliminatedKey(lobbyId)
+	entries, err := rc.client.HGetAll(rc.ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting eliminated players: %v", err)
+	}
+
+	eliminated := make([]redis_models.EliminatedPlayer, 0, len(entries))
+	for _, entry := range entries {
+		var player redis_models.EliminatedPlayer
+		if err := json.Unmarshal([]byte(entry), &player); err != nil {
+			return nil, fmt.Errorf("error unmarshaling eliminated player: %v", err)
+		}
+		eliminated = append(eliminated, player)
+	}
+	sort.Slice(eliminated, func(i, j int) bool {
+		return eliminated[i].Player.Username < eliminated[j].Player.Username
+	})
+	return eliminated, nil
+}
@@ -0,0 +1,46 @@
+package redis
+
+import (
+	redis_models "Nogler/models/redis"
+	redis_utils "Nogler/services/redis/utils"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// AppendGameEvent appends an event at the end of the lobby's replay log
+// Key format: "lobby:{id}:events"
+func (rc *RedisClient) AppendGameEvent(lobbyId string, event redis_models.GameEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshaling game event: %v", err)
+	}
+
+	key := redis_utils.FormatLobbyEventsKey(lobbyId)
+	pipe := rc.client.TxPipeline()
+	pipe.RPush(rc.ctx, key, data)
+	pipe.Expire(rc.ctx, key, 24*time.Hour)
+	if _, err := pipe.Exec(rc.ctx); err != nil {
+		return fmt.Errorf("error appending game event: %v", err)
+	}
+	return nil
+}
+
+// GetGameEvents returns the lobby's replay log, in the order the events happened
+func (rc *RedisClient) GetGameEvents(lobbyId string) ([]redis_models.GameEvent, error) {
+	key := redis_utils.FormatLobbyEventsKey(lobbyId)
+	entries, err := rc.client.LRange(rc.ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting game events: %v", err)
+	}
+
+	events := make([]redis_models.GameEvent, 0, len(entries))
+	for _, entry := range entries {
+		var event redis_models.GameEvent
+		if err := json.Unmarshal([]byte(entry), &event); err != nil {
+			return nil, fmt.Errorf("error unmarshaling game event: %v", err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
@@ -0,0 +1,112 @@
+package redis
+
+import (
+	redis_models "Nogler/models/redis"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Hash with the players waiting in the matchmaking queue, username -> JSON encoded entry
+const matchmakingQueueKey = "matchmaking_queue"
+
+// ErrAlreadyQueued is returned when a player that is already waiting joins the queue again
+var ErrAlreadyQueued = errors.New("player already in the matchmaking queue")
+
+// errEntriesClaimed is used to abort a claim when some of the entries have already left the queue
+var errEntriesClaimed = errors.New("entries already claimed")
+
+// EnqueuePlayer adds a player to the matchmaking queue
+func (rc *RedisClient) EnqueuePlayer(entry redis_models.QueueEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error marshaling queue entry: %v", err)
+	}
+
+	added, err := rc.client.HSetNX(rc.ctx, matchmakingQueueKey, entry.Username, data).Result()
+	if err != nil {
+		return fmt.Errorf("error adding player to the queue: %v", err)
+	}
+	if !added {
+		return ErrAlreadyQueued
+	}
+	return nil
+}
+
+// DequeuePlayer removes a player from the matchmaking queue
+// Returns: whether the player was in the queue
+func (rc *RedisClient) DequeuePlayer(username string) (bool, error) {
+	removed, err := rc.client.HDel(rc.ctx, matchmakingQueueKey, username).Result()
+	if err != nil {
+		return false, fmt.Errorf("error removing player from the queue: %v", err)
+	}
+	return removed > 0, nil
+}
+
+// GetQueue returns the players waiting in the matchmaking queue, the ones waiting longer first
+func (rc *RedisClient) GetQueue() ([]redis_models.QueueEntry, error) {
+	values, err := rc.client.HGetAll(rc.ctx, matchmakingQueueKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting the queue: %v", err)
+	}
+
+	entries := make([]redis_models.QueueEntry, 0, len(values))
+	for username, value := range values {
+		var entry redis_models.QueueEntry
+		if err := json.Unmarshal([]byte(value), &entry); err != nil {
+			log.Printf("[MATCHMAKING-ERROR] Discarding invalid queue entry of %s: %v", username, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].EnqueuedAt.Equal(entries[j].EnqueuedAt) {
+			return entries[i].EnqueuedAt.Before(entries[j].EnqueuedAt)
+		}
+		return entries[i].Username < entries[j].Username
+	})
+	return entries, nil
+}
+
+// ClaimQueueEntries removes the given players from the matchmaking queue, only if all of them
+// are still waiting. A match is only formed by the caller that claims it, so a player is never
+// put in two games even with several server instances polling the queue
+// Returns: whether the players were claimed
+func (rc *RedisClient) ClaimQueueEntries(usernames []string) (bool, error) {
+	for attempt := 0; attempt < maxUpdateRetries; attempt++ {
+		err := rc.client.Watch(rc.ctx, func(tx *redis.Tx) error {
+			for _, username := range usernames {
+				exists, err := tx.HExists(rc.ctx, matchmakingQueueKey, username).Result()
+				if err != nil {
+					return err
+				}
+				if !exists {
+					return errEntriesClaimed
+				}
+			}
+
+			_, err := tx.TxPipelined(rc.ctx, func(pipe redis.Pipeliner) error {
+				pipe.HDel(rc.ctx, matchmakingQueueKey, usernames...)
+				return nil
+			})
+			return err
+		}, matchmakingQueueKey)
+
+		if err == errEntriesClaimed {
+			return false, nil
+		}
+		if err != redis.TxFailedErr {
+			if err != nil {
+				return false, fmt.Errorf("error claiming queue entries: %v", err)
+			}
+			return true, nil
+		}
+		log.Printf("[REDIS-UPDATE] Conflict claiming queue entries, retrying (%d/%d)", attempt+1, maxUpdateRetries)
+	}
+	return false, ErrUpdateConflict
+}
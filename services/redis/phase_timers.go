@@ -0,0 +1,109 @@
+package redis
+
+import (
+	redis_models "Nogler/models/redis"
+	redis_utils "Nogler/services/redis/utils"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Sorted set with the pending phase timers of every lobby, scored by their deadline (unix ms).
+// The members are the JSON encoded timers
+const phaseTimersKey = "phase_timers"
+
+// SchedulePhaseTimer sets the deadline of the current phase of a lobby, replacing its previous timer
+// Key format: "lobby:{id}:phase_timer" (member of the lobby's pending timer)
+func (rc *RedisClient) SchedulePhaseTimer(timer redis_models.PhaseTimer) error {
+	member, err := json.Marshal(timer)
+	if err != nil {
+		return fmt.Errorf("error marshaling phase timer: %v", err)
+	}
+
+	err = rc.swapPhaseTimer(timer.LobbyID, func(pipe redis.Pipeliner, pointerKey string) {
+		pipe.ZAdd(rc.ctx, phaseTimersKey, redis.Z{Score: float64(timer.Deadline.UnixMilli()), Member: string(member)})
+		pipe.Set(rc.ctx, pointerKey, string(member), 24*time.Hour)
+	})
+	if err != nil {
+		return fmt.Errorf("error scheduling phase timer: %v", err)
+	}
+	return nil
+}
+
+// CancelPhaseTimer removes the pending timer of a lobby, if any
+func (rc *RedisClient) CancelPhaseTimer(lobbyId string) error {
+	err := rc.swapPhaseTimer(lobbyId, func(pipe redis.Pipeliner, pointerKey string) {
+		pipe.Del(rc.ctx, pointerKey)
+	})
+	if err != nil {
+		return fmt.Errorf("error cancelling phase timer: %v", err)
+	}
+	return nil
+}
+
+// swapPhaseTimer removes the pending timer of a lobby and runs replace in the same transaction.
+// The pointer to the pending timer is WATCHed, so two concurrent swaps can't both remove the
+// same timer and leave the other one orphaned in the sorted set: the loser retries
+func (rc *RedisClient) swapPhaseTimer(lobbyId string, replace func(pipe redis.Pipeliner, pointerKey string)) error {
+	pointerKey := redis_utils.FormatLobbyPhaseTimerKey(lobbyId)
+	for attempt := 0; attempt < maxUpdateRetries; attempt++ {
+		err := rc.client.Watch(rc.ctx, func(tx *redis.Tx) error {
+			previous, err := tx.Get(rc.ctx, pointerKey).Result()
+			if err != nil && err != redis.Nil {
+				return err
+			}
+
+			_, err = tx.TxPipelined(rc.ctx, func(pipe redis.Pipeliner) error {
+				if previous != "" {
+					pipe.ZRem(rc.ctx, phaseTimersKey, previous)
+				}
+				replace(pipe, pointerKey)
+				return nil
+			})
+			return err
+		}, pointerKey)
+
+		if err != redis.TxFailedErr {
+			return err
+		}
+		log.Printf("[REDIS-UPDATE] Conflict updating the phase timer of lobby %s, retrying (%d/%d)", lobbyId, attempt+1, maxUpdateRetries)
+	}
+	return ErrUpdateConflict
+}
+
+// ClaimDuePhaseTimers removes and returns the timers whose deadline is before now.
+// A timer is only returned to the caller that removes it, so it's never fired twice
+// even with several server instances polling
+func (rc *RedisClient) ClaimDuePhaseTimers(now time.Time) ([]redis_models.PhaseTimer, error) {
+	members, err := rc.client.ZRangeByScore(rc.ctx, phaseTimersKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting due phase timers: %v", err)
+	}
+
+	var timers []redis_models.PhaseTimer
+	for _, member := range members {
+		removed, err := rc.client.ZRem(rc.ctx, phaseTimersKey, member).Result()
+		if err != nil {
+			return timers, fmt.Errorf("error claiming phase timer: %v", err)
+		}
+		if removed == 0 {
+			// Claimed by someone else or replaced in the meantime
+			continue
+		}
+
+		var timer redis_models.PhaseTimer
+		if err := json.Unmarshal([]byte(member), &timer); err != nil {
+			log.Printf("[PHASE-TIMER-ERROR] Discarding invalid phase timer %s: %v", member, err)
+			continue
+		}
+		timers = append(timers, timer)
+	}
+	return timers, nil
+}
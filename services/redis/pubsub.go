@@ -0,0 +1,30 @@
+package redis
+
+import (
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Publish sends a message to every subscriber of a channel, in any server instance
+func (rc *RedisClient) Publish(channel string, message []byte) error {
+	if err := rc.client.Publish(rc.ctx, channel, message).Err(); err != nil {
+		return fmt.Errorf("error publishing to %s: %v", channel, err)
+	}
+	return nil
+}
+
+// Subscribe starts listening to a channel. The messages are received from the returned
+// subscription's Channel(), and it must be closed when no longer needed
+func (rc *RedisClient) Subscribe(channel string) *redis.PubSub {
+	return rc.client.Subscribe(rc.ctx, channel)
+}
+
+// CountSubscribers returns the number of subscribers of a channel
+func (rc *RedisClient) CountSubscribers(channel string) (int64, error) {
+	counts, err := rc.client.PubSubNumSub(rc.ctx, channel).Result()
+	if err != nil {
+		return 0, fmt.Errorf("error counting subscribers of %s: %v", channel, err)
+	}
+	return counts[channel], nil
+}
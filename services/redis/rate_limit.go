@@ -0,0 +1,61 @@
+package redis
+
+import (
+	redis_utils "Nogler/services/redis/utils"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Token bucket stored as a hash with the tokens left and the time (unix ms) they were last
+// refilled. A token is added every interval, up to the burst. Returns {allowed, retry after ms}
+var takeRateLimitTokenScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "refilled_at")
+local tokens = tonumber(bucket[1])
+local refilled_at = tonumber(bucket[2])
+if tokens == nil or refilled_at == nil then
+	tokens = burst
+	refilled_at = now
+end
+
+local refills = math.floor((now - refilled_at) / interval)
+if refills > 0 then
+	tokens = math.min(burst, tokens + refills)
+	refilled_at = refilled_at + refills * interval
+end
+if tokens >= burst then
+	refilled_at = now
+end
+
+local allowed = 0
+local retry_after = 0
+if tokens > 0 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry_after = interval - (now - refilled_at)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tokens, "refilled_at", refilled_at)
+redis.call("PEXPIRE", KEYS[1], burst * interval)
+return {allowed, retry_after}
+`)
+
+// TakeRateLimitToken takes a token of the bucket of a client (identity) for an action (name).
+// The bucket holds up to burst tokens and gets a new one every interval
+// Key format: "rate_limit:{name}:{identity}"
+// Returns: whether there was a token left, and if not, how long until there is one
+func (rc *RedisClient) TakeRateLimitToken(name string, identity string, burst int, interval time.Duration) (bool, time.Duration, error) {
+	result, err := takeRateLimitTokenScript.Run(rc.ctx, rc.client,
+		[]string{redis_utils.FormatRateLimitKey(name, identity)},
+		burst, interval.Milliseconds(), time.Now().UnixMilli()).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("error taking rate limit token: %v", err)
+	}
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -15,12 +16,6 @@ import (
 // DE YAGO NOSE SI ESTÁ BIEN VALE???
 //----------------------------------------------------------------------------------------------------
 
-type HandLevel struct {
-	Fichas      int `json:"fichas"`       // Score multiplier
-	Mult        int `json:"mult"`         // XP needed for next level
-	TimesPlayed int `json:"times_played"` // Tracking for stats
-}
-
 // Value supongo que usaremos en plan un int como "relacionador" de midifier con lo que hace pa aplicarlo
 type Modifier struct {
 	Value       float64   `json:"value"`
@@ -62,7 +57,8 @@ func NewRedisClient(Addr string, DB int) *RedisClient {
 	}
 }
 
-// SaveInGamePlayer stores a player's game state in Redis
+// SaveInGamePlayer stores a player's game state in Redis and adds the player
+// to the players set of its lobby
 // Key format: "player:{username}:game"
 // TTL: 24 hours
 func (rc *RedisClient) SaveInGamePlayer(player *redis_models.InGamePlayer) error {
@@ -72,8 +68,24 @@ func (rc *RedisClient) SaveInGamePlayer(player *redis_models.InGamePlayer) error
 		return fmt.Errorf("error marshaling player data: %v", err)
 	}
 
-	// Simply set the player data, lobby ID is contained within the player object
-	return rc.client.Set(rc.ctx, key, data, 24*time.Hour).Err()
+	pipe := rc.client.TxPipeline()
+	pipe.Set(rc.ctx, key, data, 24*time.Hour)
+	rc.queueAddPlayerToLobby(pipe, player)
+	if _, err := pipe.Exec(rc.ctx); err != nil {
+		return fmt.Errorf("error saving player data: %v", err)
+	}
+	return nil
+}
+
+// queueAddPlayerToLobby adds the player to the players set of its lobby
+// Key format: "lobby:{id}:players"
+func (rc *RedisClient) queueAddPlayerToLobby(pipe redis.Pipeliner, player *redis_models.InGamePlayer) {
+	if player.LobbyId == "" {
+		return
+	}
+	playersKey := redis_utils.FormatLobbyPlayersKey(player.LobbyId)
+	pipe.SAdd(rc.ctx, playersKey, player.Username)
+	pipe.Expire(rc.ctx, playersKey, 24*time.Hour)
 }
 
 // GetInGamePlayer retrieves a player's game state from Redis
@@ -96,31 +108,26 @@ func (rc *RedisClient) GetInGamePlayer(username string) (*redis_models.InGamePla
 // DeleteInGamePlayer removes a player's game state from Redis and decrements the lobby player count
 // Returns: error if operation fails
 func (rc *RedisClient) DeleteInGamePlayer(username string, lobbyId string) error {
-	// 1. Delete player game state
-	gameKey := redis_utils.FormatInGamePlayerKey(username)
-	err := rc.client.Del(rc.ctx, gameKey).Err()
-	if err != nil {
+	// 1. Delete player game state and remove it from the lobby players
+	pipe := rc.client.TxPipeline()
+	pipe.Del(rc.ctx, redis_utils.FormatInGamePlayerKey(username))
+	pipe.SRem(rc.ctx, redis_utils.FormatLobbyPlayersKey(lobbyId), username)
+	if _, err := pipe.Exec(rc.ctx); err != nil {
 		return fmt.Errorf("error deleting player data: %v", err)
 	}
 
-	// 2. Update player count in the lobby
-	lobby, err := rc.GetGameLobby(lobbyId)
+	// 2. Update player count in the lobby (decrement it if positive)
+	lobby, err := rc.UpdateGameLobby(lobbyId, func(lobby *redis_models.GameLobby) error {
+		if lobby.PlayerCount > 0 {
+			lobby.PlayerCount--
+		}
+		return nil
+	})
 	if err != nil {
-		log.Printf("[DELETE-PLAYER-WARNING] Error getting lobby for player count update: %v", err)
+		log.Printf("[DELETE-PLAYER-WARNING] Failed to update player count in Redis: %v", err)
 		return nil // Player was successfully deleted, which is the primary goal
 	}
-
-	// Decrement player count if positive
-	if lobby.PlayerCount > 0 {
-		lobby.PlayerCount--
-		log.Printf("[DELETE-PLAYER] Decremented player count for lobby %s to %d", lobbyId, lobby.PlayerCount)
-
-		// Save the updated lobby
-		if err := rc.SaveGameLobby(lobby); err != nil {
-			log.Printf("[DELETE-PLAYER-WARNING] Failed to update player count in Redis: %v", err)
-			// Still return nil since the player was deleted successfully
-		}
-	}
+	log.Printf("[DELETE-PLAYER] Player count for lobby %s is now %d", lobbyId, lobby.PlayerCount)
 
 	return nil
 }
@@ -177,6 +184,22 @@ func (rc *RedisClient) DeleteGameLobby(lobbyId string) error {
 	lobbyKey := redis_utils.FormatLobbyKey(lobbyId)
 	pipe.Del(rc.ctx, lobbyKey)
 
+	// Delete the players set (the players themselves are deleted one by one)
+	pipe.Del(rc.ctx, redis_utils.FormatLobbyPlayersKey(lobbyId))
+
+	// Delete the replay log (already persisted at game end)
+	pipe.Del(rc.ctx, redis_utils.FormatLobbyEventsKey(lobbyId))
+
+	// Delete the eliminated players (already in the match history)
+	pipe.Del(rc.ctx, redis_utils.FormatLobbyEliminatedKey(lobbyId))
+
+	// Delete the spectators
+	pipe.Del(rc.ctx, redis_utils.FormatLobbySpectatorsKey(lobbyId))
+
+	// Delete the chat history and the muted players
+	pipe.Del(rc.ctx, redis_utils.FormatLobbyChatKey(lobbyId))
+	pipe.Del(rc.ctx, redis_utils.FormatLobbyMutedKey(lobbyId))
+
 	// Execute pipeline
 	_, err := pipe.Exec(rc.ctx)
 	if err != nil {
@@ -189,14 +212,12 @@ func (rc *RedisClient) DeleteGameLobby(lobbyId string) error {
 // Key format: "lobby:{id}"
 // Updates the GameHasBegun field to true
 func (rc *RedisClient) CloseLobby(lobbyId string) error {
-	lobby, err := rc.GetGameLobby(lobbyId)
+	_, err := rc.UpdateGameLobby(lobbyId, func(lobby *redis_models.GameLobby) error {
+		lobby.GameHasBegun = true
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("error getting lobby for closing: %v", err)
-	}
-
-	lobby.GameHasBegun = true
-	if err := rc.SaveGameLobby(lobby); err != nil {
-		return fmt.Errorf("error saving closed lobby: %v", err)
+		return fmt.Errorf("error closing lobby: %v", err)
 	}
 	return nil
 }
@@ -235,12 +256,7 @@ func (rc *RedisClient) SetPackContents(key string, contents redis_models.PackCon
 }
 
 func (rc *RedisClient) UpdateDeckPlayer(player redis_models.InGamePlayer) error {
-	key := redis_utils.FormatInGamePlayerKey(player.Username)
-	data, err := json.Marshal(player)
-	if err != nil {
-		return fmt.Errorf("error marshaling player data: %v", err)
-	}
-	return rc.client.Set(rc.ctx, key, data, 24*time.Hour).Err()
+	return rc.SaveInGamePlayer(&player)
 }
 
 func (rc *RedisClient) GetCurrentBlind(lobbyId string) (int, error) {
@@ -252,51 +268,71 @@ func (rc *RedisClient) GetCurrentBlind(lobbyId string) (int, error) {
 }
 
 func (rc *RedisClient) SetCurrentHighBlind(lobbyId string, blind int, proposerUsername string) error {
-	lobby, err := rc.GetGameLobby(lobbyId)
+	_, err := rc.UpdateGameLobby(lobbyId, func(lobby *redis_models.GameLobby) error {
+		lobby.CurrentHighBlind = blind
+		lobby.HighestBlindProposer = proposerUsername
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("error getting lobby for current blind: %v", err)
+		return fmt.Errorf("error updating current blind: %v", err)
 	}
-
-	lobby.CurrentHighBlind = blind
-	lobby.HighestBlindProposer = proposerUsername
-
-	return rc.SaveGameLobby(lobby)
+	return nil
 }
 
-// GetAllPlayersInLobby retrieves all players in a specific lobby
+// GetAllPlayersInLobby retrieves all players in a specific lobby, using the players
+// set of the lobby and a single MGET of their game states
+// Key format: "lobby:{id}:players"
 func (rc *RedisClient) GetAllPlayersInLobby(lobbyId string) ([]redis_models.InGamePlayer, error) {
+	playersKey := redis_utils.FormatLobbyPlayersKey(lobbyId)
+	usernames, err := rc.client.SMembers(rc.ctx, playersKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting lobby players: %v", err)
+	}
+
 	var players []redis_models.InGamePlayer
-	pattern := "player:*:game"
-	var cursor uint64
+	if len(usernames) == 0 {
+		return players, nil
+	}
 
-	for {
-		keys, nextCursor, err := rc.client.Scan(rc.ctx, cursor, pattern, 10).Result()
-		if err != nil {
-			return nil, fmt.Errorf("error scanning for players: %v", err)
+	// Sorted, so every caller iterates the players in the same order
+	sort.Strings(usernames)
+
+	keys := make([]string, len(usernames))
+	for i, username := range usernames {
+		keys[i] = redis_utils.FormatInGamePlayerKey(username)
+	}
+
+	values, err := rc.client.MGet(rc.ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting players data: %v", err)
+	}
+
+	// Members whose game state expired or belongs to another lobby are removed from the set
+	var stale []interface{}
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			stale = append(stale, usernames[i])
+			continue
 		}
 
-		for _, key := range keys {
-			data, err := rc.client.Get(rc.ctx, key).Bytes()
-			if err != nil {
-				log.Printf("error getting player data for key %s: %v", key, err)
-				continue
-			}
-
-			var player redis_models.InGamePlayer
-			if err := json.Unmarshal(data, &player); err != nil {
-				log.Printf("error unmarshaling player data for key %s: %v", key, err)
-				continue
-			}
-
-			if player.LobbyId == lobbyId {
-				players = append(players, player)
-			}
+		var player redis_models.InGamePlayer
+		if err := json.Unmarshal([]byte(data), &player); err != nil {
+			log.Printf("error unmarshaling player data for key %s: %v", keys[i], err)
+			continue
 		}
 
-		if nextCursor == 0 {
-			break
+		if player.LobbyId != lobbyId {
+			stale = append(stale, usernames[i])
+			continue
+		}
+		players = append(players, player)
+	}
+
+	if len(stale) > 0 {
+		if err := rc.client.SRem(rc.ctx, playersKey, stale...).Err(); err != nil {
+			log.Printf("[REDIS-WARNING] Error removing stale players from lobby %s: %v", lobbyId, err)
 		}
-		cursor = nextCursor
 	}
 
 	return players, nil
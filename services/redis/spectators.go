@@ -0,0 +1,49 @@
+package redis
+
+import (
+	redis_utils "Nogler/services/redis/utils"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// AddSpectator adds a user to the spectators of a lobby
+// Key format: "lobby:{id}:spectators" (set of usernames)
+func (rc *RedisClient) AddSpectator(lobbyId string, username string) error {
+	key := redis_utils.FormatLobbySpectatorsKey(lobbyId)
+	pipe := rc.client.TxPipeline()
+	pipe.SAdd(rc.ctx, key, username)
+	pipe.Expire(rc.ctx, key, 24*time.Hour)
+	if _, err := pipe.Exec(rc.ctx); err != nil {
+		return fmt.Errorf("error adding spectator: %v", err)
+	}
+	return nil
+}
+
+// RemoveSpectator removes a user from the spectators of a lobby
+// Returns: whether the user was spectating the lobby
+func (rc *RedisClient) RemoveSpectator(lobbyId string, username string) (bool, error) {
+	removed, err := rc.client.SRem(rc.ctx, redis_utils.FormatLobbySpectatorsKey(lobbyId), username).Result()
+	if err != nil {
+		return false, fmt.Errorf("error removing spectator: %v", err)
+	}
+	return removed > 0, nil
+}
+
+// RemoveAllSpectators removes every spectator of a lobby
+func (rc *RedisClient) RemoveAllSpectators(lobbyId string) error {
+	if err := rc.client.Del(rc.ctx, redis_utils.FormatLobbySpectatorsKey(lobbyId)).Err(); err != nil {
+		return fmt.Errorf("error removing spectators: %v", err)
+	}
+	return nil
+}
+
+// GetSpectators returns the usernames of the spectators of a lobby, sorted
+func (rc *RedisClient) GetSpectators(lobbyId string) ([]string, error) {
+	spectators, err := rc.client.SMembers(rc.ctx, redis_utils.FormatLobbySpectatorsKey(lobbyId)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting spectators: %v", err)
+	}
+	sort.Strings(spectators)
+	return spectators, nil
+}
@@ -0,0 +1,48 @@
+package redis
+
+import (
+	redis_utils "Nogler/services/redis/utils"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Deletes the connection of a user only if it's still the given socket, so a socket that
+// closes after the user has connected again doesn't remove the new connection
+var deleteUserConnectionScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// SetUserConnection stores the socket a user is connected with, in any server instance
+// Key format: "user:{username}:connection"
+// TTL: 24 hours
+func (rc *RedisClient) SetUserConnection(username string, socketId string) error {
+	if err := rc.client.Set(rc.ctx, redis_utils.FormatUserConnectionKey(username), socketId, 24*time.Hour).Err(); err != nil {
+		return fmt.Errorf("error saving user connection: %v", err)
+	}
+	return nil
+}
+
+// DeleteUserConnection removes the connection of a user if it's still the given socket
+// Returns: whether the connection was removed
+func (rc *RedisClient) DeleteUserConnection(username string, socketId string) (bool, error) {
+	deleted, err := deleteUserConnectionScript.Run(rc.ctx, rc.client,
+		[]string{redis_utils.FormatUserConnectionKey(username)}, socketId).Int()
+	if err != nil {
+		return false, fmt.Errorf("error deleting user connection: %v", err)
+	}
+	return deleted > 0, nil
+}
+
+// IsUserConnected returns whether a user is connected to any server instance
+func (rc *RedisClient) IsUserConnected(username string) (bool, error) {
+	count, err := rc.client.Exists(rc.ctx, redis_utils.FormatUserConnectionKey(username)).Result()
+	if err != nil {
+		return false, fmt.Errorf("error checking user connection: %v", err)
+	}
+	return count > 0, nil
+}
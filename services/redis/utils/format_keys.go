@@ -16,6 +16,46 @@ func FormatLobbyKey(lobbyId string) string {
 	return fmt.Sprintf("lobby:%s", lobbyId)
 }
 
+func FormatLobbyPlayersKey(lobbyId string) string {
+	return fmt.Sprintf("lobby:%s:players", lobbyId)
+}
+
 func FormatPackKey(lobbyId string, currentRound int, itemId int) string {
 	return fmt.Sprintf("lobby:%s:round:%d:item_id:%d", lobbyId, currentRound, itemId)
 }
+
+func FormatLobbyPhaseTimerKey(lobbyId string) string {
+	return fmt.Sprintf("lobby:%s:phase_timer", lobbyId)
+}
+
+func FormatLobbyEventsKey(lobbyId string) string {
+	return fmt.Sprintf("lobby:%s:events", lobbyId)
+}
+
+func FormatLobbyEliminatedKey(lobbyId string) string {
+	return fmt.Sprintf("lobby:%s:eliminated", lobbyId)
+}
+
+func FormatLobbySpectatorsKey(lobbyId string) string {
+	return fmt.Sprintf("lobby:%s:spectators", lobbyId)
+}
+
+func FormatPlayerDisconnectTimerKey(username string) string {
+	return fmt.Sprintf("player:%s:disconnect_timer", username)
+}
+
+func FormatUserConnectionKey(username string) string {
+	return fmt.Sprintf("user:%s:connection", username)
+}
+
+func FormatRateLimitKey(name string, identity string) string {
+	return fmt.Sprintf("rate_limit:%s:%s", name, identity)
+}
+
+func FormatLobbyChatKey(lobbyId string) string {
+	return fmt.Sprintf("lobby:%s:chat", lobbyId)
+}
+
+func FormatLobbyMutedKey(lobbyId string) string {
+	return fmt.Sprintf("lobby:%s:muted", lobbyId)
+}
@@ -0,0 +1,174 @@
+package socketio_adapter
+
+import (
+	"Nogler/services/redis"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/zishang520/socket.io-go-parser/v2/parser"
+	"github.com/zishang520/socket.io/v2/socket"
+)
+
+// Types of the messages exchanged between server instances
+const (
+	messageBroadcast         = "broadcast"
+	messageAddSockets        = "add_sockets"
+	messageDelSockets        = "del_sockets"
+	messageDisconnectSockets = "disconnect_sockets"
+)
+
+// Message sent to the other server instances through Redis
+type adapterMessage struct {
+	Node   string                   `json:"node"` // Instance that sent the message
+	Type   string                   `json:"type"`
+	Packet *parser.Packet           `json:"packet,omitempty"`
+	Opts   *socket.BroadcastOptions `json:"opts,omitempty"`
+	Rooms  []socket.Room            `json:"rooms,omitempty"`
+	Close  bool                     `json:"close,omitempty"`
+}
+
+// RedisAdapterBuilder creates the Redis adapter of every namespace of the socket.io server.
+// With it, several instances of the server can run behind a load balancer: broadcasts and
+// room changes made in one instance are published in Redis and applied by all of them to
+// the sockets they have connected.
+// Acknowledgements and FetchSockets only reach the sockets of the local instance
+type RedisAdapterBuilder struct {
+	RedisClient *redis.RedisClient
+	node        string
+}
+
+// NewRedisAdapterBuilder returns the adapter builder of this server instance
+func NewRedisAdapterBuilder(redisClient *redis.RedisClient) *RedisAdapterBuilder {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return &RedisAdapterBuilder{
+		RedisClient: redisClient,
+		node:        hex.EncodeToString(id),
+	}
+}
+
+type redisAdapter struct {
+	socket.Adapter
+
+	redisClient *redis.RedisClient
+	node        string
+	channel     string
+	closed      chan struct{}
+	closeOnce   sync.Once
+}
+
+func (b *RedisAdapterBuilder) New(nsp socket.Namespace) socket.Adapter {
+	a := &redisAdapter{
+		Adapter:     socket.MakeAdapter(),
+		redisClient: b.RedisClient,
+		node:        b.node,
+		channel:     "socket.io#" + nsp.Name(),
+		closed:      make(chan struct{}),
+	}
+	a.Prototype(a)
+	a.Construct(nsp)
+
+	go a.listen()
+	return a
+}
+
+// Applies the messages published by the other server instances
+func (a *redisAdapter) listen() {
+	subscription := a.redisClient.Subscribe(a.channel)
+	defer subscription.Close()
+
+	messages := subscription.Channel()
+	for {
+		select {
+		case <-a.closed:
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			var msg adapterMessage
+			if err := json.Unmarshal([]byte(message.Payload), &msg); err != nil {
+				log.Printf("[ADAPTER-ERROR] Discarding invalid message: %v", err)
+				continue
+			}
+			if msg.Node == a.node {
+				continue
+			}
+			a.apply(msg)
+		}
+	}
+}
+
+func (a *redisAdapter) apply(msg adapterMessage) {
+	switch msg.Type {
+	case messageBroadcast:
+		if msg.Packet != nil {
+			a.Adapter.Broadcast(msg.Packet, msg.Opts)
+		}
+	case messageAddSockets:
+		a.Adapter.AddSockets(msg.Opts, msg.Rooms)
+	case messageDelSockets:
+		a.Adapter.DelSockets(msg.Opts, msg.Rooms)
+	case messageDisconnectSockets:
+		a.Adapter.DisconnectSockets(msg.Opts, msg.Close)
+	default:
+		log.Printf("[ADAPTER-ERROR] Unknown message type %s", msg.Type)
+	}
+}
+
+// Sends a message to the other server instances, unless the operation is only local
+func (a *redisAdapter) publish(msg adapterMessage) {
+	if msg.Opts != nil && msg.Opts.Flags != nil && msg.Opts.Flags.Local {
+		return
+	}
+
+	msg.Node = a.node
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("[ADAPTER-ERROR] Error encoding %s message: %v", msg.Type, err)
+		return
+	}
+	if err := a.redisClient.Publish(a.channel, data); err != nil {
+		log.Printf("[ADAPTER-ERROR] %v", err)
+	}
+}
+
+func (a *redisAdapter) Broadcast(packet *parser.Packet, opts *socket.BroadcastOptions) {
+	a.publish(adapterMessage{Type: messageBroadcast, Packet: packet, Opts: opts})
+	a.Adapter.Broadcast(packet, opts)
+}
+
+func (a *redisAdapter) AddSockets(opts *socket.BroadcastOptions, rooms []socket.Room) {
+	a.publish(adapterMessage{Type: messageAddSockets, Opts: opts, Rooms: rooms})
+	a.Adapter.AddSockets(opts, rooms)
+}
+
+func (a *redisAdapter) DelSockets(opts *socket.BroadcastOptions, rooms []socket.Room) {
+	a.publish(adapterMessage{Type: messageDelSockets, Opts: opts, Rooms: rooms})
+	a.Adapter.DelSockets(opts, rooms)
+}
+
+func (a *redisAdapter) DisconnectSockets(opts *socket.BroadcastOptions, status bool) {
+	a.publish(adapterMessage{Type: messageDisconnectSockets, Opts: opts, Close: status})
+	a.Adapter.DisconnectSockets(opts, status)
+}
+
+// Returns the number of server instances listening to the namespace
+func (a *redisAdapter) ServerCount() int64 {
+	count, err := a.redisClient.CountSubscribers(a.channel)
+	if err != nil || count < 1 {
+		return 1
+	}
+	return count
+}
+
+func (a *redisAdapter) Close() {
+	a.closeOnce.Do(func() {
+		close(a.closed)
+	})
+	a.Adapter.Close()
+}
@@ -0,0 +1,95 @@
+package socketio_events
+
+import (
+	"Nogler/services/app_errors"
+	"log"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zishang520/socket.io/v2/socket"
+)
+
+// Context of an event being handled. The handler answers through it: with an acknowledgement
+// if the client asked for one, or with regular events otherwise
+type Context struct {
+	Client   *socket.Socket
+	Username string
+	Event    string
+
+	ack      socket.Ack
+	answered bool
+	mu       sync.Mutex
+}
+
+// NewContext returns the context of an event. ack may be nil if the client didn't ask for an
+// acknowledgement
+func NewContext(client *socket.Socket, username string, event string, ack socket.Ack) *Context {
+	return &Context{
+		Client:   client,
+		Username: username,
+		Event:    event,
+		ack:      ack,
+	}
+}
+
+// HasAck tells if the client is waiting for an acknowledgement
+func (c *Context) HasAck() bool {
+	return c != nil && c.ack != nil
+}
+
+// Reply answers the event with data: as the acknowledgement if the client asked for one, and
+// as the given event otherwise
+func (c *Context) Reply(event string, data any) {
+	if c == nil {
+		return
+	}
+	if c.answerAck(gin.H{"ok": true, "data": data}) {
+		return
+	}
+	c.Client.Emit(event, data)
+}
+
+// Fail answers the event with an error of the catalogue: as the acknowledgement if the client
+// asked for one, and as the "error" event otherwise. It does nothing with a nil context, so
+// helpers shared with the AI players can report errors without a client
+func (c *Context) Fail(def app_errors.Definition, details ...app_errors.Details) {
+	if c == nil {
+		return
+	}
+	c.FailWith(def.New(details...))
+}
+
+// FailWith answers the event with an already built error. The event is added to its details
+func (c *Context) FailWith(err *app_errors.Error) {
+	if c == nil {
+		return
+	}
+	if err.Details == nil {
+		err.Details = app_errors.Details{}
+	}
+	err.Details["event"] = c.Event
+	log.Printf("[EVENT-ERROR] %s failed for %s: %v", c.Event, c.Username, err)
+	if c.answerAck(gin.H{"ok": false, "error": err}) {
+		return
+	}
+	c.Client.Emit("error", err)
+}
+
+// Calls the acknowledgement, only once. Returns false if the client didn't ask for it
+func (c *Context) answerAck(response gin.H) bool {
+	if c.ack == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.answered {
+		c.answered = true
+		c.ack([]any{response}, nil)
+	}
+	return true
+}
+
+// Acknowledges the event if the handler didn't answer it, so the client isn't left waiting
+func (c *Context) finish() {
+	c.answerAck(gin.H{"ok": true})
+}
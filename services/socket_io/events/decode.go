@@ -0,0 +1,114 @@
+package socketio_events
+
+import (
+	"Nogler/services/app_errors"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Decode fills req, a pointer to a struct, with the arguments of an event:
+//   - Fields tagged with `arg:"N"` take the N-th argument, so positional events like
+//     emit("kick_from_lobby", lobbyID, username) map to a struct
+//   - If no field has an arg tag, the first argument is decoded as the whole struct, for
+//     events that send a single object (or a type with its own UnmarshalJSON)
+//
+// Arguments are converted through JSON, so numbers sent by JavaScript clients fit into int
+// fields. Missing arguments leave the field empty, for Validate to reject if it's required.
+// Fields of the wrong type are rejected with the "field" and "expected" (type) details
+func Decode(args []any, req any) *app_errors.Error {
+	v := reflect.ValueOf(req).Elem()
+	t := v.Type()
+
+	positional := false
+	for i := 0; i < t.NumField(); i++ {
+		tag, ok := t.Field(i).Tag.Lookup("arg")
+		if !ok {
+			continue
+		}
+		positional = true
+
+		index, err := strconv.Atoi(tag)
+		if err != nil {
+			panic(fmt.Sprintf("invalid arg tag %q in %s.%s", tag, t.Name(), t.Field(i).Name))
+		}
+		if index >= len(args) || args[index] == nil {
+			continue
+		}
+		if err := decodeValue(args[index], v.Field(i).Addr().Interface()); err != nil {
+			return app_errors.InvalidField.New(app_errors.Details{
+				"field":    fieldName(t.Field(i)),
+				"expected": kindName(t.Field(i).Type),
+			})
+		}
+	}
+
+	if positional || len(args) == 0 || args[0] == nil {
+		return nil
+	}
+	if err := decodeValue(args[0], req); err != nil {
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Field != "" {
+			return app_errors.InvalidField.New(app_errors.Details{
+				"field":    typeErr.Field,
+				"expected": kindName(typeErr.Type),
+			})
+		}
+		return app_errors.InvalidPayload.New(app_errors.Reason(err.Error()))
+	}
+	return nil
+}
+
+// DecodeTuple decodes a JSON array into the given pointers, one element each. It's meant for
+// the UnmarshalJSON of payloads sent as arrays, like [[1, 2], ["user"]]
+func DecodeTuple(data []byte, dst ...any) error {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return err
+	}
+	for i := 0; i < len(dst) && i < len(elements); i++ {
+		if err := json.Unmarshal(elements[i], dst[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeValue(arg any, dst any) error {
+	data, err := json.Marshal(arg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// Name of a field in the errors: its JSON name, as the clients know it
+func fieldName(field reflect.StructField) string {
+	if tag := field.Tag.Get("json"); tag != "" {
+		if name, _, _ := strings.Cut(tag, ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func kindName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return kindName(t.Elem())
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	default:
+		return "object"
+	}
+}
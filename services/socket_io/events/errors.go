@@ -0,0 +1,13 @@
+package socketio_events
+
+import (
+	"Nogler/services/app_errors"
+
+	"github.com/zishang520/socket.io/v2/socket"
+)
+
+// EmitError sends an error of the catalogue to a client outside of any event, like when its
+// connection is being authenticated
+func EmitError(client *socket.Socket, def app_errors.Definition, details ...app_errors.Details) {
+	client.Emit("error", def.New(details...))
+}
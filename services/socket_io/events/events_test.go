@@ -0,0 +1,120 @@
+package socketio_events_test
+
+import (
+	"Nogler/services/app_errors"
+	socketio_events "Nogler/services/socket_io/events"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type kickRequest struct {
+	LobbyID  string `arg:"0" json:"lobby_id" validate:"required"`
+	Username string `arg:"1" json:"username" validate:"required,max=8"`
+}
+
+type blindRequest struct {
+	Blind   int    `arg:"0" json:"blind" validate:"required,min=1,max=100"`
+	Allowed *bool  `arg:"1" json:"allowed" validate:"required"`
+	Phase   string `arg:"2" json:"phase" validate:"oneof=blind shop"`
+}
+
+type handRequest struct {
+	Cards []card `json:"cards" validate:"required,max=2"`
+}
+
+type card struct {
+	Rank string `json:"rank" validate:"required"`
+}
+
+type tupleRequest struct {
+	Modifiers []int
+	Players   []string
+}
+
+func (r *tupleRequest) UnmarshalJSON(data []byte) error {
+	return socketio_events.DecodeTuple(data, &r.Modifiers, &r.Players)
+}
+
+func TestDecodePositionalArguments(t *testing.T) {
+	var req kickRequest
+	require.Nil(t, socketio_events.Decode([]any{"lobby", "bob"}, &req))
+	assert.Equal(t, kickRequest{LobbyID: "lobby", Username: "bob"}, req)
+
+	// JavaScript numbers arrive as float64
+	var blind blindRequest
+	require.Nil(t, socketio_events.Decode([]any{float64(20), true}, &blind))
+	assert.Equal(t, 20, blind.Blind)
+	assert.True(t, *blind.Allowed)
+
+	err := socketio_events.Decode([]any{"20"}, &blind)
+	require.NotNil(t, err)
+	assert.Equal(t, app_errors.InvalidField.Code, err.Code)
+	assert.Equal(t, app_errors.Details{"field": "blind", "expected": "integer"}, err.Details)
+}
+
+func TestDecodeObjectArgument(t *testing.T) {
+	var req handRequest
+	require.Nil(t, socketio_events.Decode([]any{map[string]any{"cards": []any{map[string]any{"rank": "A"}}}}, &req))
+	assert.Equal(t, []card{{Rank: "A"}}, req.Cards)
+
+	err := socketio_events.Decode([]any{map[string]any{"cards": "A"}}, &handRequest{})
+	require.NotNil(t, err)
+	assert.Equal(t, app_errors.InvalidField.Code, err.Code)
+	assert.Equal(t, "cards", err.Details["field"])
+
+	var tuple tupleRequest
+	require.Nil(t, socketio_events.Decode([]any{[]any{[]any{float64(1), float64(3)}, []any{"alice"}}}, &tuple))
+	assert.Equal(t, []int{1, 3}, tuple.Modifiers)
+	assert.Equal(t, []string{"alice"}, tuple.Players)
+}
+
+func TestValidate(t *testing.T) {
+	allowed := false
+	tests := []struct {
+		name  string
+		req   any
+		code  app_errors.Definition
+		field string
+	}{
+		{"valid", &kickRequest{LobbyID: "lobby", Username: "bob"}, app_errors.Definition{}, ""},
+		{"missing string", &kickRequest{Username: "bob"}, app_errors.MissingField, "lobby_id"},
+		{"too long", &kickRequest{LobbyID: "lobby", Username: "bartholomew"}, app_errors.InvalidField, "username"},
+		{"length in characters", &kickRequest{LobbyID: "lobby", Username: "ñañañaña"}, app_errors.Definition{}, ""},
+		{"too many characters", &kickRequest{LobbyID: "lobby", Username: "😀😀😀😀😀😀😀😀😀"}, app_errors.InvalidField, "username"},
+		{"valid number", &blindRequest{Blind: 5, Allowed: &allowed, Phase: "shop"}, app_errors.Definition{}, ""},
+		{"below min", &blindRequest{Blind: -5, Allowed: &allowed}, app_errors.InvalidField, "blind"},
+		{"above max", &blindRequest{Blind: 500, Allowed: &allowed}, app_errors.InvalidField, "blind"},
+		{"false is not missing", &blindRequest{Blind: 5, Allowed: &allowed}, app_errors.Definition{}, ""},
+		{"missing pointer", &blindRequest{Blind: 5}, app_errors.MissingField, "allowed"},
+		{"not one of", &blindRequest{Blind: 5, Allowed: &allowed, Phase: "vouchers"}, app_errors.InvalidField, "phase"},
+		{"too many items", &handRequest{Cards: []card{{"A"}, {"K"}, {"Q"}}}, app_errors.InvalidField, "cards"},
+		{"nested", &handRequest{Cards: []card{{"A"}, {}}}, app_errors.MissingField, "cards[1].rank"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := socketio_events.Validate(tt.req)
+			if tt.code.Code == "" {
+				assert.Nil(t, err)
+				return
+			}
+			require.NotNil(t, err)
+			assert.Equal(t, tt.code.Code, err.Code)
+			assert.Equal(t, tt.field, err.Details["field"])
+		})
+	}
+}
+
+func TestValidateDetails(t *testing.T) {
+	allowed := true
+	err := socketio_events.Validate(&blindRequest{Blind: 500, Allowed: &allowed})
+	require.NotNil(t, err)
+	assert.Equal(t, app_errors.Details{"field": "blind", "rule": "max", "param": "100"}, err.Details)
+
+	data, jsonErr := json.Marshal(err)
+	require.NoError(t, jsonErr)
+	assert.JSONEq(t, `{"code":"invalid_field","message":"A field has an invalid value","details":{"field":"blind","rule":"max","param":"100"}}`, string(data))
+}
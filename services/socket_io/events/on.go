@@ -0,0 +1,52 @@
+package socketio_events
+
+import (
+	"Nogler/services/app_errors"
+	"log"
+	"runtime/debug"
+
+	"github.com/zishang520/socket.io/v2/socket"
+)
+
+// Handler of an event whose payload has been decoded and validated into a T
+type Handler[T any] func(ctx *Context, req *T)
+
+// NoPayload is the request of the events that don't have arguments
+type NoPayload struct{}
+
+// On registers the handler of an event of a client. The arguments of the event are decoded
+// into a new T (see Decode) and validated (see Validate) before calling the handler, and
+// the client receives an error with the offending field if they are wrong.
+// If the client sends an acknowledgement callback, the handler's Reply and Fail answer it;
+// the event is acknowledged anyway once the handler returns
+func On[T any](client *socket.Socket, username string, event string, handler Handler[T]) {
+	client.On(event, func(args ...any) {
+		var ack socket.Ack
+		if len(args) > 0 {
+			if fn, ok := args[len(args)-1].(socket.Ack); ok {
+				ack = fn
+				args = args[:len(args)-1]
+			}
+		}
+		ctx := NewContext(client, username, event, ack)
+
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[EVENT-ERROR] Panic handling %s for %s: %v\n%s", event, username, r, debug.Stack())
+				ctx.Fail(app_errors.Internal, app_errors.Reason("Unexpected error handling the event"))
+			}
+			ctx.finish()
+		}()
+
+		req := new(T)
+		if err := Decode(args, req); err != nil {
+			ctx.FailWith(err)
+			return
+		}
+		if err := Validate(req); err != nil {
+			ctx.FailWith(err)
+			return
+		}
+		handler(ctx, req)
+	})
+}
@@ -0,0 +1,20 @@
+package socketio_events
+
+import (
+	"Nogler/services/app_errors"
+	"Nogler/services/rate_limit"
+	"Nogler/services/redis"
+)
+
+// RateLimited wraps the handler of an event with the limit of the event in rate_limit.Limits,
+// counted per user. Rejected events get the rate_limited error
+func RateLimited[T any](redisClient *redis.RedisClient, handler Handler[T]) Handler[T] {
+	return func(ctx *Context, req *T) {
+		allowed, retryAfter := rate_limit.Allow(redisClient, ctx.Event, ctx.Username)
+		if !allowed {
+			ctx.Fail(app_errors.RateLimited, app_errors.Details{"retry_after_ms": retryAfter.Milliseconds()})
+			return
+		}
+		handler(ctx, req)
+	}
+}
@@ -0,0 +1,153 @@
+package socketio_events
+
+import (
+	"Nogler/services/app_errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validate checks the `validate` tags of the fields of req, a pointer to a struct. The rules
+// are separated by commas:
+//   - required: the field can't be empty (a zero number, an empty string or list, a nil pointer)
+//   - min=N, max=N: bounds of a number, or of the length of a string (in characters, not
+//     bytes), list or object
+//   - oneof=a b c: the field must be one of the values separated by spaces
+//
+// Empty fields that aren't required skip the other rules. Nested structs (and lists of
+// structs) are validated too. The error has the "field" detail, plus the "rule" and "param"
+// that failed if the field isn't missing
+func Validate(req any) *app_errors.Error {
+	return validateStruct(reflect.ValueOf(req).Elem(), "")
+}
+
+func validateStruct(v reflect.Value, prefix string) *app_errors.Error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := prefix + fieldName(field)
+		value := v.Field(i)
+
+		if err := validateField(value, name, field.Tag.Get("validate")); err != nil {
+			return err
+		}
+		if err := validateNested(value, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateNested(v reflect.Value, name string) *app_errors.Error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return validateNested(v.Elem(), name)
+		}
+	case reflect.Struct:
+		return validateStruct(v, name+".")
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateNested(v.Index(i), fmt.Sprintf("%s[%d]", name, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateField(v reflect.Value, name string, rules string) *app_errors.Error {
+	if rules == "" {
+		return nil
+	}
+
+	if isEmpty(v) {
+		for _, rule := range strings.Split(rules, ",") {
+			if rule == "required" {
+				return app_errors.MissingField.New(app_errors.Details{"field": name})
+			}
+		}
+		return nil
+	}
+
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	for _, rule := range strings.Split(rules, ",") {
+		ruleName, param, _ := strings.Cut(rule, "=")
+		valid := true
+		switch ruleName {
+		case "required":
+			continue
+		case "min":
+			valid = compare(v, param) >= 0
+		case "max":
+			valid = compare(v, param) <= 0
+		case "oneof":
+			valid = false
+			value := fmt.Sprint(v.Interface())
+			for _, option := range strings.Fields(param) {
+				if option == value {
+					valid = true
+					break
+				}
+			}
+		default:
+			panic(fmt.Sprintf("unknown validation rule %q of %s", ruleName, name))
+		}
+		if !valid {
+			return app_errors.InvalidField.New(app_errors.Details{"field": name, "rule": ruleName, "param": param})
+		}
+	}
+	return nil
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
+
+// Compares a number, or the length of a string, list or object, with param
+func compare(v reflect.Value, param string) int {
+	bound, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic(fmt.Sprintf("invalid validation bound %q", param))
+	}
+
+	var value float64
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		value = v.Float()
+	case reflect.String:
+		// Counted in characters, like chat.MaxMessageLength
+		value = float64(utf8.RuneCountInString(v.String()))
+	case reflect.Slice, reflect.Map, reflect.Array:
+		value = float64(v.Len())
+	default:
+		panic(fmt.Sprintf("min and max can't be used with %s", v.Kind()))
+	}
+
+	switch {
+	case value < bound:
+		return -1
+	case value > bound:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	models "Nogler/models/postgres"
+	"Nogler/services/app_errors"
+	"Nogler/services/redis"
+	socketio_events "Nogler/services/socket_io/events"
+	socketio_types "Nogler/services/socket_io/types"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zishang520/socket.io/v2/socket"
+	"gorm.io/gorm"
+)
+
+// Sends the last messages of the lobby chat to a user that has just joined (or come back to)
+// the lobby. The users that were already there received them as new_lobby_message
+func sendChatHistory(redisClient *redis.RedisClient, client *socket.Socket, lobbyID string) {
+	messages, err := redisClient.GetChatHistory(lobbyID)
+	if err != nil {
+		log.Printf("[CHAT-ERROR] Error getting chat history of lobby %s: %v", lobbyID, err)
+		return
+	}
+	client.Emit("lobby_chat_history", gin.H{
+		"lobby_id": lobbyID,
+		"messages": messages,
+	})
+}
+
+// Handles mute_player: the host prevents a player from sending messages to the lobby chat
+func HandleMutePlayer(redisClient *redis.RedisClient, client *socket.Socket,
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[MuteRequest] {
+	return func(ctx *socketio_events.Context, req *MuteRequest) {
+		if !validateMute(ctx, db, username, req) {
+			return
+		}
+
+		muted, err := redisClient.MutePlayer(req.LobbyID, req.Username)
+		if err != nil {
+			log.Printf("[CHAT-ERROR] Error muting %s in lobby %s: %v", req.Username, req.LobbyID, err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error muting the player"))
+			return
+		}
+
+		log.Printf("[CHAT] %s muted %s in lobby %s", username, req.Username, req.LobbyID)
+		if muted {
+			sio.Sio_server.To(socket.Room(req.LobbyID)).Emit("player_muted", gin.H{
+				"lobby_id": req.LobbyID,
+				"username": req.Username,
+			})
+		}
+		ctx.Reply("mute_player_success", gin.H{"lobby_id": req.LobbyID, "username": req.Username})
+	}
+}
+
+// Handles unmute_player: the host lets a muted player send messages again
+func HandleUnmutePlayer(redisClient *redis.RedisClient, client *socket.Socket,
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[MuteRequest] {
+	return func(ctx *socketio_events.Context, req *MuteRequest) {
+		if !validateMute(ctx, db, username, req) {
+			return
+		}
+
+		unmuted, err := redisClient.UnmutePlayer(req.LobbyID, req.Username)
+		if err != nil {
+			log.Printf("[CHAT-ERROR] Error unmuting %s in lobby %s: %v", req.Username, req.LobbyID, err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error unmuting the player"))
+			return
+		}
+
+		log.Printf("[CHAT] %s unmuted %s in lobby %s", username, req.Username, req.LobbyID)
+		if unmuted {
+			sio.Sio_server.To(socket.Room(req.LobbyID)).Emit("player_unmuted", gin.H{
+				"lobby_id": req.LobbyID,
+				"username": req.Username,
+			})
+		}
+		ctx.Reply("unmute_player_success", gin.H{"lobby_id": req.LobbyID, "username": req.Username})
+	}
+}
+
+// Checks that the user is the host of the lobby and the player to (un)mute is in it
+func validateMute(ctx *socketio_events.Context, db *gorm.DB, username string, req *MuteRequest) bool {
+	var lobby models.GameLobby
+	if err := db.Where("id = ?", req.LobbyID).First(&lobby).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.Fail(app_errors.LobbyNotFound)
+		} else {
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Database error"))
+		}
+		return false
+	}
+
+	if username != lobby.CreatorUsername {
+		ctx.Fail(app_errors.NotLobbyHost)
+		return false
+	}
+
+	if req.Username == username {
+		ctx.Fail(app_errors.CannotMuteYourself)
+		return false
+	}
+
+	var player models.InGamePlayer
+	if result := db.Where("lobby_id = ? AND username = ?", req.LobbyID, req.Username).Limit(1).Find(&player); result.Error != nil {
+		ctx.Fail(app_errors.Internal, app_errors.Reason("Database error"))
+		return false
+	} else if result.RowsAffected == 0 {
+		ctx.Fail(app_errors.PlayerNotInLobby)
+		return false
+	}
+	return true
+}
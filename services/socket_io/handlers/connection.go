@@ -1,16 +1,55 @@
 package handlers
 
 import (
+	"Nogler/services/redis"
+	socketio_events "Nogler/services/socket_io/events"
 	socketio_types "Nogler/services/socket_io/types"
+	"Nogler/services/socket_io/utils/game_flow"
 	"fmt"
+	"log"
+
+	"github.com/zishang520/socket.io/v2/socket"
+	"gorm.io/gorm"
 )
 
-// Function to handle socket.io client disconnections.
-func HandleDisconnecting(username string, sio *socketio_types.SocketServer) func(args ...interface{}) {
+// Function to handle socket.io client disconnections. A player of a running game keeps their
+// seat for a grace period, after which they forfeit the game.
+func HandleDisconnecting(redisClient *redis.RedisClient, client *socket.Socket,
+	username string, sio *socketio_types.SocketServer) func(args ...interface{}) {
 	return func(args ...interface{}) {
-		// Remove connection from map
-		sio.RemoveConnection(username)
+		// Remove connection from map. The user may have already connected again with another socket
+		if !sio.RemoveConnection(username, client) {
+			log.Printf("[DISCONNECT] A replaced socket of %s just disconnected", username)
+			return
+		}
 		fmt.Println("A user just disconnected: ", username)
 		fmt.Println("Current connections: ", sio.UserConnections)
+
+		game_flow.StartDisconnectGracePeriod(redisClient, sio, username)
+	}
+}
+
+// Function to put a player that connects again back into their game: the socket rejoins the
+// lobby room and receives the chat history and the state of the current phase, as with
+// request_game_phase_player_info
+func HandleReconnection(redisClient *redis.RedisClient, client *socket.Socket,
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) {
+	lobbyID := game_flow.EndDisconnectGracePeriod(redisClient, sio, username)
+	if lobbyID == "" {
+		return
+	}
+
+	client.Join(socket.Room(lobbyID))
+	sendChatHistory(redisClient, client, lobbyID)
+
+	lobby, err := redisClient.GetGameLobby(lobbyID)
+	if err != nil {
+		log.Printf("[RECONNECTION-ERROR] Error getting lobby %s: %v", lobbyID, err)
+		return
+	}
+	if lobby.GameHasBegun {
+		ctx := socketio_events.NewContext(client, username, "request_game_phase_player_info", nil)
+		HandleRequestGamePhaseInfo(redisClient, client, db, username)(ctx, &LobbyRequest{LobbyID: lobbyID})
 	}
+	log.Printf("[RECONNECTION] %s rejoined lobby %s", username, lobbyID)
 }
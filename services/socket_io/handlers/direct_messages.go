@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	models "Nogler/models/postgres"
+	"Nogler/services/app_errors"
+	"Nogler/services/chat"
+	socketio_events "Nogler/services/socket_io/events"
+	socketio_types "Nogler/services/socket_io/types"
+	"Nogler/utils"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Handles send_direct_message: stores a private message to a friend and delivers it live if
+// they're connected (to any server instance). Otherwise they'll find it in their unread
+// messages (see controllers.GetUnreadDirectMessages)
+func HandleSendDirectMessage(db *gorm.DB, username string,
+	sio *socketio_types.SocketServer) socketio_events.Handler[DirectMessageRequest] {
+	return func(ctx *socketio_events.Context, req *DirectMessageRequest) {
+		if req.Username == username {
+			ctx.Fail(app_errors.NotFriends)
+			return
+		}
+
+		areFriends, err := utils.AreFriends(db, username, req.Username)
+		if err != nil {
+			log.Printf("[DIRECT-MESSAGE-ERROR] Error checking friendship of %s and %s: %v", username, req.Username, err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Database error"))
+			return
+		}
+		if !areFriends {
+			ctx.Fail(app_errors.NotFriends)
+			return
+		}
+
+		content := chat.Clean(req.Message)
+		if content == "" {
+			ctx.Fail(app_errors.MissingField, app_errors.Details{"field": "message"})
+			return
+		}
+
+		message := models.DirectMessage{
+			SenderUsername:   username,
+			ReceiverUsername: req.Username,
+			Content:          content,
+		}
+		if err := db.Create(&message).Error; err != nil {
+			log.Printf("[DIRECT-MESSAGE-ERROR] Error saving message of %s to %s: %v", username, req.Username, err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error saving the message"))
+			return
+		}
+
+		payload := gin.H{
+			"id":       message.ID,
+			"sender":   username,
+			"receiver": req.Username,
+			"message":  content,
+			"sent_at":  message.CreatedAt,
+		}
+
+		conn, delivered := sio.GetUser(req.Username)
+		if delivered {
+			if err := conn.Emit("new_direct_message", payload); err != nil {
+				log.Printf("[DIRECT-MESSAGE-ERROR] Error delivering message to %s: %v", req.Username, err)
+				delivered = false
+			}
+		}
+
+		payload["delivered"] = delivered
+		ctx.Reply("direct_message_sent", payload)
+	}
+}
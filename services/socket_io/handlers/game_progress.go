@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	redis_models "Nogler/models/redis"
+	"Nogler/services/app_errors"
 	"Nogler/services/redis"
+	socketio_events "Nogler/services/socket_io/events"
 	socketio_types "Nogler/services/socket_io/types"
 	socketio_utils "Nogler/services/socket_io/utils"
 	"Nogler/services/socket_io/utils/game_flow"
@@ -14,111 +17,76 @@ import (
 )
 
 func HandleProposeBlind(redisClient *redis.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string, sio *socketio_types.SocketServer) func(args ...interface{}) {
-	return func(args ...interface{}) {
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[ProposeBlindRequest] {
+	return func(ctx *socketio_events.Context, req *ProposeBlindRequest) {
 		log.Printf("[BLIND] %s is proposing a blind", username)
 
-		if len(args) < 2 {
-			log.Printf("[BLIND-ERROR] Missing arguments for user %s", username)
-			client.Emit("error", gin.H{"error": "Missing proposed blind or lobby"})
-			return
-		}
-
-		// Assert as float64 first
-		proposedBlindFloat, ok := args[0].(float64)
-		if !ok {
-			log.Printf("[BLIND-ERROR] Invalid type for proposed blind: expected number, got %T", args[0])
-			client.Emit("error", gin.H{"error": "Invalid proposed blind value"})
-			return
-		}
-		// Convert float64 to int
-		proposedBlind := int(proposedBlindFloat)
-
-		lobbyID := args[1].(string)
+		proposedBlind := req.Blind
+		lobbyID := req.LobbyID
 
 		isInLobby, err := utils.IsPlayerInLobby(db, lobbyID, username)
 		if err != nil {
 			log.Printf("[BLIND-ERROR] Database error: %v", err)
-			client.Emit("error", gin.H{"error": "Database error"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Database error"))
 			return
 		}
 
 		if !isInLobby {
 			log.Printf("[BLIND-ERROR] User is NOT in lobby: %s, Lobby: %s", username, lobbyID)
-			client.Emit("error", gin.H{"error": "You must join the lobby before proposing blinds"})
-			return
-		}
-
-		// Get the lobby from Redis
-		lobby, err := redisClient.GetGameLobby(lobbyID)
-		if err != nil {
-			log.Printf("[BLIND-ERROR] Error getting game lobby: %v", err)
-			client.Emit("error", gin.H{"error": "Error getting game lobby"})
+			ctx.Fail(app_errors.NotInLobby)
 			return
 		}
 
 		// Validate blind phase
-		valid, err := socketio_utils.ValidateBlindPhase(redisClient, client, lobbyID)
+		valid, err := socketio_utils.ValidateBlindPhase(redisClient, ctx, lobbyID)
 		if err != nil || !valid {
 			// Error already emitted in ValidateBlindPhase
 			return
 		}
 
-		// Get player data to apply the voucher?
-		player, err := redisClient.GetInGamePlayer(username)
-		if err != nil {
-			log.Printf("[BLIND-ERROR] Error getting player data: %v", err)
-			client.Emit("error", gin.H{"error": "Error getting player data"})
-			return
-		}
+		// Register the proposal and raise the blind in a single update, so concurrent
+		// proposals can't overwrite each other
+		var currentBlind int
+		blindRaised := false
+		lobby, err := redisClient.UpdateGameLobby(lobbyID, func(lobby *redis_models.GameLobby) error {
+			currentBlind = lobby.CurrentHighBlind
+			blindRaised = false
 
-		// Save player data
-		if err := redisClient.SaveInGamePlayer(player); err != nil {
-			log.Printf("[BLIND-ERROR] Error saving player data: %v", err)
-			client.Emit("error", gin.H{"error": "Error saving player data"})
-			return
-		}
+			// Increment the counter of proposed blinds (NEW, using a map to avoid same user incrementing the counter several times)
+			lobby.ProposedBlinds[username] = true
 
-		currentBlind, err := redisClient.GetCurrentBlind(lobbyID)
+			// Update current blind if this proposal is higher
+			// NOTE: checking that the proposed blind is higher than the current base blind
+			// to avoid setting the high blind to a lower value than the base blind
+			if proposedBlind > lobby.CurrentBaseBlind && proposedBlind > lobby.CurrentHighBlind {
+				lobby.CurrentHighBlind = proposedBlind
+				lobby.HighestBlindProposer = username
+				blindRaised = true
+			}
+			return nil
+		})
 		if err != nil {
-			log.Printf("[BLIND-ERROR] Error getting current blind: %v", err)
-			client.Emit("error", gin.H{"error": "Error getting current blind"})
+			log.Printf("[BLIND-ERROR] Error updating game lobby: %v", err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error saving game state"))
 			return
 		}
-
-		// Increment the counter of proposed blinds (NEW, using a map to avoid same user incrementing the counter several times)
-		lobby.ProposedBlinds[username] = true
 		log.Printf("[BLIND] Player %s proposed blind. Total proposals: %d/%d",
 			username, len(lobby.ProposedBlinds), lobby.PlayerCount)
 
-		// Save the updated lobby
-		err = redisClient.SaveGameLobby(lobby)
-		if err != nil {
-			log.Printf("[BLIND-ERROR] Error saving game lobby: %v", err)
-			client.Emit("error", gin.H{"error": "Error saving game state"})
-			return
-		}
-
-		// Update current blind if this proposal is higher
-		// NOTE: checking that the proposed blind is higher than the current base blind
-		// to avoid setting the high blind to a lower value than the base blind
-		if proposedBlind > lobby.CurrentBaseBlind && proposedBlind > currentBlind {
-			err := redisClient.SetCurrentHighBlind(lobbyID, proposedBlind, username)
-			if err != nil {
-				log.Printf("[BLIND-ERROR] Could not update current blind: %v", err)
-				client.Emit("error", gin.H{"error": "Error updating blind"})
-				return
-			}
-
+		if blindRaised {
 			// Broadcast the new blind value to everyone in the lobby
-			sio.Sio_server.To(socket.Room(lobbyID)).Emit("blind_updated", gin.H{
+			sio.Sio_server.To(socket.Room(lobbyID), socketio_utils.SpectatorsRoom(lobbyID)).Emit("blind_updated", gin.H{
 				"old_max_blind": currentBlind,
 				"new_blind":     proposedBlind,
 				"proposed_by":   username,
 			})
 		}
 
-		// If all players have proposed, start the round (no need to read the lobby again after calling redisClient.SetCurrentBlind)
+		socketio_utils.RecordGameEvent(redisClient, lobbyID, redis_models.EventProposeBlind, username, gin.H{
+			"proposed_blind": proposedBlind,
+		})
+
+		// If all players have proposed, start the round (the updated lobby already has every proposal)
 		if len(lobby.ProposedBlinds) >= lobby.PlayerCount {
 			log.Printf("[BLIND-COMPLETE] All players have proposed blinds (%d/%d). Starting round.",
 				len(lobby.ProposedBlinds), lobby.PlayerCount)
@@ -130,50 +98,50 @@ func HandleProposeBlind(redisClient *redis.RedisClient, client *socket.Socket,
 }
 
 func HandleContinueToNextBlind(redisClient *redis.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string, sio *socketio_types.SocketServer) func(args ...interface{}) {
-	return func(args ...interface{}) {
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[socketio_events.NoPayload] {
+	return func(ctx *socketio_events.Context, req *socketio_events.NoPayload) {
 		// Get player data to extract lobby ID
 		player, err := redisClient.GetInGamePlayer(username)
 		if err != nil {
 			log.Printf("[NEXT-BLIND-ERROR] Error getting player data: %v", err)
-			client.Emit("error", gin.H{"error": "Error retrieving player data"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error retrieving player data"))
 			return
 		}
 
 		lobbyID := player.LobbyId
 		if lobbyID == "" {
 			log.Printf("[NEXT-BLIND-ERROR] Player %s not in any lobby", username)
-			client.Emit("error", gin.H{"error": "You are not in any lobby"})
+			ctx.Fail(app_errors.NotInLobby)
 			return
 		}
 
 		log.Printf("[NEXT-BLIND] User %s requesting to continue to next blind in lobby %s", username, lobbyID)
 
 		// Validate the user and lobby
-		lobby, err := socketio_utils.ValidateLobbyAndUser(redisClient, client, db, username, lobbyID)
+		_, err = socketio_utils.ValidateLobbyAndUser(redisClient, ctx, db, username, lobbyID)
 		if err != nil {
 			return
 		}
 
 		// KEY, NEW: this event should only be emitted by clients during the VOUCHERS phase
-		valid, err := socketio_utils.ValidateVouchersPhase(redisClient, client, lobbyID)
+		valid, err := socketio_utils.ValidateVouchersPhase(redisClient, ctx, lobbyID)
 		if err != nil || !valid {
 			// Error already emitted in ValidateVouchersPhase
 			return
 		}
 
 		// Increment the finished vouchers counter (NEW, using maps now)
-		lobby.PlayersFinishedVouchers[username] = true
-		log.Printf("[NEXT-BLIND] Player %s ready for next blind. Total ready: %d/%d",
-			username, len(lobby.PlayersFinishedVouchers), lobby.PlayerCount)
-
-		// Save the updated lobby
-		err = redisClient.SaveGameLobby(lobby)
+		lobby, err := redisClient.UpdateGameLobby(lobbyID, func(lobby *redis_models.GameLobby) error {
+			lobby.PlayersFinishedVouchers[username] = true
+			return nil
+		})
 		if err != nil {
-			log.Printf("[NEXT-BLIND-ERROR] Error saving game lobby: %v", err)
-			client.Emit("error", gin.H{"error": "Error saving game state"})
+			log.Printf("[NEXT-BLIND-ERROR] Error updating game lobby: %v", err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error saving game state"))
 			return
 		}
+		log.Printf("[NEXT-BLIND] Player %s ready for next blind. Total ready: %d/%d",
+			username, len(lobby.PlayersFinishedVouchers), lobby.PlayerCount)
 
 		// If all players are ready, broadcast the starting_next_blind event
 		if len(lobby.PlayersFinishedVouchers) >= lobby.PlayerCount {
@@ -187,13 +155,13 @@ func HandleContinueToNextBlind(redisClient *redis.RedisClient, client *socket.So
 }
 
 func HandleContinueToVouchers(redisClient *redis.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string, sio *socketio_types.SocketServer) func(args ...interface{}) {
-	return func(args ...interface{}) {
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[socketio_events.NoPayload] {
+	return func(ctx *socketio_events.Context, req *socketio_events.NoPayload) {
 		// Get player data to extract lobby ID
 		player, err := redisClient.GetInGamePlayer(username)
 		if err != nil {
 			log.Printf("[VOUCHERS-ERROR] Error getting player data: %v", err)
-			client.Emit("error", gin.H{"error": "Error retrieving player data"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error retrieving player data"))
 			return
 		}
 
@@ -203,38 +171,38 @@ func HandleContinueToVouchers(redisClient *redis.RedisClient, client *socket.Soc
 		lobbyID := player.LobbyId
 		if lobbyID == "" {
 			log.Printf("[VOUCHERS-ERROR] Player %s not in any lobby", username)
-			client.Emit("error", gin.H{"error": "You are not in any lobby"})
+			ctx.Fail(app_errors.NotInLobby)
 			return
 		}
 
 		log.Printf("[VOUCHERS] User %s requesting to continue to vouchers phase in lobby %s", username, lobbyID)
 
 		// Validate the user and lobby
-		lobby, err := socketio_utils.ValidateLobbyAndUser(redisClient, client, db, username, lobbyID)
+		_, err = socketio_utils.ValidateLobbyAndUser(redisClient, ctx, db, username, lobbyID)
 		if err != nil {
 			return
 		}
 
 		// Validate shop phase - this endpoint should only be called during SHOP phase
-		valid, err := socketio_utils.ValidateShopPhase(redisClient, client, lobbyID)
+		valid, err := socketio_utils.ValidateShopPhase(redisClient, ctx, lobbyID)
 		if err != nil || !valid {
 			// Error already emitted in ValidateShopPhase
 			return
 		}
 
 		// Increment the finished shop counter
-		lobby.PlayersFinishedShop[username] = true
-		log.Printf("[VOUCHERS] Player %s ready for vouchers phase. Total ready: %d/%d",
-			username, len(lobby.PlayersFinishedShop), lobby.PlayerCount)
-
-		lobby.ShopState.Rerolls = 0
-		// Save the updated lobby
-		err = redisClient.SaveGameLobby(lobby)
+		lobby, err := redisClient.UpdateGameLobby(lobbyID, func(lobby *redis_models.GameLobby) error {
+			lobby.PlayersFinishedShop[username] = true
+			lobby.ShopState.Rerolls = 0
+			return nil
+		})
 		if err != nil {
-			log.Printf("[VOUCHERS-ERROR] Error saving game lobby: %v", err)
-			client.Emit("error", gin.H{"error": "Error saving game state"})
+			log.Printf("[VOUCHERS-ERROR] Error updating game lobby: %v", err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error saving game state"))
 			return
 		}
+		log.Printf("[VOUCHERS] Player %s ready for vouchers phase. Total ready: %d/%d",
+			username, len(lobby.PlayersFinishedShop), lobby.PlayerCount)
 
 		// If all players are ready, advance to the vouchers phase
 		if len(lobby.PlayersFinishedShop) >= lobby.PlayerCount {
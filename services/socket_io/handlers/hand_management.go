@@ -2,8 +2,10 @@ package handlers
 
 import (
 	redis_models "Nogler/models/redis"
+	"Nogler/services/app_errors"
 	"Nogler/services/poker"
 	"Nogler/services/redis"
+	socketio_events "Nogler/services/socket_io/events"
 	socketio_types "Nogler/services/socket_io/types"
 	socketio_utils "Nogler/services/socket_io/utils"
 	"Nogler/services/socket_io/utils/game_flow"
@@ -22,30 +24,24 @@ import (
 // El nivel al que tenemo sla mano para saber fichas y mult base
 // Ahora mismo está como string en el aproach mencionado sería 2 ints, fichas y mult
 func HandlePlayHand(redisClient *redis.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string, sio *socketio_types.SocketServer) func(args ...interface{}) {
-	return func(args ...interface{}) {
-
-		log.Printf("PlayHand iniciado - Usuario: %s, Args: %v, Socket ID: %s",
-			username, args, client.Id())
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[PlayHandRequest] {
+	return func(ctx *socketio_events.Context, req *PlayHandRequest) {
 
-		if len(args) < 1 {
-			log.Printf("[HAND-ERROR] Faltan argumentos para usuario %s", username)
-			client.Emit("error", gin.H{"error": "Falta la mano a jugar"})
-			return
-		}
+		log.Printf("PlayHand iniciado - Usuario: %s, Cartas: %v, Socket ID: %s",
+			username, req.CardIDs, client.Id())
 
 		// 1. Get player data from Redis to extract lobby ID
 		player, err := redisClient.GetInGamePlayer(username)
 		if err != nil {
 			log.Printf("[HAND-ERROR] Error getting player data: %v", err)
-			client.Emit("error", gin.H{"error": "Error al obtener los datos del jugador"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error getting player data"))
 			return
 		}
 
 		lobbyID := player.LobbyId
 		if lobbyID == "" {
 			log.Printf("[HAND-ERROR] User %s is not in a lobby", username)
-			client.Emit("error", gin.H{"error": "You must join a lobby before playing hands"})
+			ctx.Fail(app_errors.NotInLobby)
 			return
 		}
 
@@ -53,198 +49,203 @@ func HandlePlayHand(redisClient *redis.RedisClient, client *socket.Socket,
 		isInLobby, err := utils.IsPlayerInLobby(db, lobbyID, username)
 		if err != nil {
 			log.Printf("[HAND-ERROR] Database error: %v", err)
-			client.Emit("error", gin.H{"error": "Database error"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Database error"))
 			return
 		}
 
 		if !isInLobby {
 			log.Printf("[HAND-ERROR] User is NOT in lobby: %s, Lobby: %s", username, lobbyID)
-			client.Emit("error", gin.H{"error": "You must join the lobby before sending messages"})
+			ctx.Fail(app_errors.NotInLobby)
 			return
 		}
 
 		// Validate play round phase
-		valid, err := socketio_utils.ValidatePlayRoundPhase(redisClient, client, lobbyID)
+		valid, err := socketio_utils.ValidatePlayRoundPhase(redisClient, ctx, lobbyID)
 		if err != nil || !valid {
 			// Error already emitted in ValidatePlayRoundPhase
 			return
 		}
 
-		// 2. Check if the player has enough plays left
-		if player.HandPlaysLeft <= 0 {
-			log.Printf("[HAND-ERROR] No hand plays left %s", username)
-			client.Emit("error", gin.H{"error": "No hand plays left"})
-			return
-		}
-
-		handData := args[0].(map[string]interface{}) // Argument is expected to be a map (which is a generic object)
-		handJson, err := json.Marshal(handData)      // Convert the argument to JSON
-		if err != nil {
-			log.Printf("[HAND-ERROR] Error al convertir la mano a JSON: %v", err)
-			client.Emit("error", gin.H{"error": "Error al convertir la mano"})
-			return
-		}
-
-		// Parse the JSON into the poker.Hand struct
-		var hand poker.Hand
-		err = json.Unmarshal(handJson, &hand)
-		if err != nil {
-			log.Printf("[HAND-ERROR] Error al parsear la mano: %v", err)
-			client.Emit("error", gin.H{"error": "Error al procesar la mano"})
-			return
-		}
+		// Play the hand on the current player state, so concurrent plays can't use the same hand
+		// plays or cards, nor overwrite the changes made meanwhile (e.g. a joker sold)
+		var playErr *app_errors.Error
+		fail := func(def app_errors.Definition, details ...app_errors.Details) error {
+			playErr = def.New(details...)
+			return playErr
+		}
+		var (
+			hand                                  poker.Hand
+			trace                                 *poker.ScoringTrace
+			fichas, finalFichas, finalMult        int
+			finalGold, valorFinal                 int
+			handType                              poker.HandType
+			scored_cards, currentHand, newCards   []poker.Card
+			jokersTriggered                       []bool
+			destroyedJokers                       []int
+			remainingJokers                       poker.Jokers
+			activatedModifiers, receivedModifiers poker.Modifiers
+			deck                                  *poker.Deck
+		)
+		player, err = redisClient.UpdateInGamePlayer(username, func(player *redis_models.InGamePlayer) error {
+			// RNG of this event, derived from the lobby seed so it can be reproduced
+			rng, err := socketio_utils.GetLobbyRNG(redisClient, lobbyID, "play_hand", username, player.HandPlaysLeft)
+			if err != nil {
+				log.Printf("[HAND-ERROR] Error getting lobby RNG: %v", err)
+				return fail(app_errors.Internal, app_errors.Reason("Error getting lobby info"))
+			}
 
-		if (hand.Cards == nil) || (len(hand.Cards) == 0) {
-			log.Printf("[HAND-ERROR] No cards in hand for user %s", username)
-			client.Emit("error", gin.H{"error": "No cards in hand"})
-			return
-		}
+			// 2. Check if the player has enough plays left
+			if player.HandPlaysLeft <= 0 {
+				log.Printf("[HAND-ERROR] No hand plays left %s", username)
+				return fail(app_errors.NoHandPlaysLeft)
+			}
 
-		// Validate that the hand is valid for this player
-		valid, errMsg := play_round.ValidatePlayerHand(player, hand)
-		if !valid {
-			log.Printf("[HAND-ERROR] Invalid hand for user %s: %s", username, errMsg)
-			client.Emit("error", gin.H{"error": errMsg})
-			return
-		}
+			// Validate that the hand is valid for this player
+			cards, valid, errMsg := play_round.ValidatePlayerHand(player, req.CardIDs)
+			if !valid {
+				log.Printf("[HAND-ERROR] Invalid hand for user %s: %s", username, errMsg)
+				return fail(app_errors.InvalidCards, app_errors.Reason(errMsg))
+			}
 
-		// If no Gold was passed, set it to the player's money
-		if hand.Gold == 0 {
-			hand.Gold = player.PlayersMoney
-		}
-		log.Println("[HAND-PLAY-DEBUG] Username:", username, "jugando mano con oro:", hand.Gold)
+			// Score the cards against the jokers and gold stored in Redis, not the client ones
+			hand, err = play_round.PlayerScoringHand(player, cards)
+			if err != nil {
+				log.Printf("[HAND-ERROR] Error building the hand of user %s: %v", username, err)
+				return fail(app_errors.Internal, app_errors.Reason("Error processing player's jokers"))
+			}
+			log.Println("[HAND-PLAY-DEBUG] Username:", username, "jugando mano con oro:", hand.Gold)
 
-		// 3. Calculate base points
-		fichas, mult, handType, scored_cards := poker.BestHand(hand)
+			// 3. Calculate base points, recording every scoring step
+			trace = &poker.ScoringTrace{}
+			var mult int
+			fichas, mult, handType, scored_cards = poker.BestHand(hand, trace)
 
-		fichas += poker.AddChipsPerCard(scored_cards)
+			fichas += poker.AddChipsPerCard(scored_cards, trace)
 
-		enhancedFichas, enhancedMult := poker.ApplyEnhancements(fichas, mult, scored_cards)
+			enhancedFichas, enhancedMult, enhancedGold := poker.ApplyEnhancements(rng, fichas, mult, hand.Gold, scored_cards, trace)
 
-		// 4. Apply jokers (passing the hand which contains the jokers)
-		finalFichas, finalMult, finalGold, jokersTriggered := poker.ApplyJokers(hand, hand.Jokers, enhancedFichas, enhancedMult, hand.Gold, username)
+			// 4. Apply jokers (passing the hand which contains the jokers)
+			finalFichas, finalMult, finalGold, jokersTriggered = poker.ApplyJokers(rng, hand, hand.Jokers, enhancedFichas, enhancedMult, enhancedGold, username, trace)
 
-		log.Println("[HAND-PLAY-DEBUG] Jugador:", username, "despues de aplicar jokers tiene", finalGold, "oro")
-		// 5. Apply modifiers
+			log.Println("[HAND-PLAY-DEBUG] Jugador:", username, "despues de aplicar jokers tiene", finalGold, "oro")
+			// 5. Apply modifiers
 
-		// Apply activated modifiers
-		var activatedModifiers poker.Modifiers
-		if player.ActivatedModifiers != nil {
-			err = json.Unmarshal(player.ActivatedModifiers, &activatedModifiers)
-			if err != nil {
-				log.Printf("[HAND-ERROR] Error parsing activated modifiers: %v", err)
-				client.Emit("error", gin.H{"error": "Error parsing activated modifiers"})
-				return
+			// Apply activated modifiers
+			activatedModifiers = poker.Modifiers{}
+			if player.ActivatedModifiers != nil {
+				err = json.Unmarshal(player.ActivatedModifiers, &activatedModifiers)
+				if err != nil {
+					log.Printf("[HAND-ERROR] Error parsing activated modifiers: %v", err)
+					return fail(app_errors.Internal, app_errors.Reason("Error parsing activated modifiers"))
+				}
 			}
-		}
 
-		// Apply activated modifiers
-		finalFichas, finalMult, finalGold = poker.ApplyModifiers(hand, &activatedModifiers, finalFichas, finalMult, finalGold)
-		if err != nil {
-			log.Printf("[HAND-ERROR] Error applying modifiers: %v", err)
-			client.Emit("error", gin.H{"error": "Error applying modifiers"})
-			return
-		}
-		log.Println("[HAND-PLAY-DEBUG] Jugador:", username, "despues de aplicar modificadores activos tiene", finalGold, "oro")
-
-		// Apply received modifiers
-		var receivedModifiers poker.Modifiers
-		if player.ReceivedModifiers != nil {
-			err = json.Unmarshal(player.ReceivedModifiers, &receivedModifiers)
-			if err != nil {
-				log.Printf("[HAND-ERROR] Error parsing received modifiers: %v", err)
-				client.Emit("error", gin.H{"error": "Error parsing received modifiers"})
-				return
+			// Apply activated modifiers
+			finalFichas, finalMult, finalGold = poker.ApplyModifiers(rng, hand, &activatedModifiers, finalFichas, finalMult, finalGold, trace)
+			log.Println("[HAND-PLAY-DEBUG] Jugador:", username, "despues de aplicar modificadores activos tiene", finalGold, "oro")
+
+			// Apply received modifiers
+			receivedModifiers = poker.Modifiers{}
+			if player.ReceivedModifiers != nil {
+				err = json.Unmarshal(player.ReceivedModifiers, &receivedModifiers)
+				if err != nil {
+					log.Printf("[HAND-ERROR] Error parsing received modifiers: %v", err)
+					return fail(app_errors.Internal, app_errors.Reason("Error parsing received modifiers"))
+				}
 			}
-		}
-
-		// Apply received modifiers
-		finalFichas, finalMult, finalGold = poker.ApplyModifiers(hand, &receivedModifiers, finalFichas, finalMult, finalGold)
-		if err != nil {
-			log.Printf("[HAND-ERROR] Error applying modifiers: %v", err)
-			client.Emit("error", gin.H{"error": "Error applying modifiers"})
-			return
-		}
-		log.Println("[HAND-PLAY-DEBUG] Jugador:", username, "despues de aplicar modificadores recibidos tiene", finalGold, "oro")
 
-		valorFinal := finalFichas * finalMult
+			// Apply received modifiers
+			finalFichas, finalMult, finalGold = poker.ApplyModifiers(rng, hand, &receivedModifiers, finalFichas, finalMult, finalGold, trace)
+			log.Println("[HAND-PLAY-DEBUG] Jugador:", username, "despues de aplicar modificadores recibidos tiene", finalGold, "oro")
 
-		// 6. Update player data in Redis
+			valorFinal = finalFichas * finalMult
 
-		var currentHand []poker.Card
-		err = json.Unmarshal(player.CurrentHand, &currentHand)
-		if err != nil {
-			log.Printf("[HAND-ERROR] Error unmarshaling current hand: %v", err)
-			client.Emit("error", gin.H{"error": "Error processing current hand"})
-			return
-		}
-		// Delete the played hand from the current hand
-		for _, card := range hand.Cards {
-			for i, c := range currentHand {
-				if c.Suit == card.Suit && c.Rank == card.Rank {
-					currentHand = append(currentHand[:i], currentHand[i+1:]...)
-					break
-				}
+			// Jokers stack and may destroy themselves after scoring
+			remainingJokers = hand.Jokers
+			destroyedJokers = remainingJokers.HandPlayed(rng, jokersTriggered)
+			player.CurrentJokers, err = json.Marshal(remainingJokers)
+			if err != nil {
+				log.Printf("[HAND-ERROR] Error serializing jokers: %v", err)
+				return fail(app_errors.Internal, app_errors.Reason("Error processing player's jokers"))
 			}
-		}
 
-		var deck *poker.Deck
-		if player.CurrentDeck != nil {
-			deck, err = poker.DeckFromJSON(player.CurrentDeck)
+			// 6. Update player data in Redis
+
+			currentHand = nil
+			err = json.Unmarshal(player.CurrentHand, &currentHand)
 			if err != nil {
-				log.Printf("[DECK-ERROR] Error parsing deck: %v", err)
-				client.Emit("error", gin.H{"error": "Error al procesar el mazo"})
-				return
+				log.Printf("[HAND-ERROR] Error unmarshaling current hand: %v", err)
+				return fail(app_errors.Internal, app_errors.Reason("Error processing current hand"))
 			}
-		} else {
-			deck = &poker.Deck{
-				TotalCards:  make([]poker.Card, 0),
-				PlayedCards: make([]poker.Card, 0),
+			// Delete the played hand from the current hand
+			currentHand = poker.WithoutCards(currentHand, hand.Cards)
+
+			if player.CurrentDeck != nil {
+				deck, err = poker.DeckFromJSON(player.CurrentDeck)
+				if err != nil {
+					log.Printf("[DECK-ERROR] Error parsing deck: %v", err)
+					return fail(app_errors.Internal, app_errors.Reason("Error processing the deck"))
+				}
+			} else {
+				deck = &poker.Deck{
+					TotalCards:  make([]poker.Card, 0),
+					PlayedCards: make([]poker.Card, 0),
+				}
 			}
-		}
-
-		// Get new cards from the deck
-		newCards := deck.Draw(len(hand.Cards))
-		if newCards == nil {
-			client.Emit("error", gin.H{"error": "There are not enough cards available in the deck"})
-			return
-		}
 
-		// TODO Update around here hand in redis
+			// Get new cards from the deck
+			newCards = deck.Draw(rng, len(hand.Cards))
+			if newCards == nil {
+				return fail(app_errors.NotEnoughCards)
+			}
 
-		// Add the new cards to the hand
-		currentHand = append(currentHand, newCards...)
-		player.CurrentHand, err = json.Marshal(currentHand)
-		if err != nil {
-			log.Printf("[HAND-ERROR] Error serializing current hand: %v", err)
-			client.Emit("error", gin.H{"error": "Error serializing current hand"})
+			// Add the new cards to the hand
+			currentHand = append(currentHand, newCards...)
+			player.CurrentHand, err = json.Marshal(currentHand)
+			if err != nil {
+				log.Printf("[HAND-ERROR] Error serializing current hand: %v", err)
+				return fail(app_errors.Internal, app_errors.Reason("Error serializing current hand"))
+			}
+			// Add the played hand to the played cards
+			deck.PlayedCards = append(deck.PlayedCards, hand.Cards...)
+			// Remove the played hand from the deck
+			deck.RemoveCards(newCards)
+			player.CurrentDeck = deck.ToJSON()
+
+			player.CurrentRoundPoints += valorFinal
+			player.TotalGamePoints += valorFinal
+			player.RecordPlayedHand(handType, valorFinal)
+
+			player.HandPlaysLeft--
+			player.PlayersMoney = finalGold
+			return nil
+		})
+		if playErr != nil {
+			ctx.FailWith(playErr)
 			return
 		}
-		// Add the played hand to the played cards
-		deck.PlayedCards = append(deck.PlayedCards, hand.Cards...)
-		// Remove the played hand from the deck
-		deck.RemoveCards(newCards)
-		player.CurrentDeck = deck.ToJSON()
-
-		player.CurrentRoundPoints += valorFinal
-		player.TotalGamePoints += valorFinal
-
-		player.HandPlaysLeft--
-		err = redisClient.UpdateDeckPlayer(*player)
 		if err != nil {
 			log.Printf("[HAND-ERROR] Error updating player data: %v", err)
-			client.Emit("error", gin.H{"error": "Error updating player data"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error updating player data"))
 			return
 		}
+		if len(destroyedJokers) > 0 {
+			log.Printf("[HAND-JOKERS] Jokers %v of user %s were destroyed", destroyedJokers, username)
+		}
 
 		// Log the result
 		log.Println("Jugador ha puntuado la friolera de:", valorFinal)
 		// 7. Emit success response
-		client.Emit("played_hand", gin.H{
+		ctx.Reply("played_hand", gin.H{
 			"total_score":         valorFinal,
 			"gold":                finalGold,
 			"hand_type":           handType,
+			"hand_name":           handType.String(),
+			"hand_level":          player.HandLevels.Level(handType),
+			"jokers":              hand.Jokers.Juglares,
 			"jokersTriggered":     jokersTriggered,
+			"destroyed_jokers":    destroyedJokers,
+			"current_jokers":      remainingJokers,
 			"left_plays":          player.HandPlaysLeft,
 			"activated_modifiers": activatedModifiers,
 			"received_modifiers":  receivedModifiers,
@@ -255,26 +256,36 @@ func HandlePlayHand(redisClient *redis.RedisClient, client *socket.Socket,
 			"card_points":         fichas,
 			"red_score":           finalMult,
 			"blue_score":          finalFichas,
+			"scoring_steps":       trace.Steps,
 			"message":             "¡Mano jugada con éxito!",
 		})
 
+		// Spectators only see the score, never the cards
+		socketio_utils.EmitPlayerScored(sio, lobbyID, player, handType, valorFinal)
+
 		// 8. If the player has no plays left, emit a message
 		if player.HandPlaysLeft <= 0 {
 			client.Emit("no_plays_left", gin.H{"message": "No hand plays left"})
 			log.Printf("[HAND-NO-PLAYS] User %s has no plays left", username)
 		}
 
-		player.PlayersMoney = finalGold
-
-		// Save player data
-		if err := redisClient.SaveInGamePlayer(player); err != nil {
-			log.Printf("[PLAY-ERROR] Error saving player data: %v", err)
-			client.Emit("error", gin.H{"error": "Error saving player data"})
-			return
-		}
+		socketio_utils.RecordGameEvent(redisClient, lobbyID, redis_models.EventPlayHand, username, gin.H{
+			"cards":            hand.Cards,
+			"jokers":           hand.Jokers,
+			"hand_type":        handType,
+			"scored_cards":     scored_cards,
+			"jokers_triggered": jokersTriggered,
+			"destroyed_jokers": destroyedJokers,
+			"fichas":           finalFichas,
+			"mult":             finalMult,
+			"total_score":      valorFinal,
+			"gold":             finalGold,
+			"new_cards":        newCards,
+			"scoring_steps":    trace.Steps,
+		})
 
 		// NOTE: check it outside the `if` sentence, since the player might have reached the blind
-		checkPlayerFinishedRound(redisClient, db, client, username, lobbyID, sio)
+		checkPlayerFinishedRound(redisClient, db, ctx, username, lobbyID, sio)
 
 		//logear en redis + pg cuanto ha puntuado supongo IMPORTANTEEEEEEEEEEEEEEEEEEEEEE
 
@@ -284,13 +295,13 @@ func HandlePlayHand(redisClient *redis.RedisClient, client *socket.Socket,
 			isInLobby, err := utils.IsPlayerInLobby(db, lobbyID, username)
 			if err != nil {
 				fmt.Println("Database error:", err)
-				client.Emit("error", gin.H{"error": "Database error"})
+				ctx.Fail(app_errors.Internal, app_errors.Reason("Database error"))
 				return
 			}
 
 			if !isInLobby {
 				fmt.Println("User is NOT in lobby:", username, "Lobby:", lobbyID)
-				client.Emit("error", gin.H{"error": "You must join the lobby before sending messages"})
+				ctx.Fail(app_errors.NotInLobby)
 				return
 			}
 		*/
@@ -298,81 +309,86 @@ func HandlePlayHand(redisClient *redis.RedisClient, client *socket.Socket,
 	}
 }
 
-func updateModifiers(redisClient *redis.RedisClient, client *socket.Socket, username string, player *redis_models.InGamePlayer) {
-
-	var err error
+func updateModifiers(redisClient *redis.RedisClient, ctx *socketio_events.Context, username string) {
+	// Consume the uses of the modifiers on the current player state
+	var modifiersErr *app_errors.Error
+	fail := func(def app_errors.Definition, details ...app_errors.Details) error {
+		modifiersErr = def.New(details...)
+		return modifiersErr
+	}
+	_, err := redisClient.UpdateInGamePlayer(username, func(player *redis_models.InGamePlayer) error {
+		var err error
 
-	var activatedModifiers poker.Modifiers
-	if player.ActivatedModifiers != nil {
-		err = json.Unmarshal(player.ActivatedModifiers, &activatedModifiers)
-		if err != nil {
-			log.Printf("[HAND-ERROR] Error parsing activated modifiers: %v", err)
-			client.Emit("error", gin.H{"error": "Error parsing activated modifiers"})
-			return
+		var activatedModifiers poker.Modifiers
+		if player.ActivatedModifiers != nil {
+			err = json.Unmarshal(player.ActivatedModifiers, &activatedModifiers)
+			if err != nil {
+				log.Printf("[HAND-ERROR] Error parsing activated modifiers: %v", err)
+				return fail(app_errors.Internal, app_errors.Reason("Error parsing activated modifiers"))
+			}
 		}
-	}
 
-	// Delete modifiers if there are no more plays left of the activated modifiers
-	var remainingModifiers []poker.Modifier
+		// Delete modifiers if there are no more plays left of the activated modifiers
+		var remainingModifiers []poker.Modifier
 
-	for _, modifier := range activatedModifiers.Modificadores {
-		if modifier.Value != 1 && modifier.Value != 3 {
-			modifier.LeftUses-- // Decrease the number of uses left
-			if modifier.LeftUses != 0 {
-				remainingModifiers = append(remainingModifiers, modifier)
+		for _, modifier := range activatedModifiers.Modificadores {
+			if modifier.Value != 1 && modifier.Value != 3 {
+				modifier.LeftUses-- // Decrease the number of uses left
+				if modifier.LeftUses != 0 {
+					remainingModifiers = append(remainingModifiers, modifier)
+				}
 			}
 		}
-	}
 
-	var receivedModifiers poker.Modifiers
-	if player.ReceivedModifiers != nil {
-		err = json.Unmarshal(player.ReceivedModifiers, &receivedModifiers)
-		if err != nil {
-			log.Printf("[HAND-ERROR] Error parsing received modifiers: %v", err)
-			client.Emit("error", gin.H{"error": "Error parsing received modifiers"})
-			return
+		var receivedModifiers poker.Modifiers
+		if player.ReceivedModifiers != nil {
+			err = json.Unmarshal(player.ReceivedModifiers, &receivedModifiers)
+			if err != nil {
+				log.Printf("[HAND-ERROR] Error parsing received modifiers: %v", err)
+				return fail(app_errors.Internal, app_errors.Reason("Error parsing received modifiers"))
+			}
 		}
-	}
 
-	// Delete modifiers if there are no more plays left of the received modifiers
-	var remainingReceivedModifiers []poker.Modifier
+		// Delete modifiers if there are no more plays left of the received modifiers
+		var remainingReceivedModifiers []poker.Modifier
 
-	for _, modifier := range receivedModifiers.Modificadores {
-		if modifier.Value != 1 && modifier.Value != 3 {
-			modifier.LeftUses-- // Decrease the number of uses left
-			if modifier.LeftUses != 0 {
-				remainingReceivedModifiers = append(remainingReceivedModifiers, modifier)
+		for _, modifier := range receivedModifiers.Modificadores {
+			if modifier.Value != 1 && modifier.Value != 3 {
+				modifier.LeftUses-- // Decrease the number of uses left
+				if modifier.LeftUses != 0 {
+					remainingReceivedModifiers = append(remainingReceivedModifiers, modifier)
+				}
 			}
 		}
-	}
 
-	activatedModifiers.Modificadores = remainingModifiers
-	player.ActivatedModifiers, err = json.Marshal(activatedModifiers)
-	if err != nil {
-		log.Printf("[HAND-ERROR] Error serializing activated modifiers: %v", err)
-		client.Emit("error", gin.H{"error": "Error serializing activated modifiers"})
-		return
-	}
+		activatedModifiers.Modificadores = remainingModifiers
+		player.ActivatedModifiers, err = json.Marshal(activatedModifiers)
+		if err != nil {
+			log.Printf("[HAND-ERROR] Error serializing activated modifiers: %v", err)
+			return fail(app_errors.Internal, app_errors.Reason("Error serializing activated modifiers"))
+		}
 
-	receivedModifiers.Modificadores = remainingReceivedModifiers
-	player.ReceivedModifiers, err = json.Marshal(receivedModifiers)
-	if err != nil {
-		log.Printf("[HAND-ERROR] Error serializing received modifiers: %v", err)
-		client.Emit("error", gin.H{"error": "Error serializing received modifiers"})
+		receivedModifiers.Modificadores = remainingReceivedModifiers
+		player.ReceivedModifiers, err = json.Marshal(receivedModifiers)
+		if err != nil {
+			log.Printf("[HAND-ERROR] Error serializing received modifiers: %v", err)
+			return fail(app_errors.Internal, app_errors.Reason("Error serializing received modifiers"))
+		}
+		return nil
+	})
+	if modifiersErr != nil {
+		ctx.FailWith(modifiersErr)
 		return
 	}
-
-	// Update player data in Redis
-	err = redisClient.UpdateDeckPlayer(*player)
 	if err != nil {
 		log.Printf("[HAND-ERROR] Error updating player data: %v", err)
-		client.Emit("error", gin.H{"error": "Error updating player data"})
+		ctx.Fail(app_errors.Internal, app_errors.Reason("Error updating player data"))
 		return
 	}
 }
 
 // checkPlayerFinishedRound checks if a player has finished the round and handles it
-func checkPlayerFinishedRound(redisClient *redis.RedisClient, db *gorm.DB, client *socket.Socket, username string,
+func checkPlayerFinishedRound(redisClient *redis.RedisClient, db *gorm.DB, ctx *socketio_events.Context, username string,
 	lobbyID string, sio *socketio_types.SocketServer) {
 
 	log.Printf("[ROUND-CHECK] Checking if player %s has finished round in lobby %s", username, lobbyID)
@@ -403,22 +419,18 @@ func checkPlayerFinishedRound(redisClient *redis.RedisClient, db *gorm.DB, clien
 		}
 
 		// Mark player as finished in the lobby
-		if lobby.PlayersFinishedRound == nil {
-			lobby.PlayersFinishedRound = make(map[string]bool)
-		}
-
-		lobby.PlayersFinishedRound[username] = true
-		log.Printf("[ROUND-CHECK] Incremented finished players count to %d/%d for lobby %s",
-			len(lobby.PlayersFinishedRound), lobby.PlayerCount, lobbyID)
-
-		// Save the updated lobby
-		err = redisClient.SaveGameLobby(lobby)
+		lobby, err = redisClient.UpdateGameLobby(lobbyID, func(lobby *redis_models.GameLobby) error {
+			lobby.PlayersFinishedRound[username] = true
+			return nil
+		})
 		if err != nil {
-			log.Printf("[ROUND-CHECK-ERROR] Error saving lobby: %v", err)
+			log.Printf("[ROUND-CHECK-ERROR] Error updating lobby: %v", err)
 			return
 		}
+		log.Printf("[ROUND-CHECK] Incremented finished players count to %d/%d for lobby %s",
+			len(lobby.PlayersFinishedRound), lobby.PlayerCount, lobbyID)
 
-		updateModifiers(redisClient, client, username, player)
+		updateModifiers(redisClient, ctx, username)
 
 		// If all players have finished the round, end it
 		if len(lobby.PlayersFinishedRound) >= lobby.PlayerCount {
@@ -437,23 +449,23 @@ func ApplyJokers(h poker.Hand, fichas int, mult int) int {
 
 // Get left cards of a hand
 func HandleGetCards(redisClient *redis.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string, sio *socketio_types.SocketServer) func(args ...interface{}) {
-	return func(args ...interface{}) {
-		log.Printf("GetCards request - User: %s, Args: %v, Socket ID: %s",
-			username, args, client.Id())
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[socketio_events.NoPayload] {
+	return func(ctx *socketio_events.Context, req *socketio_events.NoPayload) {
+		log.Printf("GetCards request - User: %s, Socket ID: %s",
+			username, client.Id())
 
 		// 1. Get player data from Redis to extract lobby ID
 		player, err := redisClient.GetInGamePlayer(username)
 		if err != nil {
 			log.Printf("[GET_CARDS-ERROR] Error getting player data: %v", err)
-			client.Emit("error", gin.H{"error": "Error getting player data"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error getting player data"))
 			return
 		}
 
 		lobbyID := player.LobbyId
 		if lobbyID == "" {
 			log.Printf("[GET_CARDS-ERROR] User %s is not in a lobby", username)
-			client.Emit("error", gin.H{"error": "You must join a lobby before getting cards"})
+			ctx.Fail(app_errors.NotInLobby)
 			return
 		}
 
@@ -461,78 +473,92 @@ func HandleGetCards(redisClient *redis.RedisClient, client *socket.Socket,
 		isInLobby, err := utils.IsPlayerInLobby(db, lobbyID, username)
 		if err != nil {
 			log.Printf("[GET_CARDS-ERROR] Database error: %v", err)
-			client.Emit("error", gin.H{"error": "Database error"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Database error"))
 			return
 		}
 
 		if !isInLobby {
 			log.Printf("[GET_CARDS-ERROR] User is NOT in lobby: %s, Lobby: %s", username, lobbyID)
-			client.Emit("error", gin.H{"error": "You must join the lobby before sending messages"})
+			ctx.Fail(app_errors.NotInLobby)
 			return
 		}
 
 		// Validate play round phase
-		valid, err := socketio_utils.ValidatePlayRoundPhase(redisClient, client, lobbyID)
+		valid, err := socketio_utils.ValidatePlayRoundPhase(redisClient, ctx, lobbyID)
 		if err != nil || !valid {
 			// Error already emitted in ValidatePlayRoundPhase
 			return
 		}
 
+		// Draw the cards on the current player state, so concurrent requests can't draw twice
+		var drawErr *app_errors.Error
+		fail := func(def app_errors.Definition, details ...app_errors.Details) error {
+			drawErr = def.New(details...)
+			return drawErr
+		}
 		var deck *poker.Deck
-		if player.CurrentDeck != nil {
-			deck, err = poker.DeckFromJSON(player.CurrentDeck)
+		var hand, newCards []poker.Card
+		_, err = redisClient.UpdateInGamePlayer(username, func(player *redis_models.InGamePlayer) error {
+			// RNG of this event, derived from the lobby seed so it can be reproduced
+			rng, err := socketio_utils.GetLobbyRNG(redisClient, lobbyID, "get_cards", username, player.HandPlaysLeft, player.DiscardsLeft)
 			if err != nil {
-				log.Printf("[GET_CARDS-ERROR] Error parsing deck: %v", err)
-				client.Emit("error", gin.H{"error": "Error parsing deck"})
-				return
+				log.Printf("[GET_CARDS-ERROR] Error getting lobby RNG: %v", err)
+				return fail(app_errors.Internal, app_errors.Reason("Error getting lobby info"))
 			}
-		} else {
-			deck = &poker.Deck{
-				TotalCards:  make([]poker.Card, 0),
-				PlayedCards: make([]poker.Card, 0),
+
+			if player.CurrentDeck != nil {
+				deck, err = poker.DeckFromJSON(player.CurrentDeck)
+				if err != nil {
+					log.Printf("[GET_CARDS-ERROR] Error parsing deck: %v", err)
+					return fail(app_errors.Internal, app_errors.Reason("Error parsing deck"))
+				}
+			} else {
+				deck = &poker.Deck{
+					TotalCards:  make([]poker.Card, 0),
+					PlayedCards: make([]poker.Card, 0),
+				}
 			}
-		}
 
-		var hand []poker.Card
-		err = json.Unmarshal(player.CurrentHand, &hand)
-		if err != nil {
-			log.Printf("[GET_CARDS-ERROR] Error unmarshaling current hand: %v", err)
-			client.Emit("error", gin.H{"error": "Error processing current hand"})
-			return
-		}
+			hand = nil
+			err = json.Unmarshal(player.CurrentHand, &hand)
+			if err != nil {
+				log.Printf("[GET_CARDS-ERROR] Error unmarshaling current hand: %v", err)
+				return fail(app_errors.Internal, app_errors.Reason("Error processing current hand"))
+			}
 
-		// 3. Determine how many cards the player needs
-		cardsNeeded := 8 - len(hand)
-		if cardsNeeded <= 0 {
-			client.Emit("error", gin.H{"error": "Player already has enough cards"})
-			return
-		}
+			// 3. Determine how many cards the player needs
+			cardsNeeded := 8 - len(hand)
+			if cardsNeeded <= 0 {
+				return fail(app_errors.HandAlreadyFull)
+			}
 
-		// 4. Get the necessary cards
-		newCards := deck.Draw(cardsNeeded)
-		if newCards == nil {
-			client.Emit("error", gin.H{"error": "There are not enough cards available in the deck"})
-			return
-		}
+			// 4. Get the necessary cards
+			newCards = deck.Draw(rng, cardsNeeded)
+			if newCards == nil {
+				return fail(app_errors.NotEnoughCards)
+			}
 
-		// Update hand
-		hand = append(hand, newCards...)
+			// Update hand
+			hand = append(hand, newCards...)
 
-		// 5. Update the player info in Redis
-		deck.RemoveCards(newCards)
-		player.CurrentDeck = deck.ToJSON()
+			// 5. Update the player info in Redis
+			deck.RemoveCards(newCards)
+			player.CurrentDeck = deck.ToJSON()
 
-		player.CurrentHand, err = json.Marshal(hand)
-		if err != nil {
-			log.Printf("[GET_CARDS-ERROR] Error serializing current hand: %v", err)
-			client.Emit("error", gin.H{"error": "Error serializing current hand"})
+			player.CurrentHand, err = json.Marshal(hand)
+			if err != nil {
+				log.Printf("[GET_CARDS-ERROR] Error serializing current hand: %v", err)
+				return fail(app_errors.Internal, app_errors.Reason("Error serializing current hand"))
+			}
+			return nil
+		})
+		if drawErr != nil {
+			ctx.FailWith(drawErr)
 			return
 		}
-
-		err = redisClient.UpdateDeckPlayer(*player)
 		if err != nil {
 			log.Printf("[GET_CARDS-ERROR] Error updating player data: %v", err)
-			client.Emit("error", gin.H{"error": "Error updating player data"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error updating player data"))
 			return
 		}
 
@@ -540,7 +566,7 @@ func HandleGetCards(redisClient *redis.RedisClient, client *socket.Socket,
 		lobby, err := redisClient.GetGameLobby(lobbyID)
 		if err != nil {
 			log.Printf("[GET_CARDS-ERROR] Error getting lobby: %v", err)
-			client.Emit("error", gin.H{"error": "Error retrieving game phase"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error retrieving game phase"))
 			return
 		}
 
@@ -553,7 +579,7 @@ func HandleGetCards(redisClient *redis.RedisClient, client *socket.Socket,
 		}
 
 		// 7. Send the response to the client
-		client.Emit("got_cards", response)
+		ctx.Reply("got_cards", response)
 		log.Printf("[GET_CARDS-SUCCESS] Sent updated deck to user %s (%d total cards, phase: %s)",
 			username, response["deck_size"], lobby.CurrentPhase)
 	}
@@ -561,29 +587,23 @@ func HandleGetCards(redisClient *redis.RedisClient, client *socket.Socket,
 
 // Discard cards
 func HandleDiscardCards(redisClient *redis.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string, sio *socketio_types.SocketServer) func(args ...interface{}) {
-	return func(args ...interface{}) {
-		log.Printf("DiscardCards request - User: %s, Args: %v, Socket ID: %s",
-			username, args, client.Id())
-
-		if len(args) < 1 {
-			log.Printf("[DISCARD-ERROR] Missing arguments for user %s", username)
-			client.Emit("error", gin.H{"error": "Missing hand data"})
-			return
-		}
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[DiscardCardsRequest] {
+	return func(ctx *socketio_events.Context, req *DiscardCardsRequest) {
+		log.Printf("DiscardCards request - User: %s, Cards: %v, Socket ID: %s",
+			username, req.CardIDs, client.Id())
 
 		// 1. Get player data from Redis to extract lobby ID
 		player, err := redisClient.GetInGamePlayer(username)
 		if err != nil {
 			log.Printf("[DISCARD-ERROR] Error getting player data: %v", err)
-			client.Emit("error", gin.H{"error": "Error getting player data"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error getting player data"))
 			return
 		}
 
 		lobbyID := player.LobbyId
 		if lobbyID == "" {
 			log.Printf("[DISCARD-ERROR] User %s is not in a lobby", username)
-			client.Emit("error", gin.H{"error": "You must join a lobby before discarding cards"})
+			ctx.Fail(app_errors.NotInLobby)
 			return
 		}
 
@@ -591,117 +611,109 @@ func HandleDiscardCards(redisClient *redis.RedisClient, client *socket.Socket,
 		isInLobby, err := utils.IsPlayerInLobby(db, lobbyID, username)
 		if err != nil {
 			log.Printf("[DISCARD-ERROR] Database error: %v", err)
-			client.Emit("error", gin.H{"error": "Database error"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Database error"))
 			return
 		}
 
 		if !isInLobby {
 			log.Printf("[DISCARD-ERROR] User is NOT in lobby: %s, Lobby: %s", username, lobbyID)
-			client.Emit("error", gin.H{"error": "You must join the lobby before sending messages"})
+			ctx.Fail(app_errors.NotInLobby)
 			return
 		}
 
 		// Validate play round phase
-		valid, err := socketio_utils.ValidatePlayRoundPhase(redisClient, client, lobbyID)
+		valid, err := socketio_utils.ValidatePlayRoundPhase(redisClient, ctx, lobbyID)
 		if err != nil || !valid {
 			// Error already emitted in ValidatePlayRoundPhase
 			return
 		}
 
-		// 2. Check if the user has enough draws left
-		if player.DiscardsLeft <= 0 {
-			log.Printf("[DISCARD-ERROR] No draws left for user %s", username)
-			client.Emit("error", gin.H{"error": "No draws left"})
-			return
+		// Discard on the current player state, so concurrent discards can't use the same
+		// discards or cards
+		var discardErr *app_errors.Error
+		fail := func(def app_errors.Definition, details ...app_errors.Details) error {
+			discardErr = def.New(details...)
+			return discardErr
 		}
-
 		var deck *poker.Deck
-		if player.CurrentDeck != nil {
-			deck, err = poker.DeckFromJSON(player.CurrentDeck)
+		var hand, discard, newCards []poker.Card
+		player, err = redisClient.UpdateInGamePlayer(username, func(player *redis_models.InGamePlayer) error {
+			// RNG of this event, derived from the lobby seed so it can be reproduced
+			rng, err := socketio_utils.GetLobbyRNG(redisClient, lobbyID, "discard", username, player.DiscardsLeft)
 			if err != nil {
-				log.Printf("[DISCARD-ERROR] Error parsing deck: %v", err)
-				client.Emit("error", gin.H{"error": "Error al procesar el mazo"})
-				return
+				log.Printf("[DISCARD-ERROR] Error getting lobby RNG: %v", err)
+				return fail(app_errors.Internal, app_errors.Reason("Error getting lobby info"))
 			}
-		} else {
-			deck = &poker.Deck{
-				TotalCards:  make([]poker.Card, 0),
-				PlayedCards: make([]poker.Card, 0),
-			}
-		}
 
-		discards := args[0].([]interface{})         // Argument is expected to be a map (which is a generic object)
-		discardsJson, err := json.Marshal(discards) // Convert the argument to JSON
-		if err != nil {
-			log.Printf("[DISCARD-ERROR] Error al convertir la mano a JSON: %v", err)
-			client.Emit("error", gin.H{"error": "Error al convertir la mano"})
-			return
-		}
+			// 2. Check if the user has enough draws left
+			if player.DiscardsLeft <= 0 {
+				log.Printf("[DISCARD-ERROR] No draws left for user %s", username)
+				return fail(app_errors.NoDiscardsLeft)
+			}
 
-		// Parse the JSON into the poker.Hand struct
-		var discard []poker.Card
-		err = json.Unmarshal(discardsJson, &discard)
-		if err != nil {
-			log.Printf("[DISCARD-ERROR] Error al parsear la mano: %v", err)
-			client.Emit("error", gin.H{"error": "Error al procesar la mano"})
-			return
-		}
+			if player.CurrentDeck != nil {
+				deck, err = poker.DeckFromJSON(player.CurrentDeck)
+				if err != nil {
+					log.Printf("[DISCARD-ERROR] Error parsing deck: %v", err)
+					return fail(app_errors.Internal, app_errors.Reason("Error processing the deck"))
+				}
+			} else {
+				deck = &poker.Deck{
+					TotalCards:  make([]poker.Card, 0),
+					PlayedCards: make([]poker.Card, 0),
+				}
+			}
 
-		// Get the current hand
-		var hand []poker.Card
-		err = json.Unmarshal(player.CurrentHand, &hand)
-		if err != nil {
-			log.Printf("[GET_CARDS-ERROR] Error unmarshaling current hand: %v", err)
-			client.Emit("error", gin.H{"error": "Error processing current hand"})
-			return
-		}
+			// Get the current hand
+			hand = nil
+			err = json.Unmarshal(player.CurrentHand, &hand)
+			if err != nil {
+				log.Printf("[GET_CARDS-ERROR] Error unmarshaling current hand: %v", err)
+				return fail(app_errors.Internal, app_errors.Reason("Error processing current hand"))
+			}
 
-		// Validate that all discarded cards are in the player's hand
-		valid, errMsg := play_round.ValidatePlayerCards(hand, discard)
-		if !valid {
-			log.Printf("[DISCARD-ERROR] Invalid discard for user %s: %s", username, errMsg)
-			client.Emit("error", gin.H{"error": errMsg})
-			return
-		}
+			// Validate that all discarded cards are in the player's hand
+			var valid bool
+			var errMsg string
+			discard, valid, errMsg = play_round.ValidatePlayerCards(hand, req.CardIDs)
+			if !valid {
+				log.Printf("[DISCARD-ERROR] Invalid discard for user %s: %s", username, errMsg)
+				return fail(app_errors.InvalidCards, app_errors.Reason(errMsg))
+			}
 
-		// 5. Get new cards from the deck
-		newCards := deck.Draw(len(discard))
-		if newCards == nil {
-			client.Emit("error", gin.H{"error": "There are not enough cards available in the deck"})
-			return
-		}
+			// 5. Get new cards from the deck
+			newCards = deck.Draw(rng, len(discard))
+			if newCards == nil {
+				return fail(app_errors.NotEnoughCards)
+			}
 
-		// Also update around here player hand
-		// 6. Update the player's info in Redis
-		deck.PlayedCards = append(deck.PlayedCards, discard...)
-		deck.RemoveCards(newCards)
-		player.CurrentDeck = deck.ToJSON()
-
-		// Remove the discarded cards from the hand
-		for _, card := range discard {
-			for i, c := range hand {
-				if c.Suit == card.Suit && c.Rank == card.Rank {
-					hand = append(hand[:i], hand[i+1:]...)
-					break
-				}
+			// Also update around here player hand
+			// 6. Update the player's info in Redis
+			deck.PlayedCards = append(deck.PlayedCards, discard...)
+			deck.RemoveCards(newCards)
+			player.CurrentDeck = deck.ToJSON()
+
+			// Remove the discarded cards from the hand
+			hand = poker.WithoutCards(hand, discard)
+			// Add the new cards to the hand
+			hand = append(hand, newCards...)
+			player.CurrentHand, err = json.Marshal(hand)
+			if err != nil {
+				log.Printf("[DISCARD-ERROR] Error serializing current hand: %v", err)
+				return fail(app_errors.Internal, app_errors.Reason("Error serializing current hand"))
 			}
-		}
-		// Add the new cards to the hand
-		hand = append(hand, newCards...)
-		player.CurrentHand, err = json.Marshal(hand)
-		if err != nil {
-			log.Printf("[DISCARD-ERROR] Error serializing current hand: %v", err)
-			client.Emit("error", gin.H{"error": "Error serializing current hand"})
+
+			// Update discards left
+			player.DiscardsLeft--
+			return nil
+		})
+		if discardErr != nil {
+			ctx.FailWith(discardErr)
 			return
 		}
-
-		// Update discards left
-		player.DiscardsLeft--
-
-		err = redisClient.UpdateDeckPlayer(*player)
 		if err != nil {
 			log.Printf("[DISCARD-ERROR] Error updating player data: %v", err)
-			client.Emit("error", gin.H{"error": "Error updating player data"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error updating player data"))
 			return
 		}
 
@@ -715,43 +727,40 @@ func HandleDiscardCards(redisClient *redis.RedisClient, client *socket.Socket,
 			"new_cards":       newCards,
 		}
 
+		socketio_utils.RecordGameEvent(redisClient, lobbyID, redis_models.EventDiscardCards, username, gin.H{
+			"cards":     discard,
+			"new_cards": newCards,
+		})
+
 		// 8. Send the response to the client
-		client.Emit("discarded_cards", response)
+		ctx.Reply("discarded_cards", response)
 		log.Printf("[DISCARD-SUCCESS] Sent updated deck to user %s (%d total cards)", username, response["deck_size"])
 	}
 }
 
 func HandleGetFullDeck(redisClient *redis.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string) func(args ...interface{}) {
-	return func(args ...interface{}) {
+	db *gorm.DB, username string) socketio_events.Handler[LobbyRequest] {
+	return func(ctx *socketio_events.Context, req *LobbyRequest) {
 		log.Printf("GetFullDeck request - Usuario: %s, Socket ID: %s", username, client.Id())
 
-		// Check if lobby ID is provided
-		if len(args) < 1 {
-			log.Printf("[DECK-ERROR] Missing lobby ID for user %s", username)
-			client.Emit("error", gin.H{"error": "Missing lobby ID"})
-			return
-		}
-
-		// Get the lobby ID from args
-		lobbyID := args[0].(string)
+		lobbyID := req.LobbyID
 
 		// Verify that the player is in the lobby
 		isInLobby, err := utils.IsPlayerInLobby(db, lobbyID, username)
 		if err != nil {
 			log.Printf("[DECK-ERROR] Database error when checking lobby membership: %v", err)
-			client.Emit("error", gin.H{"error": "Database error"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Database error"))
 			return
 		}
 
 		if !isInLobby {
 			log.Printf("[DECK-ERROR] User %s is not in lobby %s", username, lobbyID)
-			client.Emit("error", gin.H{"error": "You must join a game lobby first"})
+			ctx.Fail(app_errors.NotInLobby)
 			return
 		}
 
 		// Validate play round phase
-		valid, err := socketio_utils.ValidatePlayRoundPhase(redisClient, client, lobbyID)
+		valid, err := socketio_utils.ValidatePlayRoundPhase(redisClient, ctx, lobbyID)
 		if err != nil || !valid {
 			// Error already emitted in ValidatePlayRoundPhase
 			return
@@ -761,7 +770,7 @@ func HandleGetFullDeck(redisClient *redis.RedisClient, client *socket.Socket,
 		player, err := redisClient.GetInGamePlayer(username)
 		if err != nil {
 			log.Printf("[DECK-ERROR] Error getting player data: %v", err)
-			client.Emit("error", gin.H{"error": "Error al obtener el mazo"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error getting the deck"))
 			return
 		}
 
@@ -770,7 +779,7 @@ func HandleGetFullDeck(redisClient *redis.RedisClient, client *socket.Socket,
 			deck, err = poker.DeckFromJSON(player.CurrentDeck)
 			if err != nil {
 				log.Printf("[DECK-ERROR] Error parsing deck: %v", err)
-				client.Emit("error", gin.H{"error": "Error al procesar el mazo"})
+				ctx.Fail(app_errors.Internal, app_errors.Reason("Error processing the deck"))
 				return
 			}
 		} else {
@@ -789,153 +798,114 @@ func HandleGetFullDeck(redisClient *redis.RedisClient, client *socket.Socket,
 		}
 
 		// 4. Send to client
-		client.Emit("full_deck", response)
+		ctx.Reply("full_deck", response)
 		log.Printf("Sent full deck to user %s (%d total cards)", username, response["deck_size"])
 	}
 }
 
 func HandleActivateModifiers(redisClient *redis.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string, sio *socketio_types.SocketServer) func(args ...interface{}) {
-	return func(args ...interface{}) {
-		log.Printf("ActivateModifier request - User: %s, Args: %v, Socket ID: %s",
-			username, args, client.Id())
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[ActivateModifiersRequest] {
+	return func(ctx *socketio_events.Context, req *ActivateModifiersRequest) {
+		log.Printf("ActivateModifier request - User: %s, Modifiers: %v, Socket ID: %s",
+			username, req.Modifiers, client.Id())
 
 		player, err := redisClient.GetInGamePlayer(username)
 		if err != nil {
 			log.Printf("[MODIFIER-ERROR] Error getting player data: %v", err)
-			client.Emit("error", gin.H{"error": "Error getting player data"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error getting player data"))
 			return
 		}
 
 		// Validate modifiers phase
-		valid, err := socketio_utils.ValidateVouchersPhase(redisClient, client, player.LobbyId)
+		valid, err := socketio_utils.ValidateVouchersPhase(redisClient, ctx, player.LobbyId)
 		if err != nil || !valid {
 			return
 		}
 
-		if len(args) < 1 {
-			log.Printf("[MODIFIER-ERROR] Missing arguments for user %s", username)
-			client.Emit("error", gin.H{"error": "Missing modifier or lobby"})
-			return
-		}
+		modifiers := req.Modifiers
 
-		modifiersNested, ok := args[0].([]interface{})
-		if !ok {
-			log.Printf("[MODIFIER-ERROR] Invalid type for modifiers: expected []interface{}, got %T", args[0])
-			client.Emit("error", gin.H{"error": "Invalid modifiers format"})
-			return
-		}
-
-		modifiersInterface, ok := modifiersNested[0].([]interface{})
-		if !ok {
-			log.Printf("[MODIFIER-ERROR] Expected nested array, got %T", modifiersNested[0])
-			client.Emit("error", gin.H{"error": "Invalid modifiers format"})
-			return
+		// Activate the modifiers on the current player state, so they can't be used twice
+		var modifiersErr *app_errors.Error
+		fail := func(def app_errors.Definition, details ...app_errors.Details) error {
+			modifiersErr = def.New(details...)
+			return modifiersErr
 		}
-
-		modifiers := make([]int, len(modifiersInterface))
-		for i, v := range modifiersInterface {
-			switch val := v.(type) {
-			case float64:
-				modifiers[i] = int(val)
-			case int:
-				modifiers[i] = val
-			default:
-				log.Printf("[MODIFIER-ERROR] Invalid modifier type: expected number, got %T", v)
-				client.Emit("error", gin.H{"error": "Invalid modifier value"})
-				return
+		var new_activated_modifiers []poker.Modifier
+		player, err = redisClient.UpdateInGamePlayer(username, func(player *redis_models.InGamePlayer) error {
+			if len(player.Modifiers) == 0 {
+				log.Printf("[MODIFIER-ERROR] No modifiers available for user %s", username)
+				return fail(app_errors.ModifierNotAvailable)
 			}
-		}
-
-		if len(player.Modifiers) == 0 {
-			log.Printf("[MODIFIER-ERROR] No modifiers available for user %s", username)
-			client.Emit("error", gin.H{"error": "No modifiers available"})
-			return
-		}
 
-		var player_modifiers poker.Modifiers
-		err = json.Unmarshal(player.Modifiers, &player_modifiers)
-		if err != nil {
-			log.Printf("[MODIFIER-ERROR] Error parsing modifiers: %v", err)
-			client.Emit("error", gin.H{"error": "Error parsing modifiers"})
-			return
-		}
-
-		var new_activated_modifiers []poker.Modifier
+			var player_modifiers poker.Modifiers
+			err := json.Unmarshal(player.Modifiers, &player_modifiers)
+			if err != nil {
+				log.Printf("[MODIFIER-ERROR] Error parsing modifiers: %v", err)
+				return fail(app_errors.Internal, app_errors.Reason("Error parsing modifiers"))
+			}
 
-		// Check if the modifiers are available
-		for _, modifier := range modifiers {
-			found := false
-			for _, m := range player_modifiers.Modificadores {
-				if m.Value == modifier {
-					found = true
-					new_activated_modifiers = append(new_activated_modifiers, m)
-					break
+			new_activated_modifiers = nil
+
+			// Check if the modifiers are available
+			for _, modifier := range modifiers {
+				found := false
+				for _, m := range player_modifiers.Modificadores {
+					if m.Value == modifier {
+						found = true
+						new_activated_modifiers = append(new_activated_modifiers, m)
+						break
+					}
+				}
+				if !found {
+					log.Printf("[MODIFIER-ERROR] Modifier %d not available for user %s", modifier, username)
+					return fail(app_errors.ModifierNotAvailable)
 				}
 			}
-			if !found {
-				log.Printf("[MODIFIER-ERROR] Modifier %d not available for user %s", modifier, username)
-				client.Emit("error", gin.H{"error": "Modifier not available"})
-				return
-			}
-		}
 
-		// Add the activated modifiers to the player
-		var activated_modifiers poker.Modifiers
-		err = json.Unmarshal(player.ActivatedModifiers, &activated_modifiers)
-		if err != nil {
-			log.Printf("[MODIFIER-ERROR] Error parsing modifiers: %v", err)
-			client.Emit("error", gin.H{"error": "Error parsing modifiers"})
-			return
-		}
-
-		activated_modifiers.Modificadores = append(activated_modifiers.Modificadores, new_activated_modifiers...)
-		activated_modifiersJSON, err := json.Marshal(activated_modifiers)
-		if err != nil {
-			log.Printf("[MODIFIER-ERROR] Error marshaling activated modifiers: %v", err)
-			client.Emit("error", gin.H{"error": "Error processing modifiers"})
-			return
-		}
-		player.ActivatedModifiers = activated_modifiersJSON
-		log.Printf("[MODIFIER-INFO] Activated modifiers for user %s: %v", username, activated_modifiers)
-
-		// Remove the activated modifier from the available modifiers
-		var remainingModifiers []poker.Modifier
-		usedModifiers := make(map[int]int) // Map to track used modifiers
-
-		// Initialize the map with the count of each modifier
-		for _, value := range modifiers {
-			usedModifiers[value]++
-		}
+			// Add the activated modifiers to the player
+			var activated_modifiers poker.Modifiers
+			err = json.Unmarshal(player.ActivatedModifiers, &activated_modifiers)
+			if err != nil {
+				log.Printf("[MODIFIER-ERROR] Error parsing modifiers: %v", err)
+				return fail(app_errors.Internal, app_errors.Reason("Error parsing modifiers"))
+			}
 
-		// Iterate through the available modifiers and reduce the count
-		for _, v := range player_modifiers.Modificadores {
-			if usedModifiers[v.Value] > 0 {
-				usedModifiers[v.Value]-- // Reduce the count of the used modifier
-			} else {
-				remainingModifiers = append(remainingModifiers, v) // Keep the remaining modifier
+			activated_modifiers.Modificadores = append(activated_modifiers.Modificadores, new_activated_modifiers...)
+			activated_modifiersJSON, err := json.Marshal(activated_modifiers)
+			if err != nil {
+				log.Printf("[MODIFIER-ERROR] Error marshaling activated modifiers: %v", err)
+				return fail(app_errors.Internal, app_errors.Reason("Error processing modifiers"))
 			}
-		}
+			player.ActivatedModifiers = activated_modifiersJSON
 
-		player_modifiers.Modificadores = remainingModifiers
+			// Remove the activated modifier from the available modifiers
+			player_modifiers.Modificadores = withoutUsedModifiers(player_modifiers.Modificadores, modifiers)
 
-		modifiersJSON, err := json.Marshal(player_modifiers)
-		if err != nil {
-			log.Printf("[MODIFIER-ERROR] Error marshaling modifiers: %v", err)
-			client.Emit("error", gin.H{"error": "Error processing modifiers"})
+			modifiersJSON, err := json.Marshal(player_modifiers)
+			if err != nil {
+				log.Printf("[MODIFIER-ERROR] Error marshaling modifiers: %v", err)
+				return fail(app_errors.Internal, app_errors.Reason("Error processing modifiers"))
+			}
+			player.Modifiers = modifiersJSON
+			return nil
+		})
+		if modifiersErr != nil {
+			ctx.FailWith(modifiersErr)
 			return
 		}
-		player.Modifiers = modifiersJSON
-
-		err = redisClient.UpdateDeckPlayer(*player)
 		if err != nil {
 			log.Printf("[MODIFIER-ERROR] Error updating player data: %v", err)
-			client.Emit("error", gin.H{"error": "Error updating player data"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error updating player data"))
 			return
 		}
+		log.Printf("[MODIFIER-INFO] Activated modifiers for user %s: %s", username, player.ActivatedModifiers)
+
+		socketio_utils.RecordGameEvent(redisClient, player.LobbyId, redis_models.EventActivateModifiers, username, gin.H{
+			"modifiers": new_activated_modifiers,
+		})
 
 		// Emit success response
-		client.Emit("modifiers_activated", gin.H{
+		ctx.Reply("modifiers_activated", gin.H{
 			"modifiers": player.Modifiers,
 			"activated": player.ActivatedModifiers,
 		})
@@ -944,161 +914,88 @@ func HandleActivateModifiers(redisClient *redis.RedisClient, client *socket.Sock
 }
 
 func HandleSendModifiers(redisClient *redis.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string, sio *socketio_types.SocketServer) func(args ...interface{}) {
-	return func(args ...interface{}) {
-		log.Printf("ActivateModifier request - User: %s, Args: %v, Socket ID: %s",
-			username, args, client.Id())
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[SendModifiersRequest] {
+	return func(ctx *socketio_events.Context, req *SendModifiersRequest) {
+		log.Printf("SendModifiers request - User: %s, Modifiers: %v, Players: %v, Socket ID: %s",
+			username, req.Modifiers, req.Players, client.Id())
 
 		player, err := redisClient.GetInGamePlayer(username)
 		if err != nil {
 			log.Printf("[MODIFIER-ERROR] Error getting player data: %v", err)
-			client.Emit("error", gin.H{"error": "Error getting player data"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error getting player data"))
 			return
 		}
 
 		// Validate vouchers phase
-		valid, err := socketio_utils.ValidateVouchersPhase(redisClient, client, player.LobbyId)
+		valid, err := socketio_utils.ValidateVouchersPhase(redisClient, ctx, player.LobbyId)
 		if err != nil || !valid {
 			return
 		}
 
-		if len(args) < 1 {
-			log.Printf("[MODIFIER-ERROR] Missing arguments for user %s", username)
-			client.Emit("error", gin.H{"error": "Missing modifier or lobby"})
-			return
-		}
+		modifiers := req.Modifiers
 
-		modifiersNested, ok := args[0].([]interface{})
-		if !ok {
-			log.Printf("[MODIFIER-ERROR] Invalid type for modifiers: expected []interface{}, got %T", args[0])
-			client.Emit("error", gin.H{"error": "Invalid modifiers format"})
-			return
+		// Take the modifiers from the current player state, so they can't be sent twice
+		var modifiersErr *app_errors.Error
+		fail := func(def app_errors.Definition, details ...app_errors.Details) error {
+			modifiersErr = def.New(details...)
+			return modifiersErr
 		}
-
-		modifiersInterface, ok := modifiersNested[0].([]interface{})
-		if !ok {
-			log.Printf("[MODIFIER-ERROR] Expected nested array, got %T", modifiersNested[0])
-			client.Emit("error", gin.H{"error": "Invalid modifiers format"})
-			return
-		}
-
-		modifiers := make([]int, len(modifiersInterface))
-		for i, v := range modifiersInterface {
-			switch val := v.(type) {
-			case float64:
-				modifiers[i] = int(val)
-			case int:
-				modifiers[i] = val
-			default:
-				log.Printf("[MODIFIER-ERROR] Invalid modifier type: expected number, got %T", v)
-				client.Emit("error", gin.H{"error": "Invalid modifier value"})
-				return
+		var new_activated_modifiers []poker.Modifier
+		player, err = redisClient.UpdateInGamePlayer(username, func(player *redis_models.InGamePlayer) error {
+			if len(player.Modifiers) == 0 {
+				log.Printf("[MODIFIER-ERROR] No modifiers available for user %s", username)
+				return fail(app_errors.ModifierNotAvailable)
 			}
-		}
-
-		if len(player.Modifiers) == 0 {
-			log.Printf("[MODIFIER-ERROR] No modifiers available for user %s", username)
-			client.Emit("error", gin.H{"error": "No modifiers available"})
-			return
-		}
-
-		var player_modifiers poker.Modifiers
-		err = json.Unmarshal(player.Modifiers, &player_modifiers)
-		if err != nil {
-			log.Printf("[MODIFIER-ERROR] Error parsing modifiers: %v", err)
-			client.Emit("error", gin.H{"error": "Error parsing modifiers"})
-			return
-		}
 
-		var new_activated_modifiers []poker.Modifier
+			var player_modifiers poker.Modifiers
+			err := json.Unmarshal(player.Modifiers, &player_modifiers)
+			if err != nil {
+				log.Printf("[MODIFIER-ERROR] Error parsing modifiers: %v", err)
+				return fail(app_errors.Internal, app_errors.Reason("Error parsing modifiers"))
+			}
 
-		// Check if the modifiers are available
-		for _, modifier := range modifiers {
-			found := false
-			for _, m := range player_modifiers.Modificadores {
-				if m.Value == modifier {
-					found = true
-					new_activated_modifiers = append(new_activated_modifiers, m)
-					break
+			new_activated_modifiers = nil
+
+			// Check if the modifiers are available
+			for _, modifier := range modifiers {
+				found := false
+				for _, m := range player_modifiers.Modificadores {
+					if m.Value == modifier {
+						found = true
+						new_activated_modifiers = append(new_activated_modifiers, m)
+						break
+					}
+				}
+				if !found {
+					log.Printf("[MODIFIER-ERROR] Modifier %d not available for user %s", modifier, username)
+					return fail(app_errors.ModifierNotAvailable)
 				}
 			}
-			if !found {
-				log.Printf("[MODIFIER-ERROR] Modifier %d not available for user %s", modifier, username)
-				client.Emit("error", gin.H{"error": "Modifier not available"})
-				return
-			}
-		}
 
-		// Remove the activated modifier from the available modifiers
-		var remainingModifiers []poker.Modifier
-		usedModifiers := make(map[int]int) // Map to track used modifiers
-
-		// Initialize the map with the count of each modifier
-		for _, value := range modifiers {
-			usedModifiers[value]++
-		}
+			// Remove the activated modifier from the available modifiers
+			player_modifiers.Modificadores = withoutUsedModifiers(player_modifiers.Modificadores, modifiers)
 
-		// Iterate through the available modifiers and reduce the count
-		for _, v := range player_modifiers.Modificadores {
-			if usedModifiers[v.Value] > 0 {
-				usedModifiers[v.Value]-- // Reduce the count of the used modifier
-			} else {
-				remainingModifiers = append(remainingModifiers, v) // Keep the remaining modifier
+			modifiersJSON, err := json.Marshal(player_modifiers)
+			if err != nil {
+				log.Printf("[MODIFIER-ERROR] Error marshaling modifiers: %v", err)
+				return fail(app_errors.Internal, app_errors.Reason("Error processing modifiers"))
 			}
-		}
-
-		player_modifiers.Modificadores = remainingModifiers
-
-		modifiersJSON, err := json.Marshal(player_modifiers)
-		if err != nil {
-			log.Printf("[MODIFIER-ERROR] Error marshaling modifiers: %v", err)
-			client.Emit("error", gin.H{"error": "Error processing modifiers"})
+			player.Modifiers = modifiersJSON
+			return nil
+		})
+		if modifiersErr != nil {
+			ctx.FailWith(modifiersErr)
 			return
 		}
-		player.Modifiers = modifiersJSON
-
-		err = redisClient.UpdateDeckPlayer(*player)
 		if err != nil {
 			log.Printf("[MODIFIER-ERROR] Error updating player data: %v", err)
-			client.Emit("error", gin.H{"error": "Error updating player data"})
-			return
-		}
-
-		request_players_nested, ok := args[0].([]interface{})
-		if !ok {
-			log.Printf("[MODIFIER-ERROR] Invalid type for modifiers: expected []interface{}, got %T", args[0])
-			client.Emit("error", gin.H{"error": "Invalid modifiers format"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error updating player data"))
 			return
 		}
 
-		request_players_interface, ok := request_players_nested[1].([]interface{})
-		if !ok {
-			log.Printf("[MODIFIER-ERROR] Expected nested array, got %T", modifiersNested[0])
-			client.Emit("error", gin.H{"error": "Invalid modifiers format"})
-			return
-		}
-
-		request_players := make([]string, len(request_players_interface))
-		for i, user := range request_players_interface {
-			if userStr, ok := user.(string); ok {
-				request_players[i] = userStr
-			} else {
-				log.Printf("[MODIFIER-ERROR] Invalid type for user: expected string, got %T", user)
-				client.Emit("error", gin.H{"error": "Invalid user format"})
-				return
-			}
-		}
+		request_players := req.Players
 
 		for _, request_player := range request_players {
-			// Update the receiving player
-
-			receiver, err := redisClient.GetInGamePlayer(request_player)
-			if err != nil {
-				log.Printf("[MODIFIER-ERROR] Error getting player data: %v", err)
-				client.Emit("error", gin.H{"error": "Error getting player data"})
-				return
-			}
-
 			// Add the activated modifiers to the player
 			var activated_modifiers poker.ReceivedModifiers
 			for _, modifier := range new_activated_modifiers {
@@ -1108,27 +1005,27 @@ func HandleSendModifiers(redisClient *redis.RedisClient, client *socket.Socket,
 				})
 			}
 
-			var receiver_modifiers poker.ReceivedModifiers
-			err = json.Unmarshal(receiver.ReceivedModifiers, &receiver_modifiers)
-			if err != nil {
-				log.Printf("[MODIFIER-ERROR] Error parsing modifiers: %v", err)
-				client.Emit("error", gin.H{"error": "Error parsing modifiers"})
-				return
-			}
+			// Update the receiving player on its current state, it may be playing meanwhile
+			var parseErr error
+			receiver, err := redisClient.UpdateInGamePlayer(request_player, func(receiver *redis_models.InGamePlayer) error {
+				var receiver_modifiers poker.ReceivedModifiers
+				if parseErr = json.Unmarshal(receiver.ReceivedModifiers, &receiver_modifiers); parseErr != nil {
+					return parseErr
+				}
 
-			receiver_modifiers.Received = append(receiver_modifiers.Received, activated_modifiers.Received...)
-			receiver.ReceivedModifiers, err = json.Marshal(receiver_modifiers)
-			if err != nil {
-				log.Printf("[MODIFIER-ERROR] Error marshaling activated modifiers: %v", err)
-				client.Emit("error", gin.H{"error": "Error processing modifiers"})
+				receiver_modifiers.Received = append(receiver_modifiers.Received, activated_modifiers.Received...)
+				var err error
+				receiver.ReceivedModifiers, err = json.Marshal(receiver_modifiers)
+				return err
+			})
+			if parseErr != nil {
+				log.Printf("[MODIFIER-ERROR] Error parsing modifiers: %v", parseErr)
+				ctx.Fail(app_errors.Internal, app_errors.Reason("Error parsing modifiers"))
 				return
 			}
-
-			// Save the updated player data
-			err = redisClient.UpdateDeckPlayer(*receiver)
 			if err != nil {
 				log.Printf("[MODIFIER-ERROR] Error updating player data: %v", err)
-				client.Emit("error", gin.H{"error": "Error updating player data"})
+				ctx.Fail(app_errors.Internal, app_errors.Reason("Error updating player data"))
 				return
 			}
 
@@ -1136,7 +1033,7 @@ func HandleSendModifiers(redisClient *redis.RedisClient, client *socket.Socket,
 
 			// Notify the receiving player
 			if !receiver.IsBot {
-				if conn, exists := sio.UserConnections[receiver.Username]; exists {
+				if conn, exists := sio.GetUser(receiver.Username); exists {
 					conn.Emit("modifiers_received", gin.H{
 						"modifiers": activated_modifiers,
 					})
@@ -1148,8 +1045,13 @@ func HandleSendModifiers(redisClient *redis.RedisClient, client *socket.Socket,
 			log.Printf("[MODIFIER-SUCCESS] Modifiers sent to user %s from %s", request_player, username)
 		}
 
+		socketio_utils.RecordGameEvent(redisClient, player.LobbyId, redis_models.EventSendModifiers, username, gin.H{
+			"modifiers": new_activated_modifiers,
+			"players":   request_players,
+		})
+
 		// Notify the sender
-		client.Emit("modifiers_sended", gin.H{
+		ctx.Reply("modifiers_sended", gin.H{
 			"modifiers": player.Modifiers,
 			"players":   request_players,
 		})
@@ -1157,6 +1059,27 @@ func HandleSendModifiers(redisClient *redis.RedisClient, client *socket.Socket,
 	}
 }
 
+// Returns the available modifiers without the used ones, one for every used value
+func withoutUsedModifiers(available []poker.Modifier, used []int) []poker.Modifier {
+	var remainingModifiers []poker.Modifier
+	usedModifiers := make(map[int]int) // Map to track used modifiers
+
+	// Initialize the map with the count of each modifier
+	for _, value := range used {
+		usedModifiers[value]++
+	}
+
+	// Iterate through the available modifiers and reduce the count
+	for _, v := range available {
+		if usedModifiers[v.Value] > 0 {
+			usedModifiers[v.Value]-- // Reduce the count of the used modifier
+		} else {
+			remainingModifiers = append(remainingModifiers, v) // Keep the remaining modifier
+		}
+	}
+	return remainingModifiers
+}
+
 /*
 func HandlePlayVoucher(redisClient *redis.RedisClient, client *socket.Socket,
 	db *gorm.DB, username string, sio *socketio_types.SocketServer) func(args ...interface{}) {
@@ -1205,3 +1128,53 @@ func HandlePlayVoucher(redisClient *redis.RedisClient, client *socket.Socket,
 	}
 }
 */
+
+// HandleReorderJokers sets the order in which the player's jokers are applied when
+// scoring a hand. It can be done at any phase of the game
+func HandleReorderJokers(redisClient *redis.RedisClient, client *socket.Socket,
+	db *gorm.DB, username string) socketio_events.Handler[ReorderJokersRequest] {
+	return func(ctx *socketio_events.Context, req *ReorderJokersRequest) {
+		log.Printf("ReorderJokers request - User: %s, Jokers: %v, Socket ID: %s",
+			username, req.Jokers, client.Id())
+
+		order := req.Jokers
+
+		player, err := redisClient.GetInGamePlayer(username)
+		if err != nil {
+			log.Printf("[JOKER-ORDER-ERROR] Error getting player data: %v", err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error getting player data"))
+			return
+		}
+
+		if player.LobbyId == "" {
+			log.Printf("[JOKER-ORDER-ERROR] Player %s not associated with any lobby", username)
+			ctx.Fail(app_errors.NotInLobby)
+			return
+		}
+
+		// Reorder the current jokers, they may change meanwhile (e.g. one is destroyed)
+		var orderErr error
+		_, err = redisClient.UpdateInGamePlayer(username, func(player *redis_models.InGamePlayer) error {
+			orderErr = play_round.ReorderPlayerJokers(player, order)
+			return orderErr
+		})
+		if orderErr != nil {
+			log.Printf("[JOKER-ORDER-ERROR] Invalid jokers order for user %s: %v", username, orderErr)
+			ctx.Fail(app_errors.InvalidJokerOrder, app_errors.Reason(orderErr.Error()))
+			return
+		}
+		if err != nil {
+			log.Printf("[JOKER-ORDER-ERROR] Error saving player data: %v", err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error saving player data"))
+			return
+		}
+
+		socketio_utils.RecordGameEvent(redisClient, player.LobbyId, redis_models.EventReorderJokers, username, gin.H{
+			"jokers": order,
+		})
+
+		ctx.Reply("jokers_reordered", gin.H{
+			"jokers": order,
+		})
+	}
+}
@@ -2,12 +2,18 @@ package handlers
 
 import (
 	models "Nogler/models/postgres"
+	redis_models "Nogler/models/redis"
+	"Nogler/services/app_errors"
+	"Nogler/services/chat"
 	"Nogler/services/redis"
+	socketio_events "Nogler/services/socket_io/events"
 	socketio_types "Nogler/services/socket_io/types"
-	"Nogler/services/socket_io/utils/game_flow"
+	socketio_utils "Nogler/services/socket_io/utils"
+	"Nogler/services/socket_io/utils/stages/lobby_setup"
 	"Nogler/utils"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/zishang520/socket.io/v2/socket"
@@ -23,27 +29,18 @@ import (
 
 // Function to get information about all users in a lobby.
 func GetLobbyInfo(redisClient *redis.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string) func(args ...interface{}) {
-	return func(args ...interface{}) {
-		log.Printf("[INFO] GetLobbyInfo iniciado - Usuario: %s, Args: %v", username, args)
-
-		// Validate arguments
-		if len(args) < 1 {
-			log.Printf("[INFO-ERROR] Faltan argumentos para usuario %s", username)
-			client.Emit("error", gin.H{"error": "Falta el ID del lobby"})
-			return
-		}
-
-		lobbyID := args[0].(string)
+	db *gorm.DB, username string) socketio_events.Handler[LobbyRequest] {
+	return func(ctx *socketio_events.Context, req *LobbyRequest) {
+		lobbyID := req.LobbyID
 		log.Printf("[INFO] Obteniendo información del lobby ID: %s para usuario: %s", lobbyID, username)
 
 		// Check if lobby exists
 		var lobby models.GameLobby
 		if err := db.Where("id = ?", lobbyID).First(&lobby).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
-				client.Emit("error", gin.H{"error": "Lobby not found"})
+				ctx.Fail(app_errors.LobbyNotFound)
 			} else {
-				client.Emit("error", gin.H{"error": "Database error"})
+				ctx.Fail(app_errors.Internal, app_errors.Reason("Database error"))
 			}
 			return
 		}
@@ -51,7 +48,7 @@ func GetLobbyInfo(redisClient *redis.RedisClient, client *socket.Socket,
 		// Get all players in the lobby
 		var players []models.InGamePlayer
 		if err := db.Where("lobby_id = ?", lobbyID).Find(&players).Error; err != nil {
-			client.Emit("error", gin.H{"error": "Error retrieving players"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error retrieving players"))
 			return
 		}
 
@@ -74,18 +71,37 @@ func GetLobbyInfo(redisClient *redis.RedisClient, client *socket.Socket,
 		// Get creator information
 		var creatorProfile models.GameProfile
 		if err := db.Where("username = ?", lobby.CreatorUsername).First(&creatorProfile).Error; err != nil {
-			client.Emit("error", gin.H{"error": "Error retrieving creator info"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error retrieving creator info"))
 			return
 		}
 
+		// Get the spectators (the lobby may not be in Redis if the game has already ended)
+		spectatorInfos := make([]gin.H, 0)
+		spectatingAllowed := false
+		if redisLobby, err := redisClient.GetGameLobby(lobbyID); err == nil {
+			spectatingAllowed = !redisLobby.SpectatingDisabled
+			spectators, err := redisClient.GetSpectators(lobbyID)
+			if err != nil {
+				log.Printf("[INFO-WARNING] No se pudieron obtener los espectadores del lobby %s: %v", lobbyID, err)
+			}
+			for _, spectator := range spectators {
+				spectatorInfos = append(spectatorInfos, gin.H{
+					"username":  spectator,
+					"user_icon": utils.UserIcon(db, spectator),
+				})
+			}
+		}
+
 		// Return the complete lobby info
-		client.Emit("lobby_info", gin.H{
+		ctx.Reply("lobby_info", gin.H{
 			"players": playerInfos,
 			"creator": gin.H{
 				"username":  lobby.CreatorUsername,
 				"user_icon": creatorProfile.UserIcon,
 			},
-			"lobby_id": lobbyID,
+			"lobby_id":           lobbyID,
+			"spectators":         spectatorInfos,
+			"spectating_allowed": spectatingAllowed,
 		})
 
 		log.Printf("[INFO-SUCCESS] Información del lobby %s enviada a usuario %s", lobbyID, username)
@@ -98,24 +114,19 @@ func GetLobbyInfo(redisClient *redis.RedisClient, client *socket.Socket,
 // socket.io room corresponding to that lobby, and the Redis info about the player will be updated
 // (a new `InGamePlayer` object will be inserted).
 func HandleJoinLobby(redisClient *redis.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string, sio *socketio_types.SocketServer) func(args ...interface{}) {
-	return func(args ...interface{}) {
-		log.Printf("[JOIN] HandleJoinLobby iniciado - Usuario: %s, Args: %v, Socket ID: %s",
-			username, args, client.Id())
-
-		if len(args) < 1 {
-			log.Printf("[JOIN-ERROR] Faltan argumentos para usuario %s", username)
-			client.Emit("error", gin.H{"error": "Falta el ID del lobby"})
-			return
-		}
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[LobbyRequest] {
+	return func(ctx *socketio_events.Context, req *LobbyRequest) {
+		log.Printf("[JOIN] HandleJoinLobby iniciado - Usuario: %s, Lobby: %s, Socket ID: %s",
+			username, req.LobbyID, client.Id())
 
-		// 1. Parse lobby ID from arguments
-		lobbyID := args[0].(string)
-		log.Printf("[JOIN] Procesando lobby ID: %s para usuario: %s", lobbyID, username)
+		// 1. Lobby ID from the request
+		lobbyID := req.LobbyID
 
 		// 2. Check if lobby exists in Postgres
-		isInLobby, err := utils.UserExistsInLobby(db, lobbyID, username, client)
+		isInLobby, err := utils.IsPlayerInLobby(db, lobbyID, username)
 		if err != nil {
+			log.Printf("[JOIN-ERROR] Database error: %v", err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Database error"))
 			return
 		}
 
@@ -124,8 +135,18 @@ func HandleJoinLobby(redisClient *redis.RedisClient, client *socket.Socket,
 		// (la llamada no tendrá ningún efecto, idempotencia)
 		if !isInLobby {
 			fmt.Println("User is NOT in lobby:", username, "Lobby:", lobbyID)
-			client.Emit("error", gin.H{"error": "You must join the lobby before sending messages"})
+			ctx.Fail(app_errors.NotInLobby)
+			return
+		}
+
+		// A player in a lobby can't be matched into another one
+		if removed, err := redisClient.DequeuePlayer(username); err != nil {
+			log.Printf("[JOIN-ERROR] Error removing %s from the matchmaking queue: %v", username, err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error leaving the matchmaking queue"))
 			return
+		} else if removed {
+			log.Printf("[MATCHMAKING] %s left the queue to join lobby %s", username, lobbyID)
+			client.Emit("queue_status", gin.H{"queued": false})
 		}
 
 		// 3. Join the socket to the room corresponding to the lobby id
@@ -140,7 +161,7 @@ func HandleJoinLobby(redisClient *redis.RedisClient, client *socket.Socket,
 
 		// 6. Emit success event to the client
 		log.Printf("[JOIN-SUCCESS] Usuario %s unido exitosamente al lobby %s", username, lobbyID)
-		client.Emit("joined_lobby", gin.H{
+		ctx.Reply("joined_lobby", gin.H{
 			"lobby_id":  lobbyID,
 			"username":  username,
 			"user_icon": icon,
@@ -150,7 +171,7 @@ func HandleJoinLobby(redisClient *redis.RedisClient, client *socket.Socket,
 		var profile models.GameProfile
 		if err := db.Where("username = ?", username).First(&profile).Error; err != nil {
 			log.Println("Error al obtener GameProfile:", err)
-			client.Emit("error", gin.H{"error": "Error al obtener el perfil del jugador"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error getting the player profile"))
 			return
 		}
 
@@ -160,22 +181,15 @@ func HandleJoinLobby(redisClient *redis.RedisClient, client *socket.Socket,
 			"username": username,
 			"icon":     profile.UserIcon,
 		})
+
+		sendChatHistory(redisClient, client, lobbyID)
 	}
 }
 
 func HandleExitLobby(redisClient *redis.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string) func(args ...interface{}) {
-	return func(args ...interface{}) {
-		log.Printf("[EXIT] HandleExitLobby iniciado - Usuario: %s, Args: %v", username, args)
-
-		// Validate arguments
-		if len(args) < 1 {
-			log.Printf("[EXIT-ERROR] Faltan argumentos para usuario %s", username)
-			client.Emit("error", gin.H{"error": "Falta el ID del lobby"})
-			return
-		}
-
-		lobbyID := args[0].(string)
+	db *gorm.DB, username string) socketio_events.Handler[LobbyRequest] {
+	return func(ctx *socketio_events.Context, req *LobbyRequest) {
+		lobbyID := req.LobbyID
 		log.Printf("[EXIT] Procesando salida del lobby ID: %s para usuario: %s", lobbyID, username)
 
 		// Check if lobby exists
@@ -183,9 +197,9 @@ func HandleExitLobby(redisClient *redis.RedisClient, client *socket.Socket,
 		result := db.Where("id = ?", lobbyID).First(&lobby)
 		if result.Error != nil {
 			if result.Error == gorm.ErrRecordNotFound {
-				client.Emit("error", gin.H{"error": "Lobby not found"})
+				ctx.Fail(app_errors.LobbyNotFound)
 			} else {
-				client.Emit("error", gin.H{"error": "Database error"})
+				ctx.Fail(app_errors.Internal, app_errors.Reason("Database error"))
 			}
 			return
 		}
@@ -198,21 +212,21 @@ func HandleExitLobby(redisClient *redis.RedisClient, client *socket.Socket,
 		).First(&userInLobby)
 
 		if result.RowsAffected == 0 {
-			client.Emit("error", gin.H{"error": "User is not in that lobby"})
+			ctx.Fail(app_errors.NotInLobby)
 			return
 		}
 
 		// Start transaction
 		tx := db.Begin()
 		if tx.Error != nil {
-			client.Emit("error", gin.H{"error": "Database error starting transaction"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Database error starting transaction"))
 			return
 		}
 
 		// Delete the player from lobby in PostgreSQL
 		if err := tx.Delete(&userInLobby).Error; err != nil {
 			tx.Rollback()
-			client.Emit("error", gin.H{"error": "Error removing user from lobby"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error removing user from lobby"))
 			return
 		}
 
@@ -220,14 +234,14 @@ func HandleExitLobby(redisClient *redis.RedisClient, client *socket.Socket,
 		if redisClient != nil {
 			if err := redisClient.DeleteInGamePlayer(username, lobbyID); err != nil {
 				tx.Rollback()
-				client.Emit("error", gin.H{"error": "Error removing user from Redis"})
+				ctx.Fail(app_errors.Internal, app_errors.Reason("Error removing user from Redis"))
 				return
 			}
 		}
 
 		// Commit transaction
 		if err := tx.Commit().Error; err != nil {
-			client.Emit("error", gin.H{"error": "Error committing transaction"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error committing transaction"))
 			return
 		}
 
@@ -237,13 +251,13 @@ func HandleExitLobby(redisClient *redis.RedisClient, client *socket.Socket,
 		// Check if there are no more players in the lobby
 		var playersInLobby []models.InGamePlayer
 		if err := db.Where("lobby_id = ?", lobbyID).Find(&playersInLobby).Error; err != nil {
-			client.Emit("error", gin.H{"error": "Error retrieving players in lobby"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error retrieving players in lobby"))
 			return
 		}
 
 		// Notify success
 		log.Printf("[EXIT-SUCCESS] Usuario %s ha salido exitosamente del lobby %s", username, lobbyID)
-		client.Emit("exited_lobby", gin.H{
+		ctx.Reply("exited_lobby", gin.H{
 			"lobby_id": lobbyID,
 			"message":  "Has salido del lobby exitosamente",
 		})
@@ -253,21 +267,21 @@ func HandleExitLobby(redisClient *redis.RedisClient, client *socket.Socket,
 			log.Printf("[EXIT] No players left in lobby %s. Deleting lobby...", lobbyID)
 			// Delete lobby from PostgreSQL
 			if err := db.Delete(&lobby).Error; err != nil {
-				client.Emit("error", gin.H{"error": "Error deleting lobby"})
+				ctx.Fail(app_errors.Internal, app_errors.Reason("Error deleting lobby"))
 				return
 			}
 
 			// Delete lobby from Redis
 			if redisClient != nil {
 				if err := redisClient.DeleteGameLobby(lobbyID); err != nil {
-					client.Emit("error", gin.H{"error": "Error deleting lobby from Redis"})
+					ctx.Fail(app_errors.Internal, app_errors.Reason("Error deleting lobby from Redis"))
 					return
 				}
 			}
 		}
 
 		// Broadcast to other players in the lobby that this player left
-		client.To(socket.Room(lobbyID)).Emit("player_left", gin.H{
+		client.To(socket.Room(lobbyID), socketio_utils.SpectatorsRoom(lobbyID)).Emit("player_left", gin.H{
 			"username": username,
 			"lobby_id": lobbyID,
 		})
@@ -275,19 +289,10 @@ func HandleExitLobby(redisClient *redis.RedisClient, client *socket.Socket,
 }
 
 func HandleKickFromLobby(redisClient *redis.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string, sio *socketio_types.SocketServer) func(args ...interface{}) {
-	return func(args ...interface{}) {
-		log.Printf("[KICK] HandleKickFromLobby iniciado - Usuario: %s, Args: %v", username, args)
-
-		// Validate arguments
-		if len(args) < 2 {
-			log.Printf("[KICK-ERROR] Faltan argumentos para usuario %s", username)
-			client.Emit("error", gin.H{"error": "Falta el ID del lobby o el usuario a expulsar"})
-			return
-		}
-
-		lobbyID := args[0].(string)
-		usernameToKick := args[1].(string)
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[KickRequest] {
+	return func(ctx *socketio_events.Context, req *KickRequest) {
+		lobbyID := req.LobbyID
+		usernameToKick := req.Username
 		log.Printf("[KICK] Procesando expulsión del usuario %s del lobby %s por %s",
 			usernameToKick, lobbyID, username)
 
@@ -295,16 +300,16 @@ func HandleKickFromLobby(redisClient *redis.RedisClient, client *socket.Socket,
 		var lobby models.GameLobby
 		if err := db.Where("id = ?", lobbyID).First(&lobby).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
-				client.Emit("error", gin.H{"error": "Lobby not found"})
+				ctx.Fail(app_errors.LobbyNotFound)
 			} else {
-				client.Emit("error", gin.H{"error": "Database error"})
+				ctx.Fail(app_errors.Internal, app_errors.Reason("Database error"))
 			}
 			return
 		}
 
 		// Check if the requesting user is the host
 		if username != lobby.CreatorUsername {
-			client.Emit("error", gin.H{"error": "Only the host can kick players"})
+			ctx.Fail(app_errors.NotLobbyHost)
 			return
 		}
 
@@ -316,18 +321,18 @@ func HandleKickFromLobby(redisClient *redis.RedisClient, client *socket.Socket,
 		).First(&userInLobby)
 
 		if result.RowsAffected == 0 {
-			client.Emit("error", gin.H{"error": "User is not in the lobby"})
+			ctx.Fail(app_errors.PlayerNotInLobby)
 			return
 		}
 
 		// Cannot kick yourself (the host)
 		if usernameToKick == username {
-			client.Emit("error", gin.H{"error": "Host cannot kick themselves"})
+			ctx.Fail(app_errors.CannotKickYourself)
 			return
 		}
 
 		// Get kicked user's socket connection
-		kickedUserSocket, exists := sio.GetConnection(usernameToKick)
+		kickedUserSocket, exists := sio.GetUser(usernameToKick)
 		if !exists {
 			log.Printf("[KICK-WARNING] No active socket connection found for user %s", usernameToKick)
 			// Continue with kick process even if user isn't connected
@@ -336,14 +341,14 @@ func HandleKickFromLobby(redisClient *redis.RedisClient, client *socket.Socket,
 		// Start transaction
 		tx := db.Begin()
 		if tx.Error != nil {
-			client.Emit("error", gin.H{"error": "Database error starting transaction"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Database error starting transaction"))
 			return
 		}
 
 		// Delete the player from lobby in PostgreSQL
 		if err := tx.Delete(&userInLobby).Error; err != nil {
 			tx.Rollback()
-			client.Emit("error", gin.H{"error": "Error kicking user from lobby"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error kicking user from lobby"))
 			return
 		}
 
@@ -351,14 +356,14 @@ func HandleKickFromLobby(redisClient *redis.RedisClient, client *socket.Socket,
 		if redisClient != nil {
 			if err := redisClient.DeleteInGamePlayer(usernameToKick, lobbyID); err != nil {
 				tx.Rollback()
-				client.Emit("error", gin.H{"error": "Error removing user from Redis"})
+				ctx.Fail(app_errors.Internal, app_errors.Reason("Error removing user from Redis"))
 				return
 			}
 		}
 
 		// Commit transaction
 		if err := tx.Commit().Error; err != nil {
-			client.Emit("error", gin.H{"error": "Error committing transaction"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error committing transaction"))
 			return
 		}
 
@@ -373,14 +378,14 @@ func HandleKickFromLobby(redisClient *redis.RedisClient, client *socket.Socket,
 		}
 
 		// Emit success event to the kicker
-		client.Emit("kick_success", gin.H{
+		ctx.Reply("kick_success", gin.H{
 			"message":     "Player kicked successfully",
 			"kicked_user": usernameToKick,
 			"lobby_id":    lobbyID,
 		})
 
 		// Broadcast to all users in the lobby that a player was kicked
-		client.To(socket.Room(lobbyID)).Emit("player_kicked", gin.H{
+		client.To(socket.Room(lobbyID), socketio_utils.SpectatorsRoom(lobbyID)).Emit("player_kicked", gin.H{
 			"kicked_user": usernameToKick,
 			"by_user":     username,
 			"lobby_id":    lobbyID,
@@ -395,134 +400,112 @@ func HandleKickFromLobby(redisClient *redis.RedisClient, client *socket.Socket,
 
 // Function to broadcast a message to all clients in a specific lobby.
 func BroadcastMessageToLobby(redisClient *redis.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string, sio *socketio_types.SocketServer) func(args ...interface{}) {
-	return func(args ...interface{}) {
-		lobbyID := args[0].(string)
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[BroadcastRequest] {
+	return func(ctx *socketio_events.Context, req *BroadcastRequest) {
+		lobbyID := req.LobbyID
 
 		// check if lobby exists. We could maby have a "global" lobby check,
 		// so that a connection is associated with a valid lobby only checked once
 		_, err := utils.CheckLobbyExists(db, lobbyID)
 		if err != nil {
 			fmt.Println("Lobby does not exist:", lobbyID)
-			client.Emit("error", gin.H{"error": "Lobby does not exist"})
+			ctx.Fail(app_errors.LobbyNotFound)
 			return
 		}
 
 		// NOTE: now decoding username at top level (when connection is established, just once)
 
-		message := args[1].(string) // sanitize string?
-
 		// Check if user is in lobby
 		isInLobby, err := utils.IsPlayerInLobby(db, lobbyID, username)
 		if err != nil {
 			fmt.Println("Database error:", err)
-			client.Emit("error", gin.H{"error": "Database error"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Database error"))
 			return
 		}
 
 		if !isInLobby {
 			fmt.Println("User is NOT in lobby:", username, "Lobby:", lobbyID)
-			client.Emit("error", gin.H{"error": "You must join the lobby before sending messages"})
+			ctx.Fail(app_errors.NotInLobby)
+			return
+		}
+
+		// Muted players can't send messages until the host unmutes them
+		muted, err := redisClient.IsPlayerMuted(lobbyID, username)
+		if err != nil {
+			log.Printf("[CHAT-ERROR] Error checking if %s is muted: %v", username, err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error checking if the player is muted"))
+			return
+		}
+		if muted {
+			ctx.Fail(app_errors.PlayerMuted)
+			return
+		}
+
+		message := chat.Clean(req.Message)
+		if message == "" {
+			ctx.Fail(app_errors.MissingField, app_errors.Details{"field": "message"})
 			return
 		}
 
 		fmt.Println("Broadcasting to lobby:", lobbyID, "Message:", message)
 
-		// Get user icon from PostgreSQL
-		icon := utils.UserIcon(db, username)
+		// The user icon comes from PostgreSQL
+		chatMessage := redis_models.ChatMessage{
+			Username: username,
+			UserIcon: utils.UserIcon(db, username),
+			Message:  message,
+			SentAt:   time.Now(),
+		}
+
+		// Keep the message for the users that join later. It's sent anyway if it can't be stored
+		if err := redisClient.AppendChatMessage(lobbyID, chatMessage, chat.HistorySize); err != nil {
+			log.Printf("[CHAT-ERROR] Error storing message of %s in lobby %s: %v", username, lobbyID, err)
+		}
 
 		// Send the message to all clients in the lobby
-		sio.Sio_server.To(socket.Room(lobbyID)).Emit("new_lobby_message", gin.H{"lobby_id": lobbyID, "username": username, "user_icon": icon, "message": message})
+		sio.Sio_server.To(socket.Room(lobbyID)).Emit("new_lobby_message", gin.H{
+			"lobby_id":  lobbyID,
+			"username":  username,
+			"user_icon": chatMessage.UserIcon,
+			"message":   message,
+			"sent_at":   chatMessage.SentAt,
+		})
 	}
 }
 
 func HandleStartGame(redisClient *redis.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string, sio *socketio_types.SocketServer) func(args ...interface{}) {
-	return func(args ...interface{}) {
-		log.Printf("[STARTING-GAME] HandleStartGame started - Usuario: %s, Args: %v", username, args)
-
-		if len(args) < 1 {
-			log.Printf("[START-ERROR] Arguments are missing %s", username)
-			client.Emit("error", gin.H{"error": "Lobby ID is missing"})
-			return
-		}
-
-		lobbyID := args[0].(string)
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[LobbyRequest] {
+	return func(ctx *socketio_events.Context, req *LobbyRequest) {
+		lobbyID := req.LobbyID
+		log.Printf("[STARTING-GAME] HandleStartGame started - Usuario: %s, Lobby: %s", username, lobbyID)
 
 		// Check if lobby exists in the database
 		var lobby *models.GameLobby
 		lobby, err := utils.CheckLobbyExists(db, lobbyID)
 		if err != nil {
 			fmt.Println("Lobby does not exist:", lobbyID)
-			client.Emit("error", gin.H{"error": "Lobby does not exist"})
+			ctx.Fail(app_errors.LobbyNotFound)
 			return
 		}
 
 		// Check if user is the host
 		if username != lobby.CreatorUsername {
-			client.Emit("error", gin.H{"error": "Only the host can start the game"})
+			ctx.Fail(app_errors.NotLobbyHost)
 			return
 		}
 
 		// Check if the game has already begun
 		if lobby.GameHasBegun {
-			client.Emit("error", gin.H{"error": "Game has already started"})
-			return
-		}
-
-		// Count the number of players in the lobby
-		var playerCount int64
-		if err := db.Model(&models.InGamePlayer{}).Where("lobby_id = ?", lobbyID).Count(&playerCount).Error; err != nil {
-			client.Emit("error", gin.H{"error": "Error counting players"})
-			return
-		}
-
-		// Check if there are enough players to start the game. If the lobby is public or private, we need at least 2 players.
-		// If the lobby is AI, we can start with 1 player
-		//if playerCount < 2 && (lobby.IsPublic == 1 || lobby.IsPublic == 0) {
-
-		// Check if there are enough players to start the game
-		if playerCount < 1 {
-			log.Printf("[START-ERROR] Not enough players in lobby %s (count: %d)", lobbyID, playerCount)
-			client.Emit("error", gin.H{"error": "Cannot start game with less than 2 players"})
-			return
-		}
-
-		// Get the Redis lobby to update PlayerCount
-		redisLobby, err := redisClient.GetGameLobby(lobbyID)
-		if err != nil {
-			client.Emit("error", gin.H{"error": "Error getting Redis lobby"})
-			return
-		}
-
-		// Set the player count
-		redisLobby.PlayerCount = int(playerCount)
-		if lobby.IsPublic == 2 {
-			redisLobby.PlayerCount++ // Add AI player
-		}
-
-		// Update Redis with player count
-		if err := redisClient.SaveGameLobby(redisLobby); err != nil {
-			client.Emit("error", gin.H{"error": "Error updating Redis lobby"})
+			ctx.Fail(app_errors.GameAlreadyStarted)
 			return
 		}
 
-		// Update lobby state to "in progress" in PostgreSQL
-		if err := db.Model(&models.GameLobby{}).Where("id = ?", lobbyID).Update("game_has_begun", true).Error; err != nil {
-			client.Emit("error", gin.H{"error": "Error updating lobby state"})
+		if err := lobby_setup.StartGame(redisClient, db, lobby, sio); err != nil {
+			log.Printf("[START-ERROR] Error starting game %s: %v", lobbyID, err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error starting the game"))
 			return
 		}
 
-		// Update Redis state to "in progress"
-		if redisClient != nil {
-			if err := redisClient.CloseLobby(lobbyID); err != nil {
-				client.Emit("error", gin.H{"error": "Error updating Redis state"})
-				return
-			}
-		}
-
 		log.Printf("[START-SUCCESS] The game started succesfully %s by %s", lobbyID, username)
-
-		game_flow.AdvanceToNextBlindIfUndone(redisClient, db, lobbyID, sio, true, redisLobby.CurrentRound)
 	}
 }
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	models "Nogler/models/postgres"
+	redis_models "Nogler/models/redis"
+	"Nogler/services/app_errors"
+	"Nogler/services/matchmaking"
+	"Nogler/services/redis"
+	socketio_events "Nogler/services/socket_io/events"
+	"Nogler/services/socket_io/utils/stages/lobby_setup"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zishang520/socket.io/v2/socket"
+	"gorm.io/gorm"
+)
+
+// Function to join the matchmaking queue. The player is matched with others of a similar
+// rating and receives "match_found" when their game starts
+func HandleJoinQueue(redisClient *redis.RedisClient, client *socket.Socket,
+	db *gorm.DB, username string) socketio_events.Handler[socketio_events.NoPayload] {
+	return func(ctx *socketio_events.Context, req *socketio_events.NoPayload) {
+		log.Printf("[MATCHMAKING] HandleJoinQueue started - Usuario: %s", username)
+
+		// The state of a player is stored by username, so they can't be in two lobbies at once
+		if _, err := redisClient.GetInGamePlayer(username); err == nil {
+			ctx.Fail(app_errors.AlreadyInLobby)
+			return
+		}
+
+		var profile models.GameProfile
+		if err := db.Where("username = ?", username).First(&profile).Error; err != nil {
+			log.Printf("[MATCHMAKING-ERROR] Error getting profile of %s: %v", username, err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error getting the player profile"))
+			return
+		}
+
+		entry := redis_models.QueueEntry{
+			Username:   username,
+			Rating:     profile.UserScore,
+			EnqueuedAt: time.Now(),
+		}
+		if err := redisClient.EnqueuePlayer(entry); err != nil {
+			if err == redis.ErrAlreadyQueued {
+				ctx.Fail(app_errors.AlreadyQueued)
+				return
+			}
+			log.Printf("[MATCHMAKING-ERROR] Error enqueuing %s: %v", username, err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error joining the queue"))
+			return
+		}
+
+		queue, err := redisClient.GetQueue()
+		if err != nil {
+			log.Printf("[MATCHMAKING-ERROR] %v", err)
+		}
+
+		ctx.Reply("queue_status", gin.H{
+			"queued":           true,
+			"rating":           entry.Rating,
+			"rating_band":      matchmaking.RatingBand(entry.EnqueuedAt, entry.EnqueuedAt),
+			"players_in_queue": len(queue),
+			"players_per_game": lobby_setup.MatchmakingPlayers(),
+		})
+	}
+}
+
+// Function to leave the matchmaking queue
+func HandleCancelQueue(redisClient *redis.RedisClient, client *socket.Socket,
+	username string) socketio_events.Handler[socketio_events.NoPayload] {
+	return func(ctx *socketio_events.Context, req *socketio_events.NoPayload) {
+		log.Printf("[MATCHMAKING] HandleCancelQueue started - Usuario: %s", username)
+
+		removed, err := redisClient.DequeuePlayer(username)
+		if err != nil {
+			log.Printf("[MATCHMAKING-ERROR] Error dequeuing %s: %v", username, err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error leaving the queue"))
+			return
+		}
+		if !removed {
+			ctx.Fail(app_errors.NotQueued)
+			return
+		}
+
+		ctx.Reply("queue_status", gin.H{"queued": false})
+	}
+}
+
+// Function to take a player out of the matchmaking queue when they disconnect, so they
+// aren't matched into a game they can't play
+func HandleLeaveQueueOnDisconnect(redisClient *redis.RedisClient, username string) func(args ...interface{}) {
+	return func(args ...interface{}) {
+		if removed, err := redisClient.DequeuePlayer(username); err != nil {
+			log.Printf("[MATCHMAKING-ERROR] Error dequeuing %s: %v", username, err)
+		} else if removed {
+			log.Printf("[MATCHMAKING] %s left the queue on disconnect", username)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	redis_models "Nogler/models/redis"
 	"Nogler/services/redis"
+	socketio_events "Nogler/services/socket_io/events"
 	socketio_utils "Nogler/services/socket_io/utils"
 	"log"
 	"time"
@@ -14,20 +15,13 @@ import (
 
 // HandleGetPhaseTimeout responds with the current game phase and its associated timeout
 func HandleGetPhaseTimeout(redisClient *redis.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string) func(args ...interface{}) {
-	return func(args ...interface{}) {
-		// Check if we have enough arguments
-		if len(args) < 1 {
-			log.Printf("[PHASE-TIMEOUT-ERROR] Missing lobby ID for user %s", username)
-			client.Emit("error", gin.H{"error": "Missing lobby ID"})
-			return
-		}
-
-		lobbyID := args[0].(string)
+	db *gorm.DB, username string) socketio_events.Handler[LobbyRequest] {
+	return func(ctx *socketio_events.Context, req *LobbyRequest) {
+		lobbyID := req.LobbyID
 		log.Printf("[PHASE-TIMEOUT] User %s requesting phase timeout for lobby %s", username, lobbyID)
 
 		// Validate the user and lobby
-		lobby, err := socketio_utils.ValidateLobbyAndUser(redisClient, client, db, username, lobbyID)
+		lobby, err := socketio_utils.ValidateLobbyAndUser(redisClient, ctx, db, username, lobbyID)
 		if err != nil {
 			// Error already emitted in ValidateLobbyAndUser
 			return
@@ -47,7 +41,7 @@ func HandleGetPhaseTimeout(redisClient *redis.RedisClient, client *socket.Socket
 		}
 
 		// Send the phase and timeout information to the client
-		client.Emit("phase_timeout_info", gin.H{
+		ctx.Reply("phase_timeout_info", gin.H{
 			"phase":         lobby.CurrentPhase,
 			"timeout":       phaseTimeout,
 			"current_round": lobby.CurrentRound,
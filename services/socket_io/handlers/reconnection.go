@@ -2,7 +2,9 @@ package handlers
 
 import (
 	redis_models "Nogler/models/redis"
+	"Nogler/services/app_errors"
 	"Nogler/services/redis"
+	socketio_events "Nogler/services/socket_io/events"
 	socketio_utils "Nogler/services/socket_io/utils"
 	"Nogler/services/socket_io/utils/stages/play_round"
 	"Nogler/services/socket_io/utils/stages/shop"
@@ -19,18 +21,13 @@ import (
 )
 
 func HandleRequestGamePhaseInfo(redisClient *redis.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string) func(args ...interface{}) {
-	return func(args ...interface{}) {
-		if len(args) < 1 {
-			client.Emit("error", gin.H{"error": "Lobby ID is required"})
-			return
-		}
-
-		lobbyID := args[0].(string)
+	db *gorm.DB, username string) socketio_events.Handler[LobbyRequest] {
+	return func(ctx *socketio_events.Context, req *LobbyRequest) {
+		lobbyID := req.LobbyID
 		log.Printf("[PHASE-INFO-REQUEST] Requesting phase info for lobby %s by user %s", lobbyID, username)
 
 		// Validate the user and lobby
-		lobby, err := socketio_utils.ValidateLobbyAndUser(redisClient, client, db, username, lobbyID)
+		lobby, err := socketio_utils.ValidateLobbyAndUser(redisClient, ctx, db, username, lobbyID)
 		if err != nil {
 			return
 		}
@@ -39,7 +36,7 @@ func HandleRequestGamePhaseInfo(redisClient *redis.RedisClient, client *socket.S
 		player, err := redisClient.GetInGamePlayer(username)
 		if err != nil {
 			log.Printf("[PHASE-INFO-ERROR] Error getting player data: %v", err)
-			client.Emit("error", gin.H{"error": "Error retrieving player data"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error retrieving player data"))
 			return
 		}
 
@@ -49,7 +46,7 @@ func HandleRequestGamePhaseInfo(redisClient *redis.RedisClient, client *socket.S
 			deck, err = poker.DeckFromJSON(player.CurrentDeck)
 			if err != nil {
 				log.Printf("[DISCARD-ERROR] Error parsing deck: %v", err)
-				client.Emit("error", gin.H{"error": "Error al procesar el mazo"})
+				ctx.Fail(app_errors.Internal, app_errors.Reason("Error processing the deck"))
 				return
 			}
 		}
@@ -75,7 +72,7 @@ func HandleRequestGamePhaseInfo(redisClient *redis.RedisClient, client *socket.S
 		err = json.Unmarshal(player.CurrentHand, &currentHand)
 		if err != nil {
 			log.Printf("[HAND-ERROR] Error unmarshaling current hand: %v", err)
-			client.Emit("error", gin.H{"error": "Error processing current hand"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error processing current hand"))
 			return
 		}
 
@@ -86,11 +83,12 @@ func HandleRequestGamePhaseInfo(redisClient *redis.RedisClient, client *socket.S
 		if player.CurrentJokers != nil && len(player.CurrentJokers) > 0 {
 			if err := json.Unmarshal(player.CurrentJokers, &currentJokers); err == nil {
 				// Calculate sell price for each joker
-				for _, jokerID := range currentJokers.Juglares {
+				for i, jokerID := range currentJokers.Juglares {
 					if jokerID != 0 { // Skip empty slots
 						jokersWithPrices = append(jokersWithPrices, gin.H{
 							"id":         jokerID,
 							"sell_price": poker.CalculateJokerSellPrice(jokerID),
+							"state":      currentJokers.StateAt(i),
 						})
 					}
 				}
@@ -132,6 +130,7 @@ func HandleRequestGamePhaseInfo(redisClient *redis.RedisClient, client *socket.S
 			"current_high_blind": lobby.CurrentHighBlind,
 			"current_base_blind": lobby.CurrentBaseBlind,
 			"max_rounds":         lobby.MaxRounds,
+			"rules":              lobby.Rules,
 			"players":            usersInLobby,
 
 			// Player-specific state
@@ -155,6 +154,7 @@ func HandleRequestGamePhaseInfo(redisClient *redis.RedisClient, client *socket.S
 				"vouchers":          player.Modifiers,
 				"active_vouchers":   player.ActivatedModifiers,
 				"received_vouchers": player.ReceivedModifiers,
+				"hand_levels":       player.HandLevels,
 			},
 		}
 
@@ -183,7 +183,7 @@ func HandleRequestGamePhaseInfo(redisClient *redis.RedisClient, client *socket.S
 			username, lobby.CurrentPhase, response)
 
 		// Send the comprehensive game state
-		client.Emit("game_phase_player_info", response)
+		ctx.Reply("game_phase_player_info", response)
 
 		log.Printf("[PHASE-INFO] Sent complete game info to %s for phase %s",
 			username, lobby.CurrentPhase)
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	socketio_events "Nogler/services/socket_io/events"
+	"encoding/json"
+)
+
+// Payloads of the socket.io events, decoded and validated by socketio_events.On before
+// calling the handlers. Fields tagged with `arg` are positional arguments of the event,
+// the rest are sent as a single object (see socketio_events.Decode)
+
+// Events that only need the lobby: join_lobby, exit_lobby, get_lobby_info, start_game,
+// spectate_lobby, stop_spectating, get_phase_timeout, get_full_deck and
+// request_game_phase_player_info
+type LobbyRequest struct {
+	LobbyID string `arg:"0" json:"lobby_id" validate:"required"`
+}
+
+// kick_from_lobby
+type KickRequest struct {
+	LobbyID  string `arg:"0" json:"lobby_id" validate:"required"`
+	Username string `arg:"1" json:"username" validate:"required"`
+}
+
+// broadcast_to_lobby (the maximum is chat.MaxMessageLength characters)
+type BroadcastRequest struct {
+	LobbyID string `arg:"0" json:"lobby_id" validate:"required"`
+	Message string `arg:"1" json:"message" validate:"required,max=500"`
+}
+
+// mute_player and unmute_player
+type MuteRequest struct {
+	LobbyID  string `arg:"0" json:"lobby_id" validate:"required"`
+	Username string `arg:"1" json:"username" validate:"required"`
+}
+
+// send_direct_message (the maximum is chat.MaxMessageLength characters)
+type DirectMessageRequest struct {
+	Username string `arg:"0" json:"username" validate:"required"`
+	Message  string `arg:"1" json:"message" validate:"required,max=500"`
+}
+
+// set_spectating
+type SetSpectatingRequest struct {
+	LobbyID string `arg:"0" json:"lobby_id" validate:"required"`
+	Allowed *bool  `arg:"1" json:"allowed" validate:"required"`
+}
+
+// propose_blind (the maximum is game_constants.MAX_BLIND)
+type ProposeBlindRequest struct {
+	Blind   int    `arg:"0" json:"blind" validate:"required,min=1,max=1000000"`
+	LobbyID string `arg:"1" json:"lobby_id" validate:"required"`
+}
+
+// play_hand, with the IDs of the cards of the player's hand. The jokers and gold stored in
+// Redis are used to score it
+type PlayHandRequest struct {
+	CardIDs []int `json:"card_ids" validate:"required,max=8"`
+}
+
+// discard_cards, with the IDs of the cards of the player's hand
+type DiscardCardsRequest struct {
+	CardIDs []int `arg:"0" json:"card_ids" validate:"required,max=8"`
+}
+
+// activate_modifiers, sent as [[modifier, ...]]
+type ActivateModifiersRequest struct {
+	Modifiers []int `json:"modifiers" validate:"required"`
+}
+
+func (r *ActivateModifiersRequest) UnmarshalJSON(data []byte) error {
+	if isJSONArray(data) {
+		return socketio_events.DecodeTuple(data, &r.Modifiers)
+	}
+	type plain ActivateModifiersRequest
+	return json.Unmarshal(data, (*plain)(r))
+}
+
+// send_modifiers, sent as [[modifier, ...], [username, ...]]
+type SendModifiersRequest struct {
+	Modifiers []int    `json:"modifiers" validate:"required"`
+	Players   []string `json:"players" validate:"required"`
+}
+
+func (r *SendModifiersRequest) UnmarshalJSON(data []byte) error {
+	if isJSONArray(data) {
+		return socketio_events.DecodeTuple(data, &r.Modifiers, &r.Players)
+	}
+	type plain SendModifiersRequest
+	return json.Unmarshal(data, (*plain)(r))
+}
+
+// reorder_jokers, with every joker of the player in the new order
+type ReorderJokersRequest struct {
+	Jokers []int `arg:"0" json:"jokers"`
+}
+
+// buy_pack, buy_joker, buy_voucher and buy_planet. The price is the one shown to the client, so the
+// purchase fails if it has changed
+type PurchaseRequest struct {
+	ItemID int  `arg:"0" json:"item_id" validate:"required,min=1"`
+	Price  *int `arg:"1" json:"price" validate:"required,min=0"`
+}
+
+// sell_joker
+type SellJokerRequest struct {
+	JokerID int `arg:"0" json:"joker_id" validate:"required,min=1"`
+}
+
+// choose_pack_items. The selections may be empty to keep nothing from the pack
+type PackSelectionRequest struct {
+	ItemID     int            `arg:"0" json:"item_id" validate:"required,min=1"`
+	Selections map[string]any `arg:"1" json:"selections"`
+}
+
+func isJSONArray(data []byte) bool {
+	for _, b := range data {
+		switch b {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case '[':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
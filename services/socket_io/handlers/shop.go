@@ -2,10 +2,13 @@ package handlers
 
 import (
 	game_constants "Nogler/constants/game"
+	"Nogler/services/app_errors"
 	"fmt"
 
+	redis_models "Nogler/models/redis"
 	"Nogler/services/poker"
 	redis_services "Nogler/services/redis"
+	socketio_events "Nogler/services/socket_io/events"
 	socketio_types "Nogler/services/socket_io/types"
 	socketio_utils "Nogler/services/socket_io/utils"
 	"Nogler/services/socket_io/utils/stages/play_round"
@@ -26,38 +29,20 @@ import (
 // validate that he has actually bought the pack and that the selected items were in that pack,
 // and then add those items to the player's inventory.
 func HandlePurchasePack(redisClient *redis_services.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string) func(args ...interface{}) {
-	return func(args ...interface{}) {
-		log.Printf("OpenPack iniciado - Usuario: %s, Args: %v, Socket ID: %s",
-			username, args, client.Id())
+	db *gorm.DB, username string) socketio_events.Handler[PurchaseRequest] {
+	return func(ctx *socketio_events.Context, req *PurchaseRequest) {
+		log.Printf("OpenPack iniciado - Usuario: %s, Pack: %d, Socket ID: %s",
+			username, req.ItemID, client.Id())
 
-		if len(args) < 2 { // Changed from 1 to 2 to require pack ID and price
-			log.Printf("[HAND-ERROR] Faltan argumentos para usuario %s. Los argumentos recibidos han sido: %v", username, args)
-			client.Emit("error", gin.H{"error": "Falta el pack a abrir o el precio"})
-			return
-		}
-
-		// Handle pack ID (JavaScript numbers come as float64)
-		itemIDFloat, ok := args[0].(float64)
-		if !ok {
-			client.Emit("error", gin.H{"error": "El pack ID debe ser un número"})
-			return
-		}
-		itemID := int(itemIDFloat) // Convert to int
-
-		// Get client-provided price
-		priceFloat, ok := args[1].(float64)
-		if !ok {
-			client.Emit("error", gin.H{"error": "El precio debe ser un número"})
-			return
-		}
-		clientPrice := int(priceFloat) // Convert to int
+		itemID := req.ItemID
+		// Client-provided price
+		clientPrice := *req.Price
 
 		// Get player state first to extract lobby ID
 		playerState, err := redisClient.GetInGamePlayer(username)
 		if err != nil {
 			log.Printf("[SHOP-ERROR] Error getting player state: %v", err)
-			client.Emit("error", gin.H{"error": "Error retrieving player state"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error retrieving player state"))
 			return
 		}
 
@@ -65,14 +50,14 @@ func HandlePurchasePack(redisClient *redis_services.RedisClient, client *socket.
 		lobbyID := playerState.LobbyId
 		if lobbyID == "" {
 			log.Printf("[SHOP-ERROR] Player %s not associated with any lobby", username)
-			client.Emit("error", gin.H{"error": "Player not in a lobby"})
+			ctx.Fail(app_errors.NotInLobby)
 			return
 		}
 
 		log.Printf("[INFO] Obteniendo información del lobby ID: %s para usuario: %s", lobbyID, username)
 
 		// Validate that we are in the shop phase
-		valid, err := socketio_utils.ValidateShopPhase(redisClient, client, lobbyID)
+		valid, err := socketio_utils.ValidateShopPhase(redisClient, ctx, lobbyID)
 		if err != nil || !valid {
 			// Error already emitted in ValidateShopPhase
 			return
@@ -82,18 +67,18 @@ func HandlePurchasePack(redisClient *redis_services.RedisClient, client *socket.
 		lobbyState, err := redisClient.GetGameLobby(lobbyID)
 		if err != nil {
 			log.Printf("[SHOP-ERROR] Error getting lobby state: %v", err)
-			client.Emit("error", gin.H{"error": "Error getting lobby state"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error getting lobby state"))
 			return
 		}
 
 		if lobbyState.ShopState == nil {
-			client.Emit("error", gin.H{"error": "Lobby shop state not found"})
+			ctx.Fail(app_errors.ShopNotFound)
 			return
 		}
 
 		item, exists := shop.FindShopItem(*lobbyState, itemID)
 		if !exists || item.Type != game_constants.PACK_TYPE {
-			client.Emit("invalid_pack")
+			ctx.Fail(app_errors.ShopItemNotFound)
 			return
 		}
 
@@ -104,7 +89,7 @@ func HandlePurchasePack(redisClient *redis_services.RedisClient, client *socket.
 			if playerState.CurrentJokers != nil && len(playerState.CurrentJokers) > 0 {
 				if err := json.Unmarshal(playerState.CurrentJokers, &currentJokers); err != nil {
 					log.Printf("[SHOP-ERROR] Error parsing player's jokers: %v", err)
-					client.Emit("error", gin.H{"error": "Error processing jokers"})
+					ctx.Fail(app_errors.Internal, app_errors.Reason("Error processing jokers"))
 					return
 				}
 			} else {
@@ -114,10 +99,8 @@ func HandlePurchasePack(redisClient *redis_services.RedisClient, client *socket.
 			}
 
 			// Check if player already has max jokers
-			if len(currentJokers.Juglares) >= game_constants.MaxJokersPerPlayer {
-				client.Emit("purchase_failed", gin.H{
-					"error": fmt.Sprintf("You cannot have more than %d jokers", game_constants.MaxJokersPerPlayer),
-				})
+			if len(currentJokers.Juglares) >= lobbyState.Rules.MaxJokers {
+				ctx.Fail(app_errors.MaxJokersReached, app_errors.Details{"max": lobbyState.Rules.MaxJokers})
 				return
 			}
 		}
@@ -125,7 +108,7 @@ func HandlePurchasePack(redisClient *redis_services.RedisClient, client *socket.
 		// Validate the purchase
 		if err := shop.ValidatePurchase(item, game_constants.PACK_TYPE, clientPrice, playerState); err != nil {
 			log.Printf("[SHOP-ERROR] Purchase validation failed: %v", err)
-			client.Emit("purchase_failed", gin.H{"error": err.Error()})
+			ctx.Fail(app_errors.PurchaseFailed, app_errors.Reason(err.Error()))
 			return
 		}
 
@@ -134,7 +117,8 @@ func HandlePurchasePack(redisClient *redis_services.RedisClient, client *socket.
 		// Get pack contents and process jokers to include sell prices
 		contents, err := shop.GetOrGeneratePackContents(redisClient, lobbyState, item)
 		if err != nil {
-			client.Emit("pack_generation_failed")
+			log.Printf("[SHOP-ERROR] Error generating pack contents: %v", err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error generating the pack contents"))
 			return
 		}
 
@@ -156,20 +140,31 @@ func HandlePurchasePack(redisClient *redis_services.RedisClient, client *socket.
 			}
 		}
 
-		// Update player's LastPurchasedPackItemId and deduct money
+		// Update player's LastPurchasedPackItemId and deduct money, validating the purchase
+		// again against the current player state so concurrent purchases can't overspend
 		// NOTE: potential exploit by not sending a pack selection event and
 		// then reusing this same id during the next round. Already fixed by resetting
 		// LastPurchasedPackItemId to -1 when starting the shop phase
-		playerState.LastPurchasedPackItemId = itemID
-		playerState.PlayersMoney -= item.Price // Deduct the money
-
-		// NEW, KEY: set the corresponding purchased item IDs map entry to true
-		play_round.SafelySetPlayerItemIDEntry(playerState, item)
+		var purchaseErr error
+		playerState, err = redisClient.UpdateInGamePlayer(username, func(player *redis_models.InGamePlayer) error {
+			if purchaseErr = shop.ValidatePurchase(item, game_constants.PACK_TYPE, clientPrice, player); purchaseErr != nil {
+				return purchaseErr
+			}
+			player.LastPurchasedPackItemId = itemID
+			player.PlayersMoney -= item.Price // Deduct the money
 
-		// Save the updated player state
-		if err := redisClient.SaveInGamePlayer(playerState); err != nil {
+			// NEW, KEY: set the corresponding purchased item IDs map entry to true
+			play_round.SafelySetPlayerItemIDEntry(player, item)
+			return nil
+		})
+		if purchaseErr != nil {
+			log.Printf("[SHOP-ERROR] Purchase validation failed: %v", purchaseErr)
+			ctx.Fail(app_errors.PurchaseFailed, app_errors.Reason(purchaseErr.Error()))
+			return
+		}
+		if err != nil {
 			log.Printf("[SHOP-ERROR] Error saving player state: %v", err)
-			client.Emit("error", gin.H{"error": "Failed to save purchase"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Failed to save purchase"))
 			return
 		}
 
@@ -178,6 +173,7 @@ func HandlePurchasePack(redisClient *redis_services.RedisClient, client *socket.
 			"cards":           contents.Cards,
 			"jokers":          jokersWithPrices, // Use the processed jokers with sell prices
 			"vouchers":        contents.Vouchers,
+			"planets":         contents.Planets,
 			"max_selectable":  item.MaxSelectable,
 			"pack_type":       item.PackType,
 			"remaining_money": playerState.PlayersMoney,
@@ -189,8 +185,15 @@ func HandlePurchasePack(redisClient *redis_services.RedisClient, client *socket.
 
 		log.Println("[PURCHASE-PACK] Response to return: ", res)
 
+		socketio_utils.RecordGameEvent(redisClient, lobbyID, redis_models.EventBuyPack, username, gin.H{
+			"item_id":   item.ID,
+			"pack_type": item.PackType,
+			"price":     item.Price,
+			"contents":  contents,
+		})
+
 		// Emit the pack_purchased event with joker sell prices included
-		client.Emit("pack_purchased", res)
+		ctx.Reply("pack_purchased", res)
 
 		log.Println("[PURCHASE-PACK] Returned response: ", res)
 	}
@@ -199,38 +202,19 @@ func HandlePurchasePack(redisClient *redis_services.RedisClient, client *socket.
 // TODO: set playerState.LastPurchasedPackItemId to -1 upon ending the pack selection event
 
 func HandleBuyJoker(redisClient *redis_services.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string, sio *socketio_types.SocketServer) func(args ...interface{}) {
-	return func(args ...interface{}) {
-		log.Printf("BuyJoker initiated - User: %s, Args: %v, Socket ID: %s",
-			username, args, client.Id())
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[PurchaseRequest] {
+	return func(ctx *socketio_events.Context, req *PurchaseRequest) {
+		log.Printf("BuyJoker initiated - User: %s, Item: %d, Socket ID: %s",
+			username, req.ItemID, client.Id())
 
-		if len(args) < 2 {
-			log.Printf("[SHOP-ERROR] Missing arguments for user %s", username)
-			client.Emit("error", gin.H{"error": "Missing joker ID or price"})
-			return
-		}
-
-		// Parse joker ID (JavaScript numbers come as float64)
-		itemIDFloat, ok := args[0].(float64)
-		if !ok {
-			client.Emit("error", gin.H{"error": "Item ID must be a number"})
-			return
-		}
-		itemID := int(itemIDFloat)
-
-		// Get the client-provided price
-		priceFloat, ok := args[1].(float64)
-		if !ok {
-			client.Emit("error", gin.H{"error": "Price must be a number"})
-			return
-		}
-		clientPrice := int(priceFloat)
+		itemID := req.ItemID
+		clientPrice := *req.Price
 
 		// Get player state first to extract lobby ID
 		playerState, err := redisClient.GetInGamePlayer(username)
 		if err != nil {
 			log.Printf("[SHOP-ERROR] Error getting player state: %v", err)
-			client.Emit("error", gin.H{"error": "Error retrieving player state"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error retrieving player state"))
 			return
 		}
 
@@ -238,7 +222,7 @@ func HandleBuyJoker(redisClient *redis_services.RedisClient, client *socket.Sock
 		lobbyID := playerState.LobbyId
 		if lobbyID == "" {
 			log.Printf("[SHOP-ERROR] Player %s not associated with any lobby", username)
-			client.Emit("error", gin.H{"error": "Player not in a lobby"})
+			ctx.Fail(app_errors.NotInLobby)
 			return
 		}
 
@@ -246,7 +230,7 @@ func HandleBuyJoker(redisClient *redis_services.RedisClient, client *socket.Sock
 			username, lobbyID, itemID, clientPrice)
 
 		// Validate that we are in the shop phase
-		valid, err := socketio_utils.ValidateShopPhase(redisClient, client, lobbyID)
+		valid, err := socketio_utils.ValidateShopPhase(redisClient, ctx, lobbyID)
 		if err != nil || !valid {
 			// Error already emitted in ValidateShopPhase
 			return
@@ -256,39 +240,47 @@ func HandleBuyJoker(redisClient *redis_services.RedisClient, client *socket.Sock
 		lobbyState, err := redisClient.GetGameLobby(lobbyID)
 		if err != nil {
 			log.Printf("[SHOP-ERROR] Error getting lobby state: %v", err)
-			client.Emit("error", gin.H{"error": "Error getting lobby state"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error getting lobby state"))
 			return
 		}
 
 		if lobbyState.ShopState == nil {
-			client.Emit("error", gin.H{"error": "Lobby shop state not found"})
+			ctx.Fail(app_errors.ShopNotFound)
 			return
 		}
 
 		// Find the joker in the shop
 		item, exists := shop.FindShopItem(*lobbyState, itemID)
 		if !exists {
-			client.Emit("invalid_item_id", gin.H{"error": "Shop item not found"})
+			ctx.Fail(app_errors.ShopItemNotFound)
 			return
 		}
 
-		// Process the joker purchase with price validation
-		success, updatedPlayer, err := shop.PurchaseJoker(redisClient, playerState, item, clientPrice)
-		if err != nil || !success {
-			log.Printf("[SHOP-ERROR] Purchase failed: %v", err)
-			client.Emit("purchase_failed", gin.H{"error": err.Error()})
+		// Process the joker purchase with price validation, on the current player state
+		var purchaseErr error
+		updatedPlayer, err := redisClient.UpdateInGamePlayer(username, func(player *redis_models.InGamePlayer) error {
+			_, _, purchaseErr = shop.PurchaseJoker(redisClient, player, item, clientPrice, lobbyState.Rules.MaxJokers)
+			return purchaseErr
+		})
+		if purchaseErr != nil {
+			log.Printf("[SHOP-ERROR] Purchase failed: %v", purchaseErr)
+			ctx.Fail(app_errors.PurchaseFailed, app_errors.Reason(purchaseErr.Error()))
 			return
 		}
-
-		// Save the updated player state
-		if err := redisClient.SaveInGamePlayer(updatedPlayer); err != nil {
+		if err != nil {
 			log.Printf("[SHOP-ERROR] Error saving player state: %v", err)
-			client.Emit("error", gin.H{"error": "Failed to save purchase"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Failed to save purchase"))
 			return
 		}
 
+		socketio_utils.RecordGameEvent(redisClient, lobbyID, redis_models.EventBuyJoker, username, gin.H{
+			"item_id":  item.ID,
+			"joker_id": item.JokerId,
+			"price":    item.Price,
+		})
+
 		// Notify client of successful purchase
-		client.Emit("joker_purchased", gin.H{
+		ctx.Reply("joker_purchased", gin.H{
 			"item_id":  item.ID,
 			"joker_id": item.JokerId,
 			// NOTE: the sell price is calculated based on the joker ID, not the corresponding shop item ID
@@ -299,38 +291,19 @@ func HandleBuyJoker(redisClient *redis_services.RedisClient, client *socket.Sock
 }
 
 func HandleBuyVoucher(redisClient *redis_services.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string, sio *socketio_types.SocketServer) func(args ...interface{}) {
-	return func(args ...interface{}) {
-		log.Printf("BuyVoucher initiated - User: %s, Args: %v, Socket ID: %s",
-			username, args, client.Id())
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[PurchaseRequest] {
+	return func(ctx *socketio_events.Context, req *PurchaseRequest) {
+		log.Printf("BuyVoucher initiated - User: %s, Item: %d, Socket ID: %s",
+			username, req.ItemID, client.Id())
 
-		if len(args) < 2 {
-			log.Printf("[SHOP-ERROR] Missing arguments for user %s", username)
-			client.Emit("error", gin.H{"error": "Missing voucher ID or price"})
-			return
-		}
-
-		// Parse voucher ID (JavaScript numbers come as float64)
-		itemIDFloat, ok := args[0].(float64)
-		if !ok {
-			client.Emit("error", gin.H{"error": "Voucher ID must be a number"})
-			return
-		}
-		itemID := int(itemIDFloat)
-
-		// Get the client-provided price
-		priceFloat, ok := args[1].(float64)
-		if !ok {
-			client.Emit("error", gin.H{"error": "Price must be a number"})
-			return
-		}
-		clientPrice := int(priceFloat)
+		itemID := req.ItemID
+		clientPrice := *req.Price
 
 		// Get player state first to extract lobby ID
 		playerState, err := redisClient.GetInGamePlayer(username)
 		if err != nil {
 			log.Printf("[SHOP-ERROR] Error getting player state: %v", err)
-			client.Emit("error", gin.H{"error": "Error retrieving player state"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error retrieving player state"))
 			return
 		}
 
@@ -338,7 +311,7 @@ func HandleBuyVoucher(redisClient *redis_services.RedisClient, client *socket.So
 		lobbyID := playerState.LobbyId
 		if lobbyID == "" {
 			log.Printf("[SHOP-ERROR] Player %s not associated with any lobby", username)
-			client.Emit("error", gin.H{"error": "Player not in a lobby"})
+			ctx.Fail(app_errors.NotInLobby)
 			return
 		}
 
@@ -346,7 +319,7 @@ func HandleBuyVoucher(redisClient *redis_services.RedisClient, client *socket.So
 			username, lobbyID, itemID, clientPrice)
 
 		// Validate that we are in the shop phase
-		valid, err := socketio_utils.ValidateShopPhase(redisClient, client, lobbyID)
+		valid, err := socketio_utils.ValidateShopPhase(redisClient, ctx, lobbyID)
 		if err != nil || !valid {
 			// Error already emitted in ValidateShopPhase
 			return
@@ -356,39 +329,47 @@ func HandleBuyVoucher(redisClient *redis_services.RedisClient, client *socket.So
 		lobbyState, err := redisClient.GetGameLobby(lobbyID)
 		if err != nil {
 			log.Printf("[SHOP-ERROR] Error getting lobby state: %v", err)
-			client.Emit("error", gin.H{"error": "Error getting lobby state"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error getting lobby state"))
 			return
 		}
 
 		if lobbyState.ShopState == nil {
-			client.Emit("error", gin.H{"error": "Lobby shop state not found"})
+			ctx.Fail(app_errors.ShopNotFound)
 			return
 		}
 
 		// Find the voucher in the shop
 		item, exists := shop.FindShopItem(*lobbyState, itemID)
 		if !exists {
-			client.Emit("invalid_item_id", gin.H{"error": "Shop item not found"})
+			ctx.Fail(app_errors.ShopItemNotFound)
 			return
 		}
 
-		// Process the voucher purchase with price validation
-		success, updatedPlayer, err := shop.PurchaseVoucher(redisClient, playerState, item, clientPrice)
-		if err != nil || !success {
-			log.Printf("[SHOP-ERROR] Purchase failed: %v", err)
-			client.Emit("purchase_failed", gin.H{"error": err.Error()})
+		// Process the voucher purchase with price validation, on the current player state
+		var purchaseErr error
+		updatedPlayer, err := redisClient.UpdateInGamePlayer(username, func(player *redis_models.InGamePlayer) error {
+			_, _, purchaseErr = shop.PurchaseVoucher(redisClient, player, item, clientPrice)
+			return purchaseErr
+		})
+		if purchaseErr != nil {
+			log.Printf("[SHOP-ERROR] Purchase failed: %v", purchaseErr)
+			ctx.Fail(app_errors.PurchaseFailed, app_errors.Reason(purchaseErr.Error()))
 			return
 		}
-
-		// Save the updated player state
-		if err := redisClient.SaveInGamePlayer(updatedPlayer); err != nil {
+		if err != nil {
 			log.Printf("[SHOP-ERROR] Error saving player state: %v", err)
-			client.Emit("error", gin.H{"error": "Failed to save purchase"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Failed to save purchase"))
 			return
 		}
 
+		socketio_utils.RecordGameEvent(redisClient, lobbyID, redis_models.EventBuyVoucher, username, gin.H{
+			"item_id":    item.ID,
+			"voucher_id": item.ModifierId,
+			"price":      item.Price,
+		})
+
 		// Notify client of successful purchase
-		client.Emit("voucher_purchased", gin.H{
+		ctx.Reply("voucher_purchased", gin.H{
 			"item_id":         item.ID,
 			"voucher_id":      item.ModifierId,
 			"remaining_money": updatedPlayer.PlayersMoney,
@@ -396,31 +377,20 @@ func HandleBuyVoucher(redisClient *redis_services.RedisClient, client *socket.So
 	}
 }
 
-func HandleSellJoker(redisClient *redis_services.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string) func(args ...interface{}) {
-	return func(args ...interface{}) {
-		log.Printf("SellJoker initiated - User: %s, Args: %v, Socket ID: %s",
-			username, args, client.Id())
+func HandleBuyPlanet(redisClient *redis_services.RedisClient, client *socket.Socket,
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[PurchaseRequest] {
+	return func(ctx *socketio_events.Context, req *PurchaseRequest) {
+		log.Printf("BuyPlanet initiated - User: %s, Item: %d, Socket ID: %s",
+			username, req.ItemID, client.Id())
 
-		if len(args) < 1 {
-			log.Printf("[SHOP-ERROR] Missing joker ID for user %s", username)
-			client.Emit("error", gin.H{"error": "Missing joker ID to sell"})
-			return
-		}
+		itemID := req.ItemID
+		clientPrice := *req.Price
 
-		// Parse joker ID (JavaScript numbers come as float64)
-		jokerIDFloat, ok := args[0].(float64)
-		if !ok {
-			client.Emit("error", gin.H{"error": "Joker ID must be a number"})
-			return
-		}
-		jokerID := int(jokerIDFloat)
-
-		// Get player state
+		// Get player state first to extract lobby ID
 		playerState, err := redisClient.GetInGamePlayer(username)
 		if err != nil {
 			log.Printf("[SHOP-ERROR] Error getting player state: %v", err)
-			client.Emit("error", gin.H{"error": "Error retrieving player state"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error retrieving player state"))
 			return
 		}
 
@@ -428,76 +398,156 @@ func HandleSellJoker(redisClient *redis_services.RedisClient, client *socket.Soc
 		lobbyID := playerState.LobbyId
 		if lobbyID == "" {
 			log.Printf("[SHOP-ERROR] Player %s not associated with any lobby", username)
-			client.Emit("error", gin.H{"error": "Player not in a lobby"})
+			ctx.Fail(app_errors.NotInLobby)
 			return
 		}
 
+		log.Printf("[INFO] Processing planet purchase for user: %s in lobby: %s, planet ID: %d, price: %d",
+			username, lobbyID, itemID, clientPrice)
+
 		// Validate that we are in the shop phase
-		valid, err := socketio_utils.ValidateShopPhase(redisClient, client, lobbyID)
+		valid, err := socketio_utils.ValidateShopPhase(redisClient, ctx, lobbyID)
 		if err != nil || !valid {
 			// Error already emitted in ValidateShopPhase
 			return
 		}
 
-		// Process the joker sale
-		updatedPlayer, sellPrice, err := shop.SellJoker(playerState, jokerID)
+		// Get the lobby state from Redis
+		lobbyState, err := redisClient.GetGameLobby(lobbyID)
 		if err != nil {
-			log.Printf("[SHOP-ERROR] Sale failed: %v", err)
-			client.Emit("error", gin.H{"error": err.Error()})
+			log.Printf("[SHOP-ERROR] Error getting lobby state: %v", err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error getting lobby state"))
+			return
+		}
+
+		if lobbyState.ShopState == nil {
+			ctx.Fail(app_errors.ShopNotFound)
 			return
 		}
 
-		playerState.PlayersMoney += sellPrice
+		// Find the planet in the shop
+		item, exists := shop.FindShopItem(*lobbyState, itemID)
+		if !exists {
+			ctx.Fail(app_errors.ShopItemNotFound)
+			return
+		}
 
-		// Save the updated player state
-		if err := redisClient.SaveInGamePlayer(updatedPlayer); err != nil {
+		// Process the planet purchase with price validation, on the current player state.
+		// It's used right away
+		var level int
+		var purchaseErr error
+		updatedPlayer, err := redisClient.UpdateInGamePlayer(username, func(player *redis_models.InGamePlayer) error {
+			level, _, purchaseErr = shop.PurchasePlanet(player, item, clientPrice)
+			return purchaseErr
+		})
+		if purchaseErr != nil {
+			log.Printf("[SHOP-ERROR] Purchase failed: %v", purchaseErr)
+			ctx.Fail(app_errors.PurchaseFailed, app_errors.Reason(purchaseErr.Error()))
+			return
+		}
+		if err != nil {
 			log.Printf("[SHOP-ERROR] Error saving player state: %v", err)
-			client.Emit("error", gin.H{"error": "Failed to save joker sale"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Failed to save purchase"))
 			return
 		}
 
-		// Notify client of successful sale
-		client.Emit("joker_sold", gin.H{
-			"joker_id":        jokerID,
-			"sell_price":      sellPrice,
+		socketio_utils.RecordGameEvent(redisClient, lobbyID, redis_models.EventBuyPlanet, username, gin.H{
+			"item_id":   item.ID,
+			"hand_type": item.HandType,
+			"price":     item.Price,
+		})
+
+		// Notify client of successful purchase
+		ctx.Reply("planet_purchased", gin.H{
+			"item_id":         item.ID,
+			"hand_type":       item.HandType,
+			"level":           level,
+			"hand_levels":     updatedPlayer.HandLevels,
 			"remaining_money": updatedPlayer.PlayersMoney,
 		})
 	}
 }
 
-func HandlePackSelection(redisClient *redis_services.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string, sio *socketio_types.SocketServer) func(args ...interface{}) {
-	return func(args ...interface{}) {
-		log.Printf("PackSelection initiated - User: %s, Args: %v, Socket ID: %s",
-			username, args, client.Id())
+func HandleSellJoker(redisClient *redis_services.RedisClient, client *socket.Socket,
+	db *gorm.DB, username string) socketio_events.Handler[SellJokerRequest] {
+	return func(ctx *socketio_events.Context, req *SellJokerRequest) {
+		log.Printf("SellJoker initiated - User: %s, Joker: %d, Socket ID: %s",
+			username, req.JokerID, client.Id())
+
+		jokerID := req.JokerID
 
-		// Check if we have all required arguments
-		if len(args) < 2 {
-			log.Printf("[SHOP-ERROR] Missing arguments for user %s", username)
-			client.Emit("error", gin.H{"error": "Missing pack ID or selections"})
+		// Get player state
+		playerState, err := redisClient.GetInGamePlayer(username)
+		if err != nil {
+			log.Printf("[SHOP-ERROR] Error getting player state: %v", err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error retrieving player state"))
 			return
 		}
 
-		// Parse shop item ID
-		itemIDFloat, ok := args[0].(float64)
-		if !ok {
-			client.Emit("error", gin.H{"error": "Shop item ID must be a number"})
+		// Extract lobby ID from player state
+		lobbyID := playerState.LobbyId
+		if lobbyID == "" {
+			log.Printf("[SHOP-ERROR] Player %s not associated with any lobby", username)
+			ctx.Fail(app_errors.NotInLobby)
 			return
 		}
-		itemID := int(itemIDFloat)
 
-		// Parse selected items map
-		selectionsMap, ok := args[1].(map[string]interface{})
-		if !ok {
-			client.Emit("error", gin.H{"error": "Selections must be an object"})
+		// Validate that we are in the shop phase
+		valid, err := socketio_utils.ValidateShopPhase(redisClient, ctx, lobbyID)
+		if err != nil || !valid {
+			// Error already emitted in ValidateShopPhase
 			return
 		}
 
+		// Process the joker sale on the current player state
+		var sellPrice int
+		var saleErr error
+		updatedPlayer, err := redisClient.UpdateInGamePlayer(username, func(player *redis_models.InGamePlayer) error {
+			if _, sellPrice, saleErr = shop.SellJoker(player, jokerID); saleErr != nil {
+				return saleErr
+			}
+			player.PlayersMoney += sellPrice
+			return nil
+		})
+		if saleErr != nil {
+			log.Printf("[SHOP-ERROR] Sale failed: %v", saleErr)
+			ctx.Fail(app_errors.JokerSaleFailed, app_errors.Reason(saleErr.Error()))
+			return
+		}
+		if err != nil {
+			log.Printf("[SHOP-ERROR] Error saving player state: %v", err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Failed to save joker sale"))
+			return
+		}
+
+		socketio_utils.RecordGameEvent(redisClient, lobbyID, redis_models.EventSellJoker, username, gin.H{
+			"joker_id":   jokerID,
+			"sell_price": sellPrice,
+		})
+
+		// Notify client of successful sale
+		ctx.Reply("joker_sold", gin.H{
+			"joker_id":        jokerID,
+			"sell_price":      sellPrice,
+			"remaining_money": updatedPlayer.PlayersMoney,
+		})
+	}
+}
+
+func HandlePackSelection(redisClient *redis_services.RedisClient, client *socket.Socket,
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[PackSelectionRequest] {
+	return func(ctx *socketio_events.Context, req *PackSelectionRequest) {
+		log.Printf("PackSelection initiated - User: %s, Pack: %d, Selections: %v, Socket ID: %s",
+			username, req.ItemID, req.Selections, client.Id())
+
+		itemID := req.ItemID
+		selectionsMap := req.Selections
+
 		// Get player state
 		playerState, err := redisClient.GetInGamePlayer(username)
 		if err != nil {
 			log.Printf("[SHOP-ERROR] Error getting player state: %v", err)
-			client.Emit("error", gin.H{"error": "Error retrieving player state"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error retrieving player state"))
 			return
 		}
 
@@ -505,12 +555,12 @@ func HandlePackSelection(redisClient *redis_services.RedisClient, client *socket
 		lobbyID := playerState.LobbyId
 		if lobbyID == "" {
 			log.Printf("[SHOP-ERROR] Player %s not associated with any lobby", username)
-			client.Emit("error", gin.H{"error": "Player not in a lobby"})
+			ctx.Fail(app_errors.NotInLobby)
 			return
 		}
 
 		// Validate we are in shop phase
-		valid, err := socketio_utils.ValidateShopPhase(redisClient, client, lobbyID)
+		valid, err := socketio_utils.ValidateShopPhase(redisClient, ctx, lobbyID)
 		if err != nil || !valid {
 			// Error already emitted in ValidateShopPhase
 			return
@@ -518,7 +568,7 @@ func HandlePackSelection(redisClient *redis_services.RedisClient, client *socket
 
 		// Verify that the player actually bought this pack
 		if playerState.LastPurchasedPackItemId != itemID {
-			client.Emit("error", gin.H{"error": "You have not purchased this pack or already selected items from it"})
+			ctx.Fail(app_errors.PackNotPurchased)
 			return
 		}
 
@@ -526,27 +576,42 @@ func HandlePackSelection(redisClient *redis_services.RedisClient, client *socket
 		lobbyState, err := redisClient.GetGameLobby(lobbyID)
 		if err != nil {
 			log.Printf("[SHOP-ERROR] Error getting lobby state: %v", err)
-			client.Emit("error", gin.H{"error": "Error getting lobby state"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error getting lobby state"))
 			return
 		}
 
-		// Process the selection
-		updatedPlayer, err := shop.ProcessPackSelection(redisClient, lobbyState, playerState, itemID, selectionsMap, false)
-		if err != nil {
-			log.Printf("[SHOP-ERROR] Pack selection failed: %v", err)
-			client.Emit("error", gin.H{"error": err.Error()})
+		// Process the selection on the current player state, so the pack can't be chosen twice
+		var notPurchased bool
+		var selectionErr error
+		updatedPlayer, err := redisClient.UpdateInGamePlayer(username, func(player *redis_models.InGamePlayer) error {
+			if notPurchased = player.LastPurchasedPackItemId != itemID; notPurchased {
+				return redis_services.ErrUpdateSkipped
+			}
+			_, selectionErr = shop.ProcessPackSelection(redisClient, lobbyState, player, itemID, selectionsMap, false)
+			return selectionErr
+		})
+		if notPurchased {
+			ctx.Fail(app_errors.PackNotPurchased)
 			return
 		}
-
-		// Save the updated player state
-		if err := redisClient.SaveInGamePlayer(updatedPlayer); err != nil {
+		if selectionErr != nil {
+			log.Printf("[SHOP-ERROR] Pack selection failed: %v", selectionErr)
+			ctx.Fail(app_errors.PackSelectionFailed, app_errors.Reason(selectionErr.Error()))
+			return
+		}
+		if err != nil {
 			log.Printf("[SHOP-ERROR] Error saving player state: %v", err)
-			client.Emit("error", gin.H{"error": "Failed to save pack selection"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Failed to save pack selection"))
 			return
 		}
 
+		socketio_utils.RecordGameEvent(redisClient, lobbyID, redis_models.EventChoosePackItems, username, gin.H{
+			"item_id":    itemID,
+			"selections": selectionsMap,
+		})
+
 		// Notify client of successful selection
-		client.Emit("pack_selection_complete", gin.H{
+		ctx.Reply("pack_selection_complete", gin.H{
 			"message":         "Successfully added selected items to your inventory",
 			"selections":      selectionsMap,
 			"remaining_money": updatedPlayer.PlayersMoney,
@@ -555,96 +620,99 @@ func HandlePackSelection(redisClient *redis_services.RedisClient, client *socket
 }
 
 func HandleRerollShop(redisClient *redis_services.RedisClient, client *socket.Socket,
-	db *gorm.DB, username string, sio *socketio_types.SocketServer) func(args ...interface{}) {
-	return func(args ...interface{}) {
-		log.Printf("RerollShop initiated - User: %s, Args: %v, Socket ID: %s",
-			username, args, client.Id())
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[socketio_events.NoPayload] {
+	return func(ctx *socketio_events.Context, req *socketio_events.NoPayload) {
+		log.Printf("RerollShop initiated - User: %s, Socket ID: %s",
+			username, client.Id())
 		// Get player state
 		playerState, err := redisClient.GetInGamePlayer(username)
 		if err != nil {
 			log.Printf("[SHOP-ERROR] Error getting player state: %v", err)
-			client.Emit("error", gin.H{"error": "Error retrieving player state"})
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error retrieving player state"))
 			return
 		}
 		// Extract lobby ID from player state
 		lobbyID := playerState.LobbyId
 		if lobbyID == "" {
 			log.Printf("[SHOP-ERROR] Player %s not associated with any lobby", username)
-			client.Emit("error", gin.H{"error": "Player not in a lobby"})
+			ctx.Fail(app_errors.NotInLobby)
 			return
 		}
 		// Validate we are in shop phase
-		valid, err := socketio_utils.ValidateShopPhase(redisClient, client, lobbyID)
+		valid, err := socketio_utils.ValidateShopPhase(redisClient, ctx, lobbyID)
 		if err != nil || !valid {
 			// Error already emitted in ValidateShopPhase
 			return
 		}
-		// Get the lobby state
-		lobby, err := redisClient.GetGameLobby(lobbyID)
-		if err != nil {
-			log.Printf("[SHOP-ERROR] Error getting lobby state: %v", err)
-			client.Emit("error", gin.H{"error": "Error getting lobby state"})
-			return
-		}
 		// Check if the player has enough money to reroll
 		if playerState.PlayersMoney < shop.GetRerollPriceForPlayer(playerState) {
-			client.Emit("error", gin.H{"error": "Not enough money to reroll"})
-			return
-		}
-
-		playerState.PlayersMoney -= shop.GetRerollPriceForPlayer(playerState)
-
-		// Check if it is the highest reroll
-		if playerState.Rerolls == lobby.ShopState.Rerolls {
-			// Hay que generar el nuevo reroll
-			lobby.ShopState.Rerolls++
-			playerState.Rerolls++
-			rng := rand.New(rand.NewSource(uint64(lobby.ShopState.RerollSeed) + uint64(lobby.CurrentRound) + uint64(lobby.ShopState.Rerolls)))
-			rerolledJokers := shop.GenerateRerollableItems(rng, &lobby.ShopState.NextUniqueId)
-
-			lobby.ShopState.Rerolled = append(lobby.ShopState.Rerolled, rerolledJokers)
-			// Notify client of successful selection
-			client.Emit("rerolled_jokers", gin.H{
-				"message":          "Successfully rerolled jokers",
-				"new_jokers":       rerolledJokers,
-				"next_reroll_cost": shop.GetRerollPriceForPlayer(playerState),
-				"remaining_money":  playerState.PlayersMoney,
-			})
-
-			// Save the updated player state
-			if err := redisClient.SaveInGamePlayer(playerState); err != nil {
-				log.Printf("[SHOP-ERROR] Error saving player state: %v", err)
-				client.Emit("error", gin.H{"error": "Failed to save pack selection"})
-				return
+			ctx.Fail(app_errors.NotEnoughMoney)
+			return
+		}
+
+		// Get the jokers of the player's next reroll. If it is the highest reroll, it has to be
+		// generated: the lobby is updated atomically so two players can't generate it twice
+		nextReroll := playerState.Rerolls + 1
+		var newJokers redis_models.RerolledJokers
+		_, err = redisClient.UpdateGameLobby(lobbyID, func(lobby *redis_models.GameLobby) error {
+			if playerState.Rerolls == lobby.ShopState.Rerolls {
+				// Hay que generar el nuevo reroll
+				lobby.ShopState.Rerolls++
+				rng := rand.New(rand.NewSource(uint64(lobby.ShopState.RerollSeed) + uint64(lobby.CurrentRound) + uint64(lobby.ShopState.Rerolls)))
+				newJokers = shop.GenerateRerollableItems(rng, &lobby.ShopState.NextUniqueId)
+				lobby.ShopState.Rerolled = append(lobby.ShopState.Rerolled, newJokers)
+				return nil
 			}
 
-			if err := redisClient.SaveGameLobby(lobby); err != nil {
-				log.Printf("[SHOP-ERROR] Error saving lobby state: %v", err)
-				client.Emit("error", gin.H{"error": "Failed to save lobby state"})
-				return
+			if nextReroll >= len(lobby.ShopState.Rerolled) {
+				return fmt.Errorf("reroll index out of range")
 			}
+			newJokers = lobby.ShopState.Rerolled[nextReroll]
+			return nil
+		})
+		if err != nil {
+			log.Printf("[SHOP-ERROR] Error rerolling shop: %v", err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Failed to reroll shop"))
+			return
+		}
 
-		} else {
-			playerState.Rerolls++
-			if playerState.Rerolls >= len(lobby.ShopState.Rerolled) {
-				client.Emit("error", gin.H{"error": "Reroll index out of range"})
-				return
+		// Charge the reroll on the current player state. If another reroll of the player was
+		// saved meanwhile, this one is rejected (its jokers stay for the next reroll)
+		var rerollConflict, notEnoughMoney bool
+		playerState, err = redisClient.UpdateInGamePlayer(username, func(player *redis_models.InGamePlayer) error {
+			if rerollConflict = player.Rerolls != nextReroll-1; rerollConflict {
+				return redis_services.ErrUpdateSkipped
 			}
-			newJokers := lobby.ShopState.Rerolled[playerState.Rerolls]
-
-			// Save the updated player state
-			if err := redisClient.SaveInGamePlayer(playerState); err != nil {
-				log.Printf("[SHOP-ERROR] Error saving player state: %v", err)
-				client.Emit("error", gin.H{"error": "Failed to save pack selection"})
-				return
+			if notEnoughMoney = player.PlayersMoney < shop.GetRerollPriceForPlayer(player); notEnoughMoney {
+				return redis_services.ErrUpdateSkipped
 			}
-
-			client.Emit("rerolled_jokers", gin.H{
-				"message":          "Successfully rerolled jokers",
-				"new_jokers":       newJokers,
-				"next_reroll_cost": shop.GetRerollPriceForPlayer(playerState),
-				"remaining_money":  playerState.PlayersMoney,
-			})
+			player.PlayersMoney -= shop.GetRerollPriceForPlayer(player)
+			player.Rerolls = nextReroll
+			return nil
+		})
+		if notEnoughMoney {
+			ctx.Fail(app_errors.NotEnoughMoney)
+			return
 		}
+		if rerollConflict {
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Another reroll is in progress"))
+			return
+		}
+		if err != nil {
+			log.Printf("[SHOP-ERROR] Error saving player state: %v", err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Failed to save reroll"))
+			return
+		}
+
+		ctx.Reply("rerolled_jokers", gin.H{
+			"message":          "Successfully rerolled jokers",
+			"new_jokers":       newJokers,
+			"next_reroll_cost": shop.GetRerollPriceForPlayer(playerState),
+			"remaining_money":  playerState.PlayersMoney,
+		})
+
+		socketio_utils.RecordGameEvent(redisClient, lobbyID, redis_models.EventRerollShop, username, gin.H{
+			"rerolls": playerState.Rerolls,
+		})
 	}
 }
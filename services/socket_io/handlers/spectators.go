@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	redis_models "Nogler/models/redis"
+	"Nogler/services/app_errors"
+	"Nogler/services/redis"
+	socketio_events "Nogler/services/socket_io/events"
+	socketio_types "Nogler/services/socket_io/types"
+	socketio_utils "Nogler/services/socket_io/utils"
+	"Nogler/utils"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zishang520/socket.io/v2/socket"
+	"gorm.io/gorm"
+)
+
+// Function to watch a lobby without playing. The spectator joins a separate room that only
+// receives the public events of the game (rounds, blinds, scores, eliminations and the end
+// of the game), never the hands or decks of the players
+func HandleSpectateLobby(redisClient *redis.RedisClient, client *socket.Socket,
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[LobbyRequest] {
+	return func(ctx *socketio_events.Context, req *LobbyRequest) {
+		lobbyID := req.LobbyID
+		log.Printf("[SPECTATE] HandleSpectateLobby started - Usuario: %s, Lobby: %s", username, lobbyID)
+
+		if _, err := utils.CheckLobbyExists(db, lobbyID); err != nil {
+			ctx.Fail(app_errors.LobbyNotFound)
+			return
+		}
+
+		// Players follow the game from the lobby room
+		isInLobby, err := utils.IsPlayerInLobby(db, lobbyID, username)
+		if err != nil {
+			log.Printf("[SPECTATE-ERROR] Database error: %v", err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Database error"))
+			return
+		}
+		if isInLobby {
+			ctx.Fail(app_errors.PlayerCannotSpectate)
+			return
+		}
+
+		lobby, err := redisClient.GetGameLobby(lobbyID)
+		if err != nil {
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error getting lobby info"))
+			return
+		}
+		if lobby.SpectatingDisabled {
+			ctx.Fail(app_errors.SpectatorsNotAllowed)
+			return
+		}
+
+		players, err := redisClient.GetAllPlayersInLobby(lobbyID)
+		if err != nil {
+			log.Printf("[SPECTATE-ERROR] Error getting players of lobby %s: %v", lobbyID, err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error getting lobby info"))
+			return
+		}
+
+		if err := redisClient.AddSpectator(lobbyID, username); err != nil {
+			log.Printf("[SPECTATE-ERROR] Error adding spectator %s to lobby %s: %v", username, lobbyID, err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error spectating the lobby"))
+			return
+		}
+		client.Join(socketio_utils.SpectatorsRoom(lobbyID))
+
+		// Only the public state of the game, so the spectator can catch up
+		playersInfo := make([]gin.H, 0, len(players))
+		for _, player := range players {
+			playersInfo = append(playersInfo, gin.H{
+				"username":     player.Username,
+				"user_icon":    utils.UserIcon(db, player.Username),
+				"is_bot":       player.IsBot,
+				"round_points": player.CurrentRoundPoints,
+				"total_points": player.TotalGamePoints,
+			})
+		}
+
+		ctx.Reply("spectating_lobby", gin.H{
+			"lobby_id":       lobbyID,
+			"creator":        lobby.CreatorUsername,
+			"game_has_begun": lobby.GameHasBegun,
+			"phase":          lobby.CurrentPhase,
+			"current_round":  lobby.CurrentRound,
+			"current_blind":  lobby.CurrentHighBlind,
+			"rules":          lobby.Rules,
+			"players":        playersInfo,
+		})
+
+		sio.Sio_server.To(socket.Room(lobbyID)).Emit("new_spectator", gin.H{
+			"lobby_id":  lobbyID,
+			"username":  username,
+			"user_icon": utils.UserIcon(db, username),
+		})
+
+		log.Printf("[SPECTATE-SUCCESS] %s is spectating lobby %s", username, lobbyID)
+	}
+}
+
+// Function to stop watching a lobby
+func HandleStopSpectating(redisClient *redis.RedisClient, client *socket.Socket,
+	username string, sio *socketio_types.SocketServer) socketio_events.Handler[LobbyRequest] {
+	return func(ctx *socketio_events.Context, req *LobbyRequest) {
+		lobbyID := req.LobbyID
+
+		removed, err := redisClient.RemoveSpectator(lobbyID, username)
+		if err != nil {
+			log.Printf("[SPECTATE-ERROR] Error removing spectator %s from lobby %s: %v", username, lobbyID, err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error leaving the lobby"))
+			return
+		}
+		client.Leave(socketio_utils.SpectatorsRoom(lobbyID))
+		if !removed {
+			ctx.Fail(app_errors.NotSpectating)
+			return
+		}
+
+		ctx.Reply("stopped_spectating", gin.H{"lobby_id": lobbyID})
+		sio.Sio_server.To(socket.Room(lobbyID)).Emit("spectator_left", gin.H{
+			"lobby_id": lobbyID,
+			"username": username,
+		})
+	}
+}
+
+// Function to allow or disallow spectators in a lobby (only for hosts). Disallowing it
+// removes the current spectators
+func HandleSetSpectating(redisClient *redis.RedisClient, client *socket.Socket,
+	db *gorm.DB, username string, sio *socketio_types.SocketServer) socketio_events.Handler[SetSpectatingRequest] {
+	return func(ctx *socketio_events.Context, req *SetSpectatingRequest) {
+		log.Printf("[SPECTATE] HandleSetSpectating started - Usuario: %s, Lobby: %s, Allowed: %t", username, req.LobbyID, *req.Allowed)
+
+		lobbyID := req.LobbyID
+		allowed := *req.Allowed
+
+		lobby, err := utils.CheckLobbyExists(db, lobbyID)
+		if err != nil {
+			ctx.Fail(app_errors.LobbyNotFound)
+			return
+		}
+		if username != lobby.CreatorUsername {
+			ctx.Fail(app_errors.NotLobbyHost)
+			return
+		}
+
+		_, err = redisClient.UpdateGameLobby(lobbyID, func(redisLobby *redis_models.GameLobby) error {
+			redisLobby.SpectatingDisabled = !allowed
+			return nil
+		})
+		if err != nil {
+			log.Printf("[SPECTATE-ERROR] Error updating lobby %s: %v", lobbyID, err)
+			ctx.Fail(app_errors.Internal, app_errors.Reason("Error updating the lobby"))
+			return
+		}
+
+		if !allowed {
+			room := socketio_utils.SpectatorsRoom(lobbyID)
+			sio.Sio_server.To(room).Emit("spectating_disabled", gin.H{"lobby_id": lobbyID})
+			sio.Sio_server.In(room).SocketsLeave(room)
+			if err := redisClient.RemoveAllSpectators(lobbyID); err != nil {
+				log.Printf("[SPECTATE-ERROR] %v", err)
+			}
+		}
+
+		sio.Sio_server.To(socket.Room(lobbyID)).Emit("spectating_updated", gin.H{
+			"lobby_id":           lobbyID,
+			"spectating_allowed": allowed,
+		})
+	}
+}
+
+// Function to remove a disconnected user from the lobbies they were spectating
+func HandleStopSpectatingOnDisconnect(redisClient *redis.RedisClient, client *socket.Socket,
+	username string) func(args ...interface{}) {
+	return func(args ...interface{}) {
+		// The socket is still in its rooms while disconnecting
+		for _, room := range client.Rooms().Keys() {
+			lobbyID, ok := socketio_utils.SpectatedLobbyID(room)
+			if !ok {
+				continue
+			}
+			if _, err := redisClient.RemoveSpectator(lobbyID, username); err != nil {
+				log.Printf("[SPECTATE-ERROR] %v", err)
+			}
+		}
+	}
+}
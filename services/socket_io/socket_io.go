@@ -3,9 +3,13 @@ package socket_io
 import (
 	"Nogler/services/redis"
 
+	socketio_adapter "Nogler/services/socket_io/adapter"
+	socketio_events "Nogler/services/socket_io/events"
 	"Nogler/services/socket_io/handlers"
 	socketio_types "Nogler/services/socket_io/types"
 	socketio_utils "Nogler/services/socket_io/utils"
+	"Nogler/services/socket_io/utils/game_flow"
+	"Nogler/services/socket_io/utils/stages/lobby_setup"
 	"fmt"
 	"os"
 	"os/signal"
@@ -41,8 +45,12 @@ func (sio *MySocketServer) Start(router *gin.Engine, db *gorm.DB, redisClient *r
 
 	// KEY: inicializar el map, sino panikea
 	sio.UserConnections = make(map[string]*socket.Socket)
+	sio.Redis = redisClient
 
 	sio.Sio_server = socket.NewServer(nil, nil)
+	// Broadcasts and room changes go through Redis, so several instances of the server can
+	// share the same lobbies
+	sio.Sio_server.SetAdapter(socketio_adapter.NewRedisAdapterBuilder(redisClient))
 	sio.Sio_server.On("connection", func(clients ...interface{}) {
 		client := clients[0].(*socket.Socket)
 
@@ -73,65 +81,96 @@ func (sio *MySocketServer) Start(router *gin.Engine, db *gorm.DB, redisClient *r
 			"email":    email,
 		})
 
+		// If the user was playing a game, put them back into it
+		handlers.HandleReconnection(redisClient, client, db, username, sio_casted)
+
+		// Every event is decoded and validated into its request (see handlers/requests.go) and
+		// can be answered with an acknowledgement instead of a response event. The events that
+		// can be spammed are rate limited per user (see rate_limit.Limits)
+
 		// Join the user to a room corresponding to a Nogler game lobby
-		client.On("join_lobby", handlers.HandleJoinLobby(redisClient, client, db, username, sio_casted))
+		socketio_events.On(client, username, "join_lobby", handlers.HandleJoinLobby(redisClient, client, db, username, sio_casted))
 
 		// Exit a lobby voluntarily
-		client.On("exit_lobby", handlers.HandleExitLobby(redisClient, client, db, username))
+		socketio_events.On(client, username, "exit_lobby", handlers.HandleExitLobby(redisClient, client, db, username))
 
 		// Kick a user from a lobby (only for hosts)
-		client.On("kick_from_lobby", handlers.HandleKickFromLobby(redisClient, client, db, username, sio_casted))
+		socketio_events.On(client, username, "kick_from_lobby", handlers.HandleKickFromLobby(redisClient, client, db, username, sio_casted))
 
 		// Get (username,icon) of all users in a lobby and (username,icon) of the lobby host/creator
-		client.On("get_lobby_info", handlers.GetLobbyInfo(redisClient, client, db, username))
+		socketio_events.On(client, username, "get_lobby_info", handlers.GetLobbyInfo(redisClient, client, db, username))
 
 		// Broadcast a message to all clients in a specific lobby
-		client.On("broadcast_to_lobby", handlers.BroadcastMessageToLobby(redisClient, client, db, username, sio_casted))
+		socketio_events.On(client, username, "broadcast_to_lobby", socketio_events.RateLimited(redisClient, handlers.BroadcastMessageToLobby(redisClient, client, db, username, sio_casted)))
+
+		// Send a private message to a friend, delivered live if they're connected
+		socketio_events.On(client, username, "send_direct_message", socketio_events.RateLimited(redisClient, handlers.HandleSendDirectMessage(db, username, sio_casted)))
+
+		// The host can prevent a player from sending messages to the lobby chat
+		socketio_events.On(client, username, "mute_player", handlers.HandleMutePlayer(redisClient, client, db, username, sio_casted))
+		socketio_events.On(client, username, "unmute_player", handlers.HandleUnmutePlayer(redisClient, client, db, username, sio_casted))
 
 		// NOTE: will remove sio connection from map
-		client.On("disconnecting", handlers.HandleDisconnecting(username, sio_casted))
+		client.On("disconnecting", handlers.HandleDisconnecting(redisClient, client, username, sio_casted))
+		client.On("disconnecting", handlers.HandleLeaveQueueOnDisconnect(redisClient, username))
+		client.On("disconnecting", handlers.HandleStopSpectatingOnDisconnect(redisClient, client, username))
+
+		// Join and leave the matchmaking queue
+		socketio_events.On(client, username, "join_queue", handlers.HandleJoinQueue(redisClient, client, db, username))
+		socketio_events.On(client, username, "cancel_queue", handlers.HandleCancelQueue(redisClient, client, username))
+
+		// Watch a lobby without playing, and let the host decide if it can be watched
+		socketio_events.On(client, username, "spectate_lobby", handlers.HandleSpectateLobby(redisClient, client, db, username, sio_casted))
+		socketio_events.On(client, username, "stop_spectating", handlers.HandleStopSpectating(redisClient, client, username, sio_casted))
+		socketio_events.On(client, username, "set_spectating", handlers.HandleSetSpectating(redisClient, client, db, username, sio_casted))
 
 		// Start game
-		client.On("start_game", handlers.HandleStartGame(redisClient, client, db, username, sio_casted))
+		socketio_events.On(client, username, "start_game", handlers.HandleStartGame(redisClient, client, db, username, sio_casted))
 
 		// Play a hand and recieve the type of hand and the points scored
-		client.On("play_hand", handlers.HandlePlayHand(redisClient, client, db, username, sio_casted))
+		socketio_events.On(client, username, "play_hand", socketio_events.RateLimited(redisClient, handlers.HandlePlayHand(redisClient, client, db, username, sio_casted)))
 
-		client.On("get_cards", handlers.HandleGetCards(redisClient, client, db, username, sio_casted))
+		socketio_events.On(client, username, "get_cards", handlers.HandleGetCards(redisClient, client, db, username, sio_casted))
 
-		client.On("discard_cards", handlers.HandleDiscardCards(redisClient, client, db, username, sio_casted))
+		socketio_events.On(client, username, "discard_cards", handlers.HandleDiscardCards(redisClient, client, db, username, sio_casted))
 
-		client.On("get_full_deck", handlers.HandleGetFullDeck(redisClient, client, db, username))
+		socketio_events.On(client, username, "get_full_deck", handlers.HandleGetFullDeck(redisClient, client, db, username))
 
-		client.On("propose_blind", handlers.HandleProposeBlind(redisClient, client, db, username, sio_casted))
+		socketio_events.On(client, username, "propose_blind", handlers.HandleProposeBlind(redisClient, client, db, username, sio_casted))
 
-		client.On("request_game_phase_player_info", handlers.HandleRequestGamePhaseInfo(redisClient, client, db, username))
+		socketio_events.On(client, username, "request_game_phase_player_info", handlers.HandleRequestGamePhaseInfo(redisClient, client, db, username))
 
-		client.On("continue_to_next_blind", handlers.HandleContinueToNextBlind(redisClient, client, db, username, sio_casted))
+		socketio_events.On(client, username, "continue_to_next_blind", handlers.HandleContinueToNextBlind(redisClient, client, db, username, sio_casted))
 
-		client.On("activate_modifiers", handlers.HandleActivateModifiers(redisClient, client, db, username, sio_casted))
+		socketio_events.On(client, username, "activate_modifiers", handlers.HandleActivateModifiers(redisClient, client, db, username, sio_casted))
 
-		client.On("send_modifiers", handlers.HandleSendModifiers(redisClient, client, db, username, sio_casted))
+		socketio_events.On(client, username, "send_modifiers", handlers.HandleSendModifiers(redisClient, client, db, username, sio_casted))
 
-		client.On("continue_to_vouchers", handlers.HandleContinueToVouchers(redisClient, client, db, username, sio_casted))
+		socketio_events.On(client, username, "continue_to_vouchers", handlers.HandleContinueToVouchers(redisClient, client, db, username, sio_casted))
 
-		client.On("get_phase_timeout", handlers.HandleGetPhaseTimeout(redisClient, client, db, username))
+		socketio_events.On(client, username, "get_phase_timeout", handlers.HandleGetPhaseTimeout(redisClient, client, db, username))
 
 		// TODO, NOTE: should be already covered with activate_modifiers and send_modifiers
 		//// client.On("play_voucher", handlers.HandlePlayVoucher(redisClient, client, db, username, sio_casted))
 
-		client.On("buy_joker", handlers.HandleBuyJoker(redisClient, client, db, username, sio_casted))
+		socketio_events.On(client, username, "buy_joker", handlers.HandleBuyJoker(redisClient, client, db, username, sio_casted))
+
+		socketio_events.On(client, username, "buy_voucher", handlers.HandleBuyVoucher(redisClient, client, db, username, sio_casted))
 
-		client.On("buy_voucher", handlers.HandleBuyVoucher(redisClient, client, db, username, sio_casted))
+		socketio_events.On(client, username, "buy_pack", handlers.HandlePurchasePack(redisClient, client, db, username))
 
-		client.On("buy_pack", handlers.HandlePurchasePack(redisClient, client, db, username))
+		// Planets level up a hand type as soon as they are bought
+		socketio_events.On(client, username, "buy_planet", handlers.HandleBuyPlanet(redisClient, client, db, username, sio_casted))
 
-		client.On("choose_pack_items", handlers.HandlePackSelection(redisClient, client, db, username, sio_casted))
+		socketio_events.On(client, username, "choose_pack_items", handlers.HandlePackSelection(redisClient, client, db, username, sio_casted))
 
-		client.On("reroll_shop", handlers.HandleRerollShop(redisClient, client, db, username, sio_casted))
+		socketio_events.On(client, username, "reroll_shop", socketio_events.RateLimited(redisClient, handlers.HandleRerollShop(redisClient, client, db, username, sio_casted)))
 
 		// TODO: sell_joker
-		client.On("sell_joker", handlers.HandleSellJoker(redisClient, client, db, username))
+		socketio_events.On(client, username, "sell_joker", handlers.HandleSellJoker(redisClient, client, db, username))
+
+		// Jokers are applied in the order they are stored
+		socketio_events.On(client, username, "reorder_jokers", handlers.HandleReorderJokers(redisClient, client, db, username))
 	})
 
 	// NOTE: igual lo usamos en algún momento
@@ -143,6 +182,12 @@ func (sio *MySocketServer) Start(router *gin.Engine, db *gorm.DB, redisClient *r
 	router.POST("/socket.io/*f", gin.WrapH(sio.Sio_server.ServeHandler(c)))
 	router.GET("/socket.io/*f", gin.WrapH(sio.Sio_server.ServeHandler(c)))
 
+	// Resume the phase timers left by a previous run and keep firing the new ones
+	game_flow.StartPhaseScheduler(redisClient, db, (*socketio_types.SocketServer)(sio))
+
+	// Match the players waiting in the matchmaking queue
+	lobby_setup.StartMatchmaker(redisClient, db, (*socketio_types.SocketServer)(sio))
+
 	SignalC := make(chan os.Signal, 1)
 
 	signal.Notify(SignalC, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
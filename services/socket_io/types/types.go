@@ -1,6 +1,8 @@
 package socketio_types
 
 import (
+	"Nogler/services/redis"
+	"log"
 	"sync"
 
 	"github.com/zishang520/socket.io/v2/socket"
@@ -10,9 +12,11 @@ import (
 // It is used to handle socket.io connections.
 type SocketServer struct {
 	Sio_server *socket.Server
-	// Map to track username -> socket connections
+	// Map to track username -> socket connections of this server instance
 	UserConnections map[string]*socket.Socket
 	Mutex           sync.RWMutex
+	// Keeps track of the users connected to every server instance
+	Redis *redis.RedisClient
 }
 
 func NewSocketServer() *SocketServer {
@@ -21,22 +25,98 @@ func NewSocketServer() *SocketServer {
 	}
 }
 
+// UserRoom returns the room every socket of a user joins, used to reach the user from any
+// server instance
+func UserRoom(username string) socket.Room {
+	return socket.Room("user:" + username)
+}
+
 // Add methods to manage connections
 func (s *SocketServer) AddConnection(username string, socket *socket.Socket) {
 	s.Mutex.Lock()
-	defer s.Mutex.Unlock()
 	s.UserConnections[username] = socket
+	s.Mutex.Unlock()
+
+	socket.Join(UserRoom(username))
+	if s.Redis != nil {
+		if err := s.Redis.SetUserConnection(username, string(socket.Id())); err != nil {
+			log.Printf("[CONNECTION-ERROR] %v", err)
+		}
+	}
 }
 
-func (s *SocketServer) RemoveConnection(username string) {
+// RemoveConnection forgets the connection of a user, if it's still the given socket
+// Returns: whether it was the current connection of the user
+func (s *SocketServer) RemoveConnection(username string, socket *socket.Socket) bool {
 	s.Mutex.Lock()
-	defer s.Mutex.Unlock()
-	delete(s.UserConnections, username)
+	current := s.UserConnections[username] == socket
+	if current {
+		delete(s.UserConnections, username)
+	}
+	s.Mutex.Unlock()
+
+	if s.Redis == nil {
+		return current
+	}
+	// The user may have connected again to another server instance
+	removed, err := s.Redis.DeleteUserConnection(username, string(socket.Id()))
+	if err != nil {
+		log.Printf("[CONNECTION-ERROR] %v", err)
+		return current
+	}
+	return removed
 }
 
+// GetConnection returns the socket of a user connected to this server instance. To reach
+// a user connected to any instance, use GetUser
 func (s *SocketServer) GetConnection(username string) (*socket.Socket, bool) {
 	s.Mutex.RLock()
 	defer s.Mutex.RUnlock()
 	socket, exists := s.UserConnections[username]
 	return socket, exists
 }
+
+// IsConnected returns whether a user is connected to any server instance
+func (s *SocketServer) IsConnected(username string) bool {
+	if _, exists := s.GetConnection(username); exists {
+		return true
+	}
+	if s.Redis == nil {
+		return false
+	}
+	connected, err := s.Redis.IsUserConnected(username)
+	if err != nil {
+		log.Printf("[CONNECTION-ERROR] %v", err)
+		return false
+	}
+	return connected
+}
+
+// UserConnection reaches the sockets of a user, whichever server instance they're connected to
+type UserConnection struct {
+	sio      *SocketServer
+	username string
+}
+
+// GetUser returns the connection of a user connected to any server instance
+func (s *SocketServer) GetUser(username string) (*UserConnection, bool) {
+	if !s.IsConnected(username) {
+		return nil, false
+	}
+	return &UserConnection{sio: s, username: username}, true
+}
+
+// Emit sends an event to the user
+func (c *UserConnection) Emit(ev string, args ...any) error {
+	return c.sio.Sio_server.To(UserRoom(c.username)).Emit(ev, args...)
+}
+
+// Join makes the sockets of the user join a room
+func (c *UserConnection) Join(room socket.Room) {
+	c.sio.Sio_server.In(UserRoom(c.username)).SocketsJoin(room)
+}
+
+// Leave makes the sockets of the user leave a room
+func (c *UserConnection) Leave(room socket.Room) {
+	c.sio.Sio_server.In(UserRoom(c.username)).SocketsLeave(room)
+}
@@ -0,0 +1,39 @@
+package socketio_utils
+
+import (
+	redis_models "Nogler/models/redis"
+	"Nogler/services/redis"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// Appends a state-changing event to the lobby's replay log, tagged with the lobby's
+// current round and phase. The replay is not critical for the game, so errors are only logged
+func RecordGameEvent(redisClient *redis.RedisClient, lobbyID string, eventType string, username string, data interface{}) {
+	lobby, err := redisClient.GetGameLobby(lobbyID)
+	if err != nil {
+		log.Printf("[REPLAY-ERROR] Error getting lobby %s to record %s: %v", lobbyID, eventType, err)
+		return
+	}
+
+	event := redis_models.GameEvent{
+		Type:      eventType,
+		Username:  username,
+		Round:     lobby.CurrentRound,
+		Phase:     lobby.CurrentPhase,
+		Timestamp: time.Now(),
+	}
+
+	if data != nil {
+		event.Data, err = json.Marshal(data)
+		if err != nil {
+			log.Printf("[REPLAY-ERROR] Error serializing %s event data: %v", eventType, err)
+			return
+		}
+	}
+
+	if err := redisClient.AppendGameEvent(lobbyID, event); err != nil {
+		log.Printf("[REPLAY-ERROR] Error recording %s event in lobby %s: %v", eventType, lobbyID, err)
+	}
+}
@@ -11,10 +11,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"math/rand"
 
 	"github.com/gin-gonic/gin"
 	"github.com/zishang520/socket.io/v2/socket"
+	"golang.org/x/exp/rand"
 	"gorm.io/gorm"
 )
 
@@ -81,7 +81,7 @@ func ProposeBlindAI(redisClient *redis.RedisClient, lobbyID string, sio *socketi
 		return
 	}
 
-	AI, err := getAIplayer(redisClient, lobbyID)
+	_, err = getAIplayer(redisClient, lobbyID)
 	if err != nil {
 		log.Printf("[AI-BLIND-ERROR] Error getting player data: %v", err)
 		return
@@ -91,28 +91,30 @@ func ProposeBlindAI(redisClient *redis.RedisClient, lobbyID string, sio *socketi
 	// AIMoney := AI.PlayersMoney
 
 	// TODO: check if we should do this or maybe just bet always for the minimum blind
-	// proposedBlind := AIMoney/2 + rand.Intn(AIMoney-AIMoney/2+1)
+	// proposedBlind := AIMoney/2 + rng.Intn(AIMoney-AIMoney/2+1)
+
+	rng := poker.NewGameRNG(lobby.Seed, lobby.CurrentRound, "ai_blind", currentAIPlayerUsername)
 
 	// TODO, change it
 	baseBlind := lobby.CurrentBaseBlind
 	var proposedBlind int
 	if lobby.CurrentRound < 4 {
 		// If it's the first round, set a low blind
-		i := rand.Intn(3) + 1 // +10
-		j := rand.Intn(9) + 1 // +1
+		i := rng.Intn(3) + 1 // +10
+		j := rng.Intn(9) + 1 // +1
 		proposedBlind = baseBlind + (10 * i) + j
 	} else {
 		// If it's not the first round, set a higher blind
 		// 66% +10 blind, 33% +100 blind
-		blind := rand.Intn(3) + 1
+		blind := rng.Intn(3) + 1
 		if blind == 1 || blind == 2 {
-			i := rand.Intn(7) + 1 // +10
-			j := rand.Intn(9) + 1 // +1
+			i := rng.Intn(7) + 1 // +10
+			j := rng.Intn(9) + 1 // +1
 			proposedBlind = baseBlind + (10 * i) + j
 		} else if blind == 3 {
-			i := rand.Intn(3) + 1 // +100
-			j := rand.Intn(4) + 1 // +10
-			k := rand.Intn(9) + 1 // +1
+			i := rng.Intn(3) + 1 // +100
+			j := rng.Intn(4) + 1 // +10
+			k := rng.Intn(9) + 1 // +1
 			proposedBlind = baseBlind + (100 * i) + (10 * j) + k
 		}
 	}
@@ -124,50 +126,50 @@ func ProposeBlindAI(redisClient *redis.RedisClient, lobbyID string, sio *socketi
 		proposedBlind = game_constants.MAX_BLIND
 	}
 
-	// Save player data
-	if err := redisClient.SaveInGamePlayer(AI); err != nil {
-		log.Printf("[AI-BLIND-ERROR] Error saving player data: %v", err)
-		return
-	}
+	// Register the proposal and raise the blind in a single update, so it can't
+	// overwrite the proposals of the other players
+	var currentBlind int
+	blindRaised := false
+	lobby, err = redisClient.UpdateGameLobby(lobbyID, func(lobby *redis_models.GameLobby) error {
+		currentBlind = lobby.CurrentHighBlind
+		blindRaised = false
 
-	currentBlind, err := redisClient.GetCurrentBlind(lobbyID)
+		// Increment the counter of proposed blinds (NEW, using a map to avoid same user incrementing the counter several times)
+		lobby.ProposedBlinds[currentAIPlayerUsername] = true
+
+		// Update current blind if this proposal is higher
+		if proposedBlind > lobby.CurrentHighBlind {
+			lobby.CurrentHighBlind = proposedBlind
+			lobby.HighestBlindProposer = currentAIPlayerUsername
+			blindRaised = true
+		}
+		return nil
+	})
 	if err != nil {
-		log.Printf("[AI-BLIND-ERROR] Error getting current blind: %v", err)
+		log.Printf("[AI-BLIND-ERROR] Error updating game lobby: %v", err)
 		return
 	}
-
-	// Increment the counter of proposed blinds (NEW, using a map to avoid same user incrementing the counter several times)
-	lobby.ProposedBlinds[currentAIPlayerUsername] = true
 	log.Printf("[AI-BLIND] Player %s proposed blind: %d: . Total proposals: %d/%d",
 		currentAIPlayerUsername, proposedBlind, len(lobby.ProposedBlinds), lobby.PlayerCount)
 
-	// Save the updated lobby
-	err = redisClient.SaveGameLobby(lobby)
-	if err != nil {
-		log.Printf("[AI-BLIND-ERROR] Error saving game lobby: %v", err)
-		return
-	}
-
-	// Update current blind if this proposal is higher
-	if proposedBlind > currentBlind {
-		err := redisClient.SetCurrentHighBlind(lobbyID, proposedBlind, currentAIPlayerUsername)
-		if err != nil {
-			log.Printf("[AI-BLIND-ERROR] Could not update current blind: %v", err)
-			return
-		}
+	if blindRaised {
 
 		// Broadcast the new blind value to everyone in the lobby
-		sio.Sio_server.To(socket.Room(lobbyID)).Emit("blind_updated", gin.H{
+		sio.Sio_server.To(socket.Room(lobbyID), socketio_utils.SpectatorsRoom(lobbyID)).Emit("blind_updated", gin.H{
 			"old_max_blind": currentBlind,
 			"new_blind":     proposedBlind,
 			"proposed_by":   currentAIPlayerUsername,
 		})
 	}
+
+	socketio_utils.RecordGameEvent(redisClient, lobbyID, redis_models.EventProposeBlind, currentAIPlayerUsername, gin.H{
+		"proposed_blind": proposedBlind,
+	})
 }
 
 // GET CARDS
 
-func getCardsAI(redisClient *redis.RedisClient, player *redis_models.InGamePlayer) {
+func getCardsAI(redisClient *redis.RedisClient, player *redis_models.InGamePlayer, rng *rand.Rand) {
 	log.Printf("GetCardsAI request - %s", player.Username)
 
 	var err error
@@ -200,7 +202,7 @@ func getCardsAI(redisClient *redis.RedisClient, player *redis_models.InGamePlaye
 	}
 
 	// 4. Get the necessary cards
-	newCards := deck.Draw(cardsNeeded)
+	newCards := deck.Draw(rng, cardsNeeded)
 	if newCards == nil {
 		log.Printf("[AI-GET_CARDS-ERROR] Not enough cards in the deck")
 		return
@@ -248,8 +250,13 @@ func PlayHandAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, sio
 		return
 	}
 
+	rng, err := socketio_utils.GetLobbyRNG(redisClient, lobbyID, "ai_play_hand", player.Username)
+	if err != nil {
+		return
+	}
+
 	// Get cards
-	getCardsAI(redisClient, player)
+	getCardsAI(redisClient, player, rng)
 
 	for i := 0; i < 6; i++ {
 
@@ -274,9 +281,10 @@ func PlayHandAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, sio
 
 		// Iterate through all combinations to find the best hand
 		var bestTokens, bestMult int = 0, 0
-		var bestHandType int
+		var bestHandType poker.HandType
 		var bestScoredCards []poker.Card
 		var bestHand poker.Hand
+		bestTrace := &poker.ScoringTrace{}
 
 		var jokers poker.Jokers
 		err = json.Unmarshal(player.CurrentJokers, &jokers)
@@ -290,9 +298,12 @@ func PlayHandAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, sio
 				Cards:  combination,
 				Jokers: jokers,
 				Gold:   player.PlayersMoney,
+				Levels: player.HandLevels,
 			}
-			tokens, mult, handType, scoredCards := poker.BestHand(hand)
+			trace := &poker.ScoringTrace{}
+			tokens, mult, handType, scoredCards := poker.BestHand(hand, trace)
 			if tokens*mult > bestTokens*bestMult {
+				bestTrace = trace
 				bestTokens = tokens
 				bestMult = mult
 				bestHandType = handType
@@ -302,19 +313,20 @@ func PlayHandAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, sio
 		}
 		log.Printf("[AI-HAND] Best hand type: %d, Tokens: %d, Mult: %d, Cards: %v",
 			bestHandType, bestTokens, bestMult, bestScoredCards)
-		if bestHandType > 10 && player.DiscardsLeft > 0 {
+		// Discard when the best hand is a two pair or worse
+		if bestHandType >= poker.HandTwoPair && player.DiscardsLeft > 0 {
 			// Get 1 or 2 or 3 worst cards to discard
-			size := rand.Intn(3) + 1
+			size := rng.Intn(3) + 1
 			poker.SortCards(currentHand)
 			worstCards := currentHand[:size]
-			discardCardsAI(redisClient, player, worstCards) // Discard the worst cards
+			discardCardsAI(redisClient, player, worstCards, rng) // Discard the worst cards
 			continue
 		}
 
-		enhancedFichas, enhancedMult := poker.ApplyEnhancements(bestTokens, bestMult, bestScoredCards)
+		enhancedFichas, enhancedMult, enhancedGold := poker.ApplyEnhancements(rng, bestTokens, bestMult, bestHand.Gold, bestScoredCards, bestTrace)
 
 		// 4. Apply jokers (passing the hand which contains the jokers)
-		finalFichas, finalMult, finalGold, _ := poker.ApplyJokers(bestHand, bestHand.Jokers, enhancedFichas, enhancedMult, bestHand.Gold, player.Username)
+		finalFichas, finalMult, finalGold, jokersTriggered := poker.ApplyJokers(rng, bestHand, bestHand.Jokers, enhancedFichas, enhancedMult, enhancedGold, player.Username, bestTrace)
 
 		// 5. Apply modifiers
 
@@ -329,7 +341,7 @@ func PlayHandAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, sio
 		}
 
 		// Apply activated modifiers
-		finalFichas, finalMult, finalGold = poker.ApplyModifiers(bestHand, &activatedModifiers, finalFichas, finalMult, finalGold)
+		finalFichas, finalMult, finalGold = poker.ApplyModifiers(rng, bestHand, &activatedModifiers, finalFichas, finalMult, finalGold, bestTrace)
 		if err != nil {
 			log.Printf("[AI-HAND-ERROR] Error applying modifiers: %v", err)
 			return
@@ -346,7 +358,7 @@ func PlayHandAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, sio
 		}
 
 		// Apply received modifiers
-		finalFichas, finalMult, finalGold = poker.ApplyModifiers(bestHand, &receivedModifiers, finalFichas, finalMult, finalGold)
+		finalFichas, finalMult, finalGold = poker.ApplyModifiers(rng, bestHand, &receivedModifiers, finalFichas, finalMult, finalGold, bestTrace)
 		if err != nil {
 			log.Printf("[AI-HAND-ERROR] Error applying modifiers: %v", err)
 			return
@@ -354,16 +366,18 @@ func PlayHandAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, sio
 
 		valorFinal := finalFichas * finalMult
 
+		// Jokers stack and may destroy themselves after scoring
+		remainingJokers := bestHand.Jokers
+		destroyedJokers := remainingJokers.HandPlayed(rng, jokersTriggered)
+		player.CurrentJokers, err = json.Marshal(remainingJokers)
+		if err != nil {
+			log.Printf("[AI-HAND-ERROR] Error serializing jokers: %v", err)
+			return
+		}
+
 		// 6. Update player data in Redis
 		// Delete the played hand from the current hand
-		for _, card := range bestHand.Cards {
-			for i, c := range currentHand {
-				if c.Suit == card.Suit && c.Rank == card.Rank {
-					currentHand = append(currentHand[:i], currentHand[i+1:]...)
-					break
-				}
-			}
-		}
+		currentHand = poker.WithoutCards(currentHand, bestHand.Cards)
 
 		var deck *poker.Deck
 		if player.CurrentDeck != nil {
@@ -380,7 +394,7 @@ func PlayHandAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, sio
 		}
 
 		// Get new cards from the deck
-		newCards := deck.Draw(len(bestHand.Cards))
+		newCards := deck.Draw(rng, len(bestHand.Cards))
 		if newCards == nil {
 			log.Printf("[AI-DECK-ERROR] Not enough cards in the deck")
 			return
@@ -400,6 +414,7 @@ func PlayHandAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, sio
 
 		player.CurrentRoundPoints += valorFinal
 		player.TotalGamePoints += valorFinal
+		player.RecordPlayedHand(bestHandType, valorFinal)
 		player.HandPlaysLeft--
 		err = redisClient.UpdateDeckPlayer(*player)
 		if err != nil {
@@ -412,6 +427,22 @@ func PlayHandAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, sio
 			player.Username, bestHand.Cards, finalFichas, finalMult, finalGold)
 		log.Printf("[AI-HAND] Player %s scored: %d. Current round score: %d", player.Username,
 			valorFinal, player.CurrentRoundPoints)
+
+		socketio_utils.RecordGameEvent(redisClient, lobbyID, redis_models.EventPlayHand, player.Username, gin.H{
+			"cards":            bestHand.Cards,
+			"jokers":           bestHand.Jokers,
+			"hand_type":        bestHandType,
+			"scored_cards":     bestScoredCards,
+			"jokers_triggered": jokersTriggered,
+			"destroyed_jokers": destroyedJokers,
+			"fichas":           finalFichas,
+			"mult":             finalMult,
+			"total_score":      valorFinal,
+			"gold":             finalGold,
+			"new_cards":        newCards,
+			"scoring_steps":    bestTrace.Steps,
+		})
+		socketio_utils.EmitPlayerScored(sio, lobbyID, player, bestHandType, valorFinal)
 		// 7. Emit success response (FRONTEND WILL USE IT??????? SOME OF THEM????)
 		/*
 			client.Emit("AI_played_hand", gin.H{
@@ -436,7 +467,7 @@ func PlayHandAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, sio
 	}
 }
 
-func discardCardsAI(redisClient *redis.RedisClient, player *redis_models.InGamePlayer, discard []poker.Card) {
+func discardCardsAI(redisClient *redis.RedisClient, player *redis_models.InGamePlayer, discard []poker.Card, rng *rand.Rand) {
 
 	log.Printf("[AI-DISCARD] Discarding cards")
 
@@ -464,7 +495,7 @@ func discardCardsAI(redisClient *redis.RedisClient, player *redis_models.InGameP
 	}
 
 	// 5. Get new cards from the deck
-	newCards := deck.Draw(len(discard))
+	newCards := deck.Draw(rng, len(discard))
 	if newCards == nil {
 		return
 	}
@@ -475,14 +506,7 @@ func discardCardsAI(redisClient *redis.RedisClient, player *redis_models.InGameP
 	player.CurrentDeck = deck.ToJSON()
 
 	// Remove the discarded cards from the hand
-	for _, card := range discard {
-		for i, c := range hand {
-			if c.Suit == card.Suit && c.Rank == card.Rank {
-				hand = append(hand[:i], hand[i+1:]...)
-				break
-			}
-		}
-	}
+	hand = poker.WithoutCards(hand, discard)
 	// Add the new cards to the hand
 	hand = append(hand, newCards...)
 	player.CurrentHand, err = json.Marshal(hand)
@@ -501,6 +525,11 @@ func discardCardsAI(redisClient *redis.RedisClient, player *redis_models.InGameP
 	}
 
 	log.Printf("[AI-DISCARD] Player %s discarded cards: %v", player.Username, discard)
+
+	socketio_utils.RecordGameEvent(redisClient, player.LobbyId, redis_models.EventDiscardCards, player.Username, gin.H{
+		"cards":     discard,
+		"new_cards": newCards,
+	})
 }
 
 func updateModifiersAI(redisClient *redis.RedisClient, player *redis_models.InGamePlayer) {
@@ -601,20 +630,16 @@ func checkAIFinishedRound(redisClient *redis.RedisClient, db *gorm.DB, lobbyID s
 		}
 
 		// Mark player as finished in the lobby
-		if lobby.PlayersFinishedRound == nil {
-			lobby.PlayersFinishedRound = make(map[string]bool)
-		}
-
-		lobby.PlayersFinishedRound[player.Username] = true
-		log.Printf("[ROUND-CHECK] Incremented finished players count to %d/%d for lobby %s",
-			len(lobby.PlayersFinishedRound), lobby.PlayerCount, lobbyID)
-
-		// Save the updated lobby
-		err = redisClient.SaveGameLobby(lobby)
+		lobby, err = redisClient.UpdateGameLobby(lobbyID, func(lobby *redis_models.GameLobby) error {
+			lobby.PlayersFinishedRound[player.Username] = true
+			return nil
+		})
 		if err != nil {
-			log.Printf("[ROUND-CHECK-ERROR] Error saving lobby: %v", err)
+			log.Printf("[ROUND-CHECK-ERROR] Error updating lobby: %v", err)
 			return false
 		}
+		log.Printf("[ROUND-CHECK] Incremented finished players count to %d/%d for lobby %s",
+			len(lobby.PlayersFinishedRound), lobby.PlayerCount, lobbyID)
 
 		// Update modifiers
 		updateModifiersAI(redisClient, player)
@@ -661,10 +686,12 @@ func ShopAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, shopSta
 		return
 	}
 
+	rng := poker.NewGameRNG(lobbyState.Seed, lobbyState.CurrentRound, "ai_shop", playerState.Username)
+
 	// If AI has less than 4 money, sell a joker if exists
 	if playerState.PlayersMoney < 4 {
 		// 33% chance to sell a joker
-		randomValue := rand.Intn(3)
+		randomValue := rng.Intn(3)
 		if randomValue == 0 {
 			var jokers poker.Jokers
 			err = json.Unmarshal(playerState.CurrentJokers, &jokers)
@@ -681,14 +708,14 @@ func ShopAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, shopSta
 			if numJokers == 0 {
 				log.Printf("[AI-SHOP-ERROR] No jokers to sell for player %s", playerState.Username)
 			} else {
-				jokerToSell := rand.Intn(numJokers)
+				jokerToSell := rng.Intn(numJokers)
 				sellJokerAI(redisClient, playerState, jokers.Juglares[jokerToSell])
 				// If the AI has more than 3 jokers, sell another one
 				if numJokers > 3 {
 					// Sell other joker
-					jokerToSell2 := rand.Intn(numJokers)
+					jokerToSell2 := rng.Intn(numJokers)
 					for jokerToSell2 == jokerToSell {
-						jokerToSell2 = rand.Intn(numJokers)
+						jokerToSell2 = rng.Intn(numJokers)
 					}
 					if jokerToSell2 != jokerToSell {
 						sellJokerAI(redisClient, playerState, jokers.Juglares[jokerToSell2])
@@ -712,9 +739,9 @@ func ShopAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, shopSta
 		}
 		if numJokers == 5 {
 			// 33% chance to sell a joker
-			randomValue := rand.Intn(3)
+			randomValue := rng.Intn(3)
 			if randomValue == 0 {
-				jokerToSell := rand.Intn(numJokers)
+				jokerToSell := rng.Intn(numJokers)
 				sellJokerAI(redisClient, playerState, jokers.Juglares[jokerToSell])
 			}
 		}
@@ -723,10 +750,10 @@ func ShopAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, shopSta
 	// Order to buy pack (0), joker (1) or voucher (2)
 	var order []int
 	for i := 0; i < 3; i++ {
-		randomValue := rand.Intn(3)
+		randomValue := rng.Intn(3)
 		for j := 0; j < len(order); j++ {
 			if order[j] == randomValue {
-				randomValue = rand.Intn(3)
+				randomValue = rng.Intn(3)
 				j = -1
 			}
 		}
@@ -734,25 +761,25 @@ func ShopAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, shopSta
 	}
 
 	// Until which one do we buy?
-	until := rand.Intn(3)
+	until := rng.Intn(3)
 	for i := 0; i <= until; i++ {
 		// How many do we buy? (1 or 2)
-		howMany := rand.Intn(2) + 1
+		howMany := rng.Intn(2) + 1
 		for j := 0; j < howMany; j++ {
 			switch order[i] {
 			case 0:
 				// Buy pack
 				// Which pack?
-				which := rand.Intn(len(shopState.FixedPacks))
+				which := rng.Intn(len(shopState.FixedPacks))
 				item := shopState.FixedPacks[which]
 				itemID := item.ID
 				price := shopState.FixedPacks[which].Price
-				purchasePackAI(redisClient, playerState, lobbyState, item, itemID, price)
+				purchasePackAI(redisClient, playerState, lobbyState, item, itemID, price, rng)
 			case 1:
 				// Buy joker
 				// Which joker?
 
-				// Check if already has 5 jokers
+				// Check if already has all the joker slots filled
 				if playerState.CurrentJokers != nil {
 					var jokers poker.Jokers
 					err = json.Unmarshal(playerState.CurrentJokers, &jokers)
@@ -760,8 +787,8 @@ func ShopAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, shopSta
 						log.Printf("[AI-SHOP-ERROR] Error parsing jokers: %v", err)
 						return
 					}
-					if len(jokers.Juglares) >= 5 {
-						log.Printf("[AI-SHOP-ERROR] Player %s already has 5 jokers", playerState.Username)
+					if len(jokers.Juglares) >= lobbyState.Rules.MaxJokers {
+						log.Printf("[AI-SHOP-ERROR] Player %s already has %d jokers", playerState.Username, lobbyState.Rules.MaxJokers)
 						continue
 					}
 				}
@@ -769,17 +796,17 @@ func ShopAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, shopSta
 				// NEW: Check the jokers of the LATEST reroll
 				total_rerolls_len := len(shopState.Rerolled)
 				if total_rerolls_len > 0 {
-					which := rand.Intn(len(shopState.Rerolled[total_rerolls_len-1].Jokers))
+					which := rng.Intn(len(shopState.Rerolled[total_rerolls_len-1].Jokers))
 					item := shopState.Rerolled[total_rerolls_len-1].Jokers[which]
 					itemID := item.ID
 					price := item.Price
-					purchaseJokerAI(redisClient, playerState, item, itemID, price)
+					purchaseJokerAI(redisClient, playerState, item, itemID, price, lobbyState.Rules.MaxJokers)
 				}
 			case 2:
 				// Buy voucher
 				// Which voucher?
 				if len(shopState.FixedModifiers) > 0 {
-					which := rand.Intn(len(shopState.FixedModifiers))
+					which := rng.Intn(len(shopState.FixedModifiers))
 					item := shopState.FixedModifiers[which]
 					itemID := item.ID
 					price := shopState.FixedModifiers[which].Price
@@ -794,7 +821,7 @@ func ShopAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, shopSta
 }
 
 func purchasePackAI(redisClient *redis.RedisClient, playerState *redis_models.InGamePlayer,
-	lobbyState *redis_models.GameLobby, item redis_models.ShopItem, itemID int, clientPrice int) {
+	lobbyState *redis_models.GameLobby, item redis_models.ShopItem, itemID int, clientPrice int, rng *rand.Rand) {
 	log.Printf("[AI-SHOP] Purchasing pack %d for player %s", itemID, playerState.Username)
 
 	// Validate the purchase
@@ -815,22 +842,29 @@ func purchasePackAI(redisClient *redis.RedisClient, playerState *redis_models.In
 	playerState.LastPurchasedPackItemId = itemID
 	playerState.PlayersMoney -= item.Price // Deduct the money
 
-	packSelectionAI(redisClient, playerState, lobbyState, itemID, item, content)
+	packSelectionAI(redisClient, playerState, lobbyState, itemID, item, content, rng)
 
 	// Save the updated player state
 	if err := redisClient.SaveInGamePlayer(playerState); err != nil {
 		log.Printf("[AI-SHOP-ERROR] Error saving player state: %v", err)
 		return
 	}
+
+	socketio_utils.RecordGameEvent(redisClient, playerState.LobbyId, redis_models.EventBuyPack, playerState.Username, gin.H{
+		"item_id":   item.ID,
+		"pack_type": item.PackType,
+		"price":     item.Price,
+		"contents":  content,
+	})
 }
 
 func purchaseJokerAI(redisClient *redis.RedisClient, playerState *redis_models.InGamePlayer,
-	item redis_models.ShopItem, itemID int, clientPrice int) {
+	item redis_models.ShopItem, itemID int, clientPrice int, maxJokers int) {
 
 	log.Printf("[AI-SHOP] Purchasing joker %d for player %s", itemID, playerState.Username)
 
 	// Process the joker purchase with price validation
-	success, updatedPlayer, err := shop.PurchaseJoker(redisClient, playerState, item, clientPrice)
+	success, updatedPlayer, err := shop.PurchaseJoker(redisClient, playerState, item, clientPrice, maxJokers)
 	if err != nil || !success {
 		log.Printf("[AI-SHOP-ERROR] Purchase failed: %v", err)
 		return
@@ -841,6 +875,12 @@ func purchaseJokerAI(redisClient *redis.RedisClient, playerState *redis_models.I
 		log.Printf("[AI-SHOP-ERROR] Error saving player state: %v", err)
 		return
 	}
+
+	socketio_utils.RecordGameEvent(redisClient, playerState.LobbyId, redis_models.EventBuyJoker, playerState.Username, gin.H{
+		"item_id":  item.ID,
+		"joker_id": item.JokerId,
+		"price":    item.Price,
+	})
 }
 
 func purchaseVoucherAI(redisClient *redis.RedisClient, playerState *redis_models.InGamePlayer,
@@ -858,6 +898,12 @@ func purchaseVoucherAI(redisClient *redis.RedisClient, playerState *redis_models
 		log.Printf("[AI-SHOP-ERROR] Error saving player state: %v", err)
 		return
 	}
+
+	socketio_utils.RecordGameEvent(redisClient, playerState.LobbyId, redis_models.EventBuyVoucher, playerState.Username, gin.H{
+		"item_id":    item.ID,
+		"voucher_id": item.ModifierId,
+		"price":      item.Price,
+	})
 }
 
 func sellJokerAI(redisClient *redis.RedisClient, playerState *redis_models.InGamePlayer, jokerID int) {
@@ -874,10 +920,14 @@ func sellJokerAI(redisClient *redis.RedisClient, playerState *redis_models.InGam
 		log.Printf("[AI-SHOP-ERROR] Error saving player state: %v", err)
 		return
 	}
+
+	socketio_utils.RecordGameEvent(redisClient, playerState.LobbyId, redis_models.EventSellJoker, playerState.Username, gin.H{
+		"joker_id": jokerID,
+	})
 }
 
 func packSelectionAI(redisClient *redis.RedisClient, playerState *redis_models.InGamePlayer,
-	lobbyState *redis_models.GameLobby, itemID int, item redis_models.ShopItem, content *redis_models.PackContents) {
+	lobbyState *redis_models.GameLobby, itemID int, item redis_models.ShopItem, content *redis_models.PackContents, rng *rand.Rand) {
 	log.Printf("PackSelectionAI initiated - User: %s", playerState.Username)
 
 	// Select items
@@ -887,24 +937,24 @@ func packSelectionAI(redisClient *redis.RedisClient, playerState *redis_models.I
 		// Select cards
 		whichCards := []int{}
 		for i := 0; i < item.MaxSelectable; i++ {
-			whichCard := rand.Intn(len(content.Cards))
+			whichCard := rng.Intn(len(content.Cards))
 			// Check if the card is already selected
 			for contains(whichCards, whichCard) {
-				whichCard = rand.Intn(len(content.Cards))
+				whichCard = rng.Intn(len(content.Cards))
 			}
 			whichCards = append(whichCards, whichCard)
 		}
 
-		selectedCards := make([]poker.Card, len(whichCards))
+		selectedCardIDs := make([]int, len(whichCards))
 		for i, cardIndex := range whichCards {
-			selectedCards[i] = content.Cards[cardIndex]
+			selectedCardIDs[i] = content.Cards[cardIndex].ID
 		}
 
-		selectionsMap["selectedCards"] = selectedCards
+		selectionsMap["selectedCards"] = selectedCardIDs
 	}
 
 	if len(content.Jokers) > 0 {
-		// Check if the player already has 5 jokers
+		// Check if the player already has all the joker slots filled
 		if playerState.CurrentJokers != nil {
 			var jokers poker.Jokers
 			err := json.Unmarshal(playerState.CurrentJokers, &jokers)
@@ -912,18 +962,18 @@ func packSelectionAI(redisClient *redis.RedisClient, playerState *redis_models.I
 				log.Printf("[AI-SHOP-ERROR] Error parsing jokers: %v", err)
 				return
 			}
-			if len(jokers.Juglares) >= 5 {
-				log.Printf("[AI-SHOP-ERROR] Player %s already has 5 jokers", playerState.Username)
+			if len(jokers.Juglares) >= lobbyState.Rules.MaxJokers {
+				log.Printf("[AI-SHOP-ERROR] Player %s already has %d jokers", playerState.Username, lobbyState.Rules.MaxJokers)
 				return
 			}
 		}
 		// Select jokers
 		whichJokers := []int{}
 		for j := 0; j < item.MaxSelectable; j++ {
-			whichJoker := rand.Intn(len(content.Jokers))
+			whichJoker := rng.Intn(len(content.Jokers))
 			// Check if the joker is already selected
 			for contains(whichJokers, whichJoker) {
-				whichJoker = rand.Intn(len(content.Jokers))
+				whichJoker = rng.Intn(len(content.Jokers))
 			}
 			whichJokers = append(whichJokers, whichJoker)
 		}
@@ -938,10 +988,10 @@ func packSelectionAI(redisClient *redis.RedisClient, playerState *redis_models.I
 		// Select vouchers
 		whichVouchers := []int{}
 		for i := 0; i < item.MaxSelectable; i++ {
-			whichVoucher := rand.Intn(len(content.Vouchers))
+			whichVoucher := rng.Intn(len(content.Vouchers))
 			// Check if the voucher is already selected
 			for contains(whichVouchers, whichVoucher) {
-				whichVoucher = rand.Intn(len(content.Vouchers))
+				whichVoucher = rng.Intn(len(content.Vouchers))
 			}
 			whichVouchers = append(whichVouchers, whichVoucher)
 		}
@@ -952,6 +1002,12 @@ func packSelectionAI(redisClient *redis.RedisClient, playerState *redis_models.I
 		selectionsMap["selectedVouchers"] = selectedVouchers
 	}
 
+	if len(content.Planets) > 0 {
+		// Select the first planets, they are already random
+		howMany := min(item.MaxSelectable, len(content.Planets))
+		selectionsMap["selectedPlanets"] = content.Planets[:howMany]
+	}
+
 	log.Printf("[AI-SHOP] Pack selection for player %s: %v", playerState.Username, selectionsMap)
 
 	// Verify that the player actually bought this pack
@@ -993,6 +1049,11 @@ func VouchersAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, sio
 		return
 	}
 
+	rng, err := socketio_utils.GetLobbyRNG(redisClient, lobbyID, "ai_vouchers", player.Username)
+	if err != nil {
+		return
+	}
+
 	var modifiers poker.Modifiers
 	err = json.Unmarshal(player.Modifiers, &modifiers)
 	if err != nil {
@@ -1006,17 +1067,17 @@ func VouchersAI(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, sio
 		// Order vouchers
 		var order []int
 		for i := 0; i < numModifiers; i++ {
-			randomValue := rand.Intn(numModifiers)
+			randomValue := rng.Intn(numModifiers)
 			for j := 0; j < len(order); j++ {
 				if order[j] == randomValue {
-					randomValue = rand.Intn(3)
+					randomValue = rng.Intn(3)
 					j = -1
 				}
 			}
 			order = append(order, randomValue)
 		}
 		// How many vouchers to activate?
-		numVouchers := rand.Intn(numModifiers + 1)
+		numVouchers := rng.Intn(numModifiers + 1)
 		for i := 0; i < numVouchers; i++ {
 			if modifiers.Modificadores[i].Value == 0 {
 				continue
@@ -1167,21 +1228,18 @@ func sendVoucherAI(redisClient *redis.RedisClient, player *redis_models.InGamePl
 		Sender:   player.Username,
 	})
 
-	var receiver_modifiers poker.ReceivedModifiers
-	err = json.Unmarshal(receiver.ReceivedModifiers, &receiver_modifiers)
-	if err != nil {
-		log.Printf("[MODIFIER-ERROR] Error parsing modifiers: %v", err)
-		return
-	}
+	// Update the receiving player on its current state, it may be playing meanwhile
+	receiver, err = redisClient.UpdateInGamePlayer(receiver.Username, func(receiver *redis_models.InGamePlayer) error {
+		var receiver_modifiers poker.ReceivedModifiers
+		if err := json.Unmarshal(receiver.ReceivedModifiers, &receiver_modifiers); err != nil {
+			return fmt.Errorf("error parsing modifiers: %v", err)
+		}
 
-	receiver_modifiers.Received = append(receiver_modifiers.Received, activated_modifiers.Received...)
-	receiver.ReceivedModifiers, err = json.Marshal(receiver_modifiers)
-	if err != nil {
-		log.Printf("[MODIFIER-ERROR] Error marshaling modifiers: %v", err)
-		return
-	}
-	// Save the updated player data
-	err = redisClient.UpdateDeckPlayer(*receiver)
+		receiver_modifiers.Received = append(receiver_modifiers.Received, activated_modifiers.Received...)
+		var err error
+		receiver.ReceivedModifiers, err = json.Marshal(receiver_modifiers)
+		return err
+	})
 	if err != nil {
 		log.Printf("[MODIFIER-ERROR] Error updating player data: %v", err)
 		return
@@ -1215,16 +1273,16 @@ func continueToNextBlind(redisClient *redis.RedisClient, db *gorm.DB, lobbyID st
 	log.Printf("[AI-NEXT-BLIND] %s requesting to continue to next blind in lobby %s", currentAIPlayerUsername, lobbyID)
 
 	// Increment the finished vouchers counter (NEW, using maps now)
-	lobby.PlayersFinishedVouchers[currentAIPlayerUsername] = true
-	log.Printf("[AI-NEXT-BLIND] Player %s ready for next blind. Total ready: %d/%d",
-		currentAIPlayerUsername, len(lobby.PlayersFinishedVouchers), lobby.PlayerCount)
-
-	// Save the updated lobby
-	err = redisClient.SaveGameLobby(lobby)
+	lobby, err = redisClient.UpdateGameLobby(lobbyID, func(lobby *redis_models.GameLobby) error {
+		lobby.PlayersFinishedVouchers[currentAIPlayerUsername] = true
+		return nil
+	})
 	if err != nil {
-		log.Printf("[AI-NEXT-BLIND-ERROR] Error saving game lobby: %v", err)
+		log.Printf("[AI-NEXT-BLIND-ERROR] Error updating game lobby: %v", err)
 		return
 	}
+	log.Printf("[AI-NEXT-BLIND] Player %s ready for next blind. Total ready: %d/%d",
+		currentAIPlayerUsername, len(lobby.PlayersFinishedVouchers), lobby.PlayerCount)
 
 	// If all players are ready, broadcast the starting_next_blind event
 	if len(lobby.PlayersFinishedVouchers) >= lobby.PlayerCount {
@@ -1252,16 +1310,16 @@ func continueToVouchers(redisClient *redis.RedisClient, db *gorm.DB, lobbyID str
 	log.Printf("[AI-VOUCHERS] %s requesting to continue to vouchers phase in lobby %s", currentAIPlayerUsername, lobbyID)
 
 	// Increment the finished shop counter
-	lobby.PlayersFinishedShop[currentAIPlayerUsername] = true
-	log.Printf("[AI-VOUCHERS] %s ready for vouchers phase. Total ready: %d/%d",
-		currentAIPlayerUsername, len(lobby.PlayersFinishedShop), lobby.PlayerCount)
-
-	// Save the updated lobby
-	err = redisClient.SaveGameLobby(lobby)
+	lobby, err = redisClient.UpdateGameLobby(lobbyID, func(lobby *redis_models.GameLobby) error {
+		lobby.PlayersFinishedShop[currentAIPlayerUsername] = true
+		return nil
+	})
 	if err != nil {
-		log.Printf("[AI-VOUCHERS-ERROR] Error saving game lobby: %v", err)
+		log.Printf("[AI-VOUCHERS-ERROR] Error updating game lobby: %v", err)
 		return
 	}
+	log.Printf("[AI-VOUCHERS] %s ready for vouchers phase. Total ready: %d/%d",
+		currentAIPlayerUsername, len(lobby.PlayersFinishedShop), lobby.PlayerCount)
 
 	// If all players are ready, advance to the vouchers phase
 	if len(lobby.PlayersFinishedShop) >= lobby.PlayerCount {
@@ -1,7 +1,6 @@
 package game_flow
 
 import (
-	game_constants "Nogler/constants/game"
 	redis_models "Nogler/models/redis"
 	"Nogler/services/redis"
 	socketio_types "Nogler/services/socket_io/types"
@@ -19,16 +18,6 @@ import (
 	"gorm.io/gorm"
 )
 
-// ---------------------------------------------------------------
-// TIMEOUTS
-// ---------------------------------------------------------------
-const (
-	PLAY_ROUND_TIMEOUT = 2 * time.Minute
-	BLIND_TIMEOUT      = 20 * time.Second
-	SHOP_TIMEOUT       = 1 * time.Minute
-	VOUCHER_TIMEOUT    = 1 * time.Minute // New timeout constant for voucher phase
-)
-
 // ---------------------------------------------------------------
 // Functions that are executed to start the next blind
 // ---------------------------------------------------------------
@@ -36,64 +25,47 @@ const (
 func AdvanceToNextBlindIfUndone(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, sio *socketio_types.SocketServer, isFirstBlind bool, expectedRound int) error {
 	log.Printf("[ROUND-ADVANCE] Advancing to next round for lobby %s (expected round: %d)", lobbyID, expectedRound)
 
-	// Get the lobby for early check
-	lobby, err := redisClient.GetGameLobby(lobbyID)
-	if err != nil {
-		log.Printf("[ROUND-ADVANCE-ERROR] Error getting lobby: %v", err)
-		return fmt.Errorf("error getting lobby: %v", err)
-	}
+	// Step 1: Increment the round number and reset the blinds in a single update. The checks are
+	// done inside it, so only one of the concurrent callers (timeout or last player) advances the round
+	lobby, err := redisClient.UpdateGameLobby(lobbyID, func(lobby *redis_models.GameLobby) error {
+		// Validate the round number - abort if this is an old timeout trying to advance a newer round
+		if !isFirstBlind && lobby.CurrentRound != expectedRound {
+			log.Printf("[ROUND-ADVANCE-WARN] Round mismatch - current: %d, expected: %d. Ignoring stale timeout.",
+				lobby.CurrentRound, expectedRound)
+			return redis.ErrUpdateSkipped
+		}
 
-	// Validate the round number - abort if this is an old timeout trying to advance a newer round
-	if !isFirstBlind && lobby.CurrentRound != expectedRound {
-		log.Printf("[ROUND-ADVANCE-WARN] Round mismatch - current: %d, expected: %d. Ignoring stale timeout.",
-			lobby.CurrentRound, expectedRound)
-		return nil
-	}
+		// Early return if already advancing to next round blind
+		if _, completed := lobby.VouchersCompleted[lobby.CurrentRound]; completed {
+			log.Printf("[ROUND-ADVANCE-INFO] Already advancing to next round for lobby %s, skipping", lobbyID)
+			return redis.ErrUpdateSkipped
+		}
+
+		// KEY: do it before incrementing the round, since we need to
+		// record the data from the round that just ended
+		lobby.VouchersCompleted[lobby.CurrentRound] = true
+		lobby.CurrentRound++
 
-	// Early return if already advancing to next round blind
-	if _, completed := lobby.VouchersCompleted[lobby.CurrentRound]; completed {
-		log.Printf("[ROUND-ADVANCE-INFO] Already advancing to next round for lobby %s, skipping", lobbyID)
+		// Update the CurrentBaseBlind in the lobby
+		lobby.CurrentBaseBlind = lobby.Rules.BaseBlindForRound(lobby.CurrentRound)
+
+		// NEW, CRITICAL: reset CurrentHighBlind
+		lobby.CurrentHighBlind = 0
+
+		// CRITICAL: reset highest blind proposer
+		lobby.HighestBlindProposer = ""
+		return nil
+	})
+	if err == redis.ErrUpdateSkipped {
 		return nil
 	}
-
-	// Step 1: Increment the round number
-	newRound, err := socketio_utils.SaveShopsCompletedAndIncrementGameRound(redisClient, lobbyID, 1)
 	if err != nil {
 		log.Printf("[ROUND-ADVANCE-ERROR] Failed to increment round: %v", err)
 		return fmt.Errorf("failed to increment round: %v", err)
 	}
 
-	// CRITICAL: AFTER CALLING SaveShopsCompletedAndIncrementGameRound, WE HAVE TO FETCH THE LOBBY AGAIN
-	// FROM REDIS, SINCE OTHERWISE WE'LL BE OVERWRITING THE OBJECT STORED BY IncrementGameRound
-	// TODO: check if we're making this same mistake somewhere else
-	lobby, err = redisClient.GetGameLobby(lobbyID)
-
-	// Calculate new base blind for the round: BASE_BLIND * 2^(round_number - 1)
-	newBaseBlind := game_constants.BASE_BLIND
-	for i := 1; i < newRound; i++ {
-		newBaseBlind *= 2
-		if newBaseBlind >= game_constants.MAX_BLIND {
-			newBaseBlind = game_constants.MAX_BLIND
-			break
-		}
-	}
-
-	// Update the CurrentBaseBlind in the lobby
-	lobby.CurrentBaseBlind = newBaseBlind
-
-	// NEW, CRITICAL: reset CurrentHighBlind
-	lobby.CurrentHighBlind = 0
-
-	// CRITICAL: reset highest blind proposer
-	lobby.HighestBlindProposer = ""
-
-	if err := redisClient.SaveGameLobby(lobby); err != nil {
-		log.Printf("[ROUND-ADVANCE-ERROR] Failed to update base blind: %v", err)
-		return fmt.Errorf("failed to update base blind: %v", err)
-	}
-
 	log.Printf("[ROUND-ADVANCE] Updated base blind for lobby %s to %d for round %d",
-		lobbyID, newBaseBlind, newRound)
+		lobbyID, lobby.CurrentBaseBlind, lobby.CurrentRound)
 
 	// Update the current phase (to PhaseBlind)
 	if err := socketio_utils.SetGamePhase(redisClient, lobbyID, redis_models.PhaseBlind); err != nil {
@@ -105,7 +77,7 @@ func AdvanceToNextBlindIfUndone(redisClient *redis.RedisClient, db *gorm.DB, lob
 	StartBlindTimeout(redisClient, db, lobbyID, sio, isFirstBlind)
 
 	// Step 3: Broadcast the next blind phase event
-	blind.BroadcastStartingNextBlind(redisClient, db, lobbyID, sio, int(BLIND_TIMEOUT.Seconds()))
+	blind.BroadcastStartingNextBlind(redisClient, db, lobbyID, sio, lobby.Rules.BlindSeconds)
 
 	// If the game is against the AI, we need to set the AI's blind bet
 	if lobby.IsPublic == 2 {
@@ -120,51 +92,40 @@ func StartBlindTimeout(redisClient *redis.RedisClient,
 
 	log.Printf("[BLIND-TIMEOUT] Starting blind timeout for lobby %s", lobbyID)
 
-	// Get the lobby from Redis
-	lobby, err := redisClient.GetGameLobby(lobbyID)
+	// Check if lobby exists in PostgreSQL
+	_, err := utils.CheckLobbyExists(db, lobbyID)
 	if err != nil {
-		log.Printf("[BLIND-TIMEOUT-ERROR] Error obtaining lobby to start timeout: %v", err)
+		log.Printf("[BLIND-TIMEOUT-ERROR] Lobby does not exist: %s", lobbyID)
 		return
 	}
 
-	// Check if the blind voting is already in timeout
-	if !lobby.BlindTimeout.IsZero() {
-		log.Printf("[BLIND-TIMEOUT-ERROR] Blind voting is already in timeout: %v", lobby.BlindTimeout)
-		return
-	}
+	lobby, err := redisClient.UpdateGameLobby(lobbyID, func(lobby *redis_models.GameLobby) error {
+		// Check if the blind voting is already in timeout
+		if !lobby.BlindTimeout.IsZero() {
+			log.Printf("[BLIND-TIMEOUT-ERROR] Blind voting is already in timeout: %v", lobby.BlindTimeout)
+			return redis.ErrUpdateSkipped
+		}
 
-	// Reset the shop timeout to indicate shop phase has ended
-	lobby.ShopTimeout = time.Time{}
+		// Reset the shop timeout to indicate shop phase has ended
+		lobby.ShopTimeout = time.Time{}
 
-	// Check if lobby exists in PostgreSQL
-	_, err = utils.CheckLobbyExists(db, lobbyID)
-	if err != nil {
-		log.Printf("[BLIND-TIMEOUT-ERROR] Lobby does not exist: %s", lobbyID)
+		// Reset the blind-related map
+		lobby.ProposedBlinds = make(map[string]bool)
+
+		// Set the blind timeout to the current time
+		lobby.BlindTimeout = time.Now()
+		return nil
+	})
+	if err == redis.ErrUpdateSkipped {
 		return
 	}
-
-	// Reset the blind-related map
-	lobby.ProposedBlinds = make(map[string]bool)
-
-	// Set the blind timeout to the current time
-	lobby.BlindTimeout = time.Now()
-	err = redisClient.SaveGameLobby(lobby)
 	if err != nil {
 		log.Printf("[BLIND-TIMEOUT-ERROR] Error setting lobby blind timeout: %v", err)
 		return
 	}
 
-	// Start a goroutine to handle the timeout
-	go func() {
-		// Capture the current round when creating the goroutine
-		currentRound := lobby.CurrentRound
-
-		// TODO, change the timeout value
-		time.Sleep(BLIND_TIMEOUT)
-
-		// Pass the expected round to AdvanceToNextRoundPlayIfUndone
-		AdvanceToNextRoundPlayIfUndone(redisClient, db, lobbyID, sio, currentRound)
-	}()
+	// Schedule the end of the phase in Redis, so it's not lost if the server restarts
+	schedulePhaseTimer(redisClient, lobby, redis_models.PhaseBlind)
 
 	log.Printf("[BLIND-TIMEOUT] Blind timeout started for lobby %s", lobbyID)
 }
@@ -178,47 +139,31 @@ func AdvanceToNextRoundPlayIfUndone(redisClient *redis.RedisClient, db *gorm.DB,
 	log.Printf("[ROUND-PLAY-ADVANCE] Advancing to round play phase for lobby %s (expected round: %d)",
 		lobbyID, expectedRound)
 
-	// Get the lobby to check if round already started
-	lobby, err := redisClient.GetGameLobby(lobbyID)
-	if err != nil {
-		log.Printf("[ROUND-PLAY-ADVANCE-ERROR] Error getting lobby info: %v", err)
-		return
-	}
-
-	// Validate the round number
-	if lobby.CurrentRound != expectedRound {
-		log.Printf("[ROUND-PLAY-ADVANCE-WARN] Round mismatch - current: %d, expected: %d. Ignoring stale timeout.",
-			lobby.CurrentRound, expectedRound)
-		return
-	}
-
-	// Check if this round's blind phase is already completed
-	if _, completed := lobby.BlindsCompleted[lobby.CurrentRound]; completed {
-		log.Printf("[ROUND-PLAY-ADVANCE-INFO] Round already started for lobby %s, skipping", lobbyID)
+	// Step 1: Prepare the round state in Redis
+	// KEY: play_round.PrepareRoundStart checks the round and sets the current BlindsCompleted
+	// entry to true atomically, so the round is started only once
+	updatedLobby, blind, err := play_round.PrepareRoundStart(redisClient, lobbyID, expectedRound)
+	if err == redis.ErrUpdateSkipped {
 		return
 	}
-
-	// Step 1: Prepare the round state in Redis
-	// KEY: play_round.PrepareRoundStart will set the current BlindsCompleted entry to true
-	updatedLobby, blind, err := play_round.PrepareRoundStart(redisClient, lobbyID)
 	if err != nil {
 		log.Printf("[ROUND-PLAY-ADVANCE-ERROR] Failed to prepare round: %v", err)
 		return
 	}
 
 	// Step 1.5: Apply round modifiers to all players
-	play_round.ApplyRoundModifiers(redisClient, lobbyID, sio)
+	expiredJokers := play_round.ApplyRoundModifiers(redisClient, lobbyID, sio)
 
 	// Step 2: Start the round play timeout, BEFORE ResetPlayerAndBroadcastRoundStart to send the updated timeout start date to the players
 	StartRoundPlayTimeout(redisClient, db, lobbyID, sio)
 
 	// Step 3: Broadcast round start event
-	play_round.ResetPlayerAndBroadcastRoundStart(sio, redisClient, lobbyID, updatedLobby.CurrentRound, blind, int(PLAY_ROUND_TIMEOUT.Seconds()))
+	play_round.ResetPlayerAndBroadcastRoundStart(sio, redisClient, lobbyID, updatedLobby.CurrentRound, blind, updatedLobby.Rules.PlayRoundSeconds, expiredJokers)
 
 	log.Printf("[ROUND-PLAY-ADVANCE-SUCCESS] Advanced lobby %s to round play phase", lobbyID)
 
 	// If the game is against the AI, we need to set the AI's play
-	if lobby.IsPublic == 2 {
+	if updatedLobby.IsPublic == 2 {
 		go PlayHandAI(redisClient, db, lobbyID, sio)
 	}
 }
@@ -226,42 +171,31 @@ func AdvanceToNextRoundPlayIfUndone(redisClient *redis.RedisClient, db *gorm.DB,
 func StartRoundPlayTimeout(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, sio *socketio_types.SocketServer) {
 	log.Printf("[ROUND-PLAY-TIMEOUT] Starting round play timeout for lobby %s", lobbyID)
 
-	// Get the game lobby from Redis
-	lobby, err := redisClient.GetGameLobby(lobbyID)
-	if err != nil {
-		log.Printf("[ROUND-PLAY-ERROR] Error getting lobby info: %v", err)
-		return
-	}
+	lobby, err := redisClient.UpdateGameLobby(lobbyID, func(lobby *redis_models.GameLobby) error {
+		// Check if the round is already in timeout
+		// NOTE: SHOULDN'T HAPPEN
+		if !lobby.GameRoundTimeout.IsZero() {
+			log.Printf("[ROUND-PLAY-ERROR] Round is already in timeout: %v", lobby.GameRoundTimeout)
+			return redis.ErrUpdateSkipped
+		}
+
+		// NOTE: Already done in PrepareRoundStart
+		// lobby.PlayersFinishedRound = make(map[string]bool)
 
-	// Check if the round is already in timeout
-	// NOTE: SHOULDN'T HAPPEN
-	if !lobby.GameRoundTimeout.IsZero() {
-		log.Printf("[ROUND-PLAY-ERROR] Round is already in timeout: %v", lobby.GameRoundTimeout)
+		// Set the game round timeout to the current time
+		lobby.GameRoundTimeout = time.Now()
+		return nil
+	})
+	if err == redis.ErrUpdateSkipped {
 		return
 	}
-
-	// NOTE: Already done in PrepareRoundStart
-	// lobby.PlayersFinishedRound = make(map[string]bool)
-
-	// Set the game round timeout to the current time
-	lobby.GameRoundTimeout = time.Now()
-	err = redisClient.SaveGameLobby(lobby)
 	if err != nil {
 		log.Printf("[ROUND-PLAY-ERROR] Error setting lobby round timeout: %v", err)
 		return
 	}
 
-	// Start a goroutine to handle the timeout
-	go func() {
-		// Capture the current round
-		currentRound := lobby.CurrentRound
-
-		// TODO, change the timeout value
-		time.Sleep(PLAY_ROUND_TIMEOUT)
-
-		// Call the function to handle round end
-		HandleRoundPlayEnd(redisClient, db, lobbyID, sio, currentRound)
-	}()
+	// Schedule the end of the phase in Redis, so it's not lost if the server restarts
+	schedulePhaseTimer(redisClient, lobby, redis_models.PhasePlayRound)
 
 	log.Printf("[ROUND-PLAY-TIMEOUT] Round play timeout started for lobby %s", lobbyID)
 }
@@ -277,34 +211,32 @@ func HandleRoundPlayEnd(redisClient *redis.RedisClient, db *gorm.DB, lobbyID str
 	log.Printf("[ROUND-END] Handling end of round for lobby %s (expected round: %d)",
 		lobbyID, expectedRound)
 
-	// Get the lobby from Redis
-	lobby, err := redisClient.GetGameLobby(lobbyID)
-	if err != nil {
-		log.Printf("[ROUND-END-ERROR] Error getting lobby: %v", err)
-		return
-	}
+	// CRITICAL: save game lobby to indicate round has ended (checked and marked in a single
+	// update, so the timeout and the last player can't both end the round)
+	_, err := redisClient.UpdateGameLobby(lobbyID, func(lobby *redis_models.GameLobby) error {
+		// Validate the round number
+		if lobby.CurrentRound != expectedRound {
+			log.Printf("[ROUND-END-WARN] Round mismatch - current: %d, expected: %d. Ignoring stale timeout.",
+				lobby.CurrentRound, expectedRound)
+			return redis.ErrUpdateSkipped
+		}
 
-	// Validate the round number
-	if lobby.CurrentRound != expectedRound {
-		log.Printf("[ROUND-END-WARN] Round mismatch - current: %d, expected: %d. Ignoring stale timeout.",
-			lobby.CurrentRound, expectedRound)
-		return
-	}
+		// Check if this round's blind phase is already completed
+		if _, completed := lobby.GameRoundsCompleted[lobby.CurrentRound]; completed {
+			log.Printf("[ROUND-END-INFO] Round already ended for lobby %s, skipping", lobbyID)
+			return redis.ErrUpdateSkipped
+		}
 
-	// Check if this round's blind phase is already completed
-	if _, completed := lobby.GameRoundsCompleted[lobby.CurrentRound]; completed {
-		log.Printf("[ROUND-END-INFO] Round already ended for lobby %s, skipping", lobbyID)
+		// Reset the game round timeout to indicate round has ended
+		lobby.GameRoundTimeout = time.Time{}
+
+		// NEW: mark the current game round as completed
+		lobby.GameRoundsCompleted[lobby.CurrentRound] = true
+		return nil
+	})
+	if err == redis.ErrUpdateSkipped {
 		return
 	}
-
-	// Reset the game round timeout to indicate round has ended
-	lobby.GameRoundTimeout = time.Time{}
-
-	// NEW: mark the current game round as completed
-	lobby.GameRoundsCompleted[lobby.CurrentRound] = true
-
-	// CRITICAL: save game lobby to indicate round has ended
-	err = redisClient.SaveGameLobby(lobby)
 	if err != nil {
 		log.Printf("[ROUND-END-ERROR] Error saving lobby with updated GameRoundTimeout: %v", err)
 		return
@@ -323,14 +255,14 @@ func HandleRoundPlayEnd(redisClient *redis.RedisClient, db *gorm.DB, lobbyID str
 	}
 
 	// Get updated lobby (player count might have changed after eliminations)
-	lobby, err = redisClient.GetGameLobby(lobbyID)
+	lobby, err := redisClient.GetGameLobby(lobbyID)
 	if err != nil {
 		log.Printf("[ROUND-END-ERROR] Error getting updated lobby: %v", err)
 		return
 	}
 
 	// Check if the game should end (player count <= 1 or max rounds reached)
-	if lobby.PlayerCount <= 1 || lobby.CurrentRound >= game_constants.MaxGameRounds {
+	if lobby.PlayerCount <= 1 || lobby.CurrentRound >= lobby.Rules.MaxRounds {
 		log.Printf("[ROUND-END] Game ending conditions met: players=%d, current_round=%d",
 			lobby.PlayerCount, lobby.CurrentRound)
 
@@ -364,7 +296,7 @@ func AdvanceToShop(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string,
 	}
 
 	// Initialize the shop
-	shopItems, err := shop.InitializeShop(lobbyID, lobby.CurrentRound)
+	shopItems, err := shop.InitializeShop(lobby.Seed, lobby.CurrentRound)
 	if err != nil {
 		log.Printf("[SHOP-INIT-ERROR] Error initializing shop: %v", err)
 		return
@@ -376,21 +308,15 @@ func AdvanceToShop(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string,
 		return
 	}
 
-	// Get the fresh lobby after phase update
-	lobby, err = redisClient.GetGameLobby(lobbyID)
-	if err != nil {
-		log.Printf("[SHOP-ADVANCE-ERROR] Error getting updated lobby: %v", err)
-		return
-	}
-
-	// Store shop state in lobby
-	lobby.ShopState = shopItems
-
-	// Reset shop-related counters (NEW, using map)
-	lobby.PlayersFinishedShop = make(map[string]bool)
+	lobby, err = redisClient.UpdateGameLobby(lobbyID, func(lobby *redis_models.GameLobby) error {
+		// Store shop state in lobby
+		lobby.ShopState = shopItems
 
-	// Save the updated lobby
-	if err := redisClient.SaveGameLobby(lobby); err != nil {
+		// Reset shop-related counters (NEW, using map)
+		lobby.PlayersFinishedShop = make(map[string]bool)
+		return nil
+	})
+	if err != nil {
 		log.Printf("[SHOP-ADVANCE-ERROR] Error saving lobby: %v", err)
 		return
 	}
@@ -401,7 +327,7 @@ func AdvanceToShop(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string,
 	StartShopTimeout(redisClient, db, lobbyID, sio)
 
 	// Multicast shop start to all players
-	shop.MulticastStartingShop(sio, redisClient, lobbyID, shopItems, int(SHOP_TIMEOUT.Seconds()))
+	shop.MulticastStartingShop(sio, redisClient, lobbyID, shopItems, lobby.Rules.ShopSeconds)
 
 	// If the game is against the AI, we need to set the AI's shop
 	if lobby.IsPublic == 2 {
@@ -415,38 +341,27 @@ func AdvanceToShop(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string,
 func StartShopTimeout(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, sio *socketio_types.SocketServer) {
 	log.Printf("[SHOP-TIMEOUT] Starting shop timeout for lobby %s", lobbyID)
 
-	// Get the game lobby from Redis
-	lobby, err := redisClient.GetGameLobby(lobbyID)
-	if err != nil {
-		log.Printf("[SHOP-TIMEOUT-ERROR] Error getting lobby: %v", err)
-		return
-	}
+	lobby, err := redisClient.UpdateGameLobby(lobbyID, func(lobby *redis_models.GameLobby) error {
+		// Check if shop timeout is already active
+		if !lobby.ShopTimeout.IsZero() {
+			log.Printf("[SHOP-TIMEOUT-ERROR] Shop timeout already active for lobby %s", lobbyID)
+			return redis.ErrUpdateSkipped
+		}
 
-	// Check if shop timeout is already active
-	if !lobby.ShopTimeout.IsZero() {
-		log.Printf("[SHOP-TIMEOUT-ERROR] Shop timeout already active for lobby %s", lobbyID)
+		// Set the shop timeout
+		lobby.ShopTimeout = time.Now()
+		return nil
+	})
+	if err == redis.ErrUpdateSkipped {
 		return
 	}
-
-	// Set the shop timeout
-	lobby.ShopTimeout = time.Now()
-	err = redisClient.SaveGameLobby(lobby)
 	if err != nil {
 		log.Printf("[SHOP-TIMEOUT-ERROR] Error saving shop timeout: %v", err)
 		return
 	}
 
-	// Start the timeout goroutine
-	go func() {
-		// Capture the expected round for the vouchers phase
-		currentRound := lobby.CurrentRound
-
-		// TODO, change the timeout value
-		time.Sleep(SHOP_TIMEOUT)
-
-		// Advance to vouchers phase instead of directly to blinds
-		AdvanceToVouchersIfUndone(redisClient, db, lobbyID, sio, currentRound)
-	}()
+	// Schedule the end of the phase in Redis, so it's not lost if the server restarts
+	schedulePhaseTimer(redisClient, lobby, redis_models.PhaseShop)
 
 	log.Printf("[SHOP-TIMEOUT] Shop timeout started for lobby %s", lobbyID)
 }
@@ -466,36 +381,35 @@ func AdvanceToVouchersIfUndone(
 	log.Printf("[VOUCHER-ADVANCE] Advancing to vouchers phase for lobby %s (expected round: %d)",
 		lobbyID, expectedRound)
 
-	// Get the lobby to validate state
-	lobby, err := redisClient.GetGameLobby(lobbyID)
-	if err != nil {
-		log.Printf("[VOUCHER-ADVANCE-ERROR] Error getting lobby: %v", err)
-		return
-	}
+	// Save the lobby with these changes before setting the new phase. The checks are done
+	// in the same update, so the shop is only finished once
+	lobby, err := redisClient.UpdateGameLobby(lobbyID, func(lobby *redis_models.GameLobby) error {
+		// Validate the round number to avoid stale timeouts
+		if lobby.CurrentRound != expectedRound {
+			log.Printf("[VOUCHER-ADVANCE-WARN] Round mismatch - current: %d, expected: %d. Ignoring stale timeout.",
+				lobby.CurrentRound, expectedRound)
+			return redis.ErrUpdateSkipped
+		}
 
-	// Validate the round number to avoid stale timeouts
-	if lobby.CurrentRound != expectedRound {
-		log.Printf("[VOUCHER-ADVANCE-WARN] Round mismatch - current: %d, expected: %d. Ignoring stale timeout.",
-			lobby.CurrentRound, expectedRound)
-		return
-	}
+		// Only advance if shop phase didn't already finish
+		if _, completed := lobby.ShopsCompleted[lobby.CurrentRound]; completed {
+			log.Printf("[VOUCHER-ADVANCE-INFO] Shop not timed out for lobby %s, skipping", lobbyID)
+			return redis.ErrUpdateSkipped
+		}
+
+		// Reset voucher-related counters and shop timeout
+		lobby.PlayersFinishedVouchers = make(map[string]bool)
+		// Move shop timeout reset here from StartVoucherTimeout
+		lobby.ShopTimeout = time.Time{}
 
-	// Only advance if shop phase didn't already finish
-	if _, completed := lobby.ShopsCompleted[lobby.CurrentRound]; completed {
-		log.Printf("[VOUCHER-ADVANCE-INFO] Shop not timed out for lobby %s, skipping", lobbyID)
+		// NEW: set the shops completed component map to true
+		lobby.ShopsCompleted[lobby.CurrentRound] = true
+		return nil
+	})
+	if err == redis.ErrUpdateSkipped {
 		return
 	}
-
-	// Reset voucher-related counters and shop timeout
-	lobby.PlayersFinishedVouchers = make(map[string]bool)
-	// Move shop timeout reset here from StartVoucherTimeout
-	lobby.ShopTimeout = time.Time{}
-
-	// NEW: set the shops completed component map to true
-	lobby.ShopsCompleted[lobby.CurrentRound] = true
-
-	// Save the lobby with these changes before setting the new phase
-	if err := redisClient.SaveGameLobby(lobby); err != nil {
+	if err != nil {
 		log.Printf("[VOUCHER-ADVANCE-ERROR] Error saving lobby with reset voucher counters: %v", err)
 		return
 	}
@@ -513,7 +427,7 @@ func AdvanceToVouchersIfUndone(
 	StartVoucherTimeout(redisClient, db, lobbyID, sio, expectedRound)
 
 	// Broadcast voucher phase start event to all clients
-	vouchers.MulticastStartingVouchers(sio, redisClient, db, lobbyID, int(VOUCHER_TIMEOUT.Seconds()))
+	vouchers.MulticastStartingVouchers(sio, redisClient, db, lobbyID, lobby.Rules.VouchersSeconds)
 
 	// If the game is against the AI, we need to set the AI's vouchers
 	if lobby.IsPublic == 2 {
@@ -525,34 +439,18 @@ func AdvanceToVouchersIfUndone(
 func StartVoucherTimeout(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, sio *socketio_types.SocketServer, expectedRound int) {
 	log.Printf("[VOUCHER-TIMEOUT] Starting voucher timeout for lobby %s", lobbyID)
 
-	// Get the game lobby from Redis
-	lobby, err := redisClient.GetGameLobby(lobbyID)
-	if err != nil {
-		log.Printf("[VOUCHER-TIMEOUT-ERROR] Error getting lobby: %v", err)
-		return
-	}
-
 	// Set the voucher timeout start date to now
-	lobby.VouchersTimeout = time.Now()
-
-	// Save the updated lobby
-	err = redisClient.SaveGameLobby(lobby)
+	lobby, err := redisClient.UpdateGameLobby(lobbyID, func(lobby *redis_models.GameLobby) error {
+		lobby.VouchersTimeout = time.Now()
+		return nil
+	})
 	if err != nil {
 		log.Printf("[VOUCHER-TIMEOUT-ERROR] Error saving voucher timeout: %v", err)
 		return
 	}
 
-	// Start the timeout goroutine
-	go func() {
-		// Capture the current round
-		currentRound := lobby.CurrentRound
-
-		// Wait for the voucher timeout duration
-		time.Sleep(VOUCHER_TIMEOUT)
-
-		// Advance to the next blind phase
-		AdvanceToNextBlindIfUndone(redisClient, db, lobbyID, sio, false, currentRound)
-	}()
+	// Schedule the end of the phase in Redis, so it's not lost if the server restarts
+	schedulePhaseTimer(redisClient, lobby, redis_models.PhaseVouchers)
 
 	log.Printf("[VOUCHER-TIMEOUT] Voucher timeout started for lobby %s", lobbyID)
 }
@@ -0,0 +1,78 @@
+package game_flow
+
+import (
+	redis_models "Nogler/models/redis"
+	"Nogler/services/redis"
+	socketio_types "Nogler/services/socket_io/types"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// How often the pending phase timers are checked
+const PHASE_TIMER_POLL_INTERVAL = 1 * time.Second
+
+// Stores the deadline of the phase of the lobby that has just started in Redis, according to
+// the lobby rules. It replaces the previous timer of the lobby, so the timer of a phase that
+// already ended never fires
+func schedulePhaseTimer(redisClient *redis.RedisClient, lobby *redis_models.GameLobby, phase string) {
+	err := redisClient.SchedulePhaseTimer(redis_models.PhaseTimer{
+		LobbyID:  lobby.Id,
+		Phase:    phase,
+		Round:    lobby.CurrentRound,
+		Deadline: time.Now().Add(lobby.Rules.PhaseDuration(phase)),
+	})
+	if err != nil {
+		log.Printf("[PHASE-SCHEDULER-ERROR] Error scheduling %s timer for lobby %s: %v", phase, lobby.Id, err)
+	}
+}
+
+// StartPhaseScheduler polls the phase timers stored in Redis and advances the lobbies whose
+// phase has expired. It also makes the players that didn't reconnect in time forfeit.
+// The first poll is done right away, so the timers that expired while the server was
+// down are fired on startup
+func StartPhaseScheduler(redisClient *redis.RedisClient, db *gorm.DB, sio *socketio_types.SocketServer) {
+	go func() {
+		ticker := time.NewTicker(PHASE_TIMER_POLL_INTERVAL)
+		defer ticker.Stop()
+
+		for {
+			fireDuePhaseTimers(redisClient, db, sio)
+			fireExpiredDisconnectTimers(redisClient, db, sio)
+			<-ticker.C
+		}
+	}()
+
+	log.Printf("[PHASE-SCHEDULER] Phase scheduler started")
+}
+
+func fireDuePhaseTimers(redisClient *redis.RedisClient, db *gorm.DB, sio *socketio_types.SocketServer) {
+	timers, err := redisClient.ClaimDuePhaseTimers(time.Now())
+	if err != nil {
+		log.Printf("[PHASE-SCHEDULER-ERROR] %v", err)
+	}
+
+	// Each transition runs in its own goroutine, so a slow lobby doesn't delay the others
+	for _, timer := range timers {
+		go firePhaseTimer(redisClient, db, sio, timer)
+	}
+}
+
+func firePhaseTimer(redisClient *redis.RedisClient, db *gorm.DB, sio *socketio_types.SocketServer, timer redis_models.PhaseTimer) {
+	log.Printf("[PHASE-SCHEDULER] %s timer of lobby %s expired (round %d)", timer.Phase, timer.LobbyID, timer.Round)
+
+	// The transitions still check the round, in case the phase ended right when the timer was claimed
+	switch timer.Phase {
+	case redis_models.PhaseBlind:
+		AdvanceToNextRoundPlayIfUndone(redisClient, db, timer.LobbyID, sio, timer.Round)
+	case redis_models.PhasePlayRound:
+		HandleRoundPlayEnd(redisClient, db, timer.LobbyID, sio, timer.Round)
+	case redis_models.PhaseShop:
+		AdvanceToVouchersIfUndone(redisClient, db, timer.LobbyID, sio, timer.Round)
+	case redis_models.PhaseVouchers:
+		AdvanceToNextBlindIfUndone(redisClient, db, timer.LobbyID, sio, false, timer.Round)
+	default:
+		log.Printf("[PHASE-SCHEDULER-ERROR] Unknown phase %s in timer of lobby %s", timer.Phase, timer.LobbyID)
+	}
+}
@@ -0,0 +1,169 @@
+package game_flow
+
+import (
+	game_constants "Nogler/constants/game"
+	postgres_models "Nogler/models/postgres"
+	redis_models "Nogler/models/redis"
+	"Nogler/services/redis"
+	socketio_types "Nogler/services/socket_io/types"
+	socketio_utils "Nogler/services/socket_io/utils"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zishang520/socket.io/v2/socket"
+	"gorm.io/gorm"
+)
+
+// StartDisconnectGracePeriod gives a player that has lost their connection during a game
+// RECONNECT_GRACE_PERIOD to come back before forfeiting, and tells the rest of the lobby
+func StartDisconnectGracePeriod(redisClient *redis.RedisClient, sio *socketio_types.SocketServer, username string) {
+	player, err := redisClient.GetInGamePlayer(username)
+	if err != nil {
+		// Not playing any game
+		return
+	}
+	lobby, err := redisClient.GetGameLobby(player.LobbyId)
+	if err != nil || !lobby.GameHasBegun {
+		// Players keep their seat in lobbies that haven't started
+		return
+	}
+
+	deadline := time.Now().Add(game_constants.RECONNECT_GRACE_PERIOD)
+	if err := redisClient.ScheduleDisconnectTimer(redis_models.DisconnectTimer{
+		Username: username,
+		LobbyID:  lobby.Id,
+		Deadline: deadline,
+	}); err != nil {
+		log.Printf("[PRESENCE-ERROR] Error scheduling disconnect timer of %s: %v", username, err)
+		return
+	}
+
+	log.Printf("[PRESENCE] %s disconnected from lobby %s, forfeiting at %s", username, lobby.Id, deadline.Format(time.RFC3339))
+	sio.Sio_server.To(socket.Room(lobby.Id), socketio_utils.SpectatorsRoom(lobby.Id)).Emit("player_disconnected", gin.H{
+		"lobby_id":      lobby.Id,
+		"username":      username,
+		"grace_seconds": int(game_constants.RECONNECT_GRACE_PERIOD / time.Second),
+		"deadline":      deadline.Format(time.RFC3339),
+	})
+}
+
+// EndDisconnectGracePeriod cancels the grace period of a player that has connected again and
+// tells the rest of the lobby
+// Returns: the lobby of the game the player is in, or "" if they aren't playing any game
+func EndDisconnectGracePeriod(redisClient *redis.RedisClient, sio *socketio_types.SocketServer, username string) string {
+	player, err := redisClient.GetInGamePlayer(username)
+	if err != nil {
+		return ""
+	}
+
+	cancelled, err := redisClient.CancelDisconnectTimer(username)
+	if err != nil {
+		log.Printf("[PRESENCE-ERROR] Error cancelling disconnect timer of %s: %v", username, err)
+	}
+	if cancelled {
+		log.Printf("[PRESENCE] %s reconnected to lobby %s", username, player.LobbyId)
+		sio.Sio_server.To(socket.Room(player.LobbyId), socketio_utils.SpectatorsRoom(player.LobbyId)).Emit("player_reconnected", gin.H{
+			"lobby_id": player.LobbyId,
+			"username": username,
+		})
+	}
+	return player.LobbyId
+}
+
+func fireExpiredDisconnectTimers(redisClient *redis.RedisClient, db *gorm.DB, sio *socketio_types.SocketServer) {
+	timers, err := redisClient.ClaimExpiredDisconnectTimers(time.Now())
+	if err != nil {
+		log.Printf("[PRESENCE-ERROR] %v", err)
+	}
+
+	for _, timer := range timers {
+		go forfeitPlayer(redisClient, db, sio, timer)
+	}
+}
+
+// Removes a player that didn't come back in time from their game, the same way as an
+// eliminated player, and advances the current phase if they were the only one left in it
+func forfeitPlayer(redisClient *redis.RedisClient, db *gorm.DB, sio *socketio_types.SocketServer, timer redis_models.DisconnectTimer) {
+	if sio.IsConnected(timer.Username) {
+		return
+	}
+	player, err := redisClient.GetInGamePlayer(timer.Username)
+	if err != nil || player.LobbyId != timer.LobbyID {
+		// Already out of that game
+		return
+	}
+	lobby, err := redisClient.GetGameLobby(timer.LobbyID)
+	if err != nil {
+		log.Printf("[PRESENCE-ERROR] Error getting lobby %s: %v", timer.LobbyID, err)
+		return
+	}
+
+	log.Printf("[PRESENCE] %s didn't reconnect in time, forfeiting lobby %s", timer.Username, timer.LobbyID)
+
+	// Keep their last state for the match history, written when the game ends
+	if err := redisClient.SaveEliminatedPlayer(timer.LobbyID, redis_models.EliminatedPlayer{
+		Player: *player,
+		Round:  lobby.CurrentRound,
+	}); err != nil {
+		log.Printf("[PRESENCE-ERROR] Error saving forfeited player %s: %v", timer.Username, err)
+	}
+	if err := redisClient.DeleteInGamePlayer(timer.Username, timer.LobbyID); err != nil {
+		log.Printf("[PRESENCE-ERROR] Error removing player %s from Redis: %v", timer.Username, err)
+		return
+	}
+	if err := db.Where("lobby_id = ? AND username = ?", timer.LobbyID, timer.Username).Delete(&postgres_models.InGamePlayer{}).Error; err != nil {
+		log.Printf("[PRESENCE-ERROR] Error removing player %s from PostgreSQL: %v", timer.Username, err)
+	}
+
+	// What they already did in the current phase no longer counts towards finishing it
+	_, err = redisClient.UpdateGameLobby(timer.LobbyID, func(lobby *redis_models.GameLobby) error {
+		delete(lobby.ProposedBlinds, timer.Username)
+		delete(lobby.PlayersFinishedRound, timer.Username)
+		delete(lobby.PlayersFinishedShop, timer.Username)
+		delete(lobby.PlayersFinishedVouchers, timer.Username)
+		return nil
+	})
+	if err != nil {
+		log.Printf("[PRESENCE-ERROR] Error updating lobby %s: %v", timer.LobbyID, err)
+	}
+
+	socketio_utils.RecordGameEvent(redisClient, timer.LobbyID, redis_models.EventElimination, timer.Username, gin.H{
+		"reason": "disconnected",
+	})
+	sio.Sio_server.To(socket.Room(timer.LobbyID), socketio_utils.SpectatorsRoom(timer.LobbyID)).Emit("players_eliminated", gin.H{
+		"eliminated_players": []string{timer.Username},
+		"reason":             "disconnected",
+	})
+
+	advanceIfPhaseFinished(redisClient, db, sio, timer.LobbyID)
+}
+
+// Advances the lobby to the next phase if every remaining player has finished the current one
+func advanceIfPhaseFinished(redisClient *redis.RedisClient, db *gorm.DB, sio *socketio_types.SocketServer, lobbyID string) {
+	lobby, err := redisClient.GetGameLobby(lobbyID)
+	if err != nil {
+		log.Printf("[PRESENCE-ERROR] Error getting lobby %s: %v", lobbyID, err)
+		return
+	}
+
+	// The transitions check the round, so the phase isn't advanced twice if a player finishes it at the same time
+	switch lobby.CurrentPhase {
+	case redis_models.PhaseBlind:
+		if len(lobby.ProposedBlinds) >= lobby.PlayerCount {
+			AdvanceToNextRoundPlayIfUndone(redisClient, db, lobbyID, sio, lobby.CurrentRound)
+		}
+	case redis_models.PhasePlayRound:
+		if len(lobby.PlayersFinishedRound) >= lobby.PlayerCount {
+			HandleRoundPlayEnd(redisClient, db, lobbyID, sio, lobby.CurrentRound)
+		}
+	case redis_models.PhaseShop:
+		if len(lobby.PlayersFinishedShop) >= lobby.PlayerCount {
+			AdvanceToVouchersIfUndone(redisClient, db, lobbyID, sio, lobby.CurrentRound)
+		}
+	case redis_models.PhaseVouchers:
+		if len(lobby.PlayersFinishedVouchers) >= lobby.PlayerCount {
+			AdvanceToNextBlindIfUndone(redisClient, db, lobbyID, sio, false, lobby.CurrentRound)
+		}
+	}
+}
@@ -2,23 +2,20 @@ package socketio_utils
 
 import (
 	redis_models "Nogler/models/redis"
+	"Nogler/services/app_errors"
 	"Nogler/services/redis"
-	"fmt"
+	socketio_events "Nogler/services/socket_io/events"
 	"log"
-
-	"github.com/gin-gonic/gin"
-	"github.com/zishang520/socket.io/v2/socket"
 )
 
-// ValidateGamePhase checks if the current game phase matches the expected phase
-func ValidateGamePhase(redisClient *redis.RedisClient, client *socket.Socket, lobbyID string, expectedPhase string) (bool, error) {
+// ValidateGamePhase checks if the current game phase matches the expected phase. The error is
+// reported to ctx, which is nil for the AI players
+func ValidateGamePhase(redisClient *redis.RedisClient, ctx *socketio_events.Context, lobbyID string, expectedPhase string) (bool, error) {
 	// Get the game lobby from Redis to check the current phase
 	lobby, err := redisClient.GetGameLobby(lobbyID)
 	if err != nil {
 		log.Printf("[PHASE-ERROR] Error getting lobby: %v", err)
-		if client != nil {
-			client.Emit("error", gin.H{"error": "Error checking game phase"})
-		}
+		ctx.Fail(app_errors.Internal, app_errors.Reason("Error checking game phase"))
 		return false, err
 	}
 
@@ -26,12 +23,10 @@ func ValidateGamePhase(redisClient *redis.RedisClient, client *socket.Socket, lo
 	if lobby.CurrentPhase != expectedPhase {
 		log.Printf("[PHASE-ERROR] Action attempted during wrong phase: %s (required: %s)",
 			lobby.CurrentPhase, expectedPhase)
-		if client != nil {
-			client.Emit("error", gin.H{
-				"error": fmt.Sprintf("This action is only allowed during the %s phase (current phase: %s)",
-					expectedPhase, lobby.CurrentPhase),
-			})
-		}
+		ctx.Fail(app_errors.WrongPhase, app_errors.Details{
+			"expected_phase": expectedPhase,
+			"current_phase":  lobby.CurrentPhase,
+		})
 		return false, nil
 	}
 
@@ -39,21 +34,21 @@ func ValidateGamePhase(redisClient *redis.RedisClient, client *socket.Socket, lo
 }
 
 // ValidatePlayRoundPhase specifically validates that the game is in the play round phase
-func ValidatePlayRoundPhase(redisClient *redis.RedisClient, client *socket.Socket, lobbyID string) (bool, error) {
-	return ValidateGamePhase(redisClient, client, lobbyID, redis_models.PhasePlayRound)
+func ValidatePlayRoundPhase(redisClient *redis.RedisClient, ctx *socketio_events.Context, lobbyID string) (bool, error) {
+	return ValidateGamePhase(redisClient, ctx, lobbyID, redis_models.PhasePlayRound)
 }
 
 // ValidateShopPhase specifically validates that the game is in the shop phase
-func ValidateShopPhase(redisClient *redis.RedisClient, client *socket.Socket, lobbyID string) (bool, error) {
-	return ValidateGamePhase(redisClient, client, lobbyID, redis_models.PhaseShop)
+func ValidateShopPhase(redisClient *redis.RedisClient, ctx *socketio_events.Context, lobbyID string) (bool, error) {
+	return ValidateGamePhase(redisClient, ctx, lobbyID, redis_models.PhaseShop)
 }
 
 // ValidateBlindPhase specifically validates that the game is in the blind phase
-func ValidateBlindPhase(redisClient *redis.RedisClient, client *socket.Socket, lobbyID string) (bool, error) {
-	return ValidateGamePhase(redisClient, client, lobbyID, redis_models.PhaseBlind)
+func ValidateBlindPhase(redisClient *redis.RedisClient, ctx *socketio_events.Context, lobbyID string) (bool, error) {
+	return ValidateGamePhase(redisClient, ctx, lobbyID, redis_models.PhaseBlind)
 }
 
 // ValidateVouchersPhase specifically validates that the game is in the modifiers phase
-func ValidateVouchersPhase(redisClient *redis.RedisClient, client *socket.Socket, lobbyID string) (bool, error) {
-	return ValidateGamePhase(redisClient, client, lobbyID, redis_models.PhaseVouchers)
+func ValidateVouchersPhase(redisClient *redis.RedisClient, ctx *socketio_events.Context, lobbyID string) (bool, error) {
+	return ValidateGamePhase(redisClient, ctx, lobbyID, redis_models.PhaseVouchers)
 }
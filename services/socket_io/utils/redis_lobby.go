@@ -1,67 +1,48 @@
 package socketio_utils
 
 import (
+	redis_models "Nogler/models/redis"
+	"Nogler/services/poker"
 	"Nogler/services/redis"
 	"fmt"
 	"log"
-)
-
-func SaveShopsCompletedAndIncrementGameRound(redisClient *redis.RedisClient, lobbyID string, incrementBy int) (int, error) {
-	log.Printf("[ROUND-INCREMENT] Incrementing round for lobby %s by %d", lobbyID, incrementBy)
-
-	// Get the game lobby from Redis
-	lobby, err := redisClient.GetGameLobby(lobbyID)
-	if err != nil {
-		log.Printf("[ROUND-INCREMENT-ERROR] Error getting lobby: %v", err)
-		return 0, fmt.Errorf("error getting lobby: %v", err)
-	}
-
-	// KEY: do it before incrementing the round, since we need to
-	// record the data from the round that just ended
-	lobby.VouchersCompleted[lobby.CurrentRound] = true
-
-	// Increment the current round
-	lobby.CurrentRound += incrementBy
-
-	// Save the updated lobby back to Redis
-	err = redisClient.SaveGameLobby(lobby)
-	if err != nil {
-		log.Printf("[ROUND-INCREMENT-ERROR] Error saving lobby: %v", err)
-		return 0, fmt.Errorf("error saving lobby: %v", err)
-	}
 
-	log.Printf("[ROUND-INCREMENT-SUCCESS] Lobby %s round incremented to %d",
-		lobbyID, lobby.CurrentRound)
-
-	return lobby.CurrentRound, nil
-}
+	"golang.org/x/exp/rand"
+)
 
 func SetGamePhase(redisClient *redis.RedisClient, lobbyID string, newPhase string) error {
 	log.Printf("[PHASE-CHANGE] Setting lobby %s phase to %s", lobbyID, newPhase)
 
-	// Get the game lobby from Redis
-	lobby, err := redisClient.GetGameLobby(lobbyID)
+	alreadySet := false
+	_, err := redisClient.UpdateGameLobby(lobbyID, func(lobby *redis_models.GameLobby) error {
+		// Check if phase is already set to the requested value
+		alreadySet = lobby.CurrentPhase == newPhase
+		lobby.CurrentPhase = newPhase
+		return nil
+	})
 	if err != nil {
-		log.Printf("[PHASE-CHANGE-ERROR] Error getting lobby: %v", err)
-		return fmt.Errorf("error getting lobby: %v", err)
+		log.Printf("[PHASE-CHANGE-ERROR] Error updating lobby phase: %v", err)
+		return fmt.Errorf("error updating lobby phase: %v", err)
 	}
 
-	// Check if phase is already set to the requested value
-	if lobby.CurrentPhase == newPhase {
+	if alreadySet {
 		log.Printf("[PHASE-CHANGE-INFO] Lobby %s phase already set to %s", lobbyID, newPhase)
 		return nil
 	}
 
-	// Update the phase
-	lobby.CurrentPhase = newPhase
+	log.Printf("[PHASE-CHANGE-SUCCESS] Lobby %s phase changed to %s", lobbyID, newPhase)
+	RecordGameEvent(redisClient, lobbyID, redis_models.EventPhaseChange, "", map[string]interface{}{"phase": newPhase})
+	return nil
+}
 
-	// Save the updated lobby back to Redis
-	err = redisClient.SaveGameLobby(lobby)
+// Returns the RNG for a random event of the lobby's current round, derived from the lobby seed
+// and the given parts. The parts must identify the event (e.g. username and plays left)
+func GetLobbyRNG(redisClient *redis.RedisClient, lobbyID string, parts ...interface{}) (*rand.Rand, error) {
+	lobby, err := redisClient.GetGameLobby(lobbyID)
 	if err != nil {
-		log.Printf("[PHASE-CHANGE-ERROR] Error saving lobby with updated phase: %v", err)
-		return fmt.Errorf("error saving lobby with updated phase: %v", err)
+		log.Printf("[RNG-ERROR] Error getting lobby: %v", err)
+		return nil, fmt.Errorf("error getting lobby: %v", err)
 	}
 
-	log.Printf("[PHASE-CHANGE-SUCCESS] Lobby %s phase changed to %s", lobbyID, newPhase)
-	return nil
+	return poker.NewGameRNG(lobby.Seed, append([]interface{}{lobby.CurrentRound}, parts...)...), nil
 }
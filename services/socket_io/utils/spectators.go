@@ -0,0 +1,38 @@
+package socketio_utils
+
+import (
+	redis_models "Nogler/models/redis"
+	"Nogler/services/poker"
+	socketio_types "Nogler/services/socket_io/types"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zishang520/socket.io/v2/socket"
+)
+
+const spectatorsRoomSuffix = ":spectators"
+
+// SpectatorsRoom returns the socket.io room of the spectators of a lobby. It's separate from
+// the room of the players, so spectators only get the events that are explicitly sent to them
+func SpectatorsRoom(lobbyID string) socket.Room {
+	return socket.Room(lobbyID + spectatorsRoomSuffix)
+}
+
+// SpectatedLobbyID returns the lobby of a spectators room, or false if the room isn't one
+func SpectatedLobbyID(room socket.Room) (string, bool) {
+	return strings.CutSuffix(string(room), spectatorsRoomSuffix)
+}
+
+// EmitPlayerScored tells the spectators of a lobby the score of a hand played by a player,
+// without the cards of the hand
+func EmitPlayerScored(sio *socketio_types.SocketServer, lobbyID string, player *redis_models.InGamePlayer, handType poker.HandType, score int) {
+	sio.Sio_server.To(SpectatorsRoom(lobbyID)).Emit("player_scored", gin.H{
+		"username":     player.Username,
+		"hand_type":    handType,
+		"hand_name":    handType.String(),
+		"score":        score,
+		"round_points": player.CurrentRoundPoints,
+		"total_points": player.TotalGamePoints,
+		"plays_left":   player.HandPlaysLeft,
+	})
+}
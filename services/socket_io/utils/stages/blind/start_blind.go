@@ -3,6 +3,7 @@ package blind
 import (
 	"Nogler/services/redis"
 	socketio_types "Nogler/services/socket_io/types"
+	socketio_utils "Nogler/services/socket_io/utils"
 	"log"
 	"time"
 
@@ -24,7 +25,7 @@ func BroadcastStartingNextBlind(redisClient *redis.RedisClient, db *gorm.DB, lob
 	}
 
 	// Broadcast starting_next_blind event to all players in the lobby
-	sio.Sio_server.To(socket.Room(lobbyID)).Emit("starting_next_blind", gin.H{
+	sio.Sio_server.To(socket.Room(lobbyID), socketio_utils.SpectatorsRoom(lobbyID)).Emit("starting_next_blind", gin.H{
 		"lobby_id":           lobbyID,
 		"blind_number":       lobby.CurrentRound,
 		"base_blind":         lobby.CurrentBaseBlind,
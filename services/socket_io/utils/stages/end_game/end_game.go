@@ -1,15 +1,19 @@
 package end_game
 
 import (
+	"Nogler/models/postgres"
 	redis_models "Nogler/models/redis"
 	"Nogler/services/redis"
 	socketio_types "Nogler/services/socket_io/types"
 	socketio_utils "Nogler/services/socket_io/utils"
 	"Nogler/utils"
+	"encoding/json"
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/zishang520/socket.io/v2/socket"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -49,7 +53,7 @@ func AnnounceWinners(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string
 		log.Printf("[GAME-END] No winners for lobby %s (all players eliminated)", lobbyID)
 
 		// Emit game end event with no winners
-		sio.Sio_server.To(socket.Room(lobbyID)).Emit("game_end", gin.H{
+		sio.Sio_server.To(socket.Room(lobbyID), socketio_utils.SpectatorsRoom(lobbyID)).Emit("game_end", gin.H{
 			"winners":    winnersData, // Empty array
 			"tie":        false,
 			"points":     0,
@@ -100,7 +104,7 @@ func AnnounceWinners(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string
 		}
 
 		// Broadcast game end to all players
-		sio.Sio_server.To(socket.Room(lobbyID)).Emit("game_end", gin.H{
+		sio.Sio_server.To(socket.Room(lobbyID), socketio_utils.SpectatorsRoom(lobbyID)).Emit("game_end", gin.H{
 			"winners":    winnersData,
 			"tie":        len(winners) > 1,
 			"points":     highestPoints,
@@ -109,6 +113,11 @@ func AnnounceWinners(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string
 		})
 	}
 
+	// Write the result of every player before CleanupGame deletes the game data
+	if lobby != nil {
+		saveMatchHistory(redisClient, db, lobby, players)
+	}
+
 	log.Printf("[GAME-END] Game ended for lobby %s", lobbyID)
 }
 
@@ -123,7 +132,10 @@ func CleanupGame(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string) {
 		// Continue with cleanup even if we can't get all players
 	}
 
-	// 2. Delete each player's game data from Redis
+	// 2. Persist the event log of the game before its Redis data is deleted
+	saveGameReplay(redisClient, db, lobbyID, players)
+
+	// 3. Delete each player's game data from Redis
 	for _, player := range players {
 		if err := redisClient.DeleteInGamePlayer(player.Username, lobbyID); err != nil {
 			log.Printf("[GAME-CLEANUP-ERROR] Error deleting player %s from Redis: %v",
@@ -133,7 +145,10 @@ func CleanupGame(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string) {
 		}
 	}
 
-	// 3. Delete the game lobby from Redis
+	// 4. Delete the game lobby and its pending phase timer from Redis
+	if err := redisClient.CancelPhaseTimer(lobbyID); err != nil {
+		log.Printf("[GAME-CLEANUP-ERROR] Error cancelling phase timer of lobby %s: %v", lobbyID, err)
+	}
 	if err := redisClient.DeleteGameLobby(lobbyID); err != nil {
 		log.Printf("[GAME-CLEANUP-ERROR] Error deleting lobby %s from Redis: %v",
 			lobbyID, err)
@@ -141,7 +156,7 @@ func CleanupGame(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string) {
 		log.Printf("[GAME-CLEANUP] Deleted lobby %s from Redis", lobbyID)
 	}
 
-	// 4. Use a transaction to delete PostgreSQL data
+	// 5. Use a transaction to delete PostgreSQL data
 	err = db.Transaction(func(tx *gorm.DB) error {
 		// First remove all player-lobby relationships
 		if err := tx.Exec("DELETE FROM in_game_players WHERE lobby_id = ?", lobbyID).Error; err != nil {
@@ -164,3 +179,61 @@ func CleanupGame(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string) {
 		log.Printf("[GAME-CLEANUP-SUCCESS] Successfully removed lobby %s and all related data from databases", lobbyID)
 	}
 }
+
+// saveGameReplay stores the event log of the lobby in PostgreSQL, along with its seed and players
+func saveGameReplay(redisClient *redis.RedisClient, db *gorm.DB, lobbyID string, players []redis_models.InGamePlayer) {
+	events, err := redisClient.GetGameEvents(lobbyID)
+	if err != nil {
+		log.Printf("[GAME-CLEANUP-ERROR] Error getting game events: %v", err)
+		return
+	}
+
+	var seed uint64
+	lobby, err := redisClient.GetGameLobby(lobbyID)
+	if err != nil {
+		log.Printf("[GAME-CLEANUP-ERROR] Error getting lobby seed: %v", err)
+	} else {
+		seed = lobby.Seed
+	}
+
+	// Eliminated players are no longer in Redis, but they appear in the events
+	usernames := []string{}
+	seen := make(map[string]bool)
+	addPlayer := func(username string) {
+		if username != "" && !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+	for _, event := range events {
+		addPlayer(event.Username)
+	}
+	for _, player := range players {
+		addPlayer(player.Username)
+	}
+
+	playersJSON, err := json.Marshal(usernames)
+	if err != nil {
+		log.Printf("[GAME-CLEANUP-ERROR] Error serializing replay players: %v", err)
+		return
+	}
+	eventsJSON, err := json.Marshal(events)
+	if err != nil {
+		log.Printf("[GAME-CLEANUP-ERROR] Error serializing replay events: %v", err)
+		return
+	}
+
+	replay := postgres.GameReplay{
+		LobbyID:    lobbyID,
+		Seed:       seed,
+		Players:    datatypes.JSON(playersJSON),
+		Events:     datatypes.JSON(eventsJSON),
+		FinishedAt: time.Now(),
+	}
+	if err := db.Create(&replay).Error; err != nil {
+		log.Printf("[GAME-CLEANUP-ERROR] Error saving replay: %v", err)
+		return
+	}
+
+	log.Printf("[GAME-CLEANUP] Saved replay of lobby %s with %d events", lobbyID, len(events))
+}
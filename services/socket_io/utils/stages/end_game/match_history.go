@@ -0,0 +1,215 @@
+package end_game
+
+import (
+	"Nogler/models/postgres"
+	redis_models "Nogler/models/redis"
+	"Nogler/services/poker"
+	"Nogler/services/rating"
+	"Nogler/services/redis"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Result of a player in a finished game, before being written to the match history
+type playerResult struct {
+	player         redis_models.InGamePlayer
+	placement      int
+	roundsSurvived int
+}
+
+// Ranks the players of a finished game. The players still in the game are ranked by their
+// points in the last round, and the eliminated ones after them, the ones that lasted longer first.
+// Players with the same result share placement
+func rankPlayers(players []redis_models.InGamePlayer, eliminated []redis_models.EliminatedPlayer, currentRound int) []playerResult {
+	results := make([]playerResult, 0, len(players)+len(eliminated))
+
+	remaining := append([]redis_models.InGamePlayer(nil), players...)
+	sort.SliceStable(remaining, func(i, j int) bool {
+		return remaining[i].CurrentRoundPoints > remaining[j].CurrentRoundPoints
+	})
+	for i, player := range remaining {
+		placement := i + 1
+		if i > 0 && player.CurrentRoundPoints == remaining[i-1].CurrentRoundPoints {
+			placement = results[i-1].placement
+		}
+		results = append(results, playerResult{
+			player:         player,
+			placement:      placement,
+			roundsSurvived: currentRound,
+		})
+	}
+
+	eliminated = append([]redis_models.EliminatedPlayer(nil), eliminated...)
+	sort.SliceStable(eliminated, func(i, j int) bool {
+		if eliminated[i].Round != eliminated[j].Round {
+			return eliminated[i].Round > eliminated[j].Round
+		}
+		return eliminated[i].Player.CurrentRoundPoints > eliminated[j].Player.CurrentRoundPoints
+	})
+	for i, e := range eliminated {
+		placement := len(remaining) + i + 1
+		if i > 0 && e.Round == eliminated[i-1].Round &&
+			e.Player.CurrentRoundPoints == eliminated[i-1].Player.CurrentRoundPoints {
+			placement = results[len(results)-1].placement
+		}
+		results = append(results, playerResult{
+			player:         e.Player,
+			placement:      placement,
+			roundsSurvived: e.Round - 1,
+		})
+	}
+
+	return results
+}
+
+// Computes the new ratings of the players of a finished game, given their profiles.
+// Profiles that were never rated (created before the ratings) start at the initial rating
+func updatedRatings(results []playerResult, profiles map[string]*postgres.GameProfile) (map[string]int, map[string]int) {
+	oldRatings := make(map[string]int, len(results))
+	players := make([]rating.Player, 0, len(results))
+	for _, result := range results {
+		username := result.player.Username
+		oldRatings[username] = profiles[username].UserScore
+		if oldRatings[username] <= 0 {
+			oldRatings[username] = rating.InitialRating
+		}
+		players = append(players, rating.Player{
+			Username:  username,
+			Rating:    oldRatings[username],
+			Placement: result.placement,
+		})
+	}
+	return oldRatings, rating.Update(players)
+}
+
+// Writes the match history of every (non AI) player of a finished game and updates their
+// stats and ratings. Only games with at least two (non AI) players are rated
+func saveMatchHistory(redisClient *redis.RedisClient, db *gorm.DB, lobby *redis_models.GameLobby, players []redis_models.InGamePlayer) {
+	eliminated, err := redisClient.GetEliminatedPlayers(lobby.Id)
+	if err != nil {
+		log.Printf("[MATCH-HISTORY-ERROR] Error getting eliminated players: %v", err)
+	}
+
+	results := rankPlayers(players, eliminated, lobby.CurrentRound)
+
+	usernames := make([]string, 0, len(results))
+	for _, result := range results {
+		usernames = append(usernames, result.player.Username)
+	}
+	participants, err := json.Marshal(usernames)
+	if err != nil {
+		log.Printf("[MATCH-HISTORY-ERROR] Error marshaling participants: %v", err)
+		return
+	}
+
+	// The AI doesn't have a game profile
+	humans := make([]playerResult, 0, len(results))
+	humanUsernames := make([]string, 0, len(results))
+	for _, result := range results {
+		if !result.player.IsBot {
+			humans = append(humans, result)
+			humanUsernames = append(humanUsernames, result.player.Username)
+		}
+	}
+	if len(humans) == 0 {
+		return
+	}
+
+	finishedAt := time.Now()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Lock the profiles, other games of the same players might be ending at the same time.
+		// They're locked in the same order everywhere to avoid deadlocks
+		var profileList []postgres.GameProfile
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("username IN ?", humanUsernames).Order("username").Find(&profileList).Error; err != nil {
+			return fmt.Errorf("error getting profiles: %v", err)
+		}
+		profiles := make(map[string]*postgres.GameProfile, len(profileList))
+		for i := range profileList {
+			profiles[profileList[i].Username] = &profileList[i]
+		}
+		for _, username := range humanUsernames {
+			if profiles[username] == nil {
+				return fmt.Errorf("profile of %s not found", username)
+			}
+		}
+
+		rated := len(humans) >= 2
+		oldRatings, newRatings := updatedRatings(humans, profiles)
+
+		for _, result := range humans {
+			var jokers poker.Jokers
+			if len(result.player.CurrentJokers) > 0 {
+				if err := json.Unmarshal(result.player.CurrentJokers, &jokers); err != nil {
+					log.Printf("[MATCH-HISTORY-WARN] Error parsing jokers of %s: %v", result.player.Username, err)
+				}
+			}
+			if jokers.Juglares == nil {
+				jokers.Juglares = []int{}
+			}
+			jokersJSON, err := json.Marshal(jokers.Juglares)
+			if err != nil {
+				return fmt.Errorf("error marshaling jokers: %v", err)
+			}
+
+			match := postgres.MatchHistory{
+				LobbyID:        lobby.Id,
+				Username:       result.player.Username,
+				Participants:   datatypes.JSON(participants),
+				Placement:      result.placement,
+				Winner:         result.placement == 1 && len(players) > 0,
+				RoundsSurvived: result.roundsSurvived,
+				FinalPoints:    result.player.TotalGamePoints,
+				FinalMoney:     result.player.PlayersMoney,
+				MostPlayedHand: int(result.player.MostPlayedHandType()),
+				BestHandScore:  result.player.BestHandScore,
+				Jokers:         datatypes.JSON(jokersJSON),
+				FinishedAt:     finishedAt,
+			}
+			if err := tx.Create(&match).Error; err != nil {
+				return fmt.Errorf("error saving match of %s: %v", match.Username, err)
+			}
+
+			profile := profiles[match.Username]
+			stats := postgres.ParseUserStats(profile.UserStats)
+			stats.AddMatch(match, jokers.Juglares)
+			statsJSON, err := json.Marshal(stats)
+			if err != nil {
+				return fmt.Errorf("error marshaling stats: %v", err)
+			}
+			updates := map[string]interface{}{"user_stats": datatypes.JSON(statsJSON)}
+			if rated {
+				updates["user_score"] = newRatings[match.Username]
+			}
+			if err := tx.Model(profile).Updates(updates).Error; err != nil {
+				return fmt.Errorf("error updating stats of %s: %v", match.Username, err)
+			}
+
+			if rated {
+				if err := tx.Create(&postgres.RatingHistory{
+					Username:  match.Username,
+					LobbyID:   lobby.Id,
+					OldRating: oldRatings[match.Username],
+					NewRating: newRatings[match.Username],
+					CreatedAt: finishedAt,
+				}).Error; err != nil {
+					return fmt.Errorf("error saving rating history of %s: %v", match.Username, err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[MATCH-HISTORY-ERROR] Error saving match history of lobby %s: %v", lobby.Id, err)
+		return
+	}
+
+	log.Printf("[MATCH-HISTORY] Saved match history of lobby %s (%d players)", lobby.Id, len(results))
+}
@@ -0,0 +1,199 @@
+package lobby_setup
+
+import (
+	models "Nogler/models/postgres"
+	redis_models "Nogler/models/redis"
+	"Nogler/services/poker"
+	"Nogler/services/redis"
+	socketio_types "Nogler/services/socket_io/types"
+	"Nogler/services/socket_io/utils/game_flow"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Creates the state of a player that has just joined a lobby, following the lobby rules
+func newInGamePlayer(lobby *redis_models.GameLobby, username string, isBot bool) (*redis_models.InGamePlayer, error) {
+	// Initially, the player hasn't bought any packs yet
+	purchasedPackCardsJSON, err := json.Marshal(make([]poker.Card, 0))
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling purchased cards: %v", err)
+	}
+
+	return &redis_models.InGamePlayer{
+		Username:     username,
+		LobbyId:      lobby.Id,
+		Rerolls:      0,
+		PlayersMoney: lobby.Rules.StartingMoney,
+		CurrentDeck:  poker.InitializePlayerDeck(poker.NewGameRNG(lobby.Seed, "deck", username)), // Will be initialized when game starts
+		// TODO: see in_game_player.go
+		// PlayersRemainingCards: 52,
+		Modifiers:                   nil, // Will be initialized when game starts
+		CurrentJokers:               nil, // Will be initialized when game starts
+		MostPlayedHand:              nil, // Will be initialized during game
+		HandPlaysLeft:               lobby.Rules.HandPlays,
+		DiscardsLeft:                lobby.Rules.Discards,
+		Winner:                      false,
+		CurrentRoundPoints:          0,
+		TotalGamePoints:             0,
+		IsBot:                       isBot,
+		LastPurchasedPackItemId:     -1,
+		PurchasedPackCards:          purchasedPackCardsJSON,
+		CurrentShopPurchasedItemIDs: make(map[int]bool),
+	}, nil
+}
+
+// CreateLobby creates a lobby in PostgreSQL and Redis with the given visibility (0 private,
+// 1 public, 2 AI), seed and rules. If the lobby is against the AI, the AI player is added.
+// The creator isn't added as a player, it has to join the lobby like everyone else
+func CreateLobby(db *gorm.DB, redisClient *redis.RedisClient, creator string, isPublic int,
+	seed uint64, rules redis_models.GameRules) (*models.GameLobby, error) {
+
+	rulesJSON, err := json.Marshal(rules)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling game rules: %v", err)
+	}
+
+	// Create lobby with public/private setting
+	newLobby := models.GameLobby{
+		CreatorUsername: creator,
+		NumberOfRounds:  rules.MaxRounds,
+		TotalPoints:     0,
+		IsPublic:        isPublic,
+		Rules:           rulesJSON,
+	}
+	if err := db.Create(&newLobby).Error; err != nil {
+		return nil, fmt.Errorf("error creating lobby: %v", err)
+	}
+
+	// Create corresponding Redis lobby with matching public/private setting
+	redisLobby := &redis_models.GameLobby{
+		Id:                      newLobby.ID,
+		CreatorUsername:         creator,
+		MaxRounds:               rules.MaxRounds,
+		TotalPoints:             0,
+		CreatedAt:               newLobby.CreatedAt,
+		GameHasBegun:            false,
+		IsPublic:                isPublic,
+		CurrentHighBlind:        0,
+		NumberOfVotes:           0,
+		CurrentRound:            0,
+		ProposedBlinds:          make(map[string]bool),
+		PlayersFinishedRound:    make(map[string]bool),
+		PlayersFinishedShop:     make(map[string]bool),
+		PlayersFinishedVouchers: make(map[string]bool),
+		PlayerCount:             0,
+		BlindTimeout:            time.Time{},
+		GameRoundTimeout:        time.Time{},
+		ShopTimeout:             time.Time{},
+		VouchersTimeout:         time.Time{},
+		BlindsCompleted:         make(map[int]bool),
+		GameRoundsCompleted:     make(map[int]bool),
+		ShopsCompleted:          make(map[int]bool),
+		VouchersCompleted:       make(map[int]bool),
+		CurrentPhase:            redis_models.PhaseNone, // Initialize with "none" phase
+		CurrentBaseBlind:        rules.BaseBlind,
+		Seed:                    seed,
+		Rules:                   rules,
+	}
+
+	// Rollback lobby creation in Redis and PostgreSQL
+	rollback := func() {
+		if err := redisClient.DeleteGameLobby(newLobby.ID); err != nil {
+			log.Printf("Failed to rollback Redis lobby creation: %v", err)
+		}
+		if err := db.Delete(&newLobby).Error; err != nil {
+			log.Printf("Failed to rollback PostgreSQL lobby creation: %v", err)
+		}
+	}
+
+	if isPublic == 2 {
+		// Add bot to Redis lobby
+		redisAIPlayer, err := newInGamePlayer(redisLobby, game_flow.FormatAIPlayerName(newLobby.ID), true)
+		if err == nil {
+			err = redisClient.SaveInGamePlayer(redisAIPlayer)
+		}
+		if err != nil {
+			log.Printf("[AI-ERROR] Error saving AI player in Redis: %v", err)
+			rollback()
+			return nil, fmt.Errorf("error saving AI player in Redis: %v", err)
+		}
+
+		// Update player count in Redis lobby
+		redisLobby.PlayerCount = 1
+	}
+
+	// Save the lobby in Redis
+	if err := redisClient.SaveGameLobby(redisLobby); err != nil {
+		rollback()
+		return nil, fmt.Errorf("error creating lobby in Redis: %v", err)
+	}
+
+	return &newLobby, nil
+}
+
+// AddPlayer adds a user to a lobby that hasn't started, both in PostgreSQL and Redis
+func AddPlayer(db *gorm.DB, redisClient *redis.RedisClient, lobby *redis_models.GameLobby, username string) error {
+	redisPlayer, err := newInGamePlayer(lobby, username, false)
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&models.InGamePlayer{LobbyID: lobby.Id, Username: username}).Error; err != nil {
+			return fmt.Errorf("error adding user to the lobby: %v", err)
+		}
+
+		// The transaction is rolled back if the player can't be saved in Redis
+		if err := redisClient.SaveInGamePlayer(redisPlayer); err != nil {
+			return fmt.Errorf("error adding user to Redis lobby: %v", err)
+		}
+		return nil
+	})
+}
+
+// StartGame closes the lobby to new players and starts its first blind
+func StartGame(redisClient *redis.RedisClient, db *gorm.DB, lobby *models.GameLobby, sio *socketio_types.SocketServer) error {
+	// Count the number of players in the lobby
+	var playerCount int64
+	if err := db.Model(&models.InGamePlayer{}).Where("lobby_id = ?", lobby.ID).Count(&playerCount).Error; err != nil {
+		return fmt.Errorf("error counting players: %v", err)
+	}
+
+	// Check if there are enough players to start the game. If the lobby is public or private, we need at least 2 players.
+	// If the lobby is AI, we can start with 1 player
+	//if playerCount < 2 && (lobby.IsPublic == 1 || lobby.IsPublic == 0) {
+
+	// Check if there are enough players to start the game
+	if playerCount < 1 {
+		return fmt.Errorf("not enough players in lobby %s (count: %d)", lobby.ID, playerCount)
+	}
+
+	// Update Redis with player count
+	redisLobby, err := redisClient.UpdateGameLobby(lobby.ID, func(redisLobby *redis_models.GameLobby) error {
+		// Set the player count
+		redisLobby.PlayerCount = int(playerCount)
+		if lobby.IsPublic == 2 {
+			redisLobby.PlayerCount++ // Add AI player
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error updating Redis lobby: %v", err)
+	}
+
+	// Update lobby state to "in progress" in PostgreSQL
+	if err := db.Model(&models.GameLobby{}).Where("id = ?", lobby.ID).Update("game_has_begun", true).Error; err != nil {
+		return fmt.Errorf("error updating lobby state: %v", err)
+	}
+
+	// Update Redis state to "in progress"
+	if err := redisClient.CloseLobby(lobby.ID); err != nil {
+		return fmt.Errorf("error updating Redis state: %v", err)
+	}
+
+	return game_flow.AdvanceToNextBlindIfUndone(redisClient, db, lobby.ID, sio, true, redisLobby.CurrentRound)
+}
@@ -0,0 +1,202 @@
+package lobby_setup
+
+import (
+	models "Nogler/models/postgres"
+	redis_models "Nogler/models/redis"
+	"Nogler/services/matchmaking"
+	"Nogler/services/redis"
+	socketio_types "Nogler/services/socket_io/types"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zishang520/socket.io/v2/socket"
+	"gorm.io/gorm"
+)
+
+// How often the matchmaking queue is checked
+const MATCHMAKING_POLL_INTERVAL = 1 * time.Second
+
+// Players of each matchmaking game, unless set with the MATCHMAKING_PLAYERS env variable
+const DEFAULT_MATCHMAKING_PLAYERS = 2
+
+// Maximum number of players of a lobby
+const MAX_LOBBY_PLAYERS = 8
+
+// MatchmakingPlayers returns the number of players of each matchmaking game
+func MatchmakingPlayers() int {
+	players, err := strconv.Atoi(os.Getenv("MATCHMAKING_PLAYERS"))
+	if err != nil || players < 2 || players > MAX_LOBBY_PLAYERS {
+		return DEFAULT_MATCHMAKING_PLAYERS
+	}
+	return players
+}
+
+// StartMatchmaker polls the matchmaking queue and starts a public game for every group of
+// players with similar ratings
+func StartMatchmaker(redisClient *redis.RedisClient, db *gorm.DB, sio *socketio_types.SocketServer) {
+	size := MatchmakingPlayers()
+
+	go func() {
+		ticker := time.NewTicker(MATCHMAKING_POLL_INTERVAL)
+		defer ticker.Stop()
+
+		for {
+			matchQueuedPlayers(redisClient, db, sio, size)
+			<-ticker.C
+		}
+	}()
+
+	log.Printf("[MATCHMAKING] Matchmaker started (%d players per game)", size)
+}
+
+func matchQueuedPlayers(redisClient *redis.RedisClient, db *gorm.DB, sio *socketio_types.SocketServer, size int) {
+	entries, err := redisClient.GetQueue()
+	if err != nil {
+		log.Printf("[MATCHMAKING-ERROR] %v", err)
+		return
+	}
+
+	for _, match := range matchmaking.FindMatches(entries, time.Now(), size) {
+		usernames := make([]string, len(match))
+		for i, entry := range match {
+			usernames[i] = entry.Username
+		}
+
+		// Some of the players may have cancelled or been matched by another instance
+		claimed, err := redisClient.ClaimQueueEntries(usernames)
+		if err != nil {
+			log.Printf("[MATCHMAKING-ERROR] %v", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		// Each game starts in its own goroutine, so a slow lobby doesn't delay the others
+		go func() {
+			if err := startMatch(redisClient, db, sio, match); err != nil {
+				log.Printf("[MATCHMAKING-ERROR] Error starting match of %v: %v", usernames, err)
+			}
+		}()
+	}
+}
+
+// Creates a public lobby for the matched players, joins them to its room and starts the game.
+// If any step fails, the lobby is deleted and the players are put back in the queue
+func startMatch(redisClient *redis.RedisClient, db *gorm.DB, sio *socketio_types.SocketServer, match []redis_models.QueueEntry) error {
+	usernames := make([]string, len(match))
+	for i, entry := range match {
+		usernames[i] = entry.Username
+	}
+
+	lobby, err := CreateLobby(db, redisClient, usernames[0], 1, uint64(time.Now().UnixNano()), redis_models.DefaultGameRules())
+	if err != nil {
+		requeuePlayers(redisClient, sio, match, nil)
+		return err
+	}
+
+	// Players already added to the lobby, and the ones that joined another lobby meanwhile
+	var added []string
+	busy := make(map[string]bool)
+	fail := func(err error) error {
+		deleteMatchLobby(redisClient, db, sio, lobby, added)
+		requeuePlayers(redisClient, sio, match, busy)
+		return err
+	}
+
+	redisLobby, err := redisClient.GetGameLobby(lobby.ID)
+	if err != nil {
+		return fail(fmt.Errorf("error getting Redis lobby: %v", err))
+	}
+	for _, username := range usernames {
+		// The player may have joined a lobby by hand after being claimed from the queue
+		if _, err := redisClient.GetInGamePlayer(username); err == nil {
+			busy[username] = true
+			return fail(fmt.Errorf("%s is already in a lobby", username))
+		}
+		if err := AddPlayer(db, redisClient, redisLobby, username); err != nil {
+			return fail(err)
+		}
+		added = append(added, username)
+	}
+
+	players := make([]gin.H, len(usernames))
+	for i, username := range usernames {
+		var profile models.GameProfile
+		if err := db.Where("username = ?", username).First(&profile).Error; err != nil {
+			return fail(fmt.Errorf("error getting profile of %s: %v", username, err))
+		}
+		players[i] = gin.H{
+			"username": username,
+			"icon":     profile.UserIcon,
+			"rating":   profile.UserScore,
+		}
+	}
+
+	// Players that are disconnected right now can still join the game when they reconnect
+	for _, username := range usernames {
+		if conn, ok := sio.GetUser(username); ok {
+			conn.Join(socket.Room(lobby.ID))
+		}
+	}
+	sio.Sio_server.To(socket.Room(lobby.ID)).Emit("match_found", gin.H{
+		"lobby_id": lobby.ID,
+		"players":  players,
+		"rules":    redisLobby.Rules,
+	})
+
+	log.Printf("[MATCHMAKING] Match found for %v in lobby %s", usernames, lobby.ID)
+
+	if err := StartGame(redisClient, db, lobby, sio); err != nil {
+		return fail(err)
+	}
+	return nil
+}
+
+// Deletes the lobby of a match that couldn't start, with its players, from PostgreSQL and Redis
+func deleteMatchLobby(redisClient *redis.RedisClient, db *gorm.DB, sio *socketio_types.SocketServer,
+	lobby *models.GameLobby, usernames []string) {
+	for _, username := range usernames {
+		if conn, ok := sio.GetUser(username); ok {
+			conn.Leave(socket.Room(lobby.ID))
+		}
+		if err := redisClient.DeleteInGamePlayer(username, lobby.ID); err != nil {
+			log.Printf("[MATCHMAKING-ERROR] Error deleting %s from Redis lobby %s: %v", username, lobby.ID, err)
+		}
+	}
+	if err := redisClient.DeleteGameLobby(lobby.ID); err != nil {
+		log.Printf("[MATCHMAKING-ERROR] Error deleting Redis lobby %s: %v", lobby.ID, err)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("lobby_id = ?", lobby.ID).Delete(&models.InGamePlayer{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(lobby).Error
+	})
+	if err != nil {
+		log.Printf("[MATCHMAKING-ERROR] Error deleting lobby %s: %v", lobby.ID, err)
+	}
+}
+
+// Puts the players of a match that couldn't start back in the queue, keeping their waiting
+// time, and tells them. The skipped ones (already in another lobby) aren't requeued
+func requeuePlayers(redisClient *redis.RedisClient, sio *socketio_types.SocketServer, match []redis_models.QueueEntry, skip map[string]bool) {
+	for _, entry := range match {
+		queued := false
+		if !skip[entry.Username] {
+			err := redisClient.EnqueuePlayer(entry)
+			if err != nil && err != redis.ErrAlreadyQueued {
+				log.Printf("[MATCHMAKING-ERROR] Error requeuing %s: %v", entry.Username, err)
+			}
+			queued = err == nil || err == redis.ErrAlreadyQueued
+		}
+		if conn, ok := sio.GetUser(entry.Username); ok {
+			conn.Emit("queue_status", gin.H{"queued": queued, "error": "Error creating the game"})
+		}
+	}
+}
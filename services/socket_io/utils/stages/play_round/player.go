@@ -6,6 +6,7 @@ import (
 	"Nogler/services/poker"
 	"Nogler/services/redis"
 	socketio_types "Nogler/services/socket_io/types"
+	socketio_utils "Nogler/services/socket_io/utils"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -15,79 +16,79 @@ import (
 	"gorm.io/gorm"
 )
 
-// ValidatePlayerHand checks if the hand is valid for the player
-// It validates that:
-// 1. All cards in the hand are in the player's current hand
-// 2. The gold in the hand matches the player's gold
-// 3. All jokers in the hand are in the player's current jokers
-func ValidatePlayerHand(player *redis_models.InGamePlayer, hand poker.Hand) (bool, string) {
+// ValidatePlayerHand checks if the hand is valid for the player, that is,
+// all the card IDs are of cards in the player's current hand, and returns those cards.
+// NOTE: the jokers and gold of the hand are taken from Redis (see PlayerScoringHand)
+func ValidatePlayerHand(player *redis_models.InGamePlayer, cardIDs []int) ([]poker.Card, bool, string) {
 	// Validate cards
 	var currentCards []poker.Card
 	err := json.Unmarshal(player.CurrentHand, &currentCards)
 	if err != nil {
-		return false, fmt.Sprintf("Error processing player's current hand: %v", err)
+		return nil, false, fmt.Sprintf("Error processing player's current hand: %v", err)
 	}
 
 	// Use the new helper function to validate cards
-	if valid, errMsg := ValidatePlayerCards(currentCards, hand.Cards); !valid {
-		return false, errMsg
-	}
+	return ValidatePlayerCards(currentCards, cardIDs)
+}
 
-	// Validate gold if it was passed
-	if hand.Gold != 0 && hand.Gold != player.PlayersMoney {
-		return false, fmt.Sprintf("Gold mismatch: hand has %d, player has %d",
-			hand.Gold, player.PlayersMoney)
+// PlayerScoringHand returns the hand that will be scored for the given cards, using the
+// jokers (in the order chosen by the player), gold and hand levels stored in Redis
+func PlayerScoringHand(player *redis_models.InGamePlayer, cards []poker.Card) (poker.Hand, error) {
+	var jokers poker.Jokers
+	if player.CurrentJokers != nil && len(player.CurrentJokers) > 0 {
+		if err := json.Unmarshal(player.CurrentJokers, &jokers); err != nil {
+			return poker.Hand{}, fmt.Errorf("error parsing jokers: %v", err)
+		}
 	}
 
-	// Validate jokers
-	var playerJokers poker.Jokers
-	err = json.Unmarshal(player.CurrentJokers, &playerJokers)
-	if err != nil {
-		return false, fmt.Sprintf("Error processing player's jokers: %v", err)
-	}
+	return poker.Hand{
+		Cards:  cards,
+		Jokers: jokers,
+		Gold:   player.PlayersMoney,
+		Levels: player.HandLevels,
+	}, nil
+}
 
-	// Create a map of player's jokers for easy lookup
-	jokerMap := make(map[int]bool)
-	for _, joker := range playerJokers.Juglares {
-		if joker != 0 {
-			jokerMap[joker] = true
+// ReorderPlayerJokers sets the order in which the player's jokers are applied.
+// The new order must contain exactly the jokers the player owns (see poker.Jokers.Reorder)
+func ReorderPlayerJokers(player *redis_models.InGamePlayer, order []int) error {
+	var currentJokers poker.Jokers
+	if player.CurrentJokers != nil && len(player.CurrentJokers) > 0 {
+		if err := json.Unmarshal(player.CurrentJokers, &currentJokers); err != nil {
+			return fmt.Errorf("error parsing jokers: %v", err)
 		}
 	}
 
-	// Check each joker in the hand
-	for _, joker := range hand.Jokers.Juglares {
-		if joker == 0 {
-			continue
-		}
-		if !jokerMap[joker] {
-			return false, fmt.Sprintf("Joker %d not available to player", joker)
-		}
+	// The state of every joker moves with it
+	if err := currentJokers.Reorder(order); err != nil {
+		return err
+	}
+
+	updatedJokersJSON, err := json.Marshal(currentJokers)
+	if err != nil {
+		return fmt.Errorf("error updating jokers: %v", err)
 	}
+	player.CurrentJokers = updatedJokersJSON
 
-	return true, ""
+	return nil
 }
 
-// ValidatePlayerCards checks if all the specified cards are in the player's hand
-func ValidatePlayerCards(playerCards []poker.Card, cardsToValidate []poker.Card) (bool, string) {
-	// Create a map of player's cards for efficient lookup
-	// Use rank, suit, and enhancement as the composite key
-	cardMap := make(map[string]int)
-	for _, card := range playerCards {
-		key := fmt.Sprintf("%s-%s-%d", card.Rank, card.Suit, card.Enhancement)
-		cardMap[key]++
+// ValidatePlayerCards checks if all the specified card IDs are in the player's hand, once
+// each, and returns the cards with those IDs
+func ValidatePlayerCards(playerCards []poker.Card, cardIDs []int) ([]poker.Card, bool, string) {
+	cards, err := poker.FindCards(playerCards, cardIDs)
+	if err != nil {
+		return nil, false, fmt.Sprintf("Invalid cards for player's hand: %v", err)
 	}
 
-	// Check if each card to validate is in the player's hand
-	for _, card := range cardsToValidate {
-		key := fmt.Sprintf("%s-%s-%d", card.Rank, card.Suit, card.Enhancement)
-		if count, exists := cardMap[key]; !exists || count <= 0 {
-			return false, fmt.Sprintf("Card %s-%s with enhancement %d not in player's hand",
-				card.Rank, card.Suit, card.Enhancement)
+	// The hand comes from Redis, but check its cards weren't stored broken
+	for _, card := range cards {
+		if err := card.Validate(); err != nil {
+			return nil, false, fmt.Sprintf("Card %d (%s-%s) is not valid: %v", card.ID, card.Rank, card.Suit, err)
 		}
-		cardMap[key]-- // Decrement to handle duplicate cards
 	}
 
-	return true, ""
+	return cards, true, ""
 }
 
 // Separate function to handle player eliminations based on blind achievement
@@ -193,6 +194,19 @@ func HandlePlayerEliminations(redisClient *redis.RedisClient, lobbyID string, si
 	if len(eliminatedPlayers) > 0 {
 		// Remove eliminated players from Redis and PostgreSQL
 		for _, username := range eliminatedPlayers {
+			// Keep their last state for the match history, written when the game ends
+			for _, player := range players {
+				if player.Username != username {
+					continue
+				}
+				if err := redisClient.SaveEliminatedPlayer(lobbyID, redis_models.EliminatedPlayer{
+					Player: player,
+					Round:  lobby.CurrentRound,
+				}); err != nil {
+					log.Printf("[ELIMINATION-ERROR] Error saving eliminated player %s: %v", username, err)
+				}
+			}
+
 			// Delete from Redis
 			if err := redisClient.DeleteInGamePlayer(username, lobbyID); err != nil {
 				log.Printf("[ELIMINATION-ERROR] Error removing player %s from Redis: %v", username, err)
@@ -204,20 +218,24 @@ func HandlePlayerEliminations(redisClient *redis.RedisClient, lobbyID string, si
 			} else {
 				log.Printf("[ELIMINATION] Successfully removed player %s from PostgreSQL", username)
 			}
-		}
 
-		// Update player count
-		lobby.PlayerCount -= len(eliminatedPlayers)
-		if lobby.PlayerCount < 0 {
-			lobby.PlayerCount = 0
+			socketio_utils.RecordGameEvent(redisClient, lobbyID, redis_models.EventElimination, username, gin.H{
+				"reason":             "blind_check",
+				"high_blind_value":   currentTargetBlind,
+				"base_blind":         baseBlind,
+				"proposer_succeeded": proposerReachedBlind,
+			})
 		}
 
-		if err := redisClient.SaveGameLobby(lobby); err != nil {
-			log.Printf("[ELIMINATION-ERROR] Error updating player count: %v", err)
+		// NOTE: DeleteInGamePlayer already decremented the player count, just read it again
+		if updatedLobby, err := redisClient.GetGameLobby(lobbyID); err != nil {
+			log.Printf("[ELIMINATION-ERROR] Error getting updated player count: %v", err)
+		} else {
+			lobby = updatedLobby
 		}
 
 		// Broadcast the eliminated players
-		sio.Sio_server.To(socket.Room(lobbyID)).Emit("players_eliminated", gin.H{
+		sio.Sio_server.To(socket.Room(lobbyID), socketio_utils.SpectatorsRoom(lobbyID)).Emit("players_eliminated", gin.H{
 			"eliminated_players": eliminatedPlayers,
 			"reason":             "blind_check",
 			"high_blind_value":   currentTargetBlind,
@@ -267,15 +285,17 @@ func DistributePot(redisClient *redis.RedisClient, lobbyID string, sio *socketio
 
 	// Update each player's money in Redis and PostgreSQL
 	for i := range players {
-		// Add full pot amount to each player
-		players[i].PlayersMoney += potAmount
-
-		// Save in Redis
-		if err := redisClient.SaveInGamePlayer(&players[i]); err != nil {
+		// Add full pot amount to each player, on its current state in Redis
+		updated, err := redisClient.UpdateInGamePlayer(players[i].Username, func(player *redis_models.InGamePlayer) error {
+			player.PlayersMoney += potAmount
+			return nil
+		})
+		if err != nil {
 			log.Printf("[POT-DISTRIBUTION-ERROR] Error updating player %s money in Redis: %v",
 				players[i].Username, err)
 			continue
 		}
+		players[i] = *updated
 
 		// Update in PostgreSQL
 		// NOTE: not needed
@@ -288,7 +308,7 @@ func DistributePot(redisClient *redis.RedisClient, lobbyID string, sio *socketio
 	}
 
 	// Notify players about pot distribution
-	sio.Sio_server.To(socket.Room(lobbyID)).Emit("pot_distributed", gin.H{
+	sio.Sio_server.To(socket.Room(lobbyID), socketio_utils.SpectatorsRoom(lobbyID)).Emit("pot_distributed", gin.H{
 		"pot_amount":        potAmount,
 		"players_remaining": len(players),
 	})
@@ -1,11 +1,11 @@
 package play_round
 
 import (
-	game_constants "Nogler/constants/game"
 	redis_models "Nogler/models/redis"
 	poker "Nogler/services/poker"
 	"Nogler/services/redis"
 	socketio_types "Nogler/services/socket_io/types"
+	socketio_utils "Nogler/services/socket_io/utils"
 	"encoding/json"
 	"log"
 	"time"
@@ -17,36 +17,49 @@ import (
 // Functions that are executed to start the next game round
 // ---------------------------------------------------------------
 
-func PrepareRoundStart(redisClient *redis.RedisClient, lobbyID string) (*redis_models.GameLobby, int, error) {
+// PrepareRoundStart moves the lobby to the play round phase. If the lobby is no longer in the expected
+// round or the round has already started, nothing is changed and redis.ErrUpdateSkipped is returned
+func PrepareRoundStart(redisClient *redis.RedisClient, lobbyID string, expectedRound int) (*redis_models.GameLobby, int, error) {
 	log.Printf("[ROUND-PREPARE] Preparing round start state for lobby %s", lobbyID)
 
-	// Get the game lobby from Redis
-	lobby, err := redisClient.GetGameLobby(lobbyID)
-	if err != nil {
-		log.Printf("[ROUND-PREPARE-ERROR] Error getting lobby info: %v", err)
-		return nil, 0, err
-	}
+	// CRITICAL: Save the updated lobby state BEFORE broadcasting
+	var blind int
+	lobby, err := redisClient.UpdateGameLobby(lobbyID, func(lobby *redis_models.GameLobby) error {
+		// Validate the round number
+		if lobby.CurrentRound != expectedRound {
+			log.Printf("[ROUND-PREPARE-WARN] Round mismatch - current: %d, expected: %d. Ignoring stale timeout.",
+				lobby.CurrentRound, expectedRound)
+			return redis.ErrUpdateSkipped
+		}
 
-	// Reset players finished round map in redis
-	lobby.PlayersFinishedRound = make(map[string]bool)
+		// Check if this round's blind phase is already completed
+		if _, completed := lobby.BlindsCompleted[lobby.CurrentRound]; completed {
+			log.Printf("[ROUND-PREPARE-INFO] Round already started for lobby %s, skipping", lobbyID)
+			return redis.ErrUpdateSkipped
+		}
 
-	// Reset the blind timeout to indicate round has started
-	lobby.BlindTimeout = time.Time{}
+		// Reset players finished round map in redis
+		lobby.PlayersFinishedRound = make(map[string]bool)
 
-	// Set the current phase to play round
-	lobby.CurrentPhase = redis_models.PhasePlayRound
+		// Reset the blind timeout to indicate round has started
+		lobby.BlindTimeout = time.Time{}
 
-	// Get the blind value
-	blind := max(lobby.CurrentHighBlind, lobby.CurrentBaseBlind)
+		// Set the current phase to play round
+		lobby.CurrentPhase = redis_models.PhasePlayRound
 
-	// NEW: mark the current blind phase as completed
-	lobby.BlindsCompleted[lobby.CurrentRound] = true
+		// Get the blind value
+		blind = max(lobby.CurrentHighBlind, lobby.CurrentBaseBlind)
 
-	// KEY: set the current lobby's high blind to the target value
-	lobby.CurrentHighBlind = blind
+		// NEW: mark the current blind phase as completed
+		lobby.BlindsCompleted[lobby.CurrentRound] = true
 
-	// CRITICAL: Save the updated lobby state BEFORE broadcasting
-	err = redisClient.SaveGameLobby(lobby)
+		// KEY: set the current lobby's high blind to the target value
+		lobby.CurrentHighBlind = blind
+		return nil
+	})
+	if err == redis.ErrUpdateSkipped {
+		return nil, 0, err
+	}
 	if err != nil {
 		log.Printf("[ROUND-PREPARE-ERROR] Error updating lobby state: %v", err)
 		return nil, 0, err
@@ -55,10 +68,17 @@ func PrepareRoundStart(redisClient *redis.RedisClient, lobbyID string) (*redis_m
 	log.Printf("[ROUND-PREPARE-SUCCESS] Lobby %s prepared for round start with blind %d",
 		lobbyID, blind)
 
+	socketio_utils.RecordGameEvent(redisClient, lobbyID, redis_models.EventPhaseChange, "", gin.H{
+		"phase": redis_models.PhasePlayRound,
+		"blind": blind,
+	})
+
 	return lobby, blind, nil
 }
 
-func ResetPlayerAndBroadcastRoundStart(sio *socketio_types.SocketServer, redisClient *redis.RedisClient, lobbyID string, round int, blind int, timeout int) {
+// ResetPlayerAndBroadcastRoundStart resets the round state of every player and sends them the
+// round start, with the jokers that expired (as returned by ApplyRoundModifiers)
+func ResetPlayerAndBroadcastRoundStart(sio *socketio_types.SocketServer, redisClient *redis.RedisClient, lobbyID string, round int, blind int, timeout int, expiredJokers map[string][]int) {
 	log.Printf("[ROUND-BROADCAST] Broadcasting round start event for lobby %s", lobbyID)
 
 	// Get the game lobby from Redis
@@ -82,8 +102,8 @@ func ResetPlayerAndBroadcastRoundStart(sio *socketio_types.SocketServer, redisCl
 		player.CurrentRoundPoints = 0
 
 		// Reset hand plays and discards limits
-		player.HandPlaysLeft = game_constants.TOTAL_HAND_PLAYS
-		player.DiscardsLeft = game_constants.TOTAL_DISCARDS
+		player.HandPlaysLeft = lobby.Rules.HandPlays
+		player.DiscardsLeft = lobby.Rules.Discards
 
 		// Reset current hand to empty array
 		emptyHand := []poker.Card{}
@@ -112,7 +132,7 @@ func ResetPlayerAndBroadcastRoundStart(sio *socketio_types.SocketServer, redisCl
 		}
 
 		// Shuffle the deck
-		playersCurrentDeck.Shuffle()
+		playersCurrentDeck.Shuffle(poker.NewGameRNG(lobby.Seed, "round_deck", player.Username, round))
 
 		// Update player's deck
 		player.CurrentDeck = playersCurrentDeck.ToJSON()
@@ -127,8 +147,8 @@ func ResetPlayerAndBroadcastRoundStart(sio *socketio_types.SocketServer, redisCl
 		// Update local reference for broadcast
 		players[i] = player
 
-		// Get player's socket using GetConnection
-		playerSocket, exists := sio.GetConnection(player.Username)
+		// Get player's connection, on whichever server instance it is
+		playerSocket, exists := sio.GetUser(player.Username)
 		if !exists {
 			log.Printf("[SHOP-MULTICAST-WARNING] Player %s has no active connection", player.Username)
 			continue
@@ -142,16 +162,18 @@ func ResetPlayerAndBroadcastRoundStart(sio *socketio_types.SocketServer, redisCl
 		// Send personalized message to this player
 		playerSocket.Emit("starting_round", gin.H{
 			"round_number":       round,
-			"max_rounds":         game_constants.MaxGameRounds,
+			"max_rounds":         lobby.Rules.MaxRounds,
 			"players_money":      player.PlayersMoney,
 			"blind":              playerBlind,
 			"timeout":            timeout,
 			"timeout_start_date": lobby.GameRoundTimeout.Format(time.RFC3339),
-			"total_hand_plays":   game_constants.TOTAL_HAND_PLAYS,
-			"total_discards":     game_constants.TOTAL_DISCARDS,
+			"total_hand_plays":   lobby.Rules.HandPlays,
+			"total_discards":     lobby.Rules.Discards,
 			"current_pot":        CalculatePotAmount(lobby.CurrentRound),
 			"current_jokers":     player.CurrentJokers,
+			"expired_jokers":     expiredJokers[player.Username],
 			"active_vouchers":    player.ActivatedModifiers,
+			"hand_levels":        player.HandLevels,
 			"current_deck_size":  deckSize,
 		})
 
@@ -159,21 +181,43 @@ func ResetPlayerAndBroadcastRoundStart(sio *socketio_types.SocketServer, redisCl
 			player.Username, deckSize)
 	}
 
+	// Spectators get the same event without the state of any player
+	sio.Sio_server.To(socketio_utils.SpectatorsRoom(lobbyID)).Emit("starting_round", gin.H{
+		"round_number":       round,
+		"max_rounds":         lobby.Rules.MaxRounds,
+		"blind":              blind,
+		"timeout":            timeout,
+		"timeout_start_date": lobby.GameRoundTimeout.Format(time.RFC3339),
+		"total_hand_plays":   lobby.Rules.HandPlays,
+		"total_discards":     lobby.Rules.Discards,
+		"current_pot":        CalculatePotAmount(lobby.CurrentRound),
+		"players":            len(players),
+	})
+
 	log.Printf("[ROUND-BROADCAST] Sent round start event to lobby %s with round %d and blind %d",
 		lobbyID, round, blind)
 }
 
-// Apply modifiers to all players
-func ApplyRoundModifiers(redisClient *redis.RedisClient, lobbyID string, sio *socketio_types.SocketServer) {
+// Apply modifiers to all players, and count the round for their jokers
+// Returns: the IDs of the jokers that expired, by player
+func ApplyRoundModifiers(redisClient *redis.RedisClient, lobbyID string, sio *socketio_types.SocketServer) map[string][]int {
 	log.Printf("[MODIFIER-APPLY] Applying round modifiers for lobby %s", lobbyID)
 
+	lobby, err := redisClient.GetGameLobby(lobbyID)
+	if err != nil {
+		log.Printf("[MODIFIER-APPLY-ERROR] Error getting lobby info: %v", err)
+		return nil
+	}
+
 	// Get all players in the lobby
 	players, err := redisClient.GetAllPlayersInLobby(lobbyID)
 	if err != nil {
 		log.Printf("[MODIFIER-APPLY-ERROR] Error getting players: %v", err)
-		return
+		return nil
 	}
 
+	expiredJokers := make(map[string][]int)
+
 	// Apply modifiers to each player
 	for _, player := range players {
 		// Activated modifiers
@@ -182,7 +226,7 @@ func ApplyRoundModifiers(redisClient *redis.RedisClient, lobbyID string, sio *so
 			err = json.Unmarshal(player.ActivatedModifiers, &activatedModifiers)
 			if err != nil {
 				log.Printf("[HAND-ERROR] Error parsing activated modifiers: %v", err)
-				return
+				return expiredJokers
 			}
 		}
 
@@ -192,33 +236,40 @@ func ApplyRoundModifiers(redisClient *redis.RedisClient, lobbyID string, sio *so
 			err = json.Unmarshal(player.ReceivedModifiers, &receivedModifiers)
 			if err != nil {
 				log.Printf("[HAND-ERROR] Error parsing activated modifiers: %v", err)
-				return
+				return expiredJokers
 			}
 		}
 
-		var currentJokers []poker.Jokers
-		if player.CurrentJokers != nil {
+		var currentJokers poker.Jokers
+		if player.CurrentJokers != nil && len(player.CurrentJokers) > 0 {
 			err = json.Unmarshal(player.CurrentJokers, &currentJokers)
 			if err != nil {
 				log.Printf("[HAND-ERROR] Error parsing current jokers: %v", err)
-				return
+				return expiredJokers
 			}
 		}
 
+		// Count the round for the player's jokers, removing the expired ones
+		if expired := currentJokers.RoundStarted(); len(expired) > 0 {
+			log.Printf("[MODIFIER-APPLY] Jokers %v of player %s expired", expired, player.Username)
+			expiredJokers[player.Username] = expired
+		}
+
 		// Update the player's current jokers
 		player.CurrentJokers, err = json.Marshal(currentJokers)
 		if err != nil {
 			log.Printf("[HAND-ERROR] Error serializing current jokers: %v", err)
-			return
+			return expiredJokers
 		}
 
 		currentGold := player.PlayersMoney
+		rng := poker.NewGameRNG(lobby.Seed, "round_modifiers", player.Username, lobby.CurrentRound)
 
 		// Apply activated modifiers to the player
-		goldActivated := poker.ApplyRoundModifiers(&activatedModifiers, currentGold)
+		goldActivated := poker.ApplyRoundModifiers(rng, &activatedModifiers, currentGold)
 
 		// Apply received modifiers to the player (Currently there are no received modifiers that affect at the start of the round)
-		goldReceived := poker.ApplyRoundModifiers(&receivedModifiers, goldActivated)
+		goldReceived := poker.ApplyRoundModifiers(rng, &receivedModifiers, goldActivated)
 
 		// Delete modifiers if there are no more plays left of the activated modifiers
 		var remainingModifiers []poker.Modifier
@@ -236,7 +287,7 @@ func ApplyRoundModifiers(redisClient *redis.RedisClient, lobbyID string, sio *so
 		player.ActivatedModifiers, err = json.Marshal(activatedModifiers)
 		if err != nil {
 			log.Printf("[HAND-ERROR] Error serializing activated modifiers: %v", err)
-			return
+			return expiredJokers
 		}
 
 		// Delete modifiers if there are no more plays left of the received modifiers
@@ -255,7 +306,7 @@ func ApplyRoundModifiers(redisClient *redis.RedisClient, lobbyID string, sio *so
 		player.ReceivedModifiers, err = json.Marshal(receivedModifiers)
 		if err != nil {
 			log.Printf("[HAND-ERROR] Error serializing received modifiers: %v", err)
-			return
+			return expiredJokers
 		}
 
 		// Update redis
@@ -263,11 +314,12 @@ func ApplyRoundModifiers(redisClient *redis.RedisClient, lobbyID string, sio *so
 		err = redisClient.SaveInGamePlayer(&player)
 		if err != nil {
 			log.Printf("[HAND-ERROR] Error saving player data: %v", err)
-			return
+			return expiredJokers
 		}
 		log.Printf("[HAND-INFO] Player %s updated with activated modifiers: %v", player.Username, activatedModifiers)
 
 	}
 
 	log.Printf("[MODIFIER-APPLY] Successfully applied modifiers for lobby %s", lobbyID)
+	return expiredJokers
 }
@@ -22,13 +22,16 @@ const ( // Only used here, i think its good to see it here
 	minModifiers  = 1
 	maxModifiers  = 3
 	jokersCount   = 3
-	// Now, we only have 2 fixed packs and 2 fixed vouchers
+	// Now, we only have 2 fixed packs, 2 fixed vouchers and 1 fixed planet
 	TOTAL_FIXED_PACKS    = 2
 	TOTAL_FIXED_VOUCHERS = 2
+	TOTAL_FIXED_PLANETS  = 1
+	PLANET_PRICE         = 3
 )
 
-func InitializeShop(lobbyID string, roundNumber int) (*redis.LobbyShop, error) {
-	baseSeed := GenerateSeed(lobbyID, "shop", roundNumber)
+// The shop only depends on the game seed and the round, so it can be reproduced from the lobby seed
+func InitializeShop(gameSeed uint64, roundNumber int) (*redis.LobbyShop, error) {
+	baseSeed := GenerateSeed(gameSeed, "shop", roundNumber)
 	rng := rand.New(rand.NewSource(baseSeed))
 	// NEW: unique ID for each shop item
 	nextUniqueId := 1
@@ -38,9 +41,10 @@ func InitializeShop(lobbyID string, roundNumber int) (*redis.LobbyShop, error) {
 		Rerolls:        0,
 		FixedPacks:     generateFixedPacks(rng, &nextUniqueId),
 		FixedModifiers: generateFixedModifiers(rng, &nextUniqueId),
+		FixedPlanets:   generateFixedPlanets(rng, &nextUniqueId),
 		// NOTE: fixed number of rerollable items
 		Rerolled:     make([]redis.RerolledJokers, 0),
-		RerollSeed:   GenerateSeed(lobbyID, "shop", roundNumber),
+		RerollSeed:   GenerateSeed(gameSeed, "shop", roundNumber),
 		NextUniqueId: nextUniqueId,
 	}
 	// Save first generated jokers as the rerolled 0
@@ -58,6 +62,7 @@ func generateFixedPacks(rng *rand.Rand, nextUniqueId *int) []redis.ShopItem {
 		game_constants.PACK_TYPE_CARDS,
 		game_constants.PACK_TYPE_JOKERS,
 		game_constants.PACK_TYPE_VOUCHERS,
+		game_constants.PACK_TYPE_PLANETS,
 	}
 
 	// Randomly select 2 different pack types
@@ -90,6 +95,8 @@ func generateFixedPacks(rng *rand.Rand, nextUniqueId *int) []redis.ShopItem {
 			maxSelectable = 1
 		case game_constants.PACK_TYPE_VOUCHERS:
 			maxSelectable = 2
+		case game_constants.PACK_TYPE_PLANETS:
+			maxSelectable = 1
 		default:
 			maxSelectable = 1
 		}
@@ -118,6 +125,8 @@ func calculatePackPrice(packType int) int {
 		return 4
 	case game_constants.PACK_TYPE_VOUCHERS:
 		return 3
+	case game_constants.PACK_TYPE_PLANETS:
+		return 3
 	default:
 		return 4
 	}
@@ -148,6 +157,22 @@ func generateFixedModifiers(rng *rand.Rand, nextUniqueId *int) []redis.ShopItem
 	return modifiers
 }
 
+func generateFixedPlanets(rng *rand.Rand, nextUniqueId *int) []redis.ShopItem {
+	planets := make([]redis.ShopItem, TOTAL_FIXED_PLANETS)
+
+	for i := range planets {
+		planets[i] = redis.ShopItem{
+			ID:       *nextUniqueId,
+			Type:     game_constants.PLANET_TYPE,
+			Price:    PLANET_PRICE,
+			HandType: poker.RandomHandType(rng),
+		}
+
+		*nextUniqueId++
+	}
+	return planets
+}
+
 func GenerateRerollableItems(rng *rand.Rand, nextUniqueId *int) redis.RerolledJokers {
 	// NOTE: only jokers are rerrollable items
 	rerollableItems := redis.RerolledJokers{}
@@ -204,6 +229,7 @@ func generatePackContents(seed uint64, packType int) redis.PackContents {
 		Cards:    []poker.Card{},
 		Jokers:   []poker.Jokers{},
 		Vouchers: []poker.Modifier{},
+		Planets:  []poker.HandType{},
 	}
 
 	log.Println("[GENERATE-PACK-CONTENTS] Pack type:", packType)
@@ -221,6 +247,12 @@ func generatePackContents(seed uint64, packType int) redis.PackContents {
 		// Generate 3-4 vouchers (modifiers)
 		numVouchers := 3 + rng.Intn(2) // 3 or 4
 		contents.Vouchers = generatePackVouchers(rng, numVouchers)
+
+	case game_constants.PACK_TYPE_PLANETS:
+		// Generate 3 planets
+		for i := 0; i < 3; i++ {
+			contents.Planets = append(contents.Planets, poker.RandomHandType(rng))
+		}
 	}
 
 	log.Println("[GENERATE-PACK-CONTENTS] Pack contents:", contents)
@@ -231,7 +263,6 @@ func generatePackContents(seed uint64, packType int) redis.PackContents {
 // Predefined slices for ranks and suits, we dont want to recalculate each time. might not be the best modularity but makes sense here
 var ranks = []string{"A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"}
 var suits = []string{"h", "d", "c", "s"}
-var enhancements = []int{0, 1, 2}
 
 func generateCards(rng *rand.Rand, numCards int) []poker.Card {
 	cards := make([]poker.Card, numCards)
@@ -240,8 +271,7 @@ func generateCards(rng *rand.Rand, numCards int) []poker.Card {
 	for i := 0; i < numCards; i++ {
 		rank := ranks[rng.Intn(len(ranks))]
 		suit := suits[rng.Intn(len(suits))]
-		enhancement := enhancements[rng.Intn(len(enhancements))]
-		cards[i] = poker.Card{Rank: rank, Suit: suit, Enhancement: enhancement}
+		cards[i] = poker.RandomModifications(rng, poker.Card{ID: i + 1, Rank: rank, Suit: suit})
 	}
 
 	return cards
@@ -261,6 +291,12 @@ func FindShopItem(lobby redis.GameLobby, itemID int) (redis.ShopItem, bool) {
 		}
 	}
 
+	for _, item := range lobby.ShopState.FixedPlanets {
+		if item.ID == itemID {
+			return item, true
+		}
+	}
+
 	// NEW: Check the jokers of the LATEST reroll
 	total_rerolls_len := len(lobby.ShopState.Rerolled)
 	log.Println("[FIND-SHOP-ITEM] Item ID:", itemID)
@@ -299,9 +335,9 @@ func RandomModifierType(rng *rand.Rand) string {
 	return "modifier yeahhhhh"
 }
 
-// PurchaseJoker processes the purchase of a joker by a player
+// PurchaseJoker processes the purchase of a joker by a player, who can have at most maxJokers
 func PurchaseJoker(redisClient *redis_services.RedisClient, player *redis.InGamePlayer,
-	item redis.ShopItem, clientPrice int) (bool, *redis.InGamePlayer, error) {
+	item redis.ShopItem, clientPrice int, maxJokers int) (bool, *redis.InGamePlayer, error) {
 
 	if err := ValidatePurchase(item, game_constants.JOKER_TYPE, clientPrice, player); err != nil {
 		return false, nil, err
@@ -321,13 +357,12 @@ func PurchaseJoker(redisClient *redis_services.RedisClient, player *redis.InGame
 	}
 
 	// Check if adding another joker would exceed the maximum allowed
-	if len(currentJokers.Juglares) >= game_constants.MaxJokersPerPlayer {
-		return false, nil, fmt.Errorf("cannot have more than %d jokers", game_constants.MaxJokersPerPlayer)
+	if len(currentJokers.Juglares) >= maxJokers {
+		return false, nil, fmt.Errorf("cannot have more than %d jokers", maxJokers)
 	}
 
 	// Add the joker to player's collection
-	jokerID := item.JokerId
-	currentJokers.Juglares = append(currentJokers.Juglares, jokerID)
+	currentJokers.Add(item.JokerId)
 
 	// Deduct the price from player's money
 	player.PlayersMoney -= item.Price
@@ -393,6 +428,27 @@ func PurchaseVoucher(redisClient *redis_services.RedisClient, player *redis.InGa
 	return true, player, nil
 }
 
+// PurchasePlanet processes the purchase of a planet by a player. Planets are used right away,
+// leveling up their hand type. Returns the new level of the hand type
+func PurchasePlanet(player *redis.InGamePlayer, item redis.ShopItem, clientPrice int) (int, *redis.InGamePlayer, error) {
+	if err := ValidatePurchase(item, game_constants.PLANET_TYPE, clientPrice, player); err != nil {
+		return 0, nil, err
+	}
+	if !item.HandType.Valid() {
+		return 0, nil, fmt.Errorf("invalid hand type %d", item.HandType)
+	}
+
+	level := player.HandLevels.LevelUp(item.HandType)
+
+	// Deduct the price from player's money
+	player.PlayersMoney -= item.Price
+
+	// NEW, KEY: set the corresponding purchased item IDs map entry to true
+	play_round.SafelySetPlayerItemIDEntry(player, item)
+
+	return level, player, nil
+}
+
 // ValidatePurchase performs common validation for item purchases
 func ValidatePurchase(item redis.ShopItem, expectedType string, clientPrice int, player *redis.InGamePlayer) error {
 	// Verify the item type
@@ -405,6 +461,12 @@ func ValidatePurchase(item redis.ShopItem, expectedType string, clientPrice int,
 		return fmt.Errorf("price mismatch: expected %d, got %d", item.Price, clientPrice)
 	}
 
+	// Shop items are shared by all the players, but each one can only buy an item once. Planets
+	// are used right away, so buying one again would level its hand type again
+	if player.CurrentShopPurchasedItemIDs[item.ID] {
+		return fmt.Errorf("item %d already purchased", item.ID)
+	}
+
 	// Check if player has enough money
 	if player.PlayersMoney < item.Price {
 		return fmt.Errorf("insufficient funds: need %d, have %d", item.Price, player.PlayersMoney)
@@ -427,14 +489,7 @@ func SellJoker(player *redis.InGamePlayer, jokerID int) (updatedPlayer *redis.In
 	}
 
 	// Check if player has the joker
-	foundIndex := -1
-	for i, id := range currentJokers.Juglares {
-		if id == jokerID {
-			foundIndex = i
-			break
-		}
-	}
-
+	foundIndex := currentJokers.Index(jokerID)
 	if foundIndex == -1 {
 		return nil, 0, fmt.Errorf("joker not found in inventory")
 	}
@@ -442,10 +497,8 @@ func SellJoker(player *redis.InGamePlayer, jokerID int) (updatedPlayer *redis.In
 	// Calculate sell price
 	sellPrice = poker.CalculateJokerSellPrice(jokerID)
 
-	// Remove joker from inventory (by replacing it with the last element and then truncating)
-	lastIdx := len(currentJokers.Juglares) - 1
-	currentJokers.Juglares[foundIndex] = currentJokers.Juglares[lastIdx]
-	currentJokers.Juglares = currentJokers.Juglares[:lastIdx]
+	// Remove joker (and its state) from inventory, keeping the order of the rest
+	currentJokers.RemoveAt(foundIndex)
 
 	// Update player's joker inventory
 	updatedJokersJSON, err := json.Marshal(currentJokers)
@@ -478,23 +531,24 @@ func ProcessPackSelection(redisClient *redis_services.RedisClient, lobby *redis.
 	}
 
 	// Parse selections based on pack type
-	var selectedCards []poker.Card
+	var selectedCardIDs []int
 	var selectedJokerIDs []int
 	var selectedVoucherIDs []int
+	var selectedPlanets []poker.HandType
 	totalSelected := 0
 
-	// Parse selected cards if present
+	// Parse selected cards if present, they are referenced by their ID in the pack
 	if cardsInterface, hasCards := selectionsMap["selectedCards"]; hasCards {
 
 		if isCallFromBackend {
-			// Backend calls pass in []poker.Card directly
-			backendCards, ok := cardsInterface.([]poker.Card)
+			// Backend calls pass in []int directly
+			backendCards, ok := cardsInterface.([]int)
 			if !ok {
-				return nil, fmt.Errorf("backend: selectedCards must be []poker.Card")
+				return nil, fmt.Errorf("backend: selectedCards must be []int")
 			}
 
-			// Just use the cards directly
-			selectedCards = backendCards
+			// Just use the card IDs directly
+			selectedCardIDs = backendCards
 		} else {
 			// Frontend calls pass JSON that becomes []interface{}
 			frontendCards, ok := cardsInterface.([]interface{})
@@ -502,47 +556,18 @@ func ProcessPackSelection(redisClient *redis_services.RedisClient, lobby *redis.
 				return nil, fmt.Errorf("frontend: selectedCards must be an array")
 			}
 
-			// Parse each card from the frontend format
-			for _, cardInterface := range frontendCards {
-				cardMap, ok := cardInterface.(map[string]interface{})
+			// Parse each card ID from the frontend format
+			for _, cardIDInterface := range frontendCards {
+				cardIDFloat, ok := cardIDInterface.(float64)
 				if !ok {
-					return nil, fmt.Errorf("each card must be an object")
-				}
-
-				// Parse card fields
-				rankInterface, hasRank := cardMap["Rank"]
-				suitInterface, hasSuit := cardMap["Suit"]
-
-				if !hasRank || !hasSuit {
-					return nil, fmt.Errorf("card is missing rank or suit")
-				}
-
-				rank, rankOk := rankInterface.(string)
-				suit, suitOk := suitInterface.(string)
-
-				if !rankOk || !suitOk {
-					return nil, fmt.Errorf("rank and suit must be strings")
+					return nil, fmt.Errorf("each selected card ID must be a number")
 				}
-
-				// Create card
-				card := poker.Card{
-					Rank: rank,
-					Suit: suit,
-				}
-
-				// Add enhancement if present
-				if enhancementInterface, hasEnhancement := cardMap["Enhancement"]; hasEnhancement {
-					if enhancementValue, ok := enhancementInterface.(float64); ok {
-						card.Enhancement = int(enhancementValue)
-					}
-				}
-
-				selectedCards = append(selectedCards, card)
+				selectedCardIDs = append(selectedCardIDs, int(cardIDFloat))
 			}
 		}
 
-		// Now selectedCards is populated correctly regardless of source
-		totalSelected += len(selectedCards)
+		// Now selectedCardIDs is populated correctly regardless of source
+		totalSelected += len(selectedCardIDs)
 	}
 
 	// Parse selected jokers if present
@@ -611,6 +636,36 @@ func ProcessPackSelection(redisClient *redis_services.RedisClient, lobby *redis.
 		totalSelected += len(selectedVoucherIDs)
 	}
 
+	// Parse selected planets if present, they are referenced by their hand type
+	if planetsInterface, hasPlanets := selectionsMap["selectedPlanets"]; hasPlanets {
+
+		if isCallFromBackend {
+			// Backend calls pass in []poker.HandType directly
+			backendPlanets, ok := planetsInterface.([]poker.HandType)
+			if !ok {
+				return nil, fmt.Errorf("backend: selectedPlanets must be []poker.HandType")
+			}
+
+			selectedPlanets = backendPlanets
+		} else {
+			// Frontend calls pass JSON that becomes []interface{}
+			frontendPlanets, ok := planetsInterface.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("frontend: selectedPlanets must be an array")
+			}
+
+			for _, handTypeInterface := range frontendPlanets {
+				handTypeFloat, ok := handTypeInterface.(float64)
+				if !ok {
+					return nil, fmt.Errorf("each selected planet must be a number")
+				}
+				selectedPlanets = append(selectedPlanets, poker.HandType(handTypeFloat))
+			}
+		}
+
+		totalSelected += len(selectedPlanets)
+	}
+
 	// Check if they've selected too many items
 	if totalSelected > item.MaxSelectable {
 		return nil, fmt.Errorf("you can only select up to %d items from this pack", item.MaxSelectable)
@@ -625,25 +680,17 @@ func ProcessPackSelection(redisClient *redis_services.RedisClient, lobby *redis.
 	switch item.PackType {
 	case game_constants.PACK_TYPE_CARDS:
 		// For card packs, verify selected cards
-		if len(selectedCards) == 0 {
+		if len(selectedCardIDs) == 0 {
 			return nil, fmt.Errorf("you must select at least one card from a cards pack")
 		}
-		if len(selectedJokerIDs) > 0 || len(selectedVoucherIDs) > 0 {
+		if len(selectedJokerIDs) > 0 || len(selectedVoucherIDs) > 0 || len(selectedPlanets) > 0 {
 			return nil, fmt.Errorf("you can only select cards from a cards pack")
 		}
 
 		// Verify selected cards exist in the pack
-		for _, selectedCard := range selectedCards {
-			cardFound := false
-			for _, card := range packContents.Cards {
-				if card.Rank == selectedCard.Rank && card.Suit == selectedCard.Suit && card.Enhancement == selectedCard.Enhancement {
-					cardFound = true
-					break
-				}
-			}
-			if !cardFound {
-				return nil, fmt.Errorf("card %s of %s is not in the pack", selectedCard.Rank, selectedCard.Suit)
-			}
+		selectedCards, err := poker.FindCards(packContents.Cards, selectedCardIDs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cards for the pack: %v", err)
 		}
 
 		// Add selected cards to player's inventory
@@ -656,7 +703,8 @@ func ProcessPackSelection(redisClient *redis_services.RedisClient, lobby *redis.
 			purchasedCards = []poker.Card{}
 		}
 
-		purchasedCards = append(purchasedCards, selectedCards...)
+		// The IDs of the pack are only valid in it, give them new ones in the player's deck
+		purchasedCards = append(purchasedCards, poker.AssignCardIDs(purchasedCards, selectedCards)...)
 		updatedPurchasedCardsJSON, err := json.Marshal(purchasedCards)
 		if err != nil {
 			return nil, fmt.Errorf("error updating purchased cards: %v", err)
@@ -670,7 +718,7 @@ func ProcessPackSelection(redisClient *redis_services.RedisClient, lobby *redis.
 		if len(selectedJokerIDs) == 0 {
 			return nil, fmt.Errorf("you must select at least one joker from a jokers pack")
 		}
-		if len(selectedCards) > 0 || len(selectedVoucherIDs) > 0 {
+		if len(selectedCardIDs) > 0 || len(selectedVoucherIDs) > 0 || len(selectedPlanets) > 0 {
 			return nil, fmt.Errorf("you can only select jokers from a jokers pack")
 		}
 
@@ -705,7 +753,7 @@ func ProcessPackSelection(redisClient *redis_services.RedisClient, lobby *redis.
 			}
 		}
 
-		currentJokers.Juglares = append(currentJokers.Juglares, selectedJokerIDs...)
+		currentJokers.Add(selectedJokerIDs...)
 		updatedJokersJSON, err := json.Marshal(currentJokers)
 		if err != nil {
 			return nil, fmt.Errorf("error updating jokers: %v", err)
@@ -719,7 +767,7 @@ func ProcessPackSelection(redisClient *redis_services.RedisClient, lobby *redis.
 		if len(selectedVoucherIDs) == 0 {
 			return nil, fmt.Errorf("you must select at least one voucher from a vouchers pack")
 		}
-		if len(selectedCards) > 0 || len(selectedJokerIDs) > 0 {
+		if len(selectedCardIDs) > 0 || len(selectedJokerIDs) > 0 || len(selectedPlanets) > 0 {
 			return nil, fmt.Errorf("you can only select vouchers from a vouchers pack")
 		}
 
@@ -765,6 +813,34 @@ func ProcessPackSelection(redisClient *redis_services.RedisClient, lobby *redis.
 		player.Modifiers = updatedModifiersJSON
 
 		log.Printf("[PROCESS PACK SELECTION] UPDATED selectedVouchers for player %s: %v", player.Username, selectedVoucherIDs)
+
+	case game_constants.PACK_TYPE_PLANETS:
+		// For planet packs, verify selected planets
+		if len(selectedPlanets) == 0 {
+			return nil, fmt.Errorf("you must select at least one planet from a planets pack")
+		}
+		if len(selectedCardIDs) > 0 || len(selectedJokerIDs) > 0 || len(selectedVoucherIDs) > 0 {
+			return nil, fmt.Errorf("you can only select planets from a planets pack")
+		}
+
+		// Verify selected planets exist in the pack, a pack may have the same planet twice
+		available := make(map[poker.HandType]int)
+		for _, handType := range packContents.Planets {
+			available[handType]++
+		}
+		for _, handType := range selectedPlanets {
+			if available[handType] <= 0 {
+				return nil, fmt.Errorf("planet of hand type %d is not in the pack", handType)
+			}
+			available[handType]--
+		}
+
+		// Planets are used right away
+		for _, handType := range selectedPlanets {
+			player.HandLevels.LevelUp(handType)
+		}
+
+		log.Printf("[PROCESS PACK SELECTION] UPDATED hand levels for player %s: %v", player.Username, player.HandLevels)
 	}
 
 	// Reset LastPurchasedPackItemId to prevent reuse
@@ -839,6 +915,15 @@ func RemovePurchasedItems(shopState *redis.LobbyShop, player *redis.InGamePlayer
 	}
 	shopState.FixedModifiers = filteredModifiers
 
+	// Filter fixed planets
+	filteredPlanets := make([]redis.ShopItem, 0, len(shopState.FixedPlanets))
+	for _, item := range shopState.FixedPlanets {
+		if !player.CurrentShopPurchasedItemIDs[item.ID] {
+			filteredPlanets = append(filteredPlanets, item)
+		}
+	}
+	shopState.FixedPlanets = filteredPlanets
+
 	// Filter jokers from ALL rerolls instead of just the latest one
 	for rerollIndex := 0; rerollIndex < len(shopState.Rerolled); rerollIndex++ {
 		// Since Jokers is a fixed-size array, we need to handle it differently
@@ -1,7 +1,6 @@
 package shop
 
 import (
-	game_constants "Nogler/constants/game"
 	"Nogler/models/redis"
 	"Nogler/services/poker"
 	redis_services "Nogler/services/redis"
@@ -52,8 +51,8 @@ func MulticastStartingShop(sio *socketio_types.SocketServer, redisClient *redis_
 				player.Username, err)
 		}
 
-		// Get player's socket using GetConnection
-		playerSocket, exists := sio.GetConnection(player.Username)
+		// Get player's connection, on whichever server instance it is
+		playerSocket, exists := sio.GetUser(player.Username)
 		if !exists {
 			log.Printf("[SHOP-MULTICAST-WARNING] Player %s has no active connection", player.Username)
 			continue
@@ -66,11 +65,12 @@ func MulticastStartingShop(sio *socketio_types.SocketServer, redisClient *redis_
 		if player.CurrentJokers != nil && len(player.CurrentJokers) > 0 {
 			if err := json.Unmarshal(player.CurrentJokers, &currentJokers); err == nil {
 				// Calculate sell price for each joker
-				for _, jokerID := range currentJokers.Juglares {
+				for i, jokerID := range currentJokers.Juglares {
 					if jokerID != 0 { // Skip empty slots
 						jokersWithPrices = append(jokersWithPrices, gin.H{
 							"id":         jokerID,
 							"sell_price": poker.CalculateJokerSellPrice(jokerID),
+							"state":      currentJokers.StateAt(i),
 						})
 					}
 				}
@@ -87,7 +87,7 @@ func MulticastStartingShop(sio *socketio_types.SocketServer, redisClient *redis_
 			"current_round":      lobby.CurrentRound,
 			"money":              player.PlayersMoney,
 			"players_jokers":     jokersWithPrices,
-			"max_jokers":         game_constants.MaxJokersPerPlayer,
+			"max_jokers":         lobby.Rules.MaxJokers,
 			"next_reroll_price":  GetGlobalShopRerollPrice(lobby),
 		})
 
@@ -54,7 +54,7 @@ func MulticastStartingVouchers(sio *socketio_types.SocketServer, redisClient *re
 		}
 
 		// Get the player's socket connection
-		socket, exists := sio.GetConnection(player.Username)
+		socket, exists := sio.GetUser(player.Username)
 		if exists && socket != nil {
 			// Emit personalized event to this player
 			socket.Emit("starting_vouchers", gin.H{
@@ -4,12 +4,13 @@ import (
 	"Nogler/middleware"
 	models "Nogler/models/postgres"
 	redis_models "Nogler/models/redis"
+	"Nogler/services/app_errors"
 	"Nogler/services/redis"
+	socketio_events "Nogler/services/socket_io/events"
 	"Nogler/utils"
 	"fmt"
 	"log"
 
-	"github.com/gin-gonic/gin"
 	"github.com/zishang520/socket.io/v2/socket"
 	"gorm.io/gorm"
 )
@@ -21,7 +22,7 @@ func VerifyUserConnection(client *socket.Socket, db *gorm.DB) (success bool, use
 	authData, ok := client.Handshake().Auth.(map[string]interface{})
 	if !ok {
 		fmt.Println("No auth data provided in handshake!")
-		client.Emit("error", gin.H{"error": "Authentication failed: missing auth data"})
+		socketio_events.EmitError(client, app_errors.MissingToken)
 		return false, "", ""
 	}
 
@@ -29,7 +30,7 @@ func VerifyUserConnection(client *socket.Socket, db *gorm.DB) (success bool, use
 	token, exists := authData["authorization"].(string)
 	if !exists {
 		fmt.Println("No authorization token provided in handshake!")
-		client.Emit("error", gin.H{"error": "Authentication failed: missing authorization token"})
+		socketio_events.EmitError(client, app_errors.MissingToken)
 		return false, "", ""
 	}
 
@@ -38,9 +39,8 @@ func VerifyUserConnection(client *socket.Socket, db *gorm.DB) (success bool, use
 	email, err := middleware.Socketio_JWT_decoder(authData)
 	if err != nil {
 		fmt.Println("Error decoding JWT:", err)
-		client.Emit("error", gin.H{
-			"error": "Authentication failed: invalid JWT. Remember to set it on the 'Authorization' field and with the 'Bearer ' prefix.",
-		})
+		socketio_events.EmitError(client, app_errors.InvalidToken,
+			app_errors.Reason("Remember to set it on the 'Authorization' field and with the 'Bearer ' prefix"))
 		return false, "", ""
 	}
 
@@ -49,7 +49,7 @@ func VerifyUserConnection(client *socket.Socket, db *gorm.DB) (success bool, use
 	result := db.Where("email = ?", email).First(&user)
 	if result.Error != nil {
 		fmt.Println("Error fetching user from database:", result.Error)
-		client.Emit("error", gin.H{"error": "Authentication failed: could not find user"})
+		socketio_events.EmitError(client, app_errors.AuthUserNotFound)
 		return false, "", email
 	}
 
@@ -58,7 +58,7 @@ func VerifyUserConnection(client *socket.Socket, db *gorm.DB) (success bool, use
 }
 
 // Helper function to validate lobby and user, returning the lobby if valid
-func ValidateLobbyAndUser(redisClient *redis.RedisClient, client *socket.Socket,
+func ValidateLobbyAndUser(redisClient *redis.RedisClient, ctx *socketio_events.Context,
 	db *gorm.DB, username string, lobbyID string) (*redis_models.GameLobby, error) {
 
 	log.Printf("[TIMEOUT-REQUEST] Validating lobby %s and user %s", lobbyID, username)
@@ -67,13 +67,13 @@ func ValidateLobbyAndUser(redisClient *redis.RedisClient, client *socket.Socket,
 	isInLobby, err := utils.IsPlayerInLobby(db, lobbyID, username)
 	if err != nil {
 		log.Printf("[TIMEOUT-ERROR] Database error: %v", err)
-		client.Emit("error", gin.H{"error": "Database error"})
+		ctx.Fail(app_errors.Internal, app_errors.Reason("Database error"))
 		return nil, err
 	}
 
 	if !isInLobby {
 		log.Printf("[TIMEOUT-ERROR] User is NOT in lobby: %s, Lobby: %s", username, lobbyID)
-		client.Emit("error", gin.H{"error": "You must join the lobby before requesting timeout info"})
+		ctx.Fail(app_errors.NotInLobby)
 		return nil, fmt.Errorf("user not in lobby")
 	}
 
@@ -81,7 +81,7 @@ func ValidateLobbyAndUser(redisClient *redis.RedisClient, client *socket.Socket,
 	lobby, err := redisClient.GetGameLobby(lobbyID)
 	if err != nil {
 		log.Printf("[TIMEOUT-ERROR] Error obtaining lobby: %v", err)
-		client.Emit("error", gin.H{"error": "Error obtaining lobby information"})
+		ctx.Fail(app_errors.Internal, app_errors.Reason("Error obtaining lobby information"))
 		return nil, err
 	}
 
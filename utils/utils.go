@@ -9,7 +9,6 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/gin-gonic/gin"
-	"github.com/zishang520/socket.io/v2/socket"
 )
 
 // LoggerMiddleware logs information about each request
@@ -77,16 +76,6 @@ func IsPlayerInLobby(db *gorm.DB, lobbyID string, username string) (bool, error)
 	return count > 0, nil
 }
 
-// Check if user is in lobby
-func UserExistsInLobby(db *gorm.DB, lobbyID string, username string, client *socket.Socket) (inLobby bool, e error) {
-	isInLobby, err := IsPlayerInLobby(db, lobbyID, username)
-	if err != nil {
-		fmt.Println("Database error:", err)
-		client.Emit("error", gin.H{"error": "Database error"})
-	}
-	return isInLobby, err
-}
-
 // Returns the icon of a user
 func UserIcon(db *gorm.DB, username string) int {
 	var icon int
@@ -100,3 +89,18 @@ func UserIcon(db *gorm.DB, username string) int {
 
 	return icon
 }
+
+// Checks if two users are friends
+func AreFriends(db *gorm.DB, username string, friendUsername string) (bool, error) {
+	var count int64
+	err := db.Model(&postgres.Friendship{}).
+		Where("(username1 = ? AND username2 = ?) OR (username1 = ? AND username2 = ?)",
+			username, friendUsername, friendUsername, username).
+		Count(&count).Error
+
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}